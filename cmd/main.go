@@ -20,8 +20,11 @@ package main
 import (
 	"context"
 	"fmt"
+	"os"
 
 	_ "github.com/microsoft/go-mssqldb"
+	_ "github.com/microsoft/go-mssqldb/namedpipe"
+	"github.com/GoogleCloudPlatform/sql-server-agent/internal"
 	"github.com/GoogleCloudPlatform/sql-server-agent/internal/daemon"
 	"github.com/GoogleCloudPlatform/sql-server-agent/internal/sqlservermetrics"
 	configpb "github.com/GoogleCloudPlatform/sql-server-agent/protos/sqlserveragentconfig"
@@ -39,30 +42,69 @@ func main() {
 
 	ctx := context.Background()
 	// Load default logging configuration.
-	sqlservermetrics.LoggingSetupDefault(ctx, sqlservermetrics.LogPrefix())
+	// In json stdout mode logs are written to stderr to keep stdout clean for the collected data.
+	// The output paths of the flags and the environment are set before the configuration is loaded.
+	// If they are not writable the logs are written to stderr until the configuration is loaded,
+	// which may set writable paths.
+	if flags.JSONStdout || sqlservermetrics.SetOutputPaths(flags, nil) != nil {
+		sqlservermetrics.LoggingSetupStderr(nil)
+	} else {
+		sqlservermetrics.LoggingSetupDefault(ctx, sqlservermetrics.LogPrefix())
+	}
 	// Load configuration.
-	cfg, err := sqlservermetrics.LoadConfiguration(sqlservermetrics.ConfigPath())
+	configPath := sqlservermetrics.ConfigPath()
+	if flags.ConfigStdin {
+		configPath = sqlservermetrics.StdinConfigPath
+	}
+	cfg, err := sqlservermetrics.LoadConfiguration(configPath)
+	if cfg != nil {
+		sqlservermetrics.SetSourceInstanceProperties(cfg)
+	}
+	// The debug dump is written even if the configuration is invalid.
+	if flags.DebugDump != "" {
+		if err := sqlservermetrics.WriteDebugDump(flags.DebugDump, cfg); err != nil {
+			log.Logger.Fatalw("Failed to write the debug dump", "path", flags.DebugDump, "error", err)
+		}
+		fmt.Printf("Wrote the debug dump to %s.\n", flags.DebugDump)
+		return
+	}
 	if cfg == nil {
+		if flags.Onetime {
+			log.Logger.Errorw("Failed to load configuration", "error", err)
+			os.Exit(internal.ExitConfigError)
+		}
 		log.Logger.Fatalw("Failed to load configuration", "error", err)
 	}
 	if err != nil {
 		log.Logger.Errorw("Failed to load configuration. Using default configurations", "error", err)
 	}
-	// Load logging configuration based on the configuration file.
-	sqlservermetrics.LoggingSetup(ctx, sqlservermetrics.LogPrefix(), cfg)
-
-	// onetime collection
-	if flags.Onetime {
-		if err := sqlservermetrics.OSCollection(ctx, sqlservermetrics.AgentFilePath(), sqlservermetrics.LogPrefix(), cfg, true); err != nil {
-			log.Logger.Errorw("Failed to complete os collection", "error", err)
+	if err := sqlservermetrics.SetOutputPaths(flags, cfg); err != nil {
+		if flags.Onetime {
+			log.Logger.Errorw("Invalid output paths", "error", err)
+			os.Exit(internal.ExitConfigError)
 		}
-		if err := sqlservermetrics.SQLCollection(ctx, sqlservermetrics.AgentFilePath(), sqlservermetrics.LogPrefix(), cfg, true); err != nil {
-			log.Logger.Errorw("Failed to complete sql collection", "error", err)
+		log.Logger.Fatalw("Invalid output paths", "error", err)
+	}
+	// Load logging configuration based on the configuration file and flag -log-level, which
+	// LoadConfiguration applied to cfg.
+	if flags.JSONStdout {
+		sqlservermetrics.LoggingSetupStderr(cfg)
+	} else {
+		sqlservermetrics.LoggingSetup(ctx, sqlservermetrics.LogPrefix(), cfg)
+	}
+	sqlservermetrics.SetMinTLSVersion(cfg)
+	sqlservermetrics.SetClientCertificate(cfg)
+	sqlservermetrics.SetSSHAlgorithms(cfg)
+	if err := sqlservermetrics.SetSecretProvider(cfg); err != nil {
+		if flags.Onetime {
+			log.Logger.Errorw("Invalid secret provider", "error", err)
+			os.Exit(internal.ExitConfigError)
 		}
-		return
+		log.Logger.Fatalw("Invalid secret provider", "error", err)
 	}
-	// Init UsageMetricsLogger by reading "disable_log_usage" from the configuration file.
-	sqlservermetrics.UsageMetricsLogger = sqlservermetrics.UsageMetricsLoggerInit(sqlservermetrics.ServiceName, sqlservermetrics.AgentVersion, sqlservermetrics.AgentUsageLogPrefix, !cfg.GetDisableLogUsage())
+	shutdownTracing := sqlservermetrics.SetupTracing(ctx, cfg)
+	defer shutdownTracing()
+
 	osCollectionFunc := func(cfg *configpb.Configuration, onetime bool) error {
 		return sqlservermetrics.OSCollection(ctx, sqlservermetrics.AgentFilePath(), sqlservermetrics.LogPrefix(), cfg, onetime)
 	}
@@ -70,12 +112,28 @@ func main() {
 		return sqlservermetrics.SQLCollection(ctx, sqlservermetrics.AgentFilePath(), sqlservermetrics.LogPrefix(), cfg, onetime)
 	}
 
+	// onetime collection
+	if flags.Onetime {
+		code := onetimeCollection(cfg, flags.Target, flags.JSONStdout, osCollectionFunc, sqlCollectionFunc)
+		shutdownTracing()
+		os.Exit(code)
+	}
+	// Init UsageMetricsLogger by reading "disable_log_usage" from the configuration file.
+	sqlservermetrics.UsageMetricsLogger = sqlservermetrics.UsageMetricsLoggerInit(sqlservermetrics.ServiceName, sqlservermetrics.AgentVersion, sqlservermetrics.AgentUsageLogPrefix, !cfg.GetDisableLogUsage())
+
+	// Run one cycle of the service collections to troubleshoot the service.
+	if flags.OnceAndExit {
+		sqlservermetrics.CollectionService(configPath, osCollectionFunc, sqlservermetrics.OS, true)
+		sqlservermetrics.CollectionService(configPath, sqlCollectionFunc, sqlservermetrics.SQL, true)
+		return
+	}
+
 	s, err := daemon.CreateService(
 		func() {
-			sqlservermetrics.CollectionService(sqlservermetrics.ConfigPath(), osCollectionFunc, sqlservermetrics.OS)
+			sqlservermetrics.CollectionService(configPath, osCollectionFunc, sqlservermetrics.OS, false)
 		},
 		func() {
-			sqlservermetrics.CollectionService(sqlservermetrics.ConfigPath(), sqlCollectionFunc, sqlservermetrics.SQL)
+			sqlservermetrics.CollectionService(configPath, sqlCollectionFunc, sqlservermetrics.SQL, false)
 		},
 		daemon.CreateConfig(sqlservermetrics.ServiceName, sqlservermetrics.ServiceDisplayName, sqlservermetrics.Description),
 		sqlservermetrics.UsageMetricsLogger)
@@ -88,3 +146,31 @@ func main() {
 		log.Logger.Fatal(err)
 	}
 }
+
+// onetimeCollection runs the os and sql collections once and returns the exit code of the agent.
+func onetimeCollection(cfg *configpb.Configuration, target string, jsonStdout bool, osCollection, sqlCollection func(cfg *configpb.Configuration, onetime bool) error) int {
+	if target != "" {
+		targetCfg, err := sqlservermetrics.FilterCredentialConfiguration(cfg, target)
+		if err != nil {
+			log.Logger.Errorw("Invalid onetime collection target", "target", target, "error", err)
+			return internal.ExitConfigError
+		}
+		cfg = targetCfg
+	}
+	sqlservermetrics.JSONStdout = jsonStdout
+	osErr := osCollection(cfg, true)
+	if osErr != nil {
+		log.Logger.Errorw("Failed to complete os collection", "error", osErr)
+	}
+	sqlErr := sqlCollection(cfg, true)
+	if sqlErr != nil {
+		log.Logger.Errorw("Failed to complete sql collection", "error", sqlErr)
+	}
+	if jsonStdout {
+		if err := sqlservermetrics.WriteOnetimeDetails(os.Stdout); err != nil {
+			log.Logger.Errorw("Failed to write the collected data to stdout", "error", err)
+			return internal.ExitTotalFailure
+		}
+	}
+	return internal.OnetimeExitCode(osErr, sqlErr)
+}
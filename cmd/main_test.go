@@ -0,0 +1,98 @@
+/*
+Copyright 2022 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/sql-server-agent/internal"
+	configpb "github.com/GoogleCloudPlatform/sql-server-agent/protos/sqlserveragentconfig"
+)
+
+func TestOnetimeCollection(t *testing.T) {
+	err := errors.New("collection failed")
+	cfg := &configpb.Configuration{
+		CredentialConfiguration: []*configpb.CredentialConfiguration{
+			{InstanceName: "instance-1"},
+			{InstanceName: "instance-2"},
+		},
+	}
+	tests := []struct {
+		name          string
+		target        string
+		osCollection  func(cfg *configpb.Configuration, onetime bool) error
+		sqlCollection func(cfg *configpb.Configuration, onetime bool) error
+		want          int
+	}{
+		{
+			name:          "all collections succeeded",
+			osCollection:  func(*configpb.Configuration, bool) error { return nil },
+			sqlCollection: func(*configpb.Configuration, bool) error { return nil },
+			want:          internal.ExitSuccess,
+		},
+		{
+			name:         "some sql targets failed",
+			osCollection: func(*configpb.Configuration, bool) error { return nil },
+			sqlCollection: func(cfg *configpb.Configuration, _ bool) error {
+				return internal.NewTargetErrors(len(cfg.GetCredentialConfiguration()), []error{err})
+			},
+			want: internal.ExitPartialFailure,
+		},
+		{
+			name: "all targets failed",
+			osCollection: func(cfg *configpb.Configuration, _ bool) error {
+				return internal.NewTargetErrors(len(cfg.GetCredentialConfiguration()), []error{err, err})
+			},
+			sqlCollection: func(cfg *configpb.Configuration, _ bool) error {
+				return internal.NewTargetErrors(len(cfg.GetCredentialConfiguration()), []error{err, err})
+			},
+			want: internal.ExitTotalFailure,
+		},
+		{
+			name:   "the only target failed",
+			target: "instance-2",
+			osCollection: func(cfg *configpb.Configuration, _ bool) error {
+				return internal.NewTargetErrors(len(cfg.GetCredentialConfiguration()), []error{err})
+			},
+			sqlCollection: func(cfg *configpb.Configuration, _ bool) error {
+				return internal.NewTargetErrors(len(cfg.GetCredentialConfiguration()), []error{err})
+			},
+			want: internal.ExitTotalFailure,
+		},
+		{
+			name:          "collection failed",
+			osCollection:  func(*configpb.Configuration, bool) error { return err },
+			sqlCollection: func(*configpb.Configuration, bool) error { return nil },
+			want:          internal.ExitPartialFailure,
+		},
+		{
+			name:          "invalid target",
+			target:        "instance-3",
+			osCollection:  func(*configpb.Configuration, bool) error { return nil },
+			sqlCollection: func(*configpb.Configuration, bool) error { return nil },
+			want:          internal.ExitConfigError,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := onetimeCollection(cfg, tc.target, false, tc.osCollection, tc.sqlCollection); got != tc.want {
+				t.Errorf("onetimeCollection(%q) = %d, want %d", tc.target, got, tc.want)
+			}
+		})
+	}
+}
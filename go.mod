@@ -26,8 +26,13 @@ require (
   github.com/jonboulle/clockwork v0.4.1-0.20230717050334-b1209715e43c
   github.com/kardianos/service v1.2.2
   github.com/microsoft/go-mssqldb v1.4.0
+  go.opentelemetry.io/otel v1.24.0
+  go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp v1.24.0
+  go.opentelemetry.io/otel/sdk v1.24.0
+  go.opentelemetry.io/otel/trace v1.24.0
   go.uber.org/zap v1.27.0
   golang.org/x/crypto v0.21.0
+  golang.org/x/oauth2 v0.17.0
   google.golang.org/api v0.168.0
   google.golang.org/protobuf v1.36.4
 )
@@ -52,17 +57,17 @@ require (
   github.com/google/uuid v1.6.0 // indirect
   github.com/googleapis/enterprise-certificate-proxy v0.3.2 // indirect
   github.com/googleapis/gax-go/v2 v2.12.2 // indirect
+  github.com/grpc-ecosystem/grpc-gateway/v2 v2.19.0 // indirect
   github.com/natefinch/lumberjack v2.0.0+incompatible // indirect
   github.com/pkg/errors v0.9.1 // indirect
   go.opencensus.io v0.24.0 // indirect
   go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc v0.49.0 // indirect
   go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp v0.49.0 // indirect
-  go.opentelemetry.io/otel v1.24.0 // indirect
+  go.opentelemetry.io/otel/exporters/otlp/otlptrace v1.24.0 // indirect
   go.opentelemetry.io/otel/metric v1.24.0 // indirect
-  go.opentelemetry.io/otel/trace v1.24.0 // indirect
+  go.opentelemetry.io/proto/otlp v1.1.0 // indirect
   go.uber.org/multierr v1.10.0 // indirect
   golang.org/x/net v0.23.0 // indirect
-  golang.org/x/oauth2 v0.17.0 // indirect
   golang.org/x/sync v0.6.0 // indirect
   golang.org/x/sys v0.18.0 // indirect
   golang.org/x/text v0.14.0 // indirect
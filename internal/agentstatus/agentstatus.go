@@ -72,6 +72,11 @@ const (
 	WinGuestCollectionTimeout
 	LinuxGuestCollectionTimeout
 	MappingLocalLinuxDiskTypeTimeout
+	SQLQueryTimeoutError
+	SQLPermissionDeniedError
+	SQLConnectionError
+	CommandPermissionDeniedError
+	CommandNotAllowedError
 )
 
 // NewUsageMetricsLogger wraps NewLogger function from usagemetrics package.
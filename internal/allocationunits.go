@@ -0,0 +1,79 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/workloadagentplatform/integration/common/shared/log"
+)
+
+// recommendedAllocationUnit is the allocation unit size in bytes recommended for the volumes
+// holding SQL Server data and log files.
+const recommendedAllocationUnit = 64 * 1024
+
+// volumeAllocationUnit is a windows volume and its allocation unit size, in the format of the
+// data_disk_allocation_units os field.
+type volumeAllocationUnit struct {
+	BlockSize int64
+	Caption   string
+}
+
+// AddDataVolumeAllocationUnits correlates the allocation units of the windows volumes with the
+// physical_drive of the database files in DB_LOG_DISK_SEPARATION. allocationUnits is the value of the
+// data_disk_allocation_units os field. Each database file gets the allocation_unit_size of its volume
+// and allocation_unit_64kb, which is false for the files on volumes not formatted at 64KB.
+// Both are unknown if the volume of the file is not found.
+func AddDataVolumeAllocationUnits(details []Details, allocationUnits string) {
+	blockSizes := map[string]int64{}
+	var volumes []volumeAllocationUnit
+	if allocationUnits != "" {
+		if err := json.Unmarshal([]byte(allocationUnits), &volumes); err != nil {
+			log.Logger.Warnw("Failed to parse the data disk allocation units", "error", err)
+		}
+	}
+	for _, v := range volumes {
+		blockSizes[volumeDrive(v.Caption)] = v.BlockSize
+	}
+	for _, detail := range details {
+		if detail.Name != "DB_LOG_DISK_SEPARATION" {
+			continue
+		}
+		for _, field := range detail.Fields {
+			field["allocation_unit_size"] = "unknown"
+			field["allocation_unit_64kb"] = "unknown"
+			blockSize, ok := blockSizes[strings.ToUpper(field["physical_drive"])]
+			if !ok {
+				continue
+			}
+			field["allocation_unit_size"] = strconv.FormatInt(blockSize, 10)
+			field["allocation_unit_64kb"] = strconv.FormatBool(blockSize == recommendedAllocationUnit)
+		}
+	}
+}
+
+// volumeDrive returns the drive letter of a volume caption, e.g. "D" for `D:\`.
+// Volumes mounted in a folder, e.g. `D:\mount\`, have no drive letter and return "".
+func volumeDrive(caption string) string {
+	drive, rest, ok := strings.Cut(caption, ":")
+	if !ok || strings.Trim(rest, `\`) != "" {
+		return ""
+	}
+	return strings.ToUpper(drive)
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestAddDataVolumeAllocationUnits(t *testing.T) {
+	testcases := []struct {
+		name            string
+		details         []Details
+		allocationUnits string
+		want            []Details
+	}{
+		{
+			name: "data volumes correlated with allocation units",
+			details: []Details{
+				{
+					Name: "DB_LOG_DISK_SEPARATION",
+					Fields: []map[string]string{
+						{"db_name": "db1", "filetype": "0", "physical_name": `D:\data\db1.mdf`, "physical_drive": "D"},
+						{"db_name": "db1", "filetype": "1", "physical_name": `e:\log\db1.ldf`, "physical_drive": "e"},
+						{"db_name": "db2", "filetype": "0", "physical_name": `F:\data\db2.mdf`, "physical_drive": "F"},
+					},
+				},
+				{
+					Name:   "INSTANCE_METRICS",
+					Fields: []map[string]string{{"os": "windows"}},
+				},
+			},
+			allocationUnits: `[{"BlockSize":4096,"Caption":"C:\\"},{"BlockSize":65536,"Caption":"D:\\"},{"BlockSize":4096,"Caption":"E:\\"},{"BlockSize":65536,"Caption":"F:\\mount\\"}]`,
+			want: []Details{
+				{
+					Name: "DB_LOG_DISK_SEPARATION",
+					Fields: []map[string]string{
+						{"db_name": "db1", "filetype": "0", "physical_name": `D:\data\db1.mdf`, "physical_drive": "D", "allocation_unit_size": "65536", "allocation_unit_64kb": "true"},
+						{"db_name": "db1", "filetype": "1", "physical_name": `e:\log\db1.ldf`, "physical_drive": "e", "allocation_unit_size": "4096", "allocation_unit_64kb": "false"},
+						{"db_name": "db2", "filetype": "0", "physical_name": `F:\data\db2.mdf`, "physical_drive": "F", "allocation_unit_size": "unknown", "allocation_unit_64kb": "unknown"},
+					},
+				},
+				{
+					Name:   "INSTANCE_METRICS",
+					Fields: []map[string]string{{"os": "windows"}},
+				},
+			},
+		},
+		{
+			name: "unknown physical drive",
+			details: []Details{
+				{
+					Name:   "DB_LOG_DISK_SEPARATION",
+					Fields: []map[string]string{{"db_name": "db1", "physical_drive": "unknown"}},
+				},
+			},
+			allocationUnits: `[{"BlockSize":65536,"Caption":"D:\\"}]`,
+			want: []Details{
+				{
+					Name:   "DB_LOG_DISK_SEPARATION",
+					Fields: []map[string]string{{"db_name": "db1", "physical_drive": "unknown", "allocation_unit_size": "unknown", "allocation_unit_64kb": "unknown"}},
+				},
+			},
+		},
+		{
+			name: "invalid allocation units",
+			details: []Details{
+				{
+					Name:   "DB_LOG_DISK_SEPARATION",
+					Fields: []map[string]string{{"db_name": "db1", "physical_drive": "D"}},
+				},
+			},
+			allocationUnits: "unknown",
+			want: []Details{
+				{
+					Name:   "DB_LOG_DISK_SEPARATION",
+					Fields: []map[string]string{{"db_name": "db1", "physical_drive": "D", "allocation_unit_size": "unknown", "allocation_unit_64kb": "unknown"}},
+				},
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			AddDataVolumeAllocationUnits(tc.details, tc.allocationUnits)
+			if diff := cmp.Diff(tc.want, tc.details); diff != "" {
+				t.Errorf("AddDataVolumeAllocationUnits() returned diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
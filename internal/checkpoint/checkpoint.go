@@ -0,0 +1,81 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package checkpoint records the targets collected in a collection cycle, so a restarted agent
+// can skip them until the next collection interval.
+package checkpoint
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/GoogleCloudPlatform/sql-server-agent/internal"
+)
+
+// Checkpoint is the state of the current collection cycle.
+type Checkpoint struct {
+	// CycleStart is the start time of the current collection cycle.
+	CycleStart time.Time `json:"cycle_start"`
+	// Targets are the targets collected successfully in the current cycle.
+	Targets map[string]bool `json:"targets"`
+
+	path string
+}
+
+// Load reads the checkpoint saved in path.
+// A new cycle starting at now is returned if there is no checkpoint or the saved cycle is older than
+// the collection interval.
+func Load(path string, interval time.Duration, now time.Time) (*Checkpoint, error) {
+	cp := &Checkpoint{CycleStart: now, Targets: map[string]bool{}, path: path}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cp, nil
+	}
+	if err != nil {
+		return cp, fmt.Errorf("failed to read the checkpoint file %s: %w", path, err)
+	}
+
+	saved := Checkpoint{}
+	if err := json.Unmarshal(b, &saved); err != nil {
+		return cp, fmt.Errorf("failed to parse the checkpoint file %s: %w", path, err)
+	}
+	if saved.CycleStart.After(now) || !now.Before(saved.CycleStart.Add(interval)) {
+		return cp, nil
+	}
+	if saved.Targets != nil {
+		cp.Targets = saved.Targets
+	}
+	cp.CycleStart = saved.CycleStart
+	return cp, nil
+}
+
+// Collected returns true if the target was collected in the current cycle.
+func (c *Checkpoint) Collected(target string) bool {
+	return c.Targets[target]
+}
+
+// MarkCollected records the target as collected in the current cycle and saves the checkpoint.
+func (c *Checkpoint) MarkCollected(target string) error {
+	c.Targets[target] = true
+	b, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	return internal.SaveToFile(c.path, b)
+}
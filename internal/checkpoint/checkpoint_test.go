@@ -0,0 +1,112 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package checkpoint
+
+import (
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+func TestMarkCollectedAndLoad(t *testing.T) {
+	p := path.Join(t.TempDir(), "test.checkpoint")
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	cp, err := Load(p, time.Hour, start)
+	if err != nil {
+		t.Fatalf("Load() returned an unexpected error: %v", err)
+	}
+	if err := cp.MarkCollected("0:instance-1"); err != nil {
+		t.Fatalf("MarkCollected() returned an unexpected error: %v", err)
+	}
+
+	got, err := Load(p, time.Hour, start.Add(30*time.Minute))
+	if err != nil {
+		t.Fatalf("Load() returned an unexpected error: %v", err)
+	}
+	if !got.CycleStart.Equal(start) {
+		t.Errorf("Load() returned cycle start %v, want %v", got.CycleStart, start)
+	}
+	if !got.Collected("0:instance-1") {
+		t.Errorf("Collected(%q) = false, want true", "0:instance-1")
+	}
+	if got.Collected("1:instance-2") {
+		t.Errorf("Collected(%q) = true, want false", "1:instance-2")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name          string
+		content       string
+		noFile        bool
+		now           time.Time
+		wantCollected bool
+		wantErr       bool
+	}{
+		{
+			name:          "skip targets collected in the current cycle",
+			content:       `{"cycle_start":"2026-01-01T00:00:00Z","targets":{"target":true}}`,
+			now:           start.Add(59 * time.Minute),
+			wantCollected: true,
+		},
+		{
+			name:    "new cycle after the collection interval",
+			content: `{"cycle_start":"2026-01-01T00:00:00Z","targets":{"target":true}}`,
+			now:     start.Add(time.Hour),
+		},
+		{
+			name:    "new cycle if the checkpoint is in the future",
+			content: `{"cycle_start":"2026-01-01T00:00:00Z","targets":{"target":true}}`,
+			now:     start.Add(-time.Minute),
+		},
+		{
+			name:   "new cycle without checkpoint file",
+			noFile: true,
+			now:    start,
+		},
+		{
+			name:    "new cycle with invalid checkpoint file",
+			content: `invalid`,
+			now:     start,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			p := path.Join(t.TempDir(), "test.checkpoint")
+			if !tc.noFile {
+				if err := os.WriteFile(p, []byte(tc.content), 0644); err != nil {
+					t.Fatalf("os.WriteFile() returned an unexpected error: %v", err)
+				}
+			}
+			cp, err := Load(p, time.Hour, tc.now)
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Errorf("Load() returned error: %v, want error: %v", err, tc.wantErr)
+			}
+			if cp == nil {
+				t.Fatal("Load() returned nil checkpoint")
+			}
+			if got := cp.Collected("target"); got != tc.wantCollected {
+				t.Errorf("Collected(%q) = %v, want %v", "target", got, tc.wantCollected)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package collectiondiff records the rules sent to workload manager for each target, so the rules
+// which did not change since the previous cycle can be left out of the request until the next full
+// refresh.
+package collectiondiff
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/GoogleCloudPlatform/sql-server-agent/internal"
+)
+
+// State is the record of the rules sent for each target.
+type State struct {
+	// Targets are the records of the targets keyed by target.
+	Targets map[string]*Target `json:"targets"`
+
+	path string
+}
+
+// Target is the record of the rules sent for a target.
+type Target struct {
+	// LastFullRefresh is the time all rules of the target were last sent.
+	LastFullRefresh time.Time `json:"last_full_refresh"`
+	// Hashes are the hashes of the fields of the rules last sent, keyed by rule name.
+	Hashes map[string]string `json:"hashes"`
+}
+
+// Load reads the state saved in path.
+// An empty state is returned if there is no state file, and with the error if the file can not be
+// read, so all rules are sent rather than none.
+func Load(path string) (*State, error) {
+	s := &State{Targets: map[string]*Target{}, path: path}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return s, fmt.Errorf("failed to read the collection state file %s: %w", path, err)
+	}
+
+	saved := State{}
+	if err := json.Unmarshal(b, &saved); err != nil {
+		return s, fmt.Errorf("failed to parse the collection state file %s: %w", path, err)
+	}
+	if saved.Targets != nil {
+		s.Targets = saved.Targets
+	}
+	return s, nil
+}
+
+// Changed returns the details of target to send at now and whether they are all of the details.
+// All details are sent if the target has no record, its last full refresh is older than
+// fullRefresh or in the future, otherwise only the details whose fields changed since they were
+// last sent.
+func (s *State) Changed(target string, details []internal.Details, now time.Time, fullRefresh time.Duration) ([]internal.Details, bool) {
+	t, ok := s.Targets[target]
+	if !ok || t.LastFullRefresh.After(now) || !now.Before(t.LastFullRefresh.Add(fullRefresh)) {
+		return details, true
+	}
+	changed := []internal.Details{}
+	for _, d := range details {
+		if h, ok := t.Hashes[d.Name]; !ok || h != hashFields(d) {
+			changed = append(changed, d)
+		}
+	}
+	return changed, false
+}
+
+// Record records details as sent to target at now and saves the state. full marks a full refresh.
+// The record of the target is replaced on a full refresh, so rules no longer collected are dropped.
+func (s *State) Record(target string, details []internal.Details, full bool, now time.Time) error {
+	t, ok := s.Targets[target]
+	if !ok || full {
+		t = &Target{LastFullRefresh: now, Hashes: map[string]string{}}
+		s.Targets[target] = t
+	}
+	for _, d := range details {
+		t.Hashes[d.Name] = hashFields(d)
+	}
+	b, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return internal.SaveToFile(s.path, b)
+}
+
+// hashFields returns the hash of the fields of d. The collection durations are left out, as they
+// differ in every cycle.
+func hashFields(d internal.Details) string {
+	// Maps are marshaled with sorted keys, so equal fields have equal hashes.
+	b, _ := json.Marshal(d.Fields)
+	h := sha256.Sum256(b)
+	return hex.EncodeToString(h[:])
+}
@@ -0,0 +1,186 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package collectiondiff
+
+import (
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/GoogleCloudPlatform/sql-server-agent/internal"
+)
+
+var (
+	start = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	maxdop = internal.Details{
+		Name:   "INSTANCE_MAXDOP",
+		Fields: []map[string]string{{"maxdop": "8"}},
+	}
+	maxdopChanged = internal.Details{
+		Name:   "INSTANCE_MAXDOP",
+		Fields: []map[string]string{{"maxdop": "4"}},
+	}
+	memory = internal.Details{
+		Name:   "INSTANCE_MEMORY",
+		Fields: []map[string]string{{"max_server_memory": "4096"}},
+	}
+)
+
+func TestChanged(t *testing.T) {
+	tests := []struct {
+		name     string
+		record   bool
+		details  []internal.Details
+		now      time.Time
+		want     []internal.Details
+		wantFull bool
+	}{
+		{
+			name:     "first run sends all details",
+			details:  []internal.Details{maxdop, memory},
+			now:      start,
+			want:     []internal.Details{maxdop, memory},
+			wantFull: true,
+		},
+		{
+			name:    "unchanged details are not sent",
+			record:  true,
+			details: []internal.Details{maxdop, memory},
+			now:     start.Add(time.Hour),
+			want:    []internal.Details{},
+		},
+		{
+			name:    "changed details are sent",
+			record:  true,
+			details: []internal.Details{maxdopChanged, memory},
+			now:     start.Add(time.Hour),
+			want:    []internal.Details{maxdopChanged},
+		},
+		{
+			name:    "new rules are sent",
+			record:  true,
+			details: []internal.Details{maxdop, memory, {Name: "INSTANCE_NEW"}},
+			now:     start.Add(time.Hour),
+			want:    []internal.Details{{Name: "INSTANCE_NEW"}},
+		},
+		{
+			name:    "collection durations are ignored",
+			record:  true,
+			details: []internal.Details{{Name: maxdop.Name, Fields: maxdop.Fields, CollectionDurationMs: map[string]int64{"INSTANCE_MAXDOP": 3}}},
+			now:     start.Add(time.Hour),
+			want:    []internal.Details{},
+		},
+		{
+			name:     "full refresh after the refresh interval",
+			record:   true,
+			details:  []internal.Details{maxdop, memory},
+			now:      start.Add(24 * time.Hour),
+			want:     []internal.Details{maxdop, memory},
+			wantFull: true,
+		},
+		{
+			name:     "full refresh if the record is in the future",
+			record:   true,
+			details:  []internal.Details{maxdop, memory},
+			now:      start.Add(-time.Minute),
+			want:     []internal.Details{maxdop, memory},
+			wantFull: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := Load(path.Join(t.TempDir(), "test.state"))
+			if err != nil {
+				t.Fatalf("Load() returned an unexpected error: %v", err)
+			}
+			if tc.record {
+				if err := s.Record("0:instance-1", []internal.Details{maxdop, memory}, true, start); err != nil {
+					t.Fatalf("Record() returned an unexpected error: %v", err)
+				}
+			}
+			got, gotFull := s.Changed("0:instance-1", tc.details, tc.now, 24*time.Hour)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Changed() returned an unexpected diff (-want +got):\n%s", diff)
+			}
+			if gotFull != tc.wantFull {
+				t.Errorf("Changed() returned full %v, want %v", gotFull, tc.wantFull)
+			}
+		})
+	}
+}
+
+func TestFullRefreshCadence(t *testing.T) {
+	p := path.Join(t.TempDir(), "test.state")
+	refresh := 3 * time.Hour
+	var gotFull []bool
+	for cycle := 0; cycle < 7; cycle++ {
+		// The state is loaded every cycle, as it is by the collection.
+		s, err := Load(p)
+		if err != nil {
+			t.Fatalf("Load() returned an unexpected error: %v", err)
+		}
+		now := start.Add(time.Duration(cycle) * time.Hour)
+		details, full := s.Changed("0:instance-1", []internal.Details{maxdop}, now, refresh)
+		gotFull = append(gotFull, full)
+		if err := s.Record("0:instance-1", details, full, now); err != nil {
+			t.Fatalf("Record() returned an unexpected error: %v", err)
+		}
+	}
+	want := []bool{true, false, false, true, false, false, true}
+	if diff := cmp.Diff(want, gotFull); diff != "" {
+		t.Errorf("full refreshes returned an unexpected diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestRecordKeepsTargetsApart(t *testing.T) {
+	p := path.Join(t.TempDir(), "test.state")
+	s, err := Load(p)
+	if err != nil {
+		t.Fatalf("Load() returned an unexpected error: %v", err)
+	}
+	if err := s.Record("0:instance-1", []internal.Details{maxdop}, true, start); err != nil {
+		t.Fatalf("Record() returned an unexpected error: %v", err)
+	}
+
+	got, err := Load(p)
+	if err != nil {
+		t.Fatalf("Load() returned an unexpected error: %v", err)
+	}
+	if _, full := got.Changed("0:instance-1", []internal.Details{maxdop}, start.Add(time.Hour), 24*time.Hour); full {
+		t.Errorf("Changed() of the recorded target returned full true, want false")
+	}
+	if _, full := got.Changed("1:instance-2", []internal.Details{maxdop}, start.Add(time.Hour), 24*time.Hour); !full {
+		t.Errorf("Changed() of another target returned full false, want true")
+	}
+}
+
+func TestLoadInvalidState(t *testing.T) {
+	p := path.Join(t.TempDir(), "test.state")
+	if err := os.WriteFile(p, []byte("invalid"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := Load(p)
+	if err == nil {
+		t.Errorf("Load() returned nil error, want error")
+	}
+	if _, full := s.Changed("0:instance-1", []internal.Details{maxdop}, start, time.Hour); !full {
+		t.Errorf("Changed() with an invalid state returned full false, want true")
+	}
+}
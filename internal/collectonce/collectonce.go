@@ -0,0 +1,89 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package collectonce runs the guest os and sql collections of a configuration once and returns
+// the collected details, without sending them to workload manager or saving them, so the
+// collections can be embedded in other tools.
+package collectonce
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/GoogleCloudPlatform/sql-server-agent/internal"
+	"github.com/GoogleCloudPlatform/sql-server-agent/internal/configuration"
+	configpb "github.com/GoogleCloudPlatform/sql-server-agent/protos/sqlserveragentconfig"
+)
+
+// Collectors run the collections of a single target.
+type Collectors struct {
+	// OS returns the guest os details of the target of credentialCfg.
+	OS func(ctx context.Context, credentialCfg *configpb.CredentialConfiguration) ([]internal.Details, error)
+	// SQL returns the sql details of the instance of sqlCfg on the target of credentialCfg.
+	SQL func(ctx context.Context, credentialCfg *configpb.CredentialConfiguration, sqlCfg *configuration.SQLConfig) ([]internal.Details, error)
+}
+
+// Collect runs the collections enabled in cfg once with c and returns the guest os and sql details
+// of all targets: every credential configuration for remote collection, or the first one for local
+// collection. The details of the sql instances of a target are merged by rule.
+// A target failing does not stop the collection of the others; the details collected are returned
+// with the errors of the failed targets.
+// The collections of each target are cut off after "max_cycle_duration_seconds", returning the
+// details collected so far with the "cycle_truncated" marker.
+func Collect(ctx context.Context, cfg *configpb.Configuration, c Collectors) (osDetails, sqlDetails []internal.Details, err error) {
+	credentialCfgs := cfg.GetCredentialConfiguration()
+	if len(credentialCfgs) == 0 {
+		return nil, nil, fmt.Errorf("empty credentials")
+	}
+	if !cfg.GetRemoteCollection() {
+		credentialCfgs = credentialCfgs[:1]
+	}
+
+	var errs []error
+	for _, credentialCfg := range credentialCfgs {
+		// The guest os and sql collections of a target share the maximum cycle duration, the details
+		// of both are marked if the cycle is cut off.
+		var osCount int
+		details, _ := internal.CollectWithinCycle(ctx, configuration.MaxCycleDuration(cfg), func(ctx context.Context) ([]internal.Details, error) {
+			var targetDetails []internal.Details
+			if cfg.GetCollectionConfiguration().GetCollectGuestOsMetrics() {
+				details, err := c.OS(ctx, credentialCfg)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("guest os collection of %q failed: %w", credentialCfg.GetInstanceName(), err))
+				}
+				targetDetails = append(targetDetails, details...)
+			}
+			osCount = len(targetDetails)
+			if !cfg.GetCollectionConfiguration().GetCollectSqlMetrics() {
+				return targetDetails, nil
+			}
+			var sqlDetails []internal.Details
+			for _, sqlCfg := range configuration.SQLConfigFromCredential(credentialCfg) {
+				details, err := c.SQL(ctx, credentialCfg, sqlCfg)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("sql collection of %q failed: %w", configuration.SQLHostName(sqlCfg), err))
+					continue
+				}
+				sqlDetails = internal.MergeDetails(sqlDetails, details)
+			}
+			return append(targetDetails, sqlDetails...), nil
+		})
+		osDetails = append(osDetails, details[:osCount]...)
+		sqlDetails = append(sqlDetails, details[osCount:]...)
+	}
+	return osDetails, sqlDetails, errors.Join(errs...)
+}
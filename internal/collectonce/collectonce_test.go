@@ -0,0 +1,195 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package collectonce
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/GoogleCloudPlatform/sql-server-agent/internal"
+	"github.com/GoogleCloudPlatform/sql-server-agent/internal/configuration"
+	configpb "github.com/GoogleCloudPlatform/sql-server-agent/protos/sqlserveragentconfig"
+)
+
+// fakeCollectors returns collectors reporting the instance name of each target and the host of each
+// sql instance, failing the targets and instances named "fail".
+func fakeCollectors() Collectors {
+	return Collectors{
+		OS: func(ctx context.Context, credentialCfg *configpb.CredentialConfiguration) ([]internal.Details, error) {
+			if credentialCfg.GetInstanceName() == "fail" {
+				return nil, errors.New("guest collection failed")
+			}
+			return []internal.Details{{Name: "OS", Fields: []map[string]string{{"target": credentialCfg.GetInstanceName()}}}}, nil
+		},
+		SQL: func(ctx context.Context, credentialCfg *configpb.CredentialConfiguration, sqlCfg *configuration.SQLConfig) ([]internal.Details, error) {
+			if sqlCfg.Host == "fail" {
+				return nil, errors.New("sql collection failed")
+			}
+			return []internal.Details{{Name: "SQL", Fields: []map[string]string{{"target": credentialCfg.GetInstanceName(), "host": sqlCfg.Host}}}}, nil
+		},
+	}
+}
+
+func credential(name string, hosts ...string) *configpb.CredentialConfiguration {
+	cred := &configpb.CredentialConfiguration{InstanceName: name}
+	for _, host := range hosts {
+		cred.SqlConfigurations = append(cred.SqlConfigurations, &configpb.CredentialConfiguration_SqlCredentials{Host: host})
+	}
+	return cred
+}
+
+func collectionConfiguration(guest, sql bool) *configpb.CollectionConfiguration {
+	return &configpb.CollectionConfiguration{CollectGuestOsMetrics: guest, CollectSqlMetrics: sql}
+}
+
+func TestCollect(t *testing.T) {
+	testcases := []struct {
+		name    string
+		cfg     *configpb.Configuration
+		wantOS  []internal.Details
+		wantSQL []internal.Details
+		wantErr bool
+	}{
+		{
+			name: "local collection of the first target",
+			cfg: &configpb.Configuration{
+				CollectionConfiguration: collectionConfiguration(true, true),
+				CredentialConfiguration: []*configpb.CredentialConfiguration{credential("vm1", "host1", "host2"), credential("vm2", "host3")},
+			},
+			wantOS: []internal.Details{{Name: "OS", Fields: []map[string]string{{"target": "vm1"}}}},
+			wantSQL: []internal.Details{{Name: "SQL", Fields: []map[string]string{
+				{"target": "vm1", "host": "host1"},
+				{"target": "vm1", "host": "host2"},
+			}}},
+		},
+		{
+			name: "remote collection of all targets",
+			cfg: &configpb.Configuration{
+				RemoteCollection:        true,
+				CollectionConfiguration: collectionConfiguration(true, true),
+				CredentialConfiguration: []*configpb.CredentialConfiguration{credential("vm1", "host1"), credential("vm2", "host2")},
+			},
+			wantOS: []internal.Details{
+				{Name: "OS", Fields: []map[string]string{{"target": "vm1"}}},
+				{Name: "OS", Fields: []map[string]string{{"target": "vm2"}}},
+			},
+			wantSQL: []internal.Details{
+				{Name: "SQL", Fields: []map[string]string{{"target": "vm1", "host": "host1"}}},
+				{Name: "SQL", Fields: []map[string]string{{"target": "vm2", "host": "host2"}}},
+			},
+		},
+		{
+			name: "guest collection disabled",
+			cfg: &configpb.Configuration{
+				CollectionConfiguration: collectionConfiguration(false, true),
+				CredentialConfiguration: []*configpb.CredentialConfiguration{credential("vm1", "host1")},
+			},
+			wantSQL: []internal.Details{{Name: "SQL", Fields: []map[string]string{{"target": "vm1", "host": "host1"}}}},
+		},
+		{
+			name: "sql collection disabled",
+			cfg: &configpb.Configuration{
+				CollectionConfiguration: collectionConfiguration(true, false),
+				CredentialConfiguration: []*configpb.CredentialConfiguration{credential("vm1", "host1")},
+			},
+			wantOS: []internal.Details{{Name: "OS", Fields: []map[string]string{{"target": "vm1"}}}},
+		},
+		{
+			name: "failed targets are reported with the collected details",
+			cfg: &configpb.Configuration{
+				RemoteCollection:        true,
+				CollectionConfiguration: collectionConfiguration(true, true),
+				CredentialConfiguration: []*configpb.CredentialConfiguration{credential("fail", "host1"), credential("vm2", "fail", "host2")},
+			},
+			wantOS: []internal.Details{{Name: "OS", Fields: []map[string]string{{"target": "vm2"}}}},
+			wantSQL: []internal.Details{
+				{Name: "SQL", Fields: []map[string]string{{"target": "fail", "host": "host1"}}},
+				{Name: "SQL", Fields: []map[string]string{{"target": "vm2", "host": "host2"}}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "empty credentials",
+			cfg: &configpb.Configuration{
+				CollectionConfiguration: collectionConfiguration(true, true),
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			gotOS, gotSQL, err := Collect(context.Background(), tc.cfg, fakeCollectors())
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Errorf("Collect() returned error %v, want error: %v", err, tc.wantErr)
+			}
+			if diff := cmp.Diff(tc.wantOS, gotOS); diff != "" {
+				t.Errorf("Collect() returned an unexpected diff of the os details (-want +got): %v", diff)
+			}
+			if diff := cmp.Diff(tc.wantSQL, gotSQL); diff != "" {
+				t.Errorf("Collect() returned an unexpected diff of the sql details (-want +got): %v", diff)
+			}
+		})
+	}
+}
+
+func TestCollectMaxCycleDuration(t *testing.T) {
+	c := fakeCollectors()
+	// The sql instance "slow" blocks until the cycle is cut off.
+	fastSQL := c.SQL
+	c.SQL = func(ctx context.Context, credentialCfg *configpb.CredentialConfiguration, sqlCfg *configuration.SQLConfig) ([]internal.Details, error) {
+		if sqlCfg.Host == "slow" {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		return fastSQL(ctx, credentialCfg, sqlCfg)
+	}
+	cfg := &configpb.Configuration{
+		CollectionConfiguration: collectionConfiguration(true, true),
+		RemoteCollection:        true,
+		MaxCycleDurationSeconds: 1,
+		CredentialConfiguration: []*configpb.CredentialConfiguration{credential("vm1", "host1", "slow"), credential("vm2", "host2")},
+	}
+
+	start := time.Now()
+	gotOS, gotSQL, err := Collect(context.Background(), cfg, c)
+	if elapsed := time.Since(start); elapsed > 30*time.Second {
+		t.Errorf("Collect() took %v, want the cycle of vm1 to be cut off", elapsed)
+	}
+	if err == nil {
+		t.Errorf("Collect() returned no error, want the error of the cut off instance")
+	}
+	// The details collected before the cut off are returned with the marker, other targets get a
+	// cycle of their own.
+	wantOS := []internal.Details{
+		{Name: "OS", Fields: []map[string]string{{"target": "vm1", "cycle_truncated": "true"}}},
+		{Name: "OS", Fields: []map[string]string{{"target": "vm2"}}},
+	}
+	wantSQL := []internal.Details{
+		{Name: "SQL", Fields: []map[string]string{{"target": "vm1", "host": "host1", "cycle_truncated": "true"}}},
+		{Name: "SQL", Fields: []map[string]string{{"target": "vm2", "host": "host2"}}},
+	}
+	if diff := cmp.Diff(wantOS, gotOS); diff != "" {
+		t.Errorf("Collect() returned wrong os details (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(wantSQL, gotSQL); diff != "" {
+		t.Errorf("Collect() returned wrong sql details (-want +got):\n%s", diff)
+	}
+}
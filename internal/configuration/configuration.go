@@ -18,12 +18,28 @@ limitations under the License.
 package configuration
 
 import (
+	"crypto/sha256"
+	"crypto/tls"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"github.com/GoogleCloudPlatform/sql-server-agent/internal"
+	"github.com/GoogleCloudPlatform/sql-server-agent/internal/secretmanager"
 	configpb "github.com/GoogleCloudPlatform/sql-server-agent/protos/sqlserveragentconfig"
+	"github.com/GoogleCloudPlatform/workloadagentplatform/integration/common/shared/gce/metadataserver"
 	"github.com/GoogleCloudPlatform/workloadagentplatform/integration/common/shared/log"
 )
 
@@ -55,15 +71,27 @@ var (
 		CollectionTimeoutSeconds: 10,
 		MaxRetries:               5,
 		RetryIntervalInSeconds:   3600,
+		GuestRuleConcurrency:     1,
+		SqlRuleConcurrency:       1,
+		WlmRequestTimeoutSeconds: 60,
+		MinTlsVersion:            "1.2",
 	}
 )
 
 // SQLConfig .
 type SQLConfig struct {
-	Host       string
-	Username   string
-	SecretName string
-	PortNumber int32
+	Host                  string
+	Username              string
+	SecretName            string
+	PortNumber            int32
+	HostNameInCertificate string
+	ClusterNetworkName    string
+	ExtraConnectionParams string
+	SecretProjectID       string
+	AuthMode              configpb.CredentialConfiguration_SqlCredentials_AuthMode
+	AccessTokenFile       string
+	NamedPipe             string
+	ServerSNI             string
 }
 
 // GuestConfig .
@@ -74,6 +102,9 @@ type GuestConfig struct {
 	GuestPortNumber        int32
 	LinuxRemote            bool
 	LinuxSSHPrivateKeyPath string
+	HostKeyFingerprints    []string
+	WindowsTransport       string
+	GuestSecretProjectID   string
 }
 
 // LoadConfiguration loads configuration from config file.
@@ -85,35 +116,163 @@ func LoadConfiguration(p string) (*configpb.Configuration, error) {
 	if err != nil {
 		return defaultConfig, fmt.Errorf("failed to load the configuration file. filepath: %v, error: %v", p, err)
 	}
+	return parseConfiguration(b, filepath.Dir(p))
+}
+
+// ParseConfiguration parses the configuration read from r, e.g. stdin in containers which do not
+// mount the configuration file. A relative "credential_configuration_file" is resolved against dir.
+// Returns nil with error if the configuration can not be read or is in invalid format.
+func ParseConfiguration(r io.Reader, dir string) (*configpb.Configuration, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the configuration: %v", err)
+	}
+	return parseConfiguration(b, dir)
+}
+
+// parseConfiguration parses the configuration in b and validates its values.
+func parseConfiguration(b []byte, dir string) (*configpb.Configuration, error) {
 	cfg := configpb.Configuration{}
 	if err := protojson.Unmarshal(b, &cfg); err != nil {
 		return nil, err
 	}
+	if err := loadCredentialConfigurationFile(&cfg, dir); err != nil {
+		return nil, err
+	}
+	if _, err := SecretProvider(&cfg); err != nil {
+		return nil, err
+	}
 	return validateConfigValues(&cfg), nil
 }
 
+// SecretProvider returns the secret provider of the "secret_provider" of cfg.
+// Returns error if the provider is unknown or misses its required options, e.g. the vault address.
+func SecretProvider(cfg *configpb.Configuration) (secretmanager.SecretProvider, error) {
+	pc := cfg.GetSecretProviderConfiguration()
+	return secretmanager.NewProvider(cfg.GetSecretProvider(), secretmanager.Options{
+		FileDirectory:  pc.GetFileDirectory(),
+		VaultAddress:   pc.GetVaultAddress(),
+		VaultMount:     pc.GetVaultMount(),
+		VaultKey:       pc.GetVaultKey(),
+		VaultTokenFile: pc.GetVaultTokenFile(),
+	})
+}
+
+// loadCredentialConfigurationFile appends the credential configurations read from
+// "credential_configuration_file" to cfg. A relative path is resolved against dir.
+// Returns error if the file can not be read or is in invalid format.
+func loadCredentialConfigurationFile(cfg *configpb.Configuration, dir string) error {
+	p := cfg.GetCredentialConfigurationFile()
+	if p == "" {
+		return nil
+	}
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(dir, p)
+	}
+	b, err := os.ReadFile(p)
+	if err != nil {
+		return fmt.Errorf("failed to load the credential configuration file. filepath: %v, error: %v", p, err)
+	}
+	credCfg := configpb.Configuration{}
+	if err := protojson.Unmarshal(b, &credCfg); err != nil {
+		return fmt.Errorf("invalid credential configuration file. filepath: %v, error: %v", p, err)
+	}
+	cfg.CredentialConfiguration = append(cfg.CredentialConfiguration, credCfg.GetCredentialConfiguration()...)
+	return nil
+}
+
+// FilterCredentialConfiguration returns a copy of the configuration which only keeps the
+// credential configuration matching the given target. The target is matched against
+// "instance_name" first. Otherwise a numeric target is used as the index of the credential
+// configuration. Returns error if no credential configuration matches the target.
+func FilterCredentialConfiguration(cfg *configpb.Configuration, target string) (*configpb.Configuration, error) {
+	credentialCfgs := cfg.GetCredentialConfiguration()
+	var match *configpb.CredentialConfiguration
+	for _, credentialCfg := range credentialCfgs {
+		if credentialCfg.GetInstanceName() == target {
+			match = credentialCfg
+			break
+		}
+	}
+	if match == nil {
+		if i, err := strconv.Atoi(target); err == nil && i >= 0 && i < len(credentialCfgs) {
+			match = credentialCfgs[i]
+		}
+	}
+	if match == nil {
+		return nil, fmt.Errorf("no credential configuration matches the target %q", target)
+	}
+	filtered := proto.Clone(cfg).(*configpb.Configuration)
+	filtered.CredentialConfiguration = []*configpb.CredentialConfiguration{proto.Clone(match).(*configpb.CredentialConfiguration)}
+	return filtered, nil
+}
+
 // SQLConfigFromCredential returns config for SQL collection.
 func SQLConfigFromCredential(creCfg *configpb.CredentialConfiguration) []*SQLConfig {
 	var sqlConfigs []*SQLConfig
 	for _, sqlCfg := range creCfg.GetSqlConfigurations() {
 		sqlConfigs = append(sqlConfigs, &SQLConfig{
-			Host:       sqlCfg.GetHost(),
-			Username:   sqlCfg.GetUserName(),
-			SecretName: sqlCfg.GetSecretName(),
-			PortNumber: sqlCfg.GetPortNumber(),
+			Host:                  sqlCfg.GetHost(),
+			Username:              sqlCfg.GetUserName(),
+			SecretName:            sqlCfg.GetSecretName(),
+			PortNumber:            sqlCfg.GetPortNumber(),
+			HostNameInCertificate: sqlCfg.GetHostNameInCertificate(),
+			ClusterNetworkName:    sqlCfg.GetClusterNetworkName(),
+			ExtraConnectionParams: sqlCfg.GetExtraConnectionParams(),
+			SecretProjectID:       sqlCfg.GetSecretProjectId(),
+			AuthMode:              sqlCfg.GetAuthMode(),
+			AccessTokenFile:       sqlCfg.GetAccessTokenFile(),
+			NamedPipe:             sqlCfg.GetNamedPipe(),
+			ServerSNI:             sqlCfg.GetServerSni(),
 		})
 	}
 	return sqlConfigs
 }
 
+// SQLConnectionString returns the connection string for SQL collection.
+// The connection string of the access token mode has no user id and password, the token is passed
+// to the connector instead.
+// The collection connects to master, where the rules read the instance wide views, so an offline
+// default database of the login does not fail the collection of the instance. A database in the
+// extra connection params takes precedence.
+// A named pipe replaces the server and port, go-mssqldb rejects named pipe connections with a port.
+func SQLConnectionString(sqlCfg *SQLConfig, password string) string {
+	conn := fmt.Sprintf("server=%s;user id=%s;password=%s;port=%d;database=master;", SQLHostName(sqlCfg), sqlCfg.Username, password, sqlCfg.PortNumber)
+	if UsesAccessToken(sqlCfg) {
+		conn = fmt.Sprintf("server=%s;port=%d;database=master;", SQLHostName(sqlCfg), sqlCfg.PortNumber)
+	}
+	if sqlCfg.NamedPipe != "" {
+		conn = fmt.Sprintf("server=np:%s;user id=%s;password=%s;database=master;", sqlCfg.NamedPipe, sqlCfg.Username, password)
+		if UsesAccessToken(sqlCfg) {
+			conn = fmt.Sprintf("server=np:%s;database=master;", sqlCfg.NamedPipe)
+		}
+	}
+	if sqlCfg.HostNameInCertificate != "" {
+		conn += fmt.Sprintf("hostnameincertificate=%s;", sqlCfg.HostNameInCertificate)
+	}
+	// go-mssqldb sends the host name in the certificate as the server name of the TLS handshake.
+	if sqlCfg.ServerSNI != "" {
+		conn += fmt.Sprintf("hostnameincertificate=%s;serverspn=MSSQLSvc/%s:%d;", sqlCfg.ServerSNI, sqlCfg.ServerSNI, sqlCfg.PortNumber)
+	}
+	if params := strings.TrimSpace(sqlCfg.ExtraConnectionParams); params != "" {
+		conn += params
+		if !strings.HasSuffix(params, ";") {
+			conn += ";"
+		}
+	}
+	return conn
+}
+
 // GuestConfigFromCredential returns config for guest OS collection.
 func GuestConfigFromCredential(creCfg *configpb.CredentialConfiguration) *GuestConfig {
 	switch creCfg.GuestConfigurations.(type) {
 	case *configpb.CredentialConfiguration_RemoteWin:
 		return &GuestConfig{
-			ServerName:      creCfg.GetRemoteWin().GetServerName(),
-			GuestUserName:   creCfg.GetRemoteWin().GetGuestUserName(),
-			GuestSecretName: creCfg.GetRemoteWin().GetGuestSecretName(),
+			ServerName:           creCfg.GetRemoteWin().GetServerName(),
+			GuestUserName:        creCfg.GetRemoteWin().GetGuestUserName(),
+			GuestSecretName:      creCfg.GetRemoteWin().GetGuestSecretName(),
+			WindowsTransport:     creCfg.GetRemoteWin().GetTransport().String(),
+			GuestSecretProjectID: creCfg.GetRemoteWin().GetGuestSecretProjectId(),
 		}
 	case *configpb.CredentialConfiguration_RemoteLinux:
 		return &GuestConfig{
@@ -122,11 +281,68 @@ func GuestConfigFromCredential(creCfg *configpb.CredentialConfiguration) *GuestC
 			GuestPortNumber:        creCfg.GetRemoteLinux().GetGuestPortNumber(),
 			LinuxRemote:            true,
 			LinuxSSHPrivateKeyPath: creCfg.GetRemoteLinux().GetLinuxSshPrivateKeyPath(),
+			HostKeyFingerprints:    creCfg.GetRemoteLinux().GetHostKeyFingerprints(),
 		}
 	}
 	return &GuestConfig{}
 }
 
+// ConfigSchema returns a JSON Schema of the configuration file derived from the Configuration proto.
+func ConfigSchema() (string, error) {
+	schema := messageSchema((&configpb.Configuration{}).ProtoReflect().Descriptor())
+	schema["$schema"] = "http://json-schema.org/draft-07/schema#"
+	schema["title"] = "Google Cloud Agent for SQL Server configuration"
+	return internal.PrettyStruct(schema)
+}
+
+// messageSchema returns the JSON Schema of the given message. Fields are keyed by their proto
+// names, which is the format used in configuration.json.
+func messageSchema(md protoreflect.MessageDescriptor) map[string]any {
+	properties := map[string]any{}
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		s := fieldSchema(fd)
+		if fd.IsList() {
+			s = map[string]any{"type": "array", "items": s}
+		}
+		if opts, ok := fd.Options().(*descriptorpb.FieldOptions); ok && opts.GetDeprecated() {
+			s["deprecated"] = true
+		}
+		properties[string(fd.Name())] = s
+	}
+	return map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+}
+
+// fieldSchema returns the JSON Schema of a single value of the given field.
+func fieldSchema(fd protoreflect.FieldDescriptor) map[string]any {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return map[string]any{"type": "boolean"}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Uint32Kind, protoreflect.Fixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return map[string]any{"type": "integer"}
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return map[string]any{"type": "number"}
+	case protoreflect.EnumKind:
+		var values []string
+		for i := 0; i < fd.Enum().Values().Len(); i++ {
+			values = append(values, string(fd.Enum().Values().Get(i).Name()))
+		}
+		return map[string]any{"type": "string", "enum": values}
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return messageSchema(fd.Message())
+	default:
+		return map[string]any{"type": "string"}
+	}
+}
+
 // ValidateConfigValues verifies if the numeric values from the config file are valid.
 // If not, the default value will be set to the field.
 func validateConfigValues(config *configpb.Configuration) *configpb.Configuration {
@@ -164,6 +380,78 @@ func validateConfigValues(config *configpb.Configuration) *configpb.Configuratio
 				config.RetryIntervalInSeconds = defaultValue
 			},
 		},
+		{
+			name:            "wlm_request_timeout_seconds",
+			defaultValue:    60,
+			minValue:        1,
+			valueFromConfig: config.GetWlmRequestTimeoutSeconds(),
+			setDefaultValue: func(defaultValue int32) {
+				config.WlmRequestTimeoutSeconds = defaultValue
+			},
+		},
+		{
+			name:            "sql_collection_timeout_seconds",
+			defaultValue:    0,
+			minValue:        0,
+			valueFromConfig: config.GetSqlCollectionTimeoutSeconds(),
+			setDefaultValue: func(defaultValue int32) {
+				config.SqlCollectionTimeoutSeconds = defaultValue
+			},
+		},
+		{
+			name:            "os_collection_timeout_seconds",
+			defaultValue:    0,
+			minValue:        0,
+			valueFromConfig: config.GetOsCollectionTimeoutSeconds(),
+			setDefaultValue: func(defaultValue int32) {
+				config.OsCollectionTimeoutSeconds = defaultValue
+			},
+		},
+		{
+			name:            "collection_jitter_seconds",
+			defaultValue:    0,
+			minValue:        0,
+			valueFromConfig: config.GetCollectionJitterSeconds(),
+			setDefaultValue: func(defaultValue int32) {
+				config.CollectionJitterSeconds = defaultValue
+			},
+		},
+		{
+			name:            "full_refresh_interval_in_seconds",
+			defaultValue:    0,
+			minValue:        0,
+			valueFromConfig: config.GetFullRefreshIntervalInSeconds(),
+			setDefaultValue: func(defaultValue int32) {
+				config.FullRefreshIntervalInSeconds = defaultValue
+			},
+		},
+		{
+			name:            "max_cycle_duration_seconds",
+			defaultValue:    0,
+			minValue:        0,
+			valueFromConfig: config.GetMaxCycleDurationSeconds(),
+			setDefaultValue: func(defaultValue int32) {
+				config.MaxCycleDurationSeconds = defaultValue
+			},
+		},
+		{
+			name:            "rule_failure_log_limit",
+			defaultValue:    1,
+			minValue:        -1,
+			valueFromConfig: config.GetRuleFailureLogLimit(),
+			setDefaultValue: func(defaultValue int32) {
+				config.RuleFailureLogLimit = defaultValue
+			},
+		},
+		{
+			name:            "startup_delay_seconds",
+			defaultValue:    0,
+			minValue:        0,
+			valueFromConfig: config.GetStartupDelaySeconds(),
+			setDefaultValue: func(defaultValue int32) {
+				config.StartupDelaySeconds = defaultValue
+			},
+		},
 		{
 			name:            "guest_os_metrics_collection_interval_in_seconds",
 			defaultValue:    3600,
@@ -194,30 +482,282 @@ func validateConfigValues(config *configpb.Configuration) *configpb.Configuratio
 	return config
 }
 
+// MinTLSVersion returns the minimum TLS version of connections to Google Cloud APIs set in the
+// configuration. Defaults to TLS 1.2, which is also returned with the error of an invalid value.
+func MinTLSVersion(cfg *configpb.Configuration) (uint16, error) {
+	switch cfg.GetMinTlsVersion() {
+	case "", "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	}
+	return tls.VersionTLS12, fmt.Errorf(`invalid value for "min_tls_version": %q, supported values are "1.2" and "1.3"`, cfg.GetMinTlsVersion())
+}
+
+// ClientCertificate returns the client certificate presented to Google Cloud APIs in mutual TLS,
+// loaded from "client_cert_path" and "client_key_path". Returns nil if neither is set.
+func ClientCertificate(cfg *configpb.Configuration) (*tls.Certificate, error) {
+	certPath, keyPath := cfg.GetClientCertPath(), cfg.GetClientKeyPath()
+	if certPath == "" && keyPath == "" {
+		return nil, nil
+	}
+	if certPath == "" || keyPath == "" {
+		return nil, fmt.Errorf(`"client_cert_path" and "client_key_path" must be set together`)
+	}
+	cert, err := tls.LoadX509KeyPair(certPath, keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the client certificate %q: %v", certPath, err)
+	}
+	return &cert, nil
+}
+
+// CollectPhysicalDriveMapping returns whether the database files should be mapped to the
+// physical drives they are stored on during SQL Server collection. Defaults to true when
+// "collect_physical_drive_mapping" is not set.
+func CollectPhysicalDriveMapping(cfg *configpb.Configuration) bool {
+	c := cfg.GetCollectionConfiguration()
+	if c == nil || c.CollectPhysicalDriveMapping == nil {
+		return true
+	}
+	return c.GetCollectPhysicalDriveMapping()
+}
+
+// SendHeartbeat returns true if the agent sends heartbeats to workload manager, which it does only
+// when "send_heartbeat" is set and both guest os and sql collection are disabled.
+func SendHeartbeat(cfg *configpb.Configuration) bool {
+	c := cfg.GetCollectionConfiguration()
+	return cfg.GetSendHeartbeat() && !c.GetCollectGuestOsMetrics() && !c.GetCollectSqlMetrics()
+}
+
+// SQLCollectionTimeout returns the timeout of each rule of the sql collection.
+// "sql_collection_timeout_seconds" overrides "collection_timeout_seconds" when it is set.
+func SQLCollectionTimeout(cfg *configpb.Configuration) time.Duration {
+	return collectionTimeout(cfg, cfg.GetSqlCollectionTimeoutSeconds())
+}
+
+// OSCollectionTimeout returns the timeout of each rule of the guest os collection.
+// "os_collection_timeout_seconds" overrides "collection_timeout_seconds" when it is set.
+func OSCollectionTimeout(cfg *configpb.Configuration) time.Duration {
+	return collectionTimeout(cfg, cfg.GetOsCollectionTimeoutSeconds())
+}
+
+// MaxCycleDuration returns the maximum duration of the collection of each target, 0 if
+// "max_cycle_duration_seconds" is not set.
+func MaxCycleDuration(cfg *configpb.Configuration) time.Duration {
+	return time.Duration(cfg.GetMaxCycleDurationSeconds()) * time.Second
+}
+
+// FullRefreshInterval returns the interval of sending all rules to workload manager when only the
+// changed rules are sent. Defaults to a day when "full_refresh_interval_in_seconds" is not set.
+func FullRefreshInterval(cfg *configpb.Configuration) time.Duration {
+	if s := cfg.GetFullRefreshIntervalInSeconds(); s > 0 {
+		return time.Duration(s) * time.Second
+	}
+	return 24 * time.Hour
+}
+
+// RuleFailureLogLimit returns the number of identical sql rule failures logged in detail per
+// collection, or 0 if "rule_failure_log_limit" is -1 and all failures are logged in detail.
+// Defaults to 1 when "rule_failure_log_limit" is not set.
+func RuleFailureLogLimit(cfg *configpb.Configuration) int {
+	switch n := cfg.GetRuleFailureLogLimit(); {
+	case n == 0:
+		return 1
+	case n < 0:
+		return 0
+	default:
+		return int(n)
+	}
+}
+
+// collectionTimeout returns override seconds if it is positive, or the global collection timeout.
+func collectionTimeout(cfg *configpb.Configuration, override int32) time.Duration {
+	if override > 0 {
+		return time.Duration(override) * time.Second
+	}
+	return time.Duration(cfg.GetCollectionTimeoutSeconds()) * time.Second
+}
+
+// Environment variables overriding the "log_directory" and "onetime_output_directory" of the
+// configuration.
+const (
+	LogDirectoryEnv           = "GOOGLE_CLOUD_SQL_SERVER_AGENT_LOG_DIR"
+	OnetimeOutputDirectoryEnv = "GOOGLE_CLOUD_SQL_SERVER_AGENT_OUTPUT_DIR"
+)
+
+// LogDirectory returns the directory of the log file from, in order of precedence, flagValue, the
+// LogDirectoryEnv environment variable, "log_directory" and defaultDir.
+func LogDirectory(flagValue string, cfg *configpb.Configuration, defaultDir string) string {
+	return firstPath(flagValue, os.Getenv(LogDirectoryEnv), cfg.GetLogDirectory(), defaultDir)
+}
+
+// OnetimeOutputDirectory returns the directory of the results of the onetime collections from, in
+// order of precedence, flagValue, the OnetimeOutputDirectoryEnv environment variable,
+// "onetime_output_directory" and logDir, so the results are next to the log file by default.
+func OnetimeOutputDirectory(flagValue string, cfg *configpb.Configuration, logDir string) string {
+	return firstPath(flagValue, os.Getenv(OnetimeOutputDirectoryEnv), cfg.GetOnetimeOutputDirectory(), logDir)
+}
+
+// firstPath returns the first of paths which is not blank.
+func firstPath(paths ...string) string {
+	for _, p := range paths {
+		if p = strings.TrimSpace(p); p != "" {
+			return p
+		}
+	}
+	return ""
+}
+
+// WritableDirectory returns an error if the agent can not write files in dir, e.g. on a read-only
+// filesystem. dir is created if it does not exist.
+func WritableDirectory(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, ".write-check-*")
+	if err != nil {
+		return err
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+// UsesAccessToken returns true if the agent authenticates to the sql instance with an access token
+// instead of a user name and password.
+func UsesAccessToken(sqlCfg *SQLConfig) bool {
+	return sqlCfg.AuthMode == configpb.CredentialConfiguration_SqlCredentials_ACCESS_TOKEN
+}
+
+// Environments the agent runs in.
+const (
+	EnvironmentGCE    = "gce"
+	EnvironmentOnPrem = "on_prem"
+)
+
+// IsOnPrem returns true if the configuration sets the "on_prem" environment.
+// Unknown environments are logged and treated as "gce".
+func IsOnPrem(cfg *configpb.Configuration) bool {
+	switch env := strings.ToLower(cfg.GetEnvironment()); env {
+	case EnvironmentOnPrem:
+		return true
+	case "", EnvironmentGCE:
+		return false
+	default:
+		log.Logger.Warnw("Invalid environment in the configuration. Using gce", "environment", cfg.GetEnvironment())
+		return false
+	}
+}
+
+// CloudProperties returns the properties of the host the agent runs on. On-prem hosts have no
+// metadata server, so their properties are read from "instance_properties" of the configuration
+// and readMetadata, which retries until the metadata server responds, is not called.
+func CloudProperties(cfg *configpb.Configuration, readMetadata func() *metadataserver.CloudProperties) *metadataserver.CloudProperties {
+	if !IsOnPrem(cfg) {
+		return readMetadata()
+	}
+	p := cfg.GetInstanceProperties()
+	if err := validateInstanceProperties(p); err != nil {
+		log.Logger.Errorw("Invalid instance_properties in the on_prem configuration", "error", err)
+	}
+	instanceID := p.GetInstanceId()
+	if instanceID == "" {
+		instanceID = p.GetInstanceName()
+	}
+	return &metadataserver.CloudProperties{
+		ProjectID:        p.GetProjectId(),
+		NumericProjectID: p.GetProjectNumber(),
+		InstanceID:       instanceID,
+		Zone:             p.GetZone(),
+		InstanceName:     p.GetInstanceName(),
+		Image:            metadataserver.ImageUnknown,
+		MachineType:      metadataserver.MachineTypeUnknown,
+	}
+}
+
+// validateInstanceProperties verifies the instance properties of an on-prem configuration
+// have the fields the collected data is reported with.
+func validateInstanceProperties(p *configpb.InstanceProperties) error {
+	var missing []string
+	if p.GetProjectId() == "" {
+		missing = append(missing, "project_id")
+	}
+	if p.GetZone() == "" {
+		missing = append(missing, "zone")
+	}
+	if p.GetInstanceName() == "" {
+		missing = append(missing, "instance_name")
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing %s", strings.Join(missing, ", "))
+	}
+	if !strings.Contains(p.GetZone(), "-") {
+		return fmt.Errorf("zone %q is not a zone, e.g. us-central1-a", p.GetZone())
+	}
+	return nil
+}
+
+// SecretProjectID returns the project of a secret in Secret Manager.
+// The project set in the credential configuration overrides the project of the agent.
+func SecretProjectID(projectID, agentProjectID string) string {
+	if projectID != "" {
+		return projectID
+	}
+	return agentProjectID
+}
+
+// SQLHostName returns the host to connect to and report for the sql instance.
+// The cluster network name takes precedence over host for failover cluster instances.
+func SQLHostName(sqlCfg *SQLConfig) string {
+	if sqlCfg.ClusterNetworkName != "" {
+		return sqlCfg.ClusterNetworkName
+	}
+	return sqlCfg.Host
+}
+
 // ValidateCredCfgSQL validates if the configuration file is valid for SQL collection.
 // Each CredentialConfiguration must provide valid "user_name", "secret_name" and "port_number".
+// "user_name" and "secret_name" are not used in the ACCESS_TOKEN "auth_mode".
+// "extra_connection_params" must not specify the password.
+// "port_number" is not used with a "named_pipe", which must be a full pipe path.
+// "server_sni" must be a host name and needs the TLS connection it is sent in, see validServerSNI.
 // If remote collection is enabled, the following fields must be provided:
 //
-//	"host", "instance_id", "instance_name"
+//	"host" or "cluster_network_name", "instance_id", "instance_name"
 func ValidateCredCfgSQL(remote, windows bool, sqlCfg *SQLConfig, guestCfg *GuestConfig, instanceID, instanceName string) error {
 	errMsg := "invalid value for"
 	hasError := false
 
-	if sqlCfg.Username == "" {
+	if _, ok := configpb.CredentialConfiguration_SqlCredentials_AuthMode_name[int32(sqlCfg.AuthMode)]; !ok {
+		errMsg = errMsg + ` "auth_mode"`
+		hasError = true
+	}
+	if !UsesAccessToken(sqlCfg) && sqlCfg.Username == "" {
 		errMsg = errMsg + ` "user_name"`
 		hasError = true
 	}
-	if sqlCfg.SecretName == "" {
+	if !UsesAccessToken(sqlCfg) && sqlCfg.SecretName == "" {
 		errMsg = errMsg + ` "secret_name"`
 		hasError = true
 	}
-	if sqlCfg.PortNumber == 0 {
+	if sqlCfg.PortNumber == 0 && sqlCfg.NamedPipe == "" {
 		errMsg = errMsg + ` "port_number"`
 		hasError = true
 	}
+	if sqlCfg.NamedPipe != "" && !validNamedPipe(sqlCfg.NamedPipe) {
+		errMsg = errMsg + ` "named_pipe"`
+		hasError = true
+	}
+	if sqlCfg.ServerSNI != "" && !validServerSNI(sqlCfg) {
+		errMsg = errMsg + ` "server_sni"`
+		hasError = true
+	}
+	if specifiesPassword(sqlCfg.ExtraConnectionParams) {
+		errMsg = errMsg + ` "extra_connection_params"`
+		hasError = true
+	}
 
 	if remote {
-		if sqlCfg.Host == "" {
+		if sqlCfg.Host == "" && sqlCfg.ClusterNetworkName == "" {
 			errMsg = errMsg + ` "host"`
 			hasError = true
 		}
@@ -250,6 +790,10 @@ func ValidateCredCfgSQL(remote, windows bool, sqlCfg *SQLConfig, guestCfg *Guest
 				errMsg = errMsg + ` "guest_port_number"`
 				hasError = true
 			}
+			if !validHostKeyFingerprints(guestCfg.HostKeyFingerprints) {
+				errMsg = errMsg + ` "host_key_fingerprints"`
+				hasError = true
+			}
 		}
 	}
 
@@ -259,6 +803,65 @@ func ValidateCredCfgSQL(remote, windows bool, sqlCfg *SQLConfig, guestCfg *Guest
 	return nil
 }
 
+// validHostKeyFingerprints returns true if all the fingerprints are SHA256 fingerprints as printed
+// by ssh-keygen -lf.
+func validHostKeyFingerprints(fingerprints []string) bool {
+	for _, f := range fingerprints {
+		if !strings.HasPrefix(strings.TrimSpace(f), "SHA256:") {
+			return false
+		}
+	}
+	return true
+}
+
+// namedPipeRegex matches a full named pipe path, \\host\pipe\name. The name may contain
+// backslashes, e.g. MSSQL$INSTANCE\sql\query. Semicolons would end the server of the
+// connection string.
+var namedPipeRegex = regexp.MustCompile(`(?i)^\\\\[^\\;]+\\pipe\\[^;]*[^\\;]$`)
+
+// validNamedPipe returns true if p is a full named pipe path.
+func validNamedPipe(p string) bool {
+	return namedPipeRegex.MatchString(p)
+}
+
+// serverSNIRegex matches a host name.
+var serverSNIRegex = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9.-]*[A-Za-z0-9])?$`)
+
+// validServerSNI returns true if the server SNI of sqlCfg is a host name and is sent to the
+// instance. The SNI replaces the host name in the certificate and the server SPN, so neither may be
+// set elsewhere, and it is only sent in a TLS handshake, which named pipes and encrypt=disable do
+// not route by.
+func validServerSNI(sqlCfg *SQLConfig) bool {
+	if !serverSNIRegex.MatchString(sqlCfg.ServerSNI) || sqlCfg.HostNameInCertificate != "" || sqlCfg.NamedPipe != "" {
+		return false
+	}
+	for _, param := range strings.Split(sqlCfg.ExtraConnectionParams, ";") {
+		key, value, _ := strings.Cut(param, "=")
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "hostnameincertificate", "serverspn":
+			return false
+		case "encrypt":
+			if strings.EqualFold(strings.TrimSpace(value), "disable") {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// specifiesPassword returns true if the connection string parameters set the password, which
+// must only come from secret manager.
+func specifiesPassword(params string) bool {
+	for _, param := range strings.Split(params, ";") {
+		key, _, _ := strings.Cut(param, "=")
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "password", "pwd":
+			return true
+		}
+	}
+	return false
+}
+
 // ValidateCredCfgGuest validates if the configuration file is valid for guest collection.
 // If remote collection is enabled, the following fields must be provided:
 // "server_name", "guest_user_name", "guest_secret_name", "instance_id", "instance_name"
@@ -296,6 +899,10 @@ func ValidateCredCfgGuest(remote, windows bool, guestCfg *GuestConfig, instanceI
 				errMsg = errMsg + ` "guest_port_number"`
 				hasError = true
 			}
+			if !validHostKeyFingerprints(guestCfg.HostKeyFingerprints) {
+				errMsg = errMsg + ` "host_key_fingerprints"`
+				hasError = true
+			}
 		}
 	}
 
@@ -304,3 +911,113 @@ func ValidateCredCfgGuest(remote, windows bool, guestCfg *GuestConfig, instanceI
 	}
 	return nil
 }
+
+// LogLevel returns the log level set in the configuration. Defaults to info level.
+func LogLevel(cfg *configpb.Configuration) zapcore.Level {
+	logLevel := map[string]zapcore.Level{
+		"DEBUG":   zapcore.DebugLevel,
+		"INFO":    zapcore.InfoLevel,
+		"WARNING": zapcore.WarnLevel,
+		"ERROR":   zapcore.ErrorLevel,
+	}
+	if level, ok := logLevel[cfg.GetLogLevel()]; ok {
+		return level
+	}
+	return zapcore.InfoLevel
+}
+
+// Watcher detects the changes of the configuration between the cycles of the collection service
+// and applies the changed log level to the running agent.
+type Watcher struct {
+	level zap.AtomicLevel
+	hash  [sha256.Size]byte
+	cfg   *configpb.Configuration
+}
+
+// NewWatcher returns a Watcher applying the changed log level to level.
+func NewWatcher(level zap.AtomicLevel) *Watcher {
+	return &Watcher{level: level}
+}
+
+// Update compares cfg with the configuration of the previous update and returns the names of the
+// changed top level fields. The first update only records cfg and returns nil.
+func (w *Watcher) Update(cfg *configpb.Configuration) []string {
+	b, err := proto.MarshalOptions{Deterministic: true}.Marshal(cfg)
+	if err != nil {
+		log.Logger.Warnw("Failed to marshal the configuration", "error", err)
+		return nil
+	}
+	hash := sha256.Sum256(b)
+	prev, prevHash := w.cfg, w.hash
+	w.hash, w.cfg = hash, cfg
+	if prev == nil || hash == prevHash {
+		return nil
+	}
+	changed := changedFields(prev, cfg)
+	if slices.Contains(changed, "log_level") {
+		w.level.SetLevel(LogLevel(cfg))
+	}
+	return changed
+}
+
+// changedFields returns the names of the top level fields that differ between prev and cfg.
+func changedFields(prev, cfg *configpb.Configuration) []string {
+	p, c := prev.ProtoReflect(), cfg.ProtoReflect()
+	var changed []string
+	fields := c.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if p.Has(fd) != c.Has(fd) || !p.Get(fd).Equal(c.Get(fd)) {
+			changed = append(changed, string(fd.Name()))
+		}
+	}
+	return changed
+}
+
+// redactedFields are the fields Redact replaces, since they identify the credentials of the agent.
+// Fields named like a password are redacted as well.
+var redactedFields = map[protoreflect.Name]bool{
+	"user_name":               true,
+	"secret_name":             true,
+	"guest_user_name":         true,
+	"guest_secret_name":       true,
+	"extra_connection_params": true,
+}
+
+// redactedValue replaces the value of the redacted fields.
+const redactedValue = "<redacted>"
+
+// Redact returns a copy of cfg with the user names, secret names and connection parameters of the
+// credential configurations replaced, so it can be shared for support.
+func Redact(cfg *configpb.Configuration) *configpb.Configuration {
+	if cfg == nil {
+		return nil
+	}
+	redacted := proto.Clone(cfg).(*configpb.Configuration)
+	redactMessage(redacted.ProtoReflect())
+	return redacted
+}
+
+// redactMessage replaces the set redacted fields of m and of its nested messages.
+func redactMessage(m protoreflect.Message) {
+	var fields []protoreflect.FieldDescriptor
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		switch {
+		case fd.IsMap():
+		case fd.Kind() == protoreflect.MessageKind && fd.IsList():
+			for i := 0; i < v.List().Len(); i++ {
+				redactMessage(v.List().Get(i).Message())
+			}
+		case fd.Kind() == protoreflect.MessageKind:
+			redactMessage(v.Message())
+		case fd.Kind() == protoreflect.StringKind && !fd.IsList():
+			if redactedFields[fd.Name()] || strings.Contains(string(fd.Name()), "password") {
+				fields = append(fields, fd)
+			}
+		}
+		return true
+	})
+	for _, fd := range fields {
+		m.Set(fd, protoreflect.ValueOfString(redactedValue))
+	}
+}
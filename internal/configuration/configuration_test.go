@@ -17,13 +17,32 @@ limitations under the License.
 package configuration
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
 	"os"
 	"path"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/microsoft/go-mssqldb/msdsn"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/testing/protocmp"
 	configpb "github.com/GoogleCloudPlatform/sql-server-agent/protos/sqlserveragentconfig"
+	"github.com/GoogleCloudPlatform/workloadagentplatform/integration/common/shared/gce/metadataserver"
 )
 
 func TestLoadConfiguration(t *testing.T) {
@@ -61,6 +80,7 @@ func TestLoadConfiguration(t *testing.T) {
 				LogLevel:                 "DEBUG",
 				CollectionTimeoutSeconds: 30,
 				RetryIntervalInSeconds:   3600,
+				WlmRequestTimeoutSeconds: 60,
 			},
 		},
 		{
@@ -130,476 +150,2160 @@ func TestLoadConfiguration(t *testing.T) {
 	}
 }
 
-func TestSQLConfigFromCredential(t *testing.T) {
-	tests := []struct {
-		name  string
-		input *configpb.CredentialConfiguration
-		want  []*SQLConfig
+func TestLoadConfigurationCredentialConfigurationFile(t *testing.T) {
+	testcases := []struct {
+		name        string
+		credFile    string
+		credContent string
+		want        []*configpb.CredentialConfiguration
+		wantErr     bool
 	}{
 		{
-			name: "SQLConfig with new configuration format-local",
-			input: &configpb.CredentialConfiguration{
-				SqlConfigurations: []*configpb.CredentialConfiguration_SqlCredentials{
-					&configpb.CredentialConfiguration_SqlCredentials{
-						Host:       "test-host",
-						UserName:   "test-user-name",
-						SecretName: "test-secret-name",
-						PortNumber: 1433,
+			name:     "credential configurations are merged",
+			credFile: "credentials.json",
+			credContent: `{
+	"credential_configuration": [
+		{
+			"instance_name": "remote-instance",
+			"sql_configurations": [{"host": "remote-host", "user_name": "remote-user", "secret_name": "remote-secret", "port_number": 1433}]
+		}
+	]
+}`,
+			want: []*configpb.CredentialConfiguration{
+				&configpb.CredentialConfiguration{
+					SqlConfigurations: []*configpb.CredentialConfiguration_SqlCredentials{
+						&configpb.CredentialConfiguration_SqlCredentials{Host: ".", UserName: "test-user-name", SecretName: "test-secret-name", PortNumber: 1433},
 					},
 				},
-			},
-			want: []*SQLConfig{
-				&SQLConfig{
-					Host:       "test-host",
-					Username:   "test-user-name",
-					SecretName: "test-secret-name",
-					PortNumber: 1433,
+				&configpb.CredentialConfiguration{
+					InstanceName: "remote-instance",
+					SqlConfigurations: []*configpb.CredentialConfiguration_SqlCredentials{
+						&configpb.CredentialConfiguration_SqlCredentials{Host: "remote-host", UserName: "remote-user", SecretName: "remote-secret", PortNumber: 1433},
+					},
 				},
 			},
 		},
+		{
+			name:     "missing credential configuration file",
+			credFile: "missing.json",
+			wantErr:  true,
+		},
+		{
+			name:        "invalid credential configuration file",
+			credFile:    "credentials.json",
+			credContent: `{"credential_configuration": "invalid"}`,
+			wantErr:     true,
+		},
 	}
-
-	for _, tc := range tests {
+	for _, tc := range testcases {
 		t.Run(tc.name, func(t *testing.T) {
-			got := SQLConfigFromCredential(tc.input)
-			if diff := cmp.Diff(tc.want, got); diff != "" {
-				t.Errorf("SQLConfigFromCredential(%v) returned an unexpected diff (-want +got): %v", tc.input, diff)
+			dir := t.TempDir()
+			content := fmt.Sprintf(`{
+	"collection_configuration": {},
+	"credential_configuration": [
+		{
+			"sql_configurations": [{"host": ".", "user_name": "test-user-name", "secret_name": "test-secret-name", "port_number": 1433}]
+		}
+	],
+	"credential_configuration_file": %q
+}`, tc.credFile)
+			if err := os.WriteFile(path.Join(dir, "configuration.json"), []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if tc.credContent != "" {
+				if err := os.WriteFile(path.Join(dir, tc.credFile), []byte(tc.credContent), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := LoadConfiguration(path.Join(dir, "configuration.json"))
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Fatalf("LoadConfiguration() returned error %v, want error presence = %v", err, tc.wantErr)
+			}
+			if diff := cmp.Diff(tc.want, got.GetCredentialConfiguration(), protocmp.Transform()); diff != "" {
+				t.Errorf("LoadConfiguration() returned wrong credential configuration (-want +got):\n%s", diff)
 			}
 		})
 	}
 }
 
-func TestGuestConfigFromCredential(t *testing.T) {
-	tests := []struct {
-		name  string
-		input *configpb.CredentialConfiguration
-		want  *GuestConfig
+// errReader is an io.Reader which always fails.
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) { return 0, errors.New("read error") }
+
+func TestParseConfiguration(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(path.Join(dir, "credentials.json"), []byte(`{"credential_configuration": [{"instance_name": "from-file"}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	testcases := []struct {
+		name    string
+		r       io.Reader
+		want    *configpb.Configuration
+		wantErr bool
 	}{
 		{
-			name: "GuestConfig with new configuration format-local",
-			input: &configpb.CredentialConfiguration{
-				GuestConfigurations: &configpb.CredentialConfiguration_LocalCollection{
-					LocalCollection: true,
+			name: "valid configuration",
+			r:    strings.NewReader(`{"collection_configuration": {"collect_sql_metrics": true}, "log_level": "DEBUG", "collection_timeout_seconds": 5}`),
+			want: &configpb.Configuration{
+				CollectionConfiguration: &configpb.CollectionConfiguration{
+					CollectSqlMetrics:                         true,
+					GuestOsMetricsCollectionIntervalInSeconds: 3600,
+					SqlMetricsCollectionIntervalInSeconds:     3600,
 				},
+				LogLevel:                 "DEBUG",
+				CollectionTimeoutSeconds: 5,
+				RetryIntervalInSeconds:   3600,
+				WlmRequestTimeoutSeconds: 60,
 			},
-			want: &GuestConfig{},
 		},
 		{
-			name: "GuestConfig with new configuration format-remote_win",
-			input: &configpb.CredentialConfiguration{
-				GuestConfigurations: &configpb.CredentialConfiguration_RemoteWin{
-					RemoteWin: &configpb.CredentialConfiguration_GuestCredentialsRemoteWin{
-						ServerName:      "test-server-name",
-						GuestUserName:   "test-guest-user-name",
-						GuestSecretName: "test-guest-secret-name",
-					},
+			name: "credential configuration file relative to dir",
+			r:    strings.NewReader(`{"collection_configuration": {}, "collection_timeout_seconds": 5, "credential_configuration_file": "credentials.json"}`),
+			want: &configpb.Configuration{
+				CollectionConfiguration: &configpb.CollectionConfiguration{
+					GuestOsMetricsCollectionIntervalInSeconds: 3600,
+					SqlMetricsCollectionIntervalInSeconds:     3600,
 				},
-			},
-			want: &GuestConfig{
-				ServerName:      "test-server-name",
-				GuestUserName:   "test-guest-user-name",
-				GuestSecretName: "test-guest-secret-name",
+				CredentialConfiguration:     []*configpb.CredentialConfiguration{{InstanceName: "from-file"}},
+				CredentialConfigurationFile: "credentials.json",
+				CollectionTimeoutSeconds:    5,
+				RetryIntervalInSeconds:      3600,
+				WlmRequestTimeoutSeconds:    60,
 			},
 		},
 		{
-			name: "GuestConfig with new configuration format-remote_linux",
-			input: &configpb.CredentialConfiguration{
-				GuestConfigurations: &configpb.CredentialConfiguration_RemoteLinux{
-					RemoteLinux: &configpb.CredentialConfiguration_GuestCredentialsRemoteLinux{
-						ServerName:             "test-server-name",
-						GuestUserName:          "test-guest-user-name",
-						GuestPortNumber:        22,
-						LinuxSshPrivateKeyPath: "test-linux-ssh-private-key-path",
-					},
+			name: "vault secret provider",
+			r:    strings.NewReader(`{"collection_configuration": {}, "collection_timeout_seconds": 5, "secret_provider": "vault", "secret_provider_configuration": {"vault_address": "https://vault.example.com:8200"}}`),
+			want: &configpb.Configuration{
+				CollectionConfiguration: &configpb.CollectionConfiguration{
+					GuestOsMetricsCollectionIntervalInSeconds: 3600,
+					SqlMetricsCollectionIntervalInSeconds:     3600,
 				},
+				SecretProvider:              "vault",
+				SecretProviderConfiguration: &configpb.SecretProviderConfiguration{VaultAddress: "https://vault.example.com:8200"},
+				CollectionTimeoutSeconds:    5,
+				RetryIntervalInSeconds:      3600,
+				WlmRequestTimeoutSeconds:    60,
 			},
-			want: &GuestConfig{
-				ServerName:             "test-server-name",
-				GuestUserName:          "test-guest-user-name",
-				GuestPortNumber:        22,
-				LinuxRemote:            true,
-				LinuxSSHPrivateKeyPath: "test-linux-ssh-private-key-path",
-			},
+		},
+		{
+			name:    "unknown secret provider",
+			r:       strings.NewReader(`{"secret_provider": "vualt"}`),
+			wantErr: true,
+		},
+		{
+			name:    "vault secret provider without vault address",
+			r:       strings.NewReader(`{"secret_provider": "vault"}`),
+			wantErr: true,
+		},
+		{
+			name:    "empty input",
+			r:       strings.NewReader(""),
+			wantErr: true,
+		},
+		{
+			name:    "invalid json",
+			r:       strings.NewReader(`{"log_level": `),
+			wantErr: true,
+		},
+		{
+			name:    "read error",
+			r:       errReader{},
+			wantErr: true,
 		},
 	}
-
-	for _, tc := range tests {
+	for _, tc := range testcases {
 		t.Run(tc.name, func(t *testing.T) {
-			got := GuestConfigFromCredential(tc.input)
-			if diff := cmp.Diff(tc.want, got); diff != "" {
-				t.Errorf("GuestConfigFromCredential(%v) returned an unexpected diff (-want +got): %v", tc.input, diff)
+			got, err := ParseConfiguration(tc.r, dir)
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Fatalf("ParseConfiguration() returned error %v, want error presence = %v", err, tc.wantErr)
+			}
+			if diff := cmp.Diff(tc.want, got, protocmp.Transform()); diff != "" {
+				t.Errorf("ParseConfiguration() returned diff (-want +got):\n%s", diff)
 			}
 		})
 	}
 }
 
-func TestValidateConfigValues(t *testing.T) {
-	testcases := []struct {
-		name  string
-		input *configpb.Configuration
-		want  *configpb.Configuration
+func TestFilterCredentialConfiguration(t *testing.T) {
+	cfg := &configpb.Configuration{
+		LogLevel: "DEBUG",
+		CredentialConfiguration: []*configpb.CredentialConfiguration{
+			&configpb.CredentialConfiguration{InstanceName: "instance-1", InstanceId: "1"},
+			&configpb.CredentialConfiguration{InstanceName: "instance-2", InstanceId: "2"},
+			&configpb.CredentialConfiguration{InstanceName: "0", InstanceId: "3"},
+		},
+	}
+	tests := []struct {
+		name    string
+		target  string
+		want    *configpb.Configuration
+		wantErr bool
 	}{
 		{
-			name: "values are all invalid",
-			input: &configpb.Configuration{
-				CollectionConfiguration: &configpb.CollectionConfiguration{},
-				MaxRetries:              -2,
-			},
+			name:   "match by instance name",
+			target: "instance-2",
 			want: &configpb.Configuration{
-				CollectionConfiguration: &configpb.CollectionConfiguration{
-					GuestOsMetricsCollectionIntervalInSeconds: 3600,
-					SqlMetricsCollectionIntervalInSeconds:     3600,
+				LogLevel: "DEBUG",
+				CredentialConfiguration: []*configpb.CredentialConfiguration{
+					&configpb.CredentialConfiguration{InstanceName: "instance-2", InstanceId: "2"},
 				},
-				CollectionTimeoutSeconds: 10,
-				MaxRetries:               3,
-				RetryIntervalInSeconds:   3600,
 			},
 		},
 		{
-			name: "values are all valid",
-			input: &configpb.Configuration{
-				CollectionConfiguration: &configpb.CollectionConfiguration{
-					GuestOsMetricsCollectionIntervalInSeconds: 1,
-					SqlMetricsCollectionIntervalInSeconds:     1,
+			name:   "match by index",
+			target: "1",
+			want: &configpb.Configuration{
+				LogLevel: "DEBUG",
+				CredentialConfiguration: []*configpb.CredentialConfiguration{
+					&configpb.CredentialConfiguration{InstanceName: "instance-2", InstanceId: "2"},
 				},
-				CollectionTimeoutSeconds: 1,
-				MaxRetries:               1,
-				RetryIntervalInSeconds:   1,
 			},
+		},
+		{
+			name:   "instance name takes precedence over index",
+			target: "0",
 			want: &configpb.Configuration{
-				CollectionConfiguration: &configpb.CollectionConfiguration{
-					GuestOsMetricsCollectionIntervalInSeconds: 1,
-					SqlMetricsCollectionIntervalInSeconds:     1,
+				LogLevel: "DEBUG",
+				CredentialConfiguration: []*configpb.CredentialConfiguration{
+					&configpb.CredentialConfiguration{InstanceName: "0", InstanceId: "3"},
 				},
-				CollectionTimeoutSeconds: 1,
-				MaxRetries:               1,
-				RetryIntervalInSeconds:   1,
 			},
 		},
+		{
+			name:    "unknown instance name",
+			target:  "instance-3",
+			wantErr: true,
+		},
+		{
+			name:    "index out of range",
+			target:  "3",
+			wantErr: true,
+		},
 	}
 
-	for _, tc := range testcases {
+	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			got := validateConfigValues(tc.input)
-			if diff := cmp.Diff(got, tc.want, protocmp.Transform()); diff != "" {
-				t.Errorf("ValidateConfigValues() returned wrong result (-got +want):\n%s", diff)
+			got, err := FilterCredentialConfiguration(cfg, tc.target)
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Fatalf("FilterCredentialConfiguration(%q) = %v, want error presence = %v", tc.target, err, tc.wantErr)
+			}
+			if diff := cmp.Diff(tc.want, got, protocmp.Transform()); diff != "" {
+				t.Errorf("FilterCredentialConfiguration(%q) returned an unexpected diff (-want +got): %v", tc.target, diff)
+			}
+			if len(cfg.GetCredentialConfiguration()) != 3 {
+				t.Errorf("FilterCredentialConfiguration(%q) modified the input configuration", tc.target)
 			}
 		})
 	}
 }
 
-func TestValidateCredCfgSQL(t *testing.T) {
-	testcases := []struct {
-		name             string
-		inputSQLConfig   *SQLConfig
-		inputGuestConfig *GuestConfig
-		remote           bool
-		windows          bool
-		instanceID       string
-		instanceName     string
-		wantErr          bool
-		wantErrMsg       string
+func TestSQLConfigFromCredential(t *testing.T) {
+	tests := []struct {
+		name  string
+		input *configpb.CredentialConfiguration
+		want  []*SQLConfig
 	}{
 		{
-			name: "success-local",
-			inputSQLConfig: &SQLConfig{
-				Username:   "test-user-name",
-				SecretName: "test-secret-name",
-				PortNumber: 1433,
+			name: "SQLConfig with new configuration format-local",
+			input: &configpb.CredentialConfiguration{
+				SqlConfigurations: []*configpb.CredentialConfiguration_SqlCredentials{
+					&configpb.CredentialConfiguration_SqlCredentials{
+						Host:       "test-host",
+						UserName:   "test-user-name",
+						SecretName: "test-secret-name",
+						PortNumber: 1433,
+					},
+				},
+			},
+			want: []*SQLConfig{
+				&SQLConfig{
+					Host:       "test-host",
+					Username:   "test-user-name",
+					SecretName: "test-secret-name",
+					PortNumber: 1433,
+				},
 			},
 		},
 		{
-			name: "success-remote",
-			inputSQLConfig: &SQLConfig{
-				Host:       "test-host",
-				Username:   "test-user-name",
-				SecretName: "test-secret-name",
-				PortNumber: 1433,
+			name: "SQLConfig with host name in certificate",
+			input: &configpb.CredentialConfiguration{
+				SqlConfigurations: []*configpb.CredentialConfiguration_SqlCredentials{
+					&configpb.CredentialConfiguration_SqlCredentials{
+						Host:                  "test-host",
+						UserName:              "test-user-name",
+						SecretName:            "test-secret-name",
+						PortNumber:            1433,
+						HostNameInCertificate: "test-certificate-host",
+					},
+				},
 			},
-			inputGuestConfig: &GuestConfig{
-				ServerName:      "test-server-name",
-				GuestUserName:   "test-guest-user-name",
-				GuestSecretName: "test-guest-secret-name",
+			want: []*SQLConfig{
+				&SQLConfig{
+					Host:                  "test-host",
+					Username:              "test-user-name",
+					SecretName:            "test-secret-name",
+					PortNumber:            1433,
+					HostNameInCertificate: "test-certificate-host",
+				},
 			},
-			instanceID:   "test-instance-id",
-			instanceName: "test-instance-name",
-			remote:       true,
-			windows:      true,
 		},
 		{
-			name: "failure-local-missing-user_name",
-			inputSQLConfig: &SQLConfig{
-				SecretName: "test-secret-name",
-				PortNumber: 1433,
+			name: "SQLConfig with cluster network name",
+			input: &configpb.CredentialConfiguration{
+				SqlConfigurations: []*configpb.CredentialConfiguration_SqlCredentials{
+					&configpb.CredentialConfiguration_SqlCredentials{
+						UserName:           "test-user-name",
+						SecretName:         "test-secret-name",
+						PortNumber:         1433,
+						ClusterNetworkName: "test-fci-vnn",
+					},
+				},
 			},
-			wantErr:    true,
-			wantErrMsg: `invalid value for "user_name"`,
-		},
-		{
-			name: "failure-local-missing-secret_name",
-			inputSQLConfig: &SQLConfig{
-				Username:   "test-user-name",
-				PortNumber: 1433,
+			want: []*SQLConfig{
+				&SQLConfig{
+					Username:           "test-user-name",
+					SecretName:         "test-secret-name",
+					PortNumber:         1433,
+					ClusterNetworkName: "test-fci-vnn",
+				},
 			},
-			wantErr:    true,
-			wantErrMsg: `invalid value for "secret_name"`,
 		},
 		{
-			name: "failure-local-missing-port_number",
-			inputSQLConfig: &SQLConfig{
-				Username:   "test-user-name",
-				SecretName: "test-secret-name",
+			name: "SQLConfig with extra connection params",
+			input: &configpb.CredentialConfiguration{
+				SqlConfigurations: []*configpb.CredentialConfiguration_SqlCredentials{
+					&configpb.CredentialConfiguration_SqlCredentials{
+						Host:                  "test-host",
+						UserName:              "test-user-name",
+						SecretName:            "test-secret-name",
+						PortNumber:            1433,
+						ExtraConnectionParams: "packet size=8192;",
+					},
+				},
+			},
+			want: []*SQLConfig{
+				&SQLConfig{
+					Host:                  "test-host",
+					Username:              "test-user-name",
+					SecretName:            "test-secret-name",
+					PortNumber:            1433,
+					ExtraConnectionParams: "packet size=8192;",
+				},
 			},
-			wantErr:    true,
-			wantErrMsg: `invalid value for "port_number"`,
 		},
 		{
-			name:             "failure-remote-win",
-			inputSQLConfig:   &SQLConfig{},
-			inputGuestConfig: &GuestConfig{},
-			windows:          true,
-			remote:           true,
-			wantErr:          true,
-			wantErrMsg:       `invalid value for "user_name" "secret_name" "port_number" "host" "server_name" "guest_user_name" "guest_secret_name" "instance_id" "instance_name"`,
+			name: "SQLConfig with secret project id",
+			input: &configpb.CredentialConfiguration{
+				SqlConfigurations: []*configpb.CredentialConfiguration_SqlCredentials{
+					&configpb.CredentialConfiguration_SqlCredentials{
+						Host:            "test-host",
+						UserName:        "test-user-name",
+						SecretName:      "test-secret-name",
+						PortNumber:      1433,
+						SecretProjectId: "test-secret-project",
+					},
+				},
+			},
+			want: []*SQLConfig{
+				&SQLConfig{
+					Host:            "test-host",
+					Username:        "test-user-name",
+					SecretName:      "test-secret-name",
+					PortNumber:      1433,
+					SecretProjectID: "test-secret-project",
+				},
+			},
 		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := SQLConfigFromCredential(tc.input)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("SQLConfigFromCredential(%v) returned an unexpected diff (-want +got): %v", tc.input, diff)
+			}
+		})
+	}
+}
+
+func TestSQLConnectionString(t *testing.T) {
+	tests := []struct {
+		name     string
+		sqlCfg   *SQLConfig
+		password string
+		want     string
+	}{
 		{
-			name: "failure-remote-missing host",
-			inputSQLConfig: &SQLConfig{
+			name: "default connection string",
+			sqlCfg: &SQLConfig{
+				Host:       "test-host",
 				Username:   "test-user-name",
-				SecretName: "test-secret-name",
 				PortNumber: 1433,
 			},
-			inputGuestConfig: &GuestConfig{
-				ServerName:      "test-server-name",
-				GuestUserName:   "test-guest-user-name",
-				GuestSecretName: "test-guest-secret-name",
-			},
-			remote:       true,
-			windows:      true,
-			instanceID:   "test-instance-id",
-			instanceName: "test-instance-name",
-			wantErr:      true,
-			wantErrMsg:   `invalid value for "host"`,
+			password: "test-password",
+			want:     "server=test-host;user id=test-user-name;password=test-password;port=1433;database=master;",
 		},
 		{
-			name: "failure-remote-linux-missing linux_ssh_private_key_path",
-			inputSQLConfig: &SQLConfig{
+			name: "access token connection string",
+			sqlCfg: &SQLConfig{
 				Host:       "test-host",
 				Username:   "test-user-name",
-				SecretName: "test-secret-name",
 				PortNumber: 1433,
+				AuthMode:   configpb.CredentialConfiguration_SqlCredentials_ACCESS_TOKEN,
 			},
-			inputGuestConfig: &GuestConfig{
-				ServerName:      "test-server-name",
-				GuestUserName:   "test-guest-user-name",
-				GuestPortNumber: 22,
+			want: "server=test-host;port=1433;database=master;",
+		},
+		{
+			name: "connection string with host name in certificate",
+			sqlCfg: &SQLConfig{
+				Host:                  "sql.example.com",
+				Username:              "test-user-name",
+				PortNumber:            1433,
+				HostNameInCertificate: "lb.example.com",
 			},
-			remote:       true,
-			instanceID:   "test-instance-id",
-			instanceName: "test-instance-name",
-			wantErr:      true,
-			wantErrMsg:   `invalid value for "linux_ssh_private_key_path"`,
+			password: "test-password",
+			want:     "server=sql.example.com;user id=test-user-name;password=test-password;port=1433;database=master;hostnameincertificate=lb.example.com;",
 		},
 		{
-			name: "failure-remote-linux-missing guest_port_number",
-			inputSQLConfig: &SQLConfig{
-				Host:       "test-host",
-				Username:   "test-user-name",
-				SecretName: "test-secret-name",
-				PortNumber: 1433,
+			name: "connection string with cluster network name",
+			sqlCfg: &SQLConfig{
+				Host:               "node1",
+				Username:           "test-user-name",
+				PortNumber:         1433,
+				ClusterNetworkName: "sqlfci",
 			},
-			inputGuestConfig: &GuestConfig{
-				ServerName:             "test-server-name",
-				GuestUserName:          "test-guest-user-name",
-				LinuxSSHPrivateKeyPath: "test-ssh-private-key-path",
+			password: "test-password",
+			want:     "server=sqlfci;user id=test-user-name;password=test-password;port=1433;database=master;",
+		},
+		{
+			name: "connection string with extra connection params",
+			sqlCfg: &SQLConfig{
+				Host:                  "test-host",
+				Username:              "test-user-name",
+				PortNumber:            1433,
+				ExtraConnectionParams: "packet size=8192;keepalive=30",
 			},
-			remote:       true,
-			wantErr:      true,
-			instanceID:   "test-instance-id",
-			instanceName: "test-instance-name",
-			wantErrMsg:   `invalid value for "guest_port_number"`,
+			password: "test-password",
+			want:     "server=test-host;user id=test-user-name;password=test-password;port=1433;database=master;packet size=8192;keepalive=30;",
 		},
 		{
-			name: "failure-remote-win-missing-instance_id",
-			inputSQLConfig: &SQLConfig{
-				Host:       "test-host",
-				Username:   "test-user-name",
-				SecretName: "test-secret-name",
-				PortNumber: 1433,
+			name: "extra connection params with trailing separator",
+			sqlCfg: &SQLConfig{
+				Host:                  "test-host",
+				Username:              "test-user-name",
+				PortNumber:            1433,
+				HostNameInCertificate: "lb.example.com",
+				ExtraConnectionParams: "log=1;",
 			},
-			inputGuestConfig: &GuestConfig{
-				ServerName:      "test-server-name",
-				GuestUserName:   "test-guest-user-name",
-				GuestSecretName: "test-guest-secret-name",
+			password: "test-password",
+			want:     "server=test-host;user id=test-user-name;password=test-password;port=1433;database=master;hostnameincertificate=lb.example.com;log=1;",
+		},
+		{
+			name: "extra connection params with database",
+			sqlCfg: &SQLConfig{
+				Host:                  "test-host",
+				Username:              "test-user-name",
+				PortNumber:            1433,
+				ExtraConnectionParams: "database=monitoring",
 			},
-			instanceName: "test-instance-name",
-			remote:       true,
-			windows:      true,
-			wantErr:      true,
-			wantErrMsg:   `invalid value for "instance_id"`,
+			password: "test-password",
+			want:     "server=test-host;user id=test-user-name;password=test-password;port=1433;database=master;database=monitoring;",
 		},
 		{
-			name: "failure-remote-missing-instance_name",
-			inputSQLConfig: &SQLConfig{
-				Host:       "test-host",
+			name: "connection string with server sni",
+			sqlCfg: &SQLConfig{
+				Host:       "listener.example.com",
 				Username:   "test-user-name",
-				SecretName: "test-secret-name",
 				PortNumber: 1433,
+				ServerSNI:  "sql2.example.com",
 			},
-			inputGuestConfig: &GuestConfig{
-				ServerName:      "test-server-name",
-				GuestUserName:   "test-guest-user-name",
-				GuestSecretName: "test-guest-secret-name",
+			password: "test-password",
+			want:     "server=listener.example.com;user id=test-user-name;password=test-password;port=1433;database=master;hostnameincertificate=sql2.example.com;serverspn=MSSQLSvc/sql2.example.com:1433;",
+		},
+		{
+			name: "access token connection string with server sni and extra connection params",
+			sqlCfg: &SQLConfig{
+				Host:                  "listener.example.com",
+				PortNumber:            1433,
+				AuthMode:              configpb.CredentialConfiguration_SqlCredentials_ACCESS_TOKEN,
+				ServerSNI:             "sql2.example.com",
+				ExtraConnectionParams: "encrypt=true",
 			},
-			remote:     true,
-			windows:    true,
-			instanceID: "test-instance-id",
-			wantErr:    true,
-			wantErrMsg: `invalid value for "instance_name"`,
+			want: "server=listener.example.com;port=1433;database=master;hostnameincertificate=sql2.example.com;serverspn=MSSQLSvc/sql2.example.com:1433;encrypt=true;",
 		},
 		{
-			name: "success-remote-linux",
-			inputSQLConfig: &SQLConfig{
+			name: "connection string with named pipe",
+			sqlCfg: &SQLConfig{
 				Host:       "test-host",
 				Username:   "test-user-name",
-				SecretName: "test-secret-name",
 				PortNumber: 1433,
+				NamedPipe:  `\\test-host\pipe\MSSQL$INST\sql\query`,
 			},
-			inputGuestConfig: &GuestConfig{
-				ServerName:             "test-server-name",
-				GuestUserName:          "test-guest-user-name",
-				LinuxSSHPrivateKeyPath: "test-ssh-private-key-path",
-				GuestPortNumber:        22,
+			password: "test-password",
+			want:     `server=np:\\test-host\pipe\MSSQL$INST\sql\query;user id=test-user-name;password=test-password;database=master;`,
+		},
+		{
+			name: "access token connection string with named pipe",
+			sqlCfg: &SQLConfig{
+				Host:      "test-host",
+				AuthMode:  configpb.CredentialConfiguration_SqlCredentials_ACCESS_TOKEN,
+				NamedPipe: `\\test-host\pipe\sql\query`,
 			},
-			remote:       true,
-			instanceID:   "test-instance-id",
-			instanceName: "test-instance-name",
+			want: `server=np:\\test-host\pipe\sql\query;database=master;`,
 		},
 	}
 
-	for _, tc := range testcases {
+	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			err := ValidateCredCfgSQL(tc.remote, tc.windows, tc.inputSQLConfig, tc.inputGuestConfig, tc.instanceID, tc.instanceName)
-			if gotErr := err != nil; gotErr != tc.wantErr {
-				t.Errorf("validateCredentialConfiguration() = %v, want error presence = %v", err, tc.wantErr)
-			}
-			if err != nil && err.Error() != tc.wantErrMsg {
-				t.Errorf("validateCredentialConfiguration() = %v, want error message = %v", err, tc.wantErrMsg)
+			got := SQLConnectionString(tc.sqlCfg, tc.password)
+			if got != tc.want {
+				t.Errorf("SQLConnectionString(%v) = %q, want %q", tc.sqlCfg, got, tc.want)
 			}
 		})
 	}
 }
 
-func TestValidateCredCfgGuest(t *testing.T) {
-	testcases := []struct {
-		name             string
-		inputGuestConfig *GuestConfig
-		remote           bool
-		windows          bool
-		instanceID       string
-		instanceName     string
-		wantErr          bool
-		wantErrMsg       string
+func TestMinTLSVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     *configpb.Configuration
+		want    uint16
+		wantErr bool
 	}{
 		{
-			name:             "success-local",
-			inputGuestConfig: &GuestConfig{},
+			name: "default",
+			cfg:  &configpb.Configuration{},
+			want: tls.VersionTLS12,
 		},
 		{
-			name: "success-remote-win",
-			inputGuestConfig: &GuestConfig{
-				ServerName:      "test-server-name",
-				GuestUserName:   "test-guest-user-name",
-				GuestSecretName: "test-guest-secret-name",
-			},
-			remote:       true,
-			windows:      true,
-			instanceID:   "test-instance-id",
-			instanceName: "test-instance-name",
+			name: "tls 1.2",
+			cfg:  &configpb.Configuration{MinTlsVersion: "1.2"},
+			want: tls.VersionTLS12,
 		},
 		{
-			name: "success-remote-linux",
-			inputGuestConfig: &GuestConfig{
-				ServerName:             "test-server-name",
-				GuestUserName:          "test-guest-user-name",
-				LinuxSSHPrivateKeyPath: "test-ssh-private-key-path",
-				GuestPortNumber:        22,
-			},
-			remote:       true,
-			instanceID:   "test-instance-id",
-			instanceName: "test-instance-name",
+			name: "tls 1.3",
+			cfg:  &configpb.Configuration{MinTlsVersion: "1.3"},
+			want: tls.VersionTLS13,
 		},
 		{
-			name:             "failure-remote-win",
-			inputGuestConfig: &GuestConfig{},
-			windows:          true,
-			remote:           true,
-			wantErr:          true,
-			wantErrMsg:       `invalid value for "server_name" "guest_user_name" "guest_secret_name" "instance_id" "instance_name"`,
+			name:    "tls 1.1 is not supported",
+			cfg:     &configpb.Configuration{MinTlsVersion: "1.1"},
+			want:    tls.VersionTLS12,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := MinTLSVersion(tc.cfg)
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Errorf("MinTLSVersion(%v) returned error: %v, want error: %v", tc.cfg, err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Errorf("MinTLSVersion(%v) = %v, want %v", tc.cfg, got, tc.want)
+			}
+		})
+	}
+}
+
+// writeClientCertificate writes a self-signed PEM encoded certificate and its private key to dir
+// and returns their paths.
+func writeClientCertificate(t *testing.T, dir string) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("ecdsa.GenerateKey() returned error: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "test-client"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("x509.CreateCertificate() returned error: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("x509.MarshalECPrivateKey() returned error: %v", err)
+	}
+	certPath, keyPath := path.Join(dir, "client.crt"), path.Join(dir, "client.key")
+	if err := os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatalf("os.WriteFile(%q) returned error: %v", certPath, err)
+	}
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		t.Fatalf("os.WriteFile(%q) returned error: %v", keyPath, err)
+	}
+	return certPath, keyPath
+}
+
+func TestClientCertificate(t *testing.T) {
+	dir := t.TempDir()
+	certPath, keyPath := writeClientCertificate(t, dir)
+	tests := []struct {
+		name     string
+		cfg      *configpb.Configuration
+		wantCert bool
+		wantErr  bool
+	}{
+		{
+			name: "default",
+			cfg:  &configpb.Configuration{},
 		},
 		{
-			name: "failure-remote-linux-missing-linux_ssh_private_key_path",
-			inputGuestConfig: &GuestConfig{
-				ServerName:      "test-server-name",
-				GuestUserName:   "test-guest-user-name",
-				GuestPortNumber: 22,
-			},
-			remote:       true,
-			instanceID:   "test-instance-id",
-			instanceName: "test-instance-name",
-			wantErr:      true,
-			wantErrMsg:   `invalid value for "linux_ssh_private_key_path"`,
+			name:     "client certificate",
+			cfg:      &configpb.Configuration{ClientCertPath: certPath, ClientKeyPath: keyPath},
+			wantCert: true,
 		},
 		{
-			name: "failure-remote-linux-missing-guest_port_number",
-			inputGuestConfig: &GuestConfig{
-				ServerName:             "test-server-name",
-				GuestUserName:          "test-guest-user-name",
-				LinuxSSHPrivateKeyPath: "test-ssh-private-key-path",
-			},
-			remote:       true,
-			wantErr:      true,
-			instanceID:   "test-instance-id",
-			instanceName: "test-instance-name",
-			wantErrMsg:   `invalid value for "guest_port_number"`,
+			name:    "missing key path",
+			cfg:     &configpb.Configuration{ClientCertPath: certPath},
+			wantErr: true,
 		},
 		{
-			name: "failure-remote-missing-instance_id",
-			inputGuestConfig: &GuestConfig{
-				ServerName:      "test-server-name",
-				GuestUserName:   "test-guest-user-name",
-				GuestSecretName: "test-guest-secret-name",
-			},
-			remote:       true,
-			windows:      true,
-			instanceName: "test-instance-name",
-			wantErr:      true,
-			wantErrMsg:   `invalid value for "instance_id"`,
+			name:    "missing cert path",
+			cfg:     &configpb.Configuration{ClientKeyPath: keyPath},
+			wantErr: true,
 		},
 		{
-			name: "failure-remote-missing-instance_name",
-			inputGuestConfig: &GuestConfig{
-				ServerName:      "test-server-name",
-				GuestUserName:   "test-guest-user-name",
-				GuestSecretName: "test-guest-secret-name",
-			},
-			remote:     true,
-			windows:    true,
-			instanceID: "test-instance-id",
-			wantErr:    true,
-			wantErrMsg: `invalid value for "instance_name"`,
+			name:    "cert file not found",
+			cfg:     &configpb.Configuration{ClientCertPath: path.Join(dir, "notfound.crt"), ClientKeyPath: keyPath},
+			wantErr: true,
 		},
 	}
 
-	for _, tc := range testcases {
+	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			err := ValidateCredCfgGuest(tc.remote, tc.windows, tc.inputGuestConfig, tc.instanceID, tc.instanceName)
+			got, err := ClientCertificate(tc.cfg)
 			if gotErr := err != nil; gotErr != tc.wantErr {
-				t.Errorf("validateCredentialConfiguration() = %v, want error presence = %v", err, tc.wantErr)
+				t.Errorf("ClientCertificate(%v) returned error: %v, want error: %v", tc.cfg, err, tc.wantErr)
 			}
-			if err != nil && err.Error() != tc.wantErrMsg {
+			if gotCert := got != nil; gotCert != tc.wantCert {
+				t.Fatalf("ClientCertificate(%v) = %v, want certificate: %v", tc.cfg, got, tc.wantCert)
+			}
+			if got != nil && (len(got.Certificate) != 1 || got.PrivateKey == nil) {
+				t.Errorf("ClientCertificate(%v) = %v, want the certificate and its private key", tc.cfg, got)
+			}
+		})
+	}
+}
+
+func TestCollectPhysicalDriveMapping(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  *configpb.Configuration
+		want bool
+	}{
+		{
+			name: "default",
+			cfg:  &configpb.Configuration{},
+			want: true,
+		},
+		{
+			name: "not set",
+			cfg:  &configpb.Configuration{CollectionConfiguration: &configpb.CollectionConfiguration{}},
+			want: true,
+		},
+		{
+			name: "enabled",
+			cfg:  &configpb.Configuration{CollectionConfiguration: &configpb.CollectionConfiguration{CollectPhysicalDriveMapping: proto.Bool(true)}},
+			want: true,
+		},
+		{
+			name: "disabled",
+			cfg:  &configpb.Configuration{CollectionConfiguration: &configpb.CollectionConfiguration{CollectPhysicalDriveMapping: proto.Bool(false)}},
+			want: false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := CollectPhysicalDriveMapping(tc.cfg); got != tc.want {
+				t.Errorf("CollectPhysicalDriveMapping(%v) = %v, want %v", tc.cfg, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSecretProjectID(t *testing.T) {
+	tests := []struct {
+		name           string
+		projectID      string
+		agentProjectID string
+		want           string
+	}{
+		{
+			name:           "defaults to the project of the agent",
+			agentProjectID: "agent-project",
+			want:           "agent-project",
+		},
+		{
+			name:           "secret project overrides the project of the agent",
+			projectID:      "secret-project",
+			agentProjectID: "agent-project",
+			want:           "secret-project",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := SecretProjectID(tc.projectID, tc.agentProjectID); got != tc.want {
+				t.Errorf("SecretProjectID(%q, %q) = %q, want %q", tc.projectID, tc.agentProjectID, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSQLHostName(t *testing.T) {
+	tests := []struct {
+		name   string
+		sqlCfg *SQLConfig
+		want   string
+	}{
+		{
+			name:   "host",
+			sqlCfg: &SQLConfig{Host: "test-host"},
+			want:   "test-host",
+		},
+		{
+			name:   "cluster network name overrides host",
+			sqlCfg: &SQLConfig{Host: "test-host", ClusterNetworkName: "test-fci-vnn"},
+			want:   "test-fci-vnn",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := SQLHostName(tc.sqlCfg); got != tc.want {
+				t.Errorf("SQLHostName(%v) = %q, want %q", tc.sqlCfg, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestGuestConfigFromCredential(t *testing.T) {
+	tests := []struct {
+		name  string
+		input *configpb.CredentialConfiguration
+		want  *GuestConfig
+	}{
+		{
+			name: "GuestConfig with new configuration format-local",
+			input: &configpb.CredentialConfiguration{
+				GuestConfigurations: &configpb.CredentialConfiguration_LocalCollection{
+					LocalCollection: true,
+				},
+			},
+			want: &GuestConfig{},
+		},
+		{
+			name: "GuestConfig with new configuration format-remote_win",
+			input: &configpb.CredentialConfiguration{
+				GuestConfigurations: &configpb.CredentialConfiguration_RemoteWin{
+					RemoteWin: &configpb.CredentialConfiguration_GuestCredentialsRemoteWin{
+						ServerName:      "test-server-name",
+						GuestUserName:   "test-guest-user-name",
+						GuestSecretName: "test-guest-secret-name",
+					},
+				},
+			},
+			want: &GuestConfig{
+				ServerName:       "test-server-name",
+				GuestUserName:    "test-guest-user-name",
+				GuestSecretName:  "test-guest-secret-name",
+				WindowsTransport: "WMI",
+			},
+		},
+		{
+			name: "GuestConfig with new configuration format-remote_win over winrm",
+			input: &configpb.CredentialConfiguration{
+				GuestConfigurations: &configpb.CredentialConfiguration_RemoteWin{
+					RemoteWin: &configpb.CredentialConfiguration_GuestCredentialsRemoteWin{
+						ServerName:      "test-server-name",
+						GuestUserName:   "test-guest-user-name",
+						GuestSecretName: "test-guest-secret-name",
+						Transport:       configpb.CredentialConfiguration_GuestCredentialsRemoteWin_WINRM_HTTPS,
+					},
+				},
+			},
+			want: &GuestConfig{
+				ServerName:       "test-server-name",
+				GuestUserName:    "test-guest-user-name",
+				GuestSecretName:  "test-guest-secret-name",
+				WindowsTransport: "WINRM_HTTPS",
+			},
+		},
+		{
+			name: "GuestConfig with new configuration format-remote_win with secret project id",
+			input: &configpb.CredentialConfiguration{
+				GuestConfigurations: &configpb.CredentialConfiguration_RemoteWin{
+					RemoteWin: &configpb.CredentialConfiguration_GuestCredentialsRemoteWin{
+						ServerName:           "test-server-name",
+						GuestUserName:        "test-guest-user-name",
+						GuestSecretName:      "test-guest-secret-name",
+						GuestSecretProjectId: "test-secret-project",
+					},
+				},
+			},
+			want: &GuestConfig{
+				ServerName:           "test-server-name",
+				GuestUserName:        "test-guest-user-name",
+				GuestSecretName:      "test-guest-secret-name",
+				WindowsTransport:     "WMI",
+				GuestSecretProjectID: "test-secret-project",
+			},
+		},
+		{
+			name: "GuestConfig with new configuration format-remote_linux",
+			input: &configpb.CredentialConfiguration{
+				GuestConfigurations: &configpb.CredentialConfiguration_RemoteLinux{
+					RemoteLinux: &configpb.CredentialConfiguration_GuestCredentialsRemoteLinux{
+						ServerName:             "test-server-name",
+						GuestUserName:          "test-guest-user-name",
+						GuestPortNumber:        22,
+						LinuxSshPrivateKeyPath: "test-linux-ssh-private-key-path",
+						HostKeyFingerprints:    []string{"SHA256:test-fingerprint"},
+					},
+				},
+			},
+			want: &GuestConfig{
+				ServerName:             "test-server-name",
+				GuestUserName:          "test-guest-user-name",
+				GuestPortNumber:        22,
+				LinuxRemote:            true,
+				LinuxSSHPrivateKeyPath: "test-linux-ssh-private-key-path",
+				HostKeyFingerprints:    []string{"SHA256:test-fingerprint"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := GuestConfigFromCredential(tc.input)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("GuestConfigFromCredential(%v) returned an unexpected diff (-want +got): %v", tc.input, diff)
+			}
+		})
+	}
+}
+
+func TestConfigSchema(t *testing.T) {
+	got, err := ConfigSchema()
+	if err != nil {
+		t.Fatalf("ConfigSchema() returned an unexpected error: %v", err)
+	}
+	var schema struct {
+		Type       string                     `json:"type"`
+		Properties map[string]json.RawMessage `json:"properties"`
+	}
+	if err := json.Unmarshal([]byte(got), &schema); err != nil {
+		t.Fatalf("ConfigSchema() returned invalid JSON: %v", err)
+	}
+	if schema.Type != "object" {
+		t.Errorf("ConfigSchema() type = %q, want %q", schema.Type, "object")
+	}
+	for _, field := range []string{"collection_configuration", "credential_configuration", "log_level"} {
+		if _, ok := schema.Properties[field]; !ok {
+			t.Errorf("ConfigSchema() is missing property %q", field)
+		}
+	}
+}
+
+func TestValidateConfigValues(t *testing.T) {
+	testcases := []struct {
+		name  string
+		input *configpb.Configuration
+		want  *configpb.Configuration
+	}{
+		{
+			name: "values are all invalid",
+			input: &configpb.Configuration{
+				CollectionConfiguration:     &configpb.CollectionConfiguration{},
+				MaxRetries:                  -2,
+				StartupDelaySeconds:         -1,
+				SqlCollectionTimeoutSeconds: -1,
+				OsCollectionTimeoutSeconds:  -1,
+				CollectionJitterSeconds:     -1,
+			},
+			want: &configpb.Configuration{
+				CollectionConfiguration: &configpb.CollectionConfiguration{
+					GuestOsMetricsCollectionIntervalInSeconds: 3600,
+					SqlMetricsCollectionIntervalInSeconds:     3600,
+				},
+				CollectionTimeoutSeconds: 10,
+				MaxRetries:               3,
+				RetryIntervalInSeconds:   3600,
+				WlmRequestTimeoutSeconds: 60,
+			},
+		},
+		{
+			name: "values are all valid",
+			input: &configpb.Configuration{
+				CollectionConfiguration: &configpb.CollectionConfiguration{
+					GuestOsMetricsCollectionIntervalInSeconds: 1,
+					SqlMetricsCollectionIntervalInSeconds:     1,
+				},
+				CollectionTimeoutSeconds: 1,
+				MaxRetries:               1,
+				RetryIntervalInSeconds:   1,
+				WlmRequestTimeoutSeconds: 1,
+				StartupDelaySeconds:      30,
+			},
+			want: &configpb.Configuration{
+				CollectionConfiguration: &configpb.CollectionConfiguration{
+					GuestOsMetricsCollectionIntervalInSeconds: 1,
+					SqlMetricsCollectionIntervalInSeconds:     1,
+				},
+				CollectionTimeoutSeconds: 1,
+				MaxRetries:               1,
+				RetryIntervalInSeconds:   1,
+				WlmRequestTimeoutSeconds: 1,
+				StartupDelaySeconds:      30,
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := validateConfigValues(tc.input)
+			if diff := cmp.Diff(got, tc.want, protocmp.Transform()); diff != "" {
+				t.Errorf("ValidateConfigValues() returned wrong result (-got +want):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestValidateCredCfgSQL(t *testing.T) {
+	testcases := []struct {
+		name             string
+		inputSQLConfig   *SQLConfig
+		inputGuestConfig *GuestConfig
+		remote           bool
+		windows          bool
+		instanceID       string
+		instanceName     string
+		wantErr          bool
+		wantErrMsg       string
+	}{
+		{
+			name: "success-local",
+			inputSQLConfig: &SQLConfig{
+				Username:   "test-user-name",
+				SecretName: "test-secret-name",
+				PortNumber: 1433,
+			},
+		},
+		{
+			name: "success-remote",
+			inputSQLConfig: &SQLConfig{
+				Host:       "test-host",
+				Username:   "test-user-name",
+				SecretName: "test-secret-name",
+				PortNumber: 1433,
+			},
+			inputGuestConfig: &GuestConfig{
+				ServerName:      "test-server-name",
+				GuestUserName:   "test-guest-user-name",
+				GuestSecretName: "test-guest-secret-name",
+			},
+			instanceID:   "test-instance-id",
+			instanceName: "test-instance-name",
+			remote:       true,
+			windows:      true,
+		},
+		{
+			name: "success-remote-cluster-network-name",
+			inputSQLConfig: &SQLConfig{
+				Username:           "test-user-name",
+				SecretName:         "test-secret-name",
+				PortNumber:         1433,
+				ClusterNetworkName: "test-fci-vnn",
+			},
+			inputGuestConfig: &GuestConfig{
+				ServerName:      "test-server-name",
+				GuestUserName:   "test-guest-user-name",
+				GuestSecretName: "test-guest-secret-name",
+			},
+			instanceID:   "test-instance-id",
+			instanceName: "test-instance-name",
+			remote:       true,
+			windows:      true,
+		},
+		{
+			name: "success-local-extra_connection_params",
+			inputSQLConfig: &SQLConfig{
+				Username:              "test-user-name",
+				SecretName:            "test-secret-name",
+				PortNumber:            1433,
+				ExtraConnectionParams: "packet size=8192;keepalive=30;",
+			},
+		},
+		{
+			name: "failure-local-extra_connection_params-password",
+			inputSQLConfig: &SQLConfig{
+				Username:              "test-user-name",
+				SecretName:            "test-secret-name",
+				PortNumber:            1433,
+				ExtraConnectionParams: "keepalive=30; Password=override;",
+			},
+			wantErr:    true,
+			wantErrMsg: `invalid value for "extra_connection_params"`,
+		},
+		{
+			name: "failure-local-extra_connection_params-pwd",
+			inputSQLConfig: &SQLConfig{
+				Username:              "test-user-name",
+				SecretName:            "test-secret-name",
+				PortNumber:            1433,
+				ExtraConnectionParams: "pwd=override",
+			},
+			wantErr:    true,
+			wantErrMsg: `invalid value for "extra_connection_params"`,
+		},
+		{
+			name: "success-local-named_pipe-without-port_number",
+			inputSQLConfig: &SQLConfig{
+				Username:   "test-user-name",
+				SecretName: "test-secret-name",
+				NamedPipe:  `\\test-host\pipe\MSSQL$INST\sql\query`,
+			},
+		},
+		{
+			name: "failure-local-named_pipe-without-pipe",
+			inputSQLConfig: &SQLConfig{
+				Username:   "test-user-name",
+				SecretName: "test-secret-name",
+				NamedPipe:  `\\test-host\MSSQL$INST\sql\query`,
+			},
+			wantErr:    true,
+			wantErrMsg: `invalid value for "named_pipe"`,
+		},
+		{
+			name: "failure-local-named_pipe-semicolon",
+			inputSQLConfig: &SQLConfig{
+				Username:   "test-user-name",
+				SecretName: "test-secret-name",
+				NamedPipe:  `\\test-host\pipe\sql\query;encrypt=disable`,
+			},
+			wantErr:    true,
+			wantErrMsg: `invalid value for "named_pipe"`,
+		},
+		{
+			name: "success-local-server_sni-with-encryption",
+			inputSQLConfig: &SQLConfig{
+				Username:              "test-user-name",
+				SecretName:            "test-secret-name",
+				PortNumber:            1433,
+				ServerSNI:             "sql2.example.com",
+				ExtraConnectionParams: "encrypt=true;trustservercertificate=false",
+			},
+		},
+		{
+			name: "failure-local-server_sni-not-a-host-name",
+			inputSQLConfig: &SQLConfig{
+				Username:   "test-user-name",
+				SecretName: "test-secret-name",
+				PortNumber: 1433,
+				ServerSNI:  "sql2.example.com;encrypt=disable",
+			},
+			wantErr:    true,
+			wantErrMsg: `invalid value for "server_sni"`,
+		},
+		{
+			name: "failure-local-server_sni-with-host_name_in_certificate",
+			inputSQLConfig: &SQLConfig{
+				Username:              "test-user-name",
+				SecretName:            "test-secret-name",
+				PortNumber:            1433,
+				ServerSNI:             "sql2.example.com",
+				HostNameInCertificate: "lb.example.com",
+			},
+			wantErr:    true,
+			wantErrMsg: `invalid value for "server_sni"`,
+		},
+		{
+			name: "failure-local-server_sni-with-encryption-disabled",
+			inputSQLConfig: &SQLConfig{
+				Username:              "test-user-name",
+				SecretName:            "test-secret-name",
+				PortNumber:            1433,
+				ServerSNI:             "sql2.example.com",
+				ExtraConnectionParams: "Encrypt = DISABLE",
+			},
+			wantErr:    true,
+			wantErrMsg: `invalid value for "server_sni"`,
+		},
+		{
+			name: "failure-local-server_sni-with-serverspn",
+			inputSQLConfig: &SQLConfig{
+				Username:              "test-user-name",
+				SecretName:            "test-secret-name",
+				PortNumber:            1433,
+				ServerSNI:             "sql2.example.com",
+				ExtraConnectionParams: "ServerSPN=MSSQLSvc/other:1433",
+			},
+			wantErr:    true,
+			wantErrMsg: `invalid value for "server_sni"`,
+		},
+		{
+			name: "failure-local-server_sni-with-named_pipe",
+			inputSQLConfig: &SQLConfig{
+				Username:   "test-user-name",
+				SecretName: "test-secret-name",
+				ServerSNI:  "sql2.example.com",
+				NamedPipe:  `\\test-host\pipe\sql\query`,
+			},
+			wantErr:    true,
+			wantErrMsg: `invalid value for "server_sni"`,
+		},
+		{
+			name: "success-local-access_token",
+			inputSQLConfig: &SQLConfig{
+				PortNumber: 1433,
+				AuthMode:   configpb.CredentialConfiguration_SqlCredentials_ACCESS_TOKEN,
+			},
+		},
+		{
+			name: "failure-local-access_token-missing-port_number",
+			inputSQLConfig: &SQLConfig{
+				AuthMode: configpb.CredentialConfiguration_SqlCredentials_ACCESS_TOKEN,
+			},
+			wantErr:    true,
+			wantErrMsg: `invalid value for "port_number"`,
+		},
+		{
+			name: "failure-local-invalid-auth_mode",
+			inputSQLConfig: &SQLConfig{
+				Username:   "test-user-name",
+				SecretName: "test-secret-name",
+				PortNumber: 1433,
+				AuthMode:   configpb.CredentialConfiguration_SqlCredentials_AuthMode(5),
+			},
+			wantErr:    true,
+			wantErrMsg: `invalid value for "auth_mode"`,
+		},
+		{
+			name: "failure-local-missing-user_name",
+			inputSQLConfig: &SQLConfig{
+				SecretName: "test-secret-name",
+				PortNumber: 1433,
+			},
+			wantErr:    true,
+			wantErrMsg: `invalid value for "user_name"`,
+		},
+		{
+			name: "failure-local-missing-secret_name",
+			inputSQLConfig: &SQLConfig{
+				Username:   "test-user-name",
+				PortNumber: 1433,
+			},
+			wantErr:    true,
+			wantErrMsg: `invalid value for "secret_name"`,
+		},
+		{
+			name: "failure-local-missing-port_number",
+			inputSQLConfig: &SQLConfig{
+				Username:   "test-user-name",
+				SecretName: "test-secret-name",
+			},
+			wantErr:    true,
+			wantErrMsg: `invalid value for "port_number"`,
+		},
+		{
+			name:             "failure-remote-win",
+			inputSQLConfig:   &SQLConfig{},
+			inputGuestConfig: &GuestConfig{},
+			windows:          true,
+			remote:           true,
+			wantErr:          true,
+			wantErrMsg:       `invalid value for "user_name" "secret_name" "port_number" "host" "server_name" "guest_user_name" "guest_secret_name" "instance_id" "instance_name"`,
+		},
+		{
+			name: "failure-remote-missing host",
+			inputSQLConfig: &SQLConfig{
+				Username:   "test-user-name",
+				SecretName: "test-secret-name",
+				PortNumber: 1433,
+			},
+			inputGuestConfig: &GuestConfig{
+				ServerName:      "test-server-name",
+				GuestUserName:   "test-guest-user-name",
+				GuestSecretName: "test-guest-secret-name",
+			},
+			remote:       true,
+			windows:      true,
+			instanceID:   "test-instance-id",
+			instanceName: "test-instance-name",
+			wantErr:      true,
+			wantErrMsg:   `invalid value for "host"`,
+		},
+		{
+			name: "failure-remote-linux-missing linux_ssh_private_key_path",
+			inputSQLConfig: &SQLConfig{
+				Host:       "test-host",
+				Username:   "test-user-name",
+				SecretName: "test-secret-name",
+				PortNumber: 1433,
+			},
+			inputGuestConfig: &GuestConfig{
+				ServerName:      "test-server-name",
+				GuestUserName:   "test-guest-user-name",
+				GuestPortNumber: 22,
+			},
+			remote:       true,
+			instanceID:   "test-instance-id",
+			instanceName: "test-instance-name",
+			wantErr:      true,
+			wantErrMsg:   `invalid value for "linux_ssh_private_key_path"`,
+		},
+		{
+			name: "failure-remote-linux-missing guest_port_number",
+			inputSQLConfig: &SQLConfig{
+				Host:       "test-host",
+				Username:   "test-user-name",
+				SecretName: "test-secret-name",
+				PortNumber: 1433,
+			},
+			inputGuestConfig: &GuestConfig{
+				ServerName:             "test-server-name",
+				GuestUserName:          "test-guest-user-name",
+				LinuxSSHPrivateKeyPath: "test-ssh-private-key-path",
+			},
+			remote:       true,
+			wantErr:      true,
+			instanceID:   "test-instance-id",
+			instanceName: "test-instance-name",
+			wantErrMsg:   `invalid value for "guest_port_number"`,
+		},
+		{
+			name: "failure-remote-win-missing-instance_id",
+			inputSQLConfig: &SQLConfig{
+				Host:       "test-host",
+				Username:   "test-user-name",
+				SecretName: "test-secret-name",
+				PortNumber: 1433,
+			},
+			inputGuestConfig: &GuestConfig{
+				ServerName:      "test-server-name",
+				GuestUserName:   "test-guest-user-name",
+				GuestSecretName: "test-guest-secret-name",
+			},
+			instanceName: "test-instance-name",
+			remote:       true,
+			windows:      true,
+			wantErr:      true,
+			wantErrMsg:   `invalid value for "instance_id"`,
+		},
+		{
+			name: "failure-remote-missing-instance_name",
+			inputSQLConfig: &SQLConfig{
+				Host:       "test-host",
+				Username:   "test-user-name",
+				SecretName: "test-secret-name",
+				PortNumber: 1433,
+			},
+			inputGuestConfig: &GuestConfig{
+				ServerName:      "test-server-name",
+				GuestUserName:   "test-guest-user-name",
+				GuestSecretName: "test-guest-secret-name",
+			},
+			remote:     true,
+			windows:    true,
+			instanceID: "test-instance-id",
+			wantErr:    true,
+			wantErrMsg: `invalid value for "instance_name"`,
+		},
+		{
+			name: "success-remote-linux",
+			inputSQLConfig: &SQLConfig{
+				Host:       "test-host",
+				Username:   "test-user-name",
+				SecretName: "test-secret-name",
+				PortNumber: 1433,
+			},
+			inputGuestConfig: &GuestConfig{
+				ServerName:             "test-server-name",
+				GuestUserName:          "test-guest-user-name",
+				LinuxSSHPrivateKeyPath: "test-ssh-private-key-path",
+				GuestPortNumber:        22,
+			},
+			remote:       true,
+			instanceID:   "test-instance-id",
+			instanceName: "test-instance-name",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateCredCfgSQL(tc.remote, tc.windows, tc.inputSQLConfig, tc.inputGuestConfig, tc.instanceID, tc.instanceName)
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Errorf("validateCredentialConfiguration() = %v, want error presence = %v", err, tc.wantErr)
+			}
+			if err != nil && err.Error() != tc.wantErrMsg {
+				t.Errorf("validateCredentialConfiguration() = %v, want error message = %v", err, tc.wantErrMsg)
+			}
+		})
+	}
+}
+
+func TestSQLConnectionStringServerSNI(t *testing.T) {
+	sqlCfg := &SQLConfig{
+		Host:                  "listener.example.com",
+		Username:              "test-user-name",
+		PortNumber:            1433,
+		ServerSNI:             "sql2.example.com",
+		ExtraConnectionParams: "encrypt=true",
+	}
+	// The driver sends the host name in the certificate as the server name of the TLS handshake.
+	got, err := msdsn.Parse(SQLConnectionString(sqlCfg, "test-password"))
+	if err != nil {
+		t.Fatalf("msdsn.Parse() returned unexpected error: %v", err)
+	}
+	if got.Host != "listener.example.com" {
+		t.Errorf("msdsn.Parse() returned host %q, want %q", got.Host, "listener.example.com")
+	}
+	if got.TLSConfig == nil || got.TLSConfig.ServerName != "sql2.example.com" {
+		t.Errorf("msdsn.Parse() returned TLS config %+v, want server name %q", got.TLSConfig, "sql2.example.com")
+	}
+	if got.ServerSPN != "MSSQLSvc/sql2.example.com:1433" {
+		t.Errorf("msdsn.Parse() returned server SPN %q, want %q", got.ServerSPN, "MSSQLSvc/sql2.example.com:1433")
+	}
+}
+
+func TestValidNamedPipe(t *testing.T) {
+	tests := []struct {
+		pipe string
+		want bool
+	}{
+		{pipe: `\\test-host\pipe\sql\query`, want: true},
+		{pipe: `\\.\pipe\MSSQL$INST\sql\query`, want: true},
+		{pipe: `\\test-host\PIPE\custom`, want: true},
+		{pipe: `test-host\pipe\sql\query`, want: false},
+		{pipe: `\\test-host\pipe\`, want: false},
+		{pipe: `\\test-host\pipe\sql\query\`, want: false},
+		{pipe: `\\\pipe\sql\query`, want: false},
+		{pipe: `\\test-host\share\sql\query`, want: false},
+		{pipe: `\\test-host\pipe\sql;query`, want: false},
+	}
+	for _, tc := range tests {
+		if got := validNamedPipe(tc.pipe); got != tc.want {
+			t.Errorf("validNamedPipe(%q) = %v, want %v", tc.pipe, got, tc.want)
+		}
+	}
+}
+
+func TestValidateCredCfgGuest(t *testing.T) {
+	testcases := []struct {
+		name             string
+		inputGuestConfig *GuestConfig
+		remote           bool
+		windows          bool
+		instanceID       string
+		instanceName     string
+		wantErr          bool
+		wantErrMsg       string
+	}{
+		{
+			name:             "success-local",
+			inputGuestConfig: &GuestConfig{},
+		},
+		{
+			name: "success-remote-win",
+			inputGuestConfig: &GuestConfig{
+				ServerName:      "test-server-name",
+				GuestUserName:   "test-guest-user-name",
+				GuestSecretName: "test-guest-secret-name",
+			},
+			remote:       true,
+			windows:      true,
+			instanceID:   "test-instance-id",
+			instanceName: "test-instance-name",
+		},
+		{
+			name: "success-remote-linux",
+			inputGuestConfig: &GuestConfig{
+				ServerName:             "test-server-name",
+				GuestUserName:          "test-guest-user-name",
+				LinuxSSHPrivateKeyPath: "test-ssh-private-key-path",
+				GuestPortNumber:        22,
+			},
+			remote:       true,
+			instanceID:   "test-instance-id",
+			instanceName: "test-instance-name",
+		},
+		{
+			name:             "failure-remote-win",
+			inputGuestConfig: &GuestConfig{},
+			windows:          true,
+			remote:           true,
+			wantErr:          true,
+			wantErrMsg:       `invalid value for "server_name" "guest_user_name" "guest_secret_name" "instance_id" "instance_name"`,
+		},
+		{
+			name: "success-remote-linux-host-key-fingerprints",
+			inputGuestConfig: &GuestConfig{
+				ServerName:             "test-server-name",
+				GuestUserName:          "test-guest-user-name",
+				LinuxSSHPrivateKeyPath: "test-ssh-private-key-path",
+				GuestPortNumber:        22,
+				HostKeyFingerprints:    []string{"SHA256:nThbg6kXUpJWGl7E1IGOCspRomTxdCARLviKw6E5SY8"},
+			},
+			remote:       true,
+			instanceID:   "test-instance-id",
+			instanceName: "test-instance-name",
+		},
+		{
+			name: "failure-remote-linux-invalid-host-key-fingerprints",
+			inputGuestConfig: &GuestConfig{
+				ServerName:             "test-server-name",
+				GuestUserName:          "test-guest-user-name",
+				LinuxSSHPrivateKeyPath: "test-ssh-private-key-path",
+				GuestPortNumber:        22,
+				HostKeyFingerprints:    []string{"MD5:16:27:ac:a5:76:28:2d:36:63:1b:56:4d:eb:df:a6:48"},
+			},
+			remote:       true,
+			instanceID:   "test-instance-id",
+			instanceName: "test-instance-name",
+			wantErr:      true,
+			wantErrMsg:   `invalid value for "host_key_fingerprints"`,
+		},
+		{
+			name: "failure-remote-linux-missing-linux_ssh_private_key_path",
+			inputGuestConfig: &GuestConfig{
+				ServerName:      "test-server-name",
+				GuestUserName:   "test-guest-user-name",
+				GuestPortNumber: 22,
+			},
+			remote:       true,
+			instanceID:   "test-instance-id",
+			instanceName: "test-instance-name",
+			wantErr:      true,
+			wantErrMsg:   `invalid value for "linux_ssh_private_key_path"`,
+		},
+		{
+			name: "failure-remote-linux-missing-guest_port_number",
+			inputGuestConfig: &GuestConfig{
+				ServerName:             "test-server-name",
+				GuestUserName:          "test-guest-user-name",
+				LinuxSSHPrivateKeyPath: "test-ssh-private-key-path",
+			},
+			remote:       true,
+			wantErr:      true,
+			instanceID:   "test-instance-id",
+			instanceName: "test-instance-name",
+			wantErrMsg:   `invalid value for "guest_port_number"`,
+		},
+		{
+			name: "failure-remote-missing-instance_id",
+			inputGuestConfig: &GuestConfig{
+				ServerName:      "test-server-name",
+				GuestUserName:   "test-guest-user-name",
+				GuestSecretName: "test-guest-secret-name",
+			},
+			remote:       true,
+			windows:      true,
+			instanceName: "test-instance-name",
+			wantErr:      true,
+			wantErrMsg:   `invalid value for "instance_id"`,
+		},
+		{
+			name: "failure-remote-missing-instance_name",
+			inputGuestConfig: &GuestConfig{
+				ServerName:      "test-server-name",
+				GuestUserName:   "test-guest-user-name",
+				GuestSecretName: "test-guest-secret-name",
+			},
+			remote:     true,
+			windows:    true,
+			instanceID: "test-instance-id",
+			wantErr:    true,
+			wantErrMsg: `invalid value for "instance_name"`,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateCredCfgGuest(tc.remote, tc.windows, tc.inputGuestConfig, tc.instanceID, tc.instanceName)
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Errorf("validateCredentialConfiguration() = %v, want error presence = %v", err, tc.wantErr)
+			}
+			if err != nil && err.Error() != tc.wantErrMsg {
 				t.Errorf("validateCredentialConfiguration() = %v, want error message = %v", err, tc.wantErrMsg)
 			}
 		})
 	}
 }
+
+func TestLogLevel(t *testing.T) {
+	tests := []struct {
+		logLevel string
+		want     zapcore.Level
+	}{
+		{logLevel: "", want: zapcore.InfoLevel},
+		{logLevel: "DEBUG", want: zapcore.DebugLevel},
+		{logLevel: "INFO", want: zapcore.InfoLevel},
+		{logLevel: "WARNING", want: zapcore.WarnLevel},
+		{logLevel: "ERROR", want: zapcore.ErrorLevel},
+		{logLevel: "TRACE", want: zapcore.InfoLevel},
+	}
+	for _, tc := range tests {
+		if got := LogLevel(&configpb.Configuration{LogLevel: tc.logLevel}); got != tc.want {
+			t.Errorf("LogLevel(%q) = %v, want %v", tc.logLevel, got, tc.want)
+		}
+	}
+}
+
+func TestWatcherUpdate(t *testing.T) {
+	level := zap.NewAtomicLevelAt(zapcore.InfoLevel)
+	w := NewWatcher(level)
+	cfg := &configpb.Configuration{
+		LogLevel:                "INFO",
+		MaxRetries:              5,
+		CollectionConfiguration: &configpb.CollectionConfiguration{CollectSqlMetrics: true},
+	}
+	updates := []struct {
+		name      string
+		cfg       *configpb.Configuration
+		want      []string
+		wantLevel zapcore.Level
+	}{
+		{
+			name:      "first update",
+			cfg:       cfg,
+			wantLevel: zapcore.InfoLevel,
+		},
+		{
+			name:      "unchanged",
+			cfg:       proto.Clone(cfg).(*configpb.Configuration),
+			wantLevel: zapcore.InfoLevel,
+		},
+		{
+			name: "changed log level",
+			cfg: &configpb.Configuration{
+				LogLevel:                "DEBUG",
+				MaxRetries:              5,
+				CollectionConfiguration: &configpb.CollectionConfiguration{CollectSqlMetrics: true},
+			},
+			want:      []string{"log_level"},
+			wantLevel: zapcore.DebugLevel,
+		},
+		{
+			name: "changed collection configuration",
+			cfg: &configpb.Configuration{
+				LogLevel:                "DEBUG",
+				CollectionConfiguration: &configpb.CollectionConfiguration{CollectSqlMetrics: false},
+			},
+			want:      []string{"collection_configuration", "max_retries"},
+			wantLevel: zapcore.DebugLevel,
+		},
+	}
+	// The updates run in order as the cycles of the collection service.
+	for _, u := range updates {
+		got := w.Update(u.cfg)
+		if diff := cmp.Diff(u.want, got); diff != "" {
+			t.Errorf("Update() for %s returned diff (-want +got):\n%s", u.name, diff)
+		}
+		if got := level.Level(); got != u.wantLevel {
+			t.Errorf("Update() for %s set log level %v, want %v", u.name, got, u.wantLevel)
+		}
+	}
+}
+
+func TestRedact(t *testing.T) {
+	cfg := &configpb.Configuration{
+		LogLevel: "INFO",
+		CredentialConfiguration: []*configpb.CredentialConfiguration{
+			{
+				InstanceName: "instance",
+				SqlConfigurations: []*configpb.CredentialConfiguration_SqlCredentials{
+					{
+						Host:                  "localhost",
+						UserName:              "sqladmin",
+						SecretName:            "sql-secret",
+						PortNumber:            1433,
+						ExtraConnectionParams: "packet size=8192;",
+					},
+				},
+				GuestConfigurations: &configpb.CredentialConfiguration_RemoteWin{
+					RemoteWin: &configpb.CredentialConfiguration_GuestCredentialsRemoteWin{
+						ServerName:      "server",
+						GuestUserName:   "guestadmin",
+						GuestSecretName: "guest-secret",
+					},
+				},
+			},
+		},
+	}
+	want := &configpb.Configuration{
+		LogLevel: "INFO",
+		CredentialConfiguration: []*configpb.CredentialConfiguration{
+			{
+				InstanceName: "instance",
+				SqlConfigurations: []*configpb.CredentialConfiguration_SqlCredentials{
+					{
+						Host:                  "localhost",
+						UserName:              "<redacted>",
+						SecretName:            "<redacted>",
+						PortNumber:            1433,
+						ExtraConnectionParams: "<redacted>",
+					},
+				},
+				GuestConfigurations: &configpb.CredentialConfiguration_RemoteWin{
+					RemoteWin: &configpb.CredentialConfiguration_GuestCredentialsRemoteWin{
+						ServerName:      "server",
+						GuestUserName:   "<redacted>",
+						GuestSecretName: "<redacted>",
+					},
+				},
+			},
+		},
+	}
+	original := proto.Clone(cfg)
+	got := Redact(cfg)
+	if diff := cmp.Diff(want, got, protocmp.Transform()); diff != "" {
+		t.Errorf("Redact() returned diff (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(original, cfg, protocmp.Transform()); diff != "" {
+		t.Errorf("Redact() modified the configuration (-want +got):\n%s", diff)
+	}
+	if got := Redact(nil); got != nil {
+		t.Errorf("Redact(nil) = %v, want nil", got)
+	}
+}
+
+func TestSendHeartbeat(t *testing.T) {
+	testcases := []struct {
+		name string
+		cfg  *configpb.Configuration
+		want bool
+	}{
+		{
+			name: "collection disabled",
+			cfg: &configpb.Configuration{
+				CollectionConfiguration: &configpb.CollectionConfiguration{},
+				SendHeartbeat:           true,
+			},
+			want: true,
+		},
+		{
+			name: "no collection configuration",
+			cfg:  &configpb.Configuration{SendHeartbeat: true},
+			want: true,
+		},
+		{
+			name: "heartbeat not enabled",
+			cfg: &configpb.Configuration{
+				CollectionConfiguration: &configpb.CollectionConfiguration{},
+			},
+			want: false,
+		},
+		{
+			name: "guest os collection enabled",
+			cfg: &configpb.Configuration{
+				CollectionConfiguration: &configpb.CollectionConfiguration{CollectGuestOsMetrics: true},
+				SendHeartbeat:           true,
+			},
+			want: false,
+		},
+		{
+			name: "sql collection enabled",
+			cfg: &configpb.Configuration{
+				CollectionConfiguration: &configpb.CollectionConfiguration{CollectSqlMetrics: true},
+				SendHeartbeat:           true,
+			},
+			want: false,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := SendHeartbeat(tc.cfg); got != tc.want {
+				t.Errorf("SendHeartbeat() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCollectionTimeout(t *testing.T) {
+	testcases := []struct {
+		name    string
+		cfg     *configpb.Configuration
+		wantSQL time.Duration
+		wantOS  time.Duration
+	}{
+		{
+			name: "no overrides",
+			cfg: &configpb.Configuration{
+				CollectionTimeoutSeconds: 10,
+			},
+			wantSQL: 10 * time.Second,
+			wantOS:  10 * time.Second,
+		},
+		{
+			name: "sql override",
+			cfg: &configpb.Configuration{
+				CollectionTimeoutSeconds:    10,
+				SqlCollectionTimeoutSeconds: 60,
+			},
+			wantSQL: 60 * time.Second,
+			wantOS:  10 * time.Second,
+		},
+		{
+			name: "os override",
+			cfg: &configpb.Configuration{
+				CollectionTimeoutSeconds:   10,
+				OsCollectionTimeoutSeconds: 30,
+			},
+			wantSQL: 10 * time.Second,
+			wantOS:  30 * time.Second,
+		},
+		{
+			name: "both overrides",
+			cfg: &configpb.Configuration{
+				CollectionTimeoutSeconds:    10,
+				SqlCollectionTimeoutSeconds: 5,
+				OsCollectionTimeoutSeconds:  120,
+			},
+			wantSQL: 5 * time.Second,
+			wantOS:  120 * time.Second,
+		},
+		{
+			name: "negative overrides fall back to the global timeout",
+			cfg: &configpb.Configuration{
+				CollectionTimeoutSeconds:    10,
+				SqlCollectionTimeoutSeconds: -1,
+				OsCollectionTimeoutSeconds:  -1,
+			},
+			wantSQL: 10 * time.Second,
+			wantOS:  10 * time.Second,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := SQLCollectionTimeout(tc.cfg); got != tc.wantSQL {
+				t.Errorf("SQLCollectionTimeout() = %v, want %v", got, tc.wantSQL)
+			}
+			if got := OSCollectionTimeout(tc.cfg); got != tc.wantOS {
+				t.Errorf("OSCollectionTimeout() = %v, want %v", got, tc.wantOS)
+			}
+		})
+	}
+}
+
+func TestFullRefreshInterval(t *testing.T) {
+	testcases := []struct {
+		name string
+		cfg  *configpb.Configuration
+		want time.Duration
+	}{
+		{
+			name: "default",
+			cfg:  &configpb.Configuration{},
+			want: 24 * time.Hour,
+		},
+		{
+			name: "set",
+			cfg:  &configpb.Configuration{FullRefreshIntervalInSeconds: 3600},
+			want: time.Hour,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := FullRefreshInterval(tc.cfg); got != tc.want {
+				t.Errorf("FullRefreshInterval() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestLogDirectory(t *testing.T) {
+	testcases := []struct {
+		name      string
+		flagValue string
+		env       string
+		cfg       *configpb.Configuration
+		want      string
+	}{
+		{
+			name:      "flag takes precedence",
+			flagValue: "/flag/logs",
+			env:       "/env/logs",
+			cfg:       &configpb.Configuration{LogDirectory: "/config/logs"},
+			want:      "/flag/logs",
+		},
+		{
+			name: "environment over configuration",
+			env:  "/env/logs",
+			cfg:  &configpb.Configuration{LogDirectory: "/config/logs"},
+			want: "/env/logs",
+		},
+		{
+			name: "configuration over default",
+			cfg:  &configpb.Configuration{LogDirectory: "/config/logs"},
+			want: "/config/logs",
+		},
+		{
+			name: "default",
+			cfg:  &configpb.Configuration{LogDirectory: " "},
+			want: "/default/logs",
+		},
+		{
+			name: "default without configuration",
+			want: "/default/logs",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv(LogDirectoryEnv, tc.env)
+			if got := LogDirectory(tc.flagValue, tc.cfg, "/default/logs"); got != tc.want {
+				t.Errorf("LogDirectory(%q) = %q, want %q", tc.flagValue, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestOnetimeOutputDirectory(t *testing.T) {
+	testcases := []struct {
+		name      string
+		flagValue string
+		env       string
+		cfg       *configpb.Configuration
+		want      string
+	}{
+		{
+			name:      "flag takes precedence",
+			flagValue: "/flag/out",
+			env:       "/env/out",
+			cfg:       &configpb.Configuration{OnetimeOutputDirectory: "/config/out"},
+			want:      "/flag/out",
+		},
+		{
+			name: "environment over configuration",
+			env:  "/env/out",
+			cfg:  &configpb.Configuration{OnetimeOutputDirectory: "/config/out"},
+			want: "/env/out",
+		},
+		{
+			name: "configuration over log directory",
+			cfg:  &configpb.Configuration{OnetimeOutputDirectory: "/config/out"},
+			want: "/config/out",
+		},
+		{
+			name: "defaults to the log directory",
+			cfg:  &configpb.Configuration{},
+			want: "/logs",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv(OnetimeOutputDirectoryEnv, tc.env)
+			if got := OnetimeOutputDirectory(tc.flagValue, tc.cfg, "/logs"); got != tc.want {
+				t.Errorf("OnetimeOutputDirectory(%q) = %q, want %q", tc.flagValue, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestWritableDirectory(t *testing.T) {
+	dir := t.TempDir()
+	if err := WritableDirectory(dir); err != nil {
+		t.Errorf("WritableDirectory(%q) returned unexpected error: %v", dir, err)
+	}
+	missing := path.Join(dir, "logs", "agent")
+	if err := WritableDirectory(missing); err != nil {
+		t.Errorf("WritableDirectory(%q) returned unexpected error: %v", missing, err)
+	}
+	if _, err := os.Stat(missing); err != nil {
+		t.Errorf("WritableDirectory(%q) did not create the directory: %v", missing, err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("WritableDirectory() left files in %q: %v", dir, entries)
+	}
+
+	// A directory below a file can not be created, like on a read-only filesystem.
+	file := path.Join(dir, "file")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := WritableDirectory(path.Join(file, "logs")); err == nil {
+		t.Errorf("WritableDirectory(%q) returned no error, want an error", path.Join(file, "logs"))
+	}
+}
+
+func TestMaxCycleDuration(t *testing.T) {
+	testcases := []struct {
+		name string
+		cfg  *configpb.Configuration
+		want time.Duration
+	}{
+		{
+			name: "default",
+			cfg:  &configpb.Configuration{},
+			want: 0,
+		},
+		{
+			name: "set",
+			cfg:  &configpb.Configuration{MaxCycleDurationSeconds: 600},
+			want: 10 * time.Minute,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := MaxCycleDuration(tc.cfg); got != tc.want {
+				t.Errorf("MaxCycleDuration() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRuleFailureLogLimit(t *testing.T) {
+	testcases := []struct {
+		name string
+		cfg  *configpb.Configuration
+		want int
+	}{
+		{
+			name: "default",
+			cfg:  &configpb.Configuration{},
+			want: 1,
+		},
+		{
+			name: "set",
+			cfg:  &configpb.Configuration{RuleFailureLogLimit: 3},
+			want: 3,
+		},
+		{
+			name: "no limit",
+			cfg:  &configpb.Configuration{RuleFailureLogLimit: -1},
+			want: 0,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := RuleFailureLogLimit(tc.cfg); got != tc.want {
+				t.Errorf("RuleFailureLogLimit() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestIsOnPrem(t *testing.T) {
+	testcases := []struct {
+		name string
+		cfg  *configpb.Configuration
+		want bool
+	}{
+		{
+			name: "nil configuration",
+			want: false,
+		},
+		{
+			name: "default environment",
+			cfg:  &configpb.Configuration{},
+			want: false,
+		},
+		{
+			name: "gce",
+			cfg:  &configpb.Configuration{Environment: "gce"},
+			want: false,
+		},
+		{
+			name: "on_prem",
+			cfg:  &configpb.Configuration{Environment: "on_prem"},
+			want: true,
+		},
+		{
+			name: "on_prem in upper case",
+			cfg:  &configpb.Configuration{Environment: "ON_PREM"},
+			want: true,
+		},
+		{
+			name: "unknown environment",
+			cfg:  &configpb.Configuration{Environment: "azure"},
+			want: false,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := IsOnPrem(tc.cfg); got != tc.want {
+				t.Errorf("IsOnPrem() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCloudProperties(t *testing.T) {
+	gceProperties := &metadataserver.CloudProperties{
+		ProjectID:    "gce-project",
+		InstanceID:   "123",
+		Zone:         "us-east1-b",
+		InstanceName: "gce-instance",
+	}
+	testcases := []struct {
+		name             string
+		cfg              *configpb.Configuration
+		want             *metadataserver.CloudProperties
+		wantReadMetadata bool
+	}{
+		{
+			name:             "gce reads the metadata server",
+			cfg:              &configpb.Configuration{},
+			want:             gceProperties,
+			wantReadMetadata: true,
+		},
+		{
+			name: "gce ignores instance properties",
+			cfg: &configpb.Configuration{
+				InstanceProperties: &configpb.InstanceProperties{ProjectId: "on-prem-project"},
+			},
+			want:             gceProperties,
+			wantReadMetadata: true,
+		},
+		{
+			name: "on_prem reads the configuration",
+			cfg: &configpb.Configuration{
+				Environment: "on_prem",
+				InstanceProperties: &configpb.InstanceProperties{
+					ProjectId:     "on-prem-project",
+					ProjectNumber: "456",
+					Zone:          "us-central1-a",
+					InstanceName:  "on-prem-host",
+					InstanceId:    "host-1",
+				},
+			},
+			want: &metadataserver.CloudProperties{
+				ProjectID:        "on-prem-project",
+				NumericProjectID: "456",
+				InstanceID:       "host-1",
+				Zone:             "us-central1-a",
+				InstanceName:     "on-prem-host",
+				Image:            metadataserver.ImageUnknown,
+				MachineType:      metadataserver.MachineTypeUnknown,
+			},
+		},
+		{
+			name: "on_prem instance id defaults to instance name",
+			cfg: &configpb.Configuration{
+				Environment: "on_prem",
+				InstanceProperties: &configpb.InstanceProperties{
+					ProjectId:    "on-prem-project",
+					Zone:         "us-central1-a",
+					InstanceName: "on-prem-host",
+				},
+			},
+			want: &metadataserver.CloudProperties{
+				ProjectID:    "on-prem-project",
+				InstanceID:   "on-prem-host",
+				Zone:         "us-central1-a",
+				InstanceName: "on-prem-host",
+				Image:        metadataserver.ImageUnknown,
+				MachineType:  metadataserver.MachineTypeUnknown,
+			},
+		},
+		{
+			name: "on_prem without instance properties does not read the metadata server",
+			cfg:  &configpb.Configuration{Environment: "on_prem"},
+			want: &metadataserver.CloudProperties{
+				Image:       metadataserver.ImageUnknown,
+				MachineType: metadataserver.MachineTypeUnknown,
+			},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			readMetadata := false
+			got := CloudProperties(tc.cfg, func() *metadataserver.CloudProperties {
+				readMetadata = true
+				return gceProperties
+			})
+			if readMetadata != tc.wantReadMetadata {
+				t.Errorf("CloudProperties() read the metadata server = %v, want %v", readMetadata, tc.wantReadMetadata)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("CloudProperties() returned diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCloudPropertiesOnPremDoesNotBlock(t *testing.T) {
+	cfg := &configpb.Configuration{
+		Environment: "on_prem",
+		InstanceProperties: &configpb.InstanceProperties{
+			ProjectId:    "on-prem-project",
+			Zone:         "us-central1-a",
+			InstanceName: "on-prem-host",
+		},
+	}
+	// The metadata server of a non-GCE host never responds.
+	block := make(chan struct{})
+	defer close(block)
+	done := make(chan *metadataserver.CloudProperties)
+	go func() {
+		done <- CloudProperties(cfg, func() *metadataserver.CloudProperties {
+			<-block
+			return nil
+		})
+	}()
+	select {
+	case got := <-done:
+		if got.ProjectID != "on-prem-project" {
+			t.Errorf("CloudProperties().ProjectID = %q, want %q", got.ProjectID, "on-prem-project")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("CloudProperties() blocked on the metadata server for an on_prem configuration")
+	}
+}
+
+func TestValidateInstanceProperties(t *testing.T) {
+	testcases := []struct {
+		name    string
+		p       *configpb.InstanceProperties
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			p: &configpb.InstanceProperties{
+				ProjectId:    "project",
+				Zone:         "us-central1-a",
+				InstanceName: "host",
+			},
+		},
+		{
+			name:    "nil",
+			wantErr: true,
+		},
+		{
+			name: "missing zone",
+			p: &configpb.InstanceProperties{
+				ProjectId:    "project",
+				InstanceName: "host",
+			},
+			wantErr: true,
+		},
+		{
+			name: "zone without region",
+			p: &configpb.InstanceProperties{
+				ProjectId:    "project",
+				Zone:         "uscentral1",
+				InstanceName: "host",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := validateInstanceProperties(tc.p); (err != nil) != tc.wantErr {
+				t.Errorf("validateInstanceProperties() = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/GoogleCloudPlatform/workloadagentplatform/integration/common/shared/log"
+)
+
+// CycleTruncatedField is stamped into the fields of the details collected by a cycle cut off by
+// the maximum cycle duration.
+const CycleTruncatedField = "cycle_truncated"
+
+// CollectWithinCycle runs collect with a context cancelled after maxDuration and returns the
+// details collected. If maxDuration runs out, the remaining work of collect is cancelled and the
+// details collected so far are returned, marked with the "cycle_truncated" field. A maxDuration of 0
+// does not limit collect.
+func CollectWithinCycle(ctx context.Context, maxDuration time.Duration, collect func(ctx context.Context) ([]Details, error)) ([]Details, error) {
+	if maxDuration <= 0 {
+		return collect(ctx)
+	}
+	cycleCtx, cancel := context.WithTimeout(ctx, maxDuration)
+	defer cancel()
+	details, err := collect(cycleCtx)
+	if ctx.Err() == nil && errors.Is(cycleCtx.Err(), context.DeadlineExceeded) {
+		log.Logger.Warnw("Collection exceeded the maximum cycle duration, the remaining work is cancelled", "max_cycle_duration", maxDuration)
+		AddCycleTruncated(details)
+	}
+	return details, err
+}
+
+// AddCycleTruncated stamps the "cycle_truncated" marker into every field of the given details.
+func AddCycleTruncated(details []Details) {
+	for _, detail := range details {
+		for _, field := range detail.Fields {
+			field[CycleTruncatedField] = "true"
+		}
+	}
+}
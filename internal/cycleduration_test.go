@@ -0,0 +1,106 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCollectWithinCycle(t *testing.T) {
+	// slowCollect collects the first rule, then blocks on the second until the cycle is cancelled.
+	slowCollect := func(ctx context.Context) ([]Details, error) {
+		details := []Details{{Name: "RULE1", Fields: []map[string]string{{"value": "1"}}}}
+		select {
+		case <-ctx.Done():
+			return details, nil
+		case <-time.After(time.Minute):
+		}
+		return append(details, Details{Name: "RULE2", Fields: []map[string]string{{"value": "2"}}}), nil
+	}
+	fastCollect := func(ctx context.Context) ([]Details, error) {
+		return []Details{{Name: "RULE1", Fields: []map[string]string{{"value": "1"}}}}, nil
+	}
+	errCollect := func(ctx context.Context) ([]Details, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	tests := []struct {
+		name        string
+		maxDuration time.Duration
+		collect     func(ctx context.Context) ([]Details, error)
+		want        []Details
+		wantErr     error
+	}{
+		{
+			name:        "long cycle is cut off with partial details",
+			maxDuration: 50 * time.Millisecond,
+			collect:     slowCollect,
+			want:        []Details{{Name: "RULE1", Fields: []map[string]string{{"value": "1", "cycle_truncated": "true"}}}},
+		},
+		{
+			name:        "cycle within the maximum duration",
+			maxDuration: time.Minute,
+			collect:     fastCollect,
+			want:        []Details{{Name: "RULE1", Fields: []map[string]string{{"value": "1"}}}},
+		},
+		{
+			name:    "no maximum duration",
+			collect: fastCollect,
+			want:    []Details{{Name: "RULE1", Fields: []map[string]string{{"value": "1"}}}},
+		},
+		{
+			name:        "cut off cycle returns the error of collect",
+			maxDuration: 50 * time.Millisecond,
+			collect:     errCollect,
+			wantErr:     context.DeadlineExceeded,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			start := time.Now()
+			got, err := CollectWithinCycle(context.Background(), tc.maxDuration, tc.collect)
+			if !errors.Is(err, tc.wantErr) {
+				t.Errorf("CollectWithinCycle() returned error %v, want %v", err, tc.wantErr)
+			}
+			if elapsed := time.Since(start); elapsed > 30*time.Second {
+				t.Errorf("CollectWithinCycle() took %v, want the cycle to be cut off", elapsed)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("CollectWithinCycle() returned an unexpected diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCollectWithinCycleParentCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	got, _ := CollectWithinCycle(ctx, time.Minute, func(ctx context.Context) ([]Details, error) {
+		return []Details{{Name: "RULE1", Fields: []map[string]string{{"value": "1"}}}}, ctx.Err()
+	})
+	// Cancelling the parent is not a cut off by the maximum cycle duration.
+	want := []Details{{Name: "RULE1", Fields: []map[string]string{{"value": "1"}}}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("CollectWithinCycle() returned an unexpected diff (-want +got):\n%s", diff)
+	}
+}
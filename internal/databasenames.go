@@ -0,0 +1,48 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// hashedNameLength is the number of bytes of the hash kept in a hashed database name.
+const hashedNameLength = 16
+
+// HashDatabaseNames replaces the db_name of every field in details with a hash of the name salted
+// with salt. A name always has the same hash for the same salt, so the hashed names can still be
+// compared across rules and collections. Unknown names are kept.
+func HashDatabaseNames(details []Details, salt string) {
+	for _, detail := range details {
+		for _, field := range detail.Fields {
+			name, ok := field["db_name"]
+			if !ok || name == "unknown" {
+				continue
+			}
+			field["db_name"] = hashDatabaseName(name, salt)
+		}
+	}
+}
+
+// hashDatabaseName returns the salted hash of name, e.g. "db-3f1c...".
+func hashDatabaseName(name, salt string) string {
+	mac := hmac.New(sha256.New, []byte(salt))
+	mac.Write([]byte(name))
+	return "db-" + hex.EncodeToString(mac.Sum(nil)[:hashedNameLength])
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHashDatabaseNames(t *testing.T) {
+	newDetails := func() []Details {
+		return []Details{
+			{
+				Name: "DB_LOG_DISK_SEPARATION",
+				Fields: []map[string]string{
+					{"db_name": "sales", "physical_drive": "D"},
+					{"db_name": "hr", "physical_drive": "E"},
+				},
+			},
+			{
+				Name:   "DB_TRUSTWORTHY",
+				Fields: []map[string]string{{"db_name": "sales", "trustworthy": "false"}, {"db_name": "unknown", "trustworthy": "true"}},
+			},
+			{
+				Name:   "INSTANCE_METRICS",
+				Fields: []map[string]string{{"os": "windows"}},
+			},
+		}
+	}
+	details := newDetails()
+	HashDatabaseNames(details, "test-project")
+
+	for _, detail := range details {
+		for _, field := range detail.Fields {
+			name, ok := field["db_name"]
+			if !ok || name == "unknown" {
+				continue
+			}
+			if name == "sales" || name == "hr" || !strings.HasPrefix(name, "db-") {
+				t.Errorf("HashDatabaseNames() kept db_name %q in %s, want a hash", name, detail.Name)
+			}
+		}
+	}
+	if got := details[2].Fields[0]; len(got) != 1 || got["os"] != "windows" {
+		t.Errorf("HashDatabaseNames() changed the fields without db_name: %v", got)
+	}
+	if got := details[1].Fields[1]["db_name"]; got != "unknown" {
+		t.Errorf("HashDatabaseNames() hashed unknown db_name to %q, want unknown", got)
+	}
+
+	sales := details[0].Fields[0]["db_name"]
+	if got := details[1].Fields[0]["db_name"]; got != sales {
+		t.Errorf("HashDatabaseNames() hashed sales to %q and %q across rules, want the same hash", sales, got)
+	}
+	if hr := details[0].Fields[1]["db_name"]; hr == sales {
+		t.Errorf("HashDatabaseNames() hashed sales and hr to the same %q, want different hashes", hr)
+	}
+
+	again := newDetails()
+	HashDatabaseNames(again, "test-project")
+	if got := again[0].Fields[0]["db_name"]; got != sales {
+		t.Errorf("HashDatabaseNames() hashed sales to %q and %q across collections, want the same hash", sales, got)
+	}
+	otherSalt := newDetails()
+	HashDatabaseNames(otherSalt, "other-project")
+	if got := otherSalt[0].Fields[0]["db_name"]; got == sales {
+		t.Errorf("HashDatabaseNames() hashed sales to %q with different salts, want different hashes", got)
+	}
+}
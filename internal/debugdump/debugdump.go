@@ -0,0 +1,127 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package debugdump writes the support bundle of the agent.
+package debugdump
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"github.com/GoogleCloudPlatform/sql-server-agent/internal/configuration"
+	configpb "github.com/GoogleCloudPlatform/sql-server-agent/protos/sqlserveragentconfig"
+)
+
+// maxLogLines is the number of most recent log lines included in the dump.
+const maxLogLines = 1000
+
+// Contents are the contents of a debug dump.
+type Contents struct {
+	// Configuration is the configuration of the agent. It is redacted before it is written.
+	Configuration *configpb.Configuration
+	// LogFile is the path of the log file of the agent.
+	LogFile string
+	// ResultFiles are the paths of the collection results persisted in onetime mode.
+	ResultFiles []string
+	// Metadata describes the agent and the machine it runs on.
+	Metadata map[string]string
+}
+
+// Write writes a zip archive of the contents to path. The archive holds configuration.json,
+// agent.log with the most recent log lines, metadata.json and the result files under results/.
+// A missing log or result file is skipped, so a dump can be taken before the first collection.
+func Write(path string, c Contents) (err error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+	w := zip.NewWriter(f)
+
+	cfg, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(configuration.Redact(c.Configuration))
+	if err != nil {
+		return fmt.Errorf("failed to marshal the configuration: %v", err)
+	}
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, cfg, "", "  "); err != nil {
+		return fmt.Errorf("failed to marshal the configuration: %v", err)
+	}
+	if err := writeFile(w, "configuration.json", indented.Bytes()); err != nil {
+		return err
+	}
+	metadata, err := json.MarshalIndent(c.Metadata, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal the metadata: %v", err)
+	}
+	if err := writeFile(w, "metadata.json", metadata); err != nil {
+		return err
+	}
+	logs, err := os.ReadFile(c.LogFile)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read the log file: %v", err)
+	}
+	if err := writeFile(w, "agent.log", []byte(lastLines(string(logs), maxLogLines))); err != nil {
+		return err
+	}
+	for _, r := range c.ResultFiles {
+		b, err := os.ReadFile(r)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read the result file %s: %v", r, err)
+		}
+		if err := writeFile(w, "results/"+filepath.Base(r), b); err != nil {
+			return err
+		}
+	}
+	return w.Close()
+}
+
+// writeFile adds a file with the given name and content to the archive.
+func writeFile(w *zip.Writer, name string, content []byte) error {
+	fw, err := w.Create(name)
+	if err != nil {
+		return fmt.Errorf("failed to add %s to the debug dump: %v", name, err)
+	}
+	if _, err := fw.Write(content); err != nil {
+		return fmt.Errorf("failed to add %s to the debug dump: %v", name, err)
+	}
+	return nil
+}
+
+// lastLines returns the last n lines of s.
+func lastLines(s string, n int) string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "")
+}
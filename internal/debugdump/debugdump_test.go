@@ -0,0 +1,120 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debugdump
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	configpb "github.com/GoogleCloudPlatform/sql-server-agent/protos/sqlserveragentconfig"
+)
+
+// readArchive returns the files of the zip archive at path keyed by name.
+func readArchive(t *testing.T, path string) map[string]string {
+	t.Helper()
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatalf("zip.OpenReader(%q) returned an unexpected error: %v", path, err)
+	}
+	defer r.Close()
+	files := map[string]string{}
+	for _, f := range r.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("Open() of %s returned an unexpected error: %v", f.Name, err)
+		}
+		b, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("ReadAll() of %s returned an unexpected error: %v", f.Name, err)
+		}
+		files[f.Name] = string(b)
+	}
+	return files
+}
+
+func TestWrite(t *testing.T) {
+	dir := t.TempDir()
+	var logs strings.Builder
+	for i := 0; i < maxLogLines+5; i++ {
+		fmt.Fprintf(&logs, "log line %d\n", i)
+	}
+	logFile := filepath.Join(dir, "google-cloud-sql-server-agent.log")
+	if err := os.WriteFile(logFile, []byte(logs.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+	resultFile := filepath.Join(dir, "localhost-sql.json")
+	if err := os.WriteFile(resultFile, []byte(`{"insight":{}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &configpb.Configuration{
+		LogLevel: "DEBUG",
+		CredentialConfiguration: []*configpb.CredentialConfiguration{
+			{
+				SqlConfigurations: []*configpb.CredentialConfiguration_SqlCredentials{
+					{Host: "localhost", UserName: "sqladmin", SecretName: "sql-secret", PortNumber: 1433},
+				},
+			},
+		},
+	}
+	path := filepath.Join(dir, "dump.zip")
+
+	err := Write(path, Contents{
+		Configuration: cfg,
+		LogFile:       logFile,
+		ResultFiles:   []string{resultFile, filepath.Join(dir, "localhost-guest.json")},
+		Metadata:      map[string]string{"agent_version": "1.0"},
+	})
+	if err != nil {
+		t.Fatalf("Write() returned an unexpected error: %v", err)
+	}
+
+	files := readArchive(t, path)
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	wantNames := []string{"agent.log", "configuration.json", "metadata.json", "results/localhost-sql.json"}
+	if diff := cmp.Diff(wantNames, names, cmpopts.SortSlices(func(a, b string) bool { return a < b })); diff != "" {
+		t.Errorf("Write() archive files diff (-want +got):\n%s", diff)
+	}
+	for _, secret := range []string{"sqladmin", "sql-secret"} {
+		if strings.Contains(files["configuration.json"], secret) {
+			t.Errorf("Write() configuration.json = %s, want %q redacted", files["configuration.json"], secret)
+		}
+	}
+	if !strings.Contains(files["configuration.json"], `"log_level": "DEBUG"`) {
+		t.Errorf("Write() configuration.json = %s, want it to contain the log level", files["configuration.json"])
+	}
+	gotLines := strings.Split(strings.TrimSuffix(files["agent.log"], "\n"), "\n")
+	if len(gotLines) != maxLogLines || gotLines[0] != "log line 5" {
+		t.Errorf("Write() agent.log has %d lines starting with %q, want %d lines starting with %q", len(gotLines), gotLines[0], maxLogLines, "log line 5")
+	}
+	if got := files["results/localhost-sql.json"]; got != `{"insight":{}}` {
+		t.Errorf("Write() results/localhost-sql.json = %q, want %q", got, `{"insight":{}}`)
+	}
+	if !strings.Contains(files["metadata.json"], `"agent_version": "1.0"`) {
+		t.Errorf("Write() metadata.json = %s, want it to contain the agent version", files["metadata.json"])
+	}
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"strconv"
+	"strings"
+)
+
+// editionLimit is the compute capacity a SQL Server edition can use.
+// A limit of 0 means the edition is not limited.
+type editionLimit struct {
+	edition     string
+	maxCPUCount int64
+	maxMemoryKb int64
+}
+
+// editionLimits are the compute capacity limits of the SQL Server editions, matched by the prefix
+// of the edition reported in INSTANCE_METRICS. The memory limit is the buffer pool limit.
+// https://learn.microsoft.com/en-us/sql/sql-server/compute-capacity-limits-by-edition-of-sql-server
+var editionLimits = []editionLimit{
+	{edition: "Enterprise"},
+	{edition: "Developer"},
+	{edition: "Evaluation"},
+	{edition: "Standard", maxCPUCount: 24, maxMemoryKb: 128 * 1024 * 1024},
+	{edition: "Web", maxCPUCount: 16, maxMemoryKb: 64 * 1024 * 1024},
+	{edition: "Express", maxCPUCount: 4, maxMemoryKb: 1410 * 1024},
+}
+
+// AddEditionLimits appends the INSTANCE_EDITION_LIMITS details derived from the edition, cpu count
+// and physical memory in INSTANCE_METRICS. It flags the instances running on a machine with more
+// cpus or memory than their edition can use.
+func AddEditionLimits(details []Details) []Details {
+	var fields []map[string]string
+	for _, detail := range details {
+		if detail.Name != "INSTANCE_METRICS" {
+			continue
+		}
+		for _, field := range detail.Fields {
+			fields = append(fields, editionLimitFields(field))
+		}
+	}
+	if len(fields) == 0 {
+		return details
+	}
+	return append(details, Details{Name: "INSTANCE_EDITION_LIMITS", Fields: fields})
+}
+
+// editionLimitFields returns the edition limits of the instance in the INSTANCE_METRICS field.
+func editionLimitFields(metrics map[string]string) map[string]string {
+	res := map[string]string{
+		"edition":                 metrics["edition"],
+		"edition_cpu_limit":       "unknown",
+		"edition_memory_limit_kb": "unknown",
+		"exceeds_cpu_limit":       "unknown",
+		"exceeds_memory_limit":    "unknown",
+	}
+	limit, ok := findEditionLimit(metrics["edition"])
+	if !ok {
+		return res
+	}
+	res["edition_cpu_limit"] = limitString(limit.maxCPUCount)
+	res["edition_memory_limit_kb"] = limitString(limit.maxMemoryKb)
+	res["exceeds_cpu_limit"] = exceedsLimit(metrics["cpu_count"], limit.maxCPUCount)
+	res["exceeds_memory_limit"] = exceedsLimit(metrics["physical_memory_kb"], limit.maxMemoryKb)
+	return res
+}
+
+func findEditionLimit(edition string) (editionLimit, bool) {
+	for _, limit := range editionLimits {
+		if strings.HasPrefix(edition, limit.edition) {
+			return limit, true
+		}
+	}
+	return editionLimit{}, false
+}
+
+func limitString(limit int64) string {
+	if limit == 0 {
+		return "unlimited"
+	}
+	return strconv.FormatInt(limit, 10)
+}
+
+// exceedsLimit returns whether the value exceeds the limit, or "unknown" if the value is not a number.
+func exceedsLimit(value string, limit int64) string {
+	v, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return "unknown"
+	}
+	return strconv.FormatBool(limit != 0 && v > limit)
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestAddEditionLimits(t *testing.T) {
+	testcases := []struct {
+		name    string
+		details []Details
+		want    []Details
+	}{
+		{
+			name: "standard edition exceeds limits",
+			details: []Details{
+				{
+					Name:   "INSTANCE_METRICS",
+					Fields: []map[string]string{{"edition": "Standard Edition (64-bit)", "cpu_count": "32", "physical_memory_kb": "268435456"}},
+				},
+			},
+			want: []Details{
+				{
+					Name:   "INSTANCE_METRICS",
+					Fields: []map[string]string{{"edition": "Standard Edition (64-bit)", "cpu_count": "32", "physical_memory_kb": "268435456"}},
+				},
+				{
+					Name: "INSTANCE_EDITION_LIMITS",
+					Fields: []map[string]string{{
+						"edition":                 "Standard Edition (64-bit)",
+						"edition_cpu_limit":       "24",
+						"edition_memory_limit_kb": "134217728",
+						"exceeds_cpu_limit":       "true",
+						"exceeds_memory_limit":    "true",
+					}},
+				},
+			},
+		},
+		{
+			name: "standard edition within limits",
+			details: []Details{
+				{
+					Name:   "INSTANCE_METRICS",
+					Fields: []map[string]string{{"edition": "Standard Edition (64-bit)", "cpu_count": "8", "physical_memory_kb": "67108864"}},
+				},
+			},
+			want: []Details{
+				{
+					Name:   "INSTANCE_METRICS",
+					Fields: []map[string]string{{"edition": "Standard Edition (64-bit)", "cpu_count": "8", "physical_memory_kb": "67108864"}},
+				},
+				{
+					Name: "INSTANCE_EDITION_LIMITS",
+					Fields: []map[string]string{{
+						"edition":                 "Standard Edition (64-bit)",
+						"edition_cpu_limit":       "24",
+						"edition_memory_limit_kb": "134217728",
+						"exceeds_cpu_limit":       "false",
+						"exceeds_memory_limit":    "false",
+					}},
+				},
+			},
+		},
+		{
+			name: "enterprise edition is unlimited",
+			details: []Details{
+				{
+					Name:   "INSTANCE_METRICS",
+					Fields: []map[string]string{{"edition": "Enterprise Edition: Core-based Licensing (64-bit)", "cpu_count": "96", "physical_memory_kb": "805306368"}},
+				},
+			},
+			want: []Details{
+				{
+					Name:   "INSTANCE_METRICS",
+					Fields: []map[string]string{{"edition": "Enterprise Edition: Core-based Licensing (64-bit)", "cpu_count": "96", "physical_memory_kb": "805306368"}},
+				},
+				{
+					Name: "INSTANCE_EDITION_LIMITS",
+					Fields: []map[string]string{{
+						"edition":                 "Enterprise Edition: Core-based Licensing (64-bit)",
+						"edition_cpu_limit":       "unlimited",
+						"edition_memory_limit_kb": "unlimited",
+						"exceeds_cpu_limit":       "false",
+						"exceeds_memory_limit":    "false",
+					}},
+				},
+			},
+		},
+		{
+			name: "unknown edition and cpu count",
+			details: []Details{
+				{
+					Name:   "INSTANCE_METRICS",
+					Fields: []map[string]string{{"edition": "unknown", "cpu_count": "unknown", "physical_memory_kb": "unknown"}},
+				},
+			},
+			want: []Details{
+				{
+					Name:   "INSTANCE_METRICS",
+					Fields: []map[string]string{{"edition": "unknown", "cpu_count": "unknown", "physical_memory_kb": "unknown"}},
+				},
+				{
+					Name: "INSTANCE_EDITION_LIMITS",
+					Fields: []map[string]string{{
+						"edition":                 "unknown",
+						"edition_cpu_limit":       "unknown",
+						"edition_memory_limit_kb": "unknown",
+						"exceeds_cpu_limit":       "unknown",
+						"exceeds_memory_limit":    "unknown",
+					}},
+				},
+			},
+		},
+		{
+			name: "no instance metrics",
+			details: []Details{
+				{Name: "DB_MAX_PARALLELISM", Fields: []map[string]string{{"maxDegreeOfParallelism": "0"}}},
+			},
+			want: []Details{
+				{Name: "DB_MAX_PARALLELISM", Fields: []map[string]string{{"maxDegreeOfParallelism": "0"}}},
+			},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := AddEditionLimits(tc.details)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("AddEditionLimits() returned diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -19,36 +19,67 @@ package flags
 
 import (
 	"fmt"
+	"strings"
 
 	"flag"
+	"google.golang.org/protobuf/proto"
 	"github.com/GoogleCloudPlatform/sql-server-agent/internal/agentstatus"
+	"github.com/GoogleCloudPlatform/sql-server-agent/internal/configuration"
+	"github.com/GoogleCloudPlatform/sql-server-agent/internal/guestcollector"
 	"github.com/GoogleCloudPlatform/sql-server-agent/internal"
+	configpb "github.com/GoogleCloudPlatform/sql-server-agent/protos/sqlserveragentconfig"
+	"github.com/GoogleCloudPlatform/workloadagentplatform/integration/common/shared/usagemetrics"
 )
 
+// logLevels are the supported values of flag -log-level, same as "log_level" in the configuration.
+var logLevels = map[string]bool{
+	"DEBUG":   true,
+	"INFO":    true,
+	"WARNING": true,
+	"ERROR":   true,
+}
+
 // AgentFlags .
 type AgentFlags struct {
-	Action        string
-	Onetime       bool
-	Address       string
-	Protocol      string
-	errorLogFile  string
-	logName       string
-	logStatus     string
-	logVersion    string
-	version       bool
-	help          bool
-	h             bool
-	projectID     string
-	zone          string
-	instance      string
-	projectNumber string
-	image         string
+	Action            string
+	Onetime           bool
+	OnceAndExit       bool
+	Target            string
+	JSONStdout        bool
+	LogLevel          string
+	DebugDump         string
+	ConfigStdin       bool
+	LogDir            string
+	OutputDir         string
+	Address           string
+	Protocol          string
+	errorLogFile      string
+	logName           string
+	logStatus         string
+	logVersion        string
+	version           bool
+	help              bool
+	h                 bool
+	cloudProperties   func() *usagemetrics.CloudProperties
+	printConfigSchema bool
+	printCommands     bool
+	printNamespaces   bool
 }
 
 // NewAgentFlags initialize flags and return the reference of struct agentFlags.
-func NewAgentFlags(projectID, zone, instance, projectNumber, image string) *AgentFlags {
+// cloudProperties returns the properties of the host the status is logged with. It is only called
+// for flag -logstatus, as it may wait for the metadata server.
+func NewAgentFlags(cloudProperties func() *usagemetrics.CloudProperties) *AgentFlags {
 	action := flag.String("action", "", "Action for running the agent.")
-	onetime := flag.Bool("onetime", false, "Onetime mode for the agent.")
+	onetime := flag.Bool("onetime", false, "Onetime mode for the agent. Exits with 0 on success, 1 if some collections failed, 2 if all collections failed and 3 on an invalid configuration.")
+	onceAndExit := flag.Bool("once-and-exit", false, "Run one cycle of the service collections and exit.")
+	target := flag.String("target", "", "Restrict onetime collection to the credential configuration with the given instance_name or index.")
+	jsonStdout := flag.Bool("json-stdout", false, "Write the onetime collection results as JSON to stdout instead of files.")
+	logLevel := flag.String("log-level", "", "Override the log level of the configuration file: DEBUG, INFO, WARNING or ERROR.")
+	debugDump := flag.String("debug-dump", "", "Write a zip archive with the redacted configuration, recent logs and last collection results to the given path and exit.")
+	configStdin := flag.Bool("config-stdin", false, "Read the configuration as JSON from stdin instead of the configuration file.")
+	logDir := flag.String("log-dir", "", "Write the log file to the given directory. Overrides environment variable GOOGLE_CLOUD_SQL_SERVER_AGENT_LOG_DIR and log_directory of the configuration file.")
+	outputDir := flag.String("output-dir", "", "Write the onetime collection results to the given directory. Overrides environment variable GOOGLE_CLOUD_SQL_SERVER_AGENT_OUTPUT_DIR and onetime_output_directory of the configuration file.")
 	version := flag.Bool("agent_version", false, "Display the version of the agent.")
 	help := flag.Bool("help", false, "Display the usage of each flag.")
 	h := flag.Bool("h", false, "Display the usage of each flag.")
@@ -59,28 +90,38 @@ func NewAgentFlags(projectID, zone, instance, projectNumber, image string) *Agen
 	logStatus := flag.String("logstatus", "", "log usage status")
 	logVersion := flag.String("logversion", "", "log usage version")
 	logName := flag.String("logname", "", "name of the log type")
+	printConfigSchema := flag.Bool("print-config-schema", false, "Print the JSON schema of the configuration file and exit.")
+	printCommands := flag.Bool("print-commands", false, "Print the commands the agent may run on linux guests and exit.")
+	printNamespaces := flag.Bool("print-wmi-namespaces", false, "Print the WMI namespaces the agent may query on windows guests and exit.")
 
 	if !flag.Parsed() {
 		flag.Parse()
 	}
 
 	return &AgentFlags{
-		Action:        *action,
-		Onetime:       *onetime,
-		Address:       *address,
-		Protocol:      *protocol,
-		errorLogFile:  *errorLogfile,
-		version:       *version,
-		help:          *help,
-		h:             *h,
-		logStatus:     *logStatus,
-		logVersion:    *logVersion,
-		logName:       *logName,
-		projectID:     projectID,
-		zone:          zone,
-		instance:      instance,
-		projectNumber: projectNumber,
-		image:         image,
+		Action:            *action,
+		Onetime:           *onetime,
+		OnceAndExit:       *onceAndExit,
+		Target:            *target,
+		JSONStdout:        *jsonStdout,
+		LogLevel:          strings.ToUpper(*logLevel),
+		DebugDump:         *debugDump,
+		ConfigStdin:       *configStdin,
+		LogDir:            *logDir,
+		OutputDir:         *outputDir,
+		Address:           *address,
+		Protocol:          *protocol,
+		errorLogFile:      *errorLogfile,
+		version:           *version,
+		help:              *help,
+		h:                 *h,
+		logStatus:         *logStatus,
+		logVersion:        *logVersion,
+		logName:           *logName,
+		cloudProperties:   cloudProperties,
+		printConfigSchema: *printConfigSchema,
+		printCommands:     *printCommands,
+		printNamespaces:   *printNamespaces,
 	}
 }
 
@@ -94,10 +135,34 @@ func (af *AgentFlags) Execute() (string, bool) {
 	if af.version {
 		return fmt.Sprintf("Google Cloud SQL Server Agent version: %v.", internal.AgentVersion), false
 	}
+	if af.printConfigSchema {
+		return af.configSchema()
+	}
+	if af.printCommands {
+		return strings.Join(guestcollector.LinuxCommands(), "\n"), false
+	}
+	if af.printNamespaces {
+		return strings.Join(guestcollector.WMINamespaces(), "\n"), false
+	}
 	if af.logStatus != "" {
 		return af.status()
 	}
-	if af.Onetime {
+	if af.Target != "" && !af.Onetime {
+		return "Flag -target is only supported in onetime mode -onetime.", false
+	}
+	if af.JSONStdout && !af.Onetime {
+		return "Flag -json-stdout is only supported in onetime mode -onetime.", false
+	}
+	if af.LogLevel != "" && !logLevels[af.LogLevel] {
+		return fmt.Sprintf("Invalid value %q for flag -log-level. Supported values are DEBUG, INFO, WARNING and ERROR.", af.LogLevel), false
+	}
+	if af.OnceAndExit && af.Onetime {
+		return "Flags -once-and-exit and -onetime cannot be used together.", false
+	}
+	if af.DebugDump != "" && (af.Onetime || af.OnceAndExit) {
+		return "Flag -debug-dump cannot be used with -onetime or -once-and-exit.", false
+	}
+	if af.Onetime || af.OnceAndExit || af.DebugDump != "" {
 		return "", true
 	}
 	// TODO - LCM integration.
@@ -107,6 +172,18 @@ func (af *AgentFlags) Execute() (string, bool) {
 	return "", true
 }
 
+// OverrideLogLevel returns the configuration with the log level of flag -log-level if the flag is
+// set, or the configuration itself otherwise. The configuration is copied before it is changed, as
+// it may be shared, e.g. the default configuration.
+func (af *AgentFlags) OverrideLogLevel(cfg *configpb.Configuration) *configpb.Configuration {
+	if af.LogLevel == "" || cfg == nil {
+		return cfg
+	}
+	cfg = proto.Clone(cfg).(*configpb.Configuration)
+	cfg.LogLevel = af.LogLevel
+	return cfg
+}
+
 func (af *AgentFlags) usage() string {
 	return `Usage: google-cloud-sql-server-agent -(h|agent_version|onetime)`
 }
@@ -119,7 +196,18 @@ func (af *AgentFlags) status() (string, bool) {
 		return "Please specify the version of the log -logversion.", false
 	}
 	ap := agentstatus.NewAgentProperties(af.logName, af.logVersion, internal.AgentUsageLogPrefix, true)
-	cp := agentstatus.NewCloudProperties(af.projectID, af.zone, af.instance, af.projectNumber, af.image)
+	cp := &usagemetrics.CloudProperties{}
+	if af.cloudProperties != nil {
+		cp = af.cloudProperties()
+	}
 	agentstatus.NewUsageMetricsLogger(ap, cp, []string{}).LogStatus(agentstatus.Status(af.logStatus), "")
 	return "", false
 }
+
+func (af *AgentFlags) configSchema() (string, bool) {
+	schema, err := configuration.ConfigSchema()
+	if err != nil {
+		return fmt.Sprintf("Failed to generate the configuration schema: %v", err), false
+	}
+	return schema, false
+}
@@ -17,14 +17,18 @@ limitations under the License.
 package flags
 
 import (
+	"encoding/json"
 	"fmt"
+	"strings"
 	"testing"
 
+	"google.golang.org/protobuf/proto"
 	"github.com/GoogleCloudPlatform/sql-server-agent/internal"
+	configpb "github.com/GoogleCloudPlatform/sql-server-agent/protos/sqlserveragentconfig"
 )
 
 func TestNewAgentFlags(t *testing.T) {
-	af := NewAgentFlags("", "", "", "", "")
+	af := NewAgentFlags(nil)
 	if af.help != false {
 		t.Errorf("NewAgentFlags() = %v, want %v", af.help, true)
 	}
@@ -73,6 +77,66 @@ func TestExecute(t *testing.T) {
 			wantStr:  "",
 			wantBool: true,
 		},
+		{
+			name:     "flag --once-and-exit is enabled",
+			af:       &AgentFlags{OnceAndExit: true},
+			wantStr:  "",
+			wantBool: true,
+		},
+		{
+			name:     "flag --once-and-exit cannot be used with --onetime",
+			af:       &AgentFlags{Onetime: true, OnceAndExit: true},
+			wantStr:  "Flags -once-and-exit and -onetime cannot be used together.",
+			wantBool: false,
+		},
+		{
+			name:     "flag --debug-dump is enabled",
+			af:       &AgentFlags{DebugDump: "/tmp/dump.zip"},
+			wantStr:  "",
+			wantBool: true,
+		},
+		{
+			name:     "flag --debug-dump cannot be used with --onetime",
+			af:       &AgentFlags{DebugDump: "/tmp/dump.zip", Onetime: true},
+			wantStr:  "Flag -debug-dump cannot be used with -onetime or -once-and-exit.",
+			wantBool: false,
+		},
+		{
+			name:     "flag --target is enabled with --onetime",
+			af:       &AgentFlags{Onetime: true, Target: "instance-1"},
+			wantStr:  "",
+			wantBool: true,
+		},
+		{
+			name:     "flag --target requires flag --onetime",
+			af:       &AgentFlags{Action: "run", Target: "instance-1"},
+			wantStr:  "Flag -target is only supported in onetime mode -onetime.",
+			wantBool: false,
+		},
+		{
+			name:     "flag --json-stdout is enabled with --onetime",
+			af:       &AgentFlags{Onetime: true, JSONStdout: true},
+			wantStr:  "",
+			wantBool: true,
+		},
+		{
+			name:     "flag --json-stdout requires flag --onetime",
+			af:       &AgentFlags{Action: "run", JSONStdout: true},
+			wantStr:  "Flag -json-stdout is only supported in onetime mode -onetime.",
+			wantBool: false,
+		},
+		{
+			name:     "flag --log-level is valid",
+			af:       &AgentFlags{Onetime: true, LogLevel: "DEBUG"},
+			wantStr:  "",
+			wantBool: true,
+		},
+		{
+			name:     "flag --log-level is invalid",
+			af:       &AgentFlags{Onetime: true, LogLevel: "VERBOSE"},
+			wantStr:  `Invalid value "VERBOSE" for flag -log-level. Supported values are DEBUG, INFO, WARNING and ERROR.`,
+			wantBool: false,
+		},
 		{
 			name:     "flag --action is empty",
 			af:       &AgentFlags{Action: ""},
@@ -126,3 +190,75 @@ func TestExecute(t *testing.T) {
 		})
 	}
 }
+
+func TestExecutePrintConfigSchema(t *testing.T) {
+	af := &AgentFlags{printConfigSchema: true, Onetime: true}
+	gotStr, gotBool := af.Execute()
+	if gotBool {
+		t.Errorf("Execute(%v) returned proceed = true, want false", af)
+	}
+	if gotStr == "" || !json.Valid([]byte(gotStr)) {
+		t.Errorf("Execute(%v) = %q, want non-empty valid JSON", af, gotStr)
+	}
+}
+
+func TestExecutePrintCommands(t *testing.T) {
+	af := &AgentFlags{printCommands: true}
+	gotStr, gotBool := af.Execute()
+	if gotBool {
+		t.Errorf("Execute(%v) returned proceed = true, want false", af)
+	}
+	if !strings.Contains(gotStr, "sysctl -n vm.swappiness") {
+		t.Errorf("Execute(%v) = %q, want it to contain %q", af, gotStr, "sysctl -n vm.swappiness")
+	}
+}
+
+func TestExecutePrintWMINamespaces(t *testing.T) {
+	af := &AgentFlags{printNamespaces: true}
+	gotStr, gotBool := af.Execute()
+	if gotBool {
+		t.Errorf("Execute(%v) returned proceed = true, want false", af)
+	}
+	if !strings.Contains(gotStr, `root\cimv2\power`) {
+		t.Errorf("Execute(%v) = %q, want it to contain %q", af, gotStr, `root\cimv2\power`)
+	}
+}
+
+func TestOverrideLogLevel(t *testing.T) {
+	testcases := []struct {
+		name string
+		af   *AgentFlags
+		cfg  *configpb.Configuration
+		want string
+	}{
+		{
+			name: "flag wins over the configuration",
+			af:   &AgentFlags{LogLevel: "DEBUG"},
+			cfg:  &configpb.Configuration{LogLevel: "ERROR"},
+			want: "DEBUG",
+		},
+		{
+			name: "flag sets the level missing in the configuration",
+			af:   &AgentFlags{LogLevel: "WARNING"},
+			cfg:  &configpb.Configuration{},
+			want: "WARNING",
+		},
+		{
+			name: "configuration is kept without the flag",
+			af:   &AgentFlags{},
+			cfg:  &configpb.Configuration{LogLevel: "ERROR"},
+			want: "ERROR",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			before := proto.Clone(tc.cfg)
+			if got := tc.af.OverrideLogLevel(tc.cfg).GetLogLevel(); got != tc.want {
+				t.Errorf("OverrideLogLevel() set log level %q, want %q", got, tc.want)
+			}
+			if !proto.Equal(before, tc.cfg) {
+				t.Errorf("OverrideLogLevel() modified the configuration to %v, want %v", tc.cfg, before)
+			}
+		})
+	}
+}
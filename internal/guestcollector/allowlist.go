@@ -0,0 +1,143 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package guestcollector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/workloadagentplatform/integration/common/shared/commandlineexecutor"
+	"github.com/GoogleCloudPlatform/workloadagentplatform/integration/common/shared/log"
+
+	"github.com/GoogleCloudPlatform/sql-server-agent/internal"
+	"github.com/GoogleCloudPlatform/sql-server-agent/internal/remote"
+)
+
+// ErrCommandNotAllowed is returned when the linux collector is asked to run a command that is
+// not in the allowlist.
+var ErrCommandNotAllowed = errors.New("command is not in the allowlist of the linux collector")
+
+// pathPlaceholder is the placeholder of a shell-quoted file path in allowedLinuxCommands.
+const pathPlaceholder = "<path>"
+
+// allowedLinuxCommands are the only commands the agent runs on linux guests. "%s" is a
+// placeholder for the name of a block device.
+var allowedLinuxCommands = []string{
+	localSSDCommand,
+	localSSDCommandForSuse,
+	powerPlanCommand,
+	dataDiskAllocationUnitsCommand + "%s",
+	gcbdrAgentRunningCommand,
+	vmSwappinessCommand,
+	vmMaxMapCountCommand,
+	kernelNumaBalancingCommand,
+	numaMemInfoCommand,
+	ioSchedulerCommand,
+	cpuGovernorCommand,
+	clocksourceCommand,
+	fmt.Sprintf(physicalDriveFindCommand, pathPlaceholder, pathPlaceholder),
+	fmt.Sprintf(physicalDriveDfCommand, pathPlaceholder),
+	physicalDriveMountCommand,
+}
+
+// allowedLinuxCommandRegex matches a command against the allowlist. Device names may only contain
+// letters, digits, "_" and "-". Paths must be a single shell-quoted word as built by shellQuote.
+var allowedLinuxCommandRegex = func() *regexp.Regexp {
+	var patterns []string
+	for _, command := range allowedLinuxCommands {
+		pattern := strings.ReplaceAll(regexp.QuoteMeta(command), "%s", "[A-Za-z0-9_-]+")
+		patterns = append(patterns, strings.ReplaceAll(pattern, pathPlaceholder, `'(?:[^']|'\\'')*'`))
+	}
+	return regexp.MustCompile("^(" + strings.Join(patterns, "|") + ")$")
+}()
+
+// LinuxCommands returns the commands the linux collector is allowed to run.
+func LinuxCommands() []string {
+	var commands []string
+	for _, command := range allowedLinuxCommands {
+		commands = append(commands, strings.ReplaceAll(command, "%s", "<device>"))
+	}
+	return commands
+}
+
+// checkLinuxCommand returns ErrCommandNotAllowed if the command is not in the allowlist.
+func checkLinuxCommand(command string) error {
+	if !allowedLinuxCommandRegex.MatchString(command) {
+		log.Logger.Errorw("Refused to run a command not in the allowlist", "command", command)
+		return fmt.Errorf("%w: %q", ErrCommandNotAllowed, command)
+	}
+	return nil
+}
+
+// runAllowedCommand runs an allowlisted command on the local machine.
+func runAllowedCommand(ctx context.Context, command string, exec commandlineexecutor.Execute) (string, error) {
+	if err := checkLinuxCommand(command); err != nil {
+		return "", err
+	}
+	return internal.CommandLineExecutorWrapper(ctx, "/bin/sh", fmt.Sprintf(" -c '%s'", command), exec)
+}
+
+// runAllowedRemoteCommand runs an allowlisted command on the remote machine.
+func runAllowedRemoteCommand(r remote.Executor, command string, s remote.SSHSessionInterface) (string, error) {
+	if err := checkLinuxCommand(command); err != nil {
+		return "", err
+	}
+	return r.Run(command, s)
+}
+
+// runAllowedCommandWithPipes runs an allowlisted command, which may contain pipes, on the remote
+// machine.
+func runAllowedCommandWithPipes(command string, r remote.Executor) (string, error) {
+	if err := checkLinuxCommand(command); err != nil {
+		return "", err
+	}
+	return remote.RunCommandWithPipes(command, r)
+}
+
+// ErrNamespaceNotAllowed is returned when the windows collector is asked to query a WMI namespace
+// that is not in the allowlist.
+var ErrNamespaceNotAllowed = errors.New("WMI namespace is not in the allowlist of the windows collector")
+
+// allowedWMINamespaces are the only WMI namespaces the windows collector queries.
+var allowedWMINamespaces = []string{
+	`root\cimv2`,
+	`root\cimv2\power`,
+	`root\microsoft\windows\storage`,
+	`root\microsoft\windows\deduplication`,
+	`root\SecurityCenter2`,
+}
+
+// WMINamespaces returns the WMI namespaces the windows collector is allowed to query.
+func WMINamespaces() []string {
+	return slices.Clone(allowedWMINamespaces)
+}
+
+// checkWMINamespace returns ErrNamespaceNotAllowed if the namespace is not in the allowlist.
+// WMI namespaces are case insensitive.
+func checkWMINamespace(namespace string) error {
+	for _, allowed := range allowedWMINamespaces {
+		if strings.EqualFold(namespace, allowed) {
+			return nil
+		}
+	}
+	log.Logger.Errorw("Refused to query a WMI namespace not in the allowlist", "namespace", namespace)
+	return fmt.Errorf("%w: %q", ErrNamespaceNotAllowed, namespace)
+}
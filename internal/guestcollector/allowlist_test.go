@@ -0,0 +1,214 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package guestcollector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/GoogleCloudPlatform/workloadagentplatform/integration/common/shared/commandlineexecutor"
+)
+
+func TestCheckLinuxCommand(t *testing.T) {
+	testcases := []struct {
+		name    string
+		command string
+		wantErr error
+	}{
+		{
+			name:    "sysctl command",
+			command: vmSwappinessCommand,
+		},
+		{
+			name:    "command with pipes",
+			command: gcbdrAgentRunningCommand,
+		},
+		{
+			name:    "block size of a data disk",
+			command: dataDiskAllocationUnitsCommand + "sdb",
+		},
+		{
+			name:    "io scheduler of a nvme disk",
+			command: fmt.Sprintf(ioSchedulerCommand, "nvme0n1"),
+		},
+		{
+			name:    "sysfs setting",
+			command: clocksourceCommand,
+		},
+		{
+			name:    "find of a quoted path",
+			command: fmt.Sprintf(physicalDriveFindCommand, shellQuote("/var/opt/mssql/it's/"), shellQuote("my db.mdf")),
+		},
+		{
+			name:    "df of a quoted path",
+			command: fmt.Sprintf(physicalDriveDfCommand, shellQuote("/var/opt/mssql/a|b.mdf")),
+		},
+		{
+			name:    "mount",
+			command: physicalDriveMountCommand,
+		},
+		{
+			name:    "df of an unquoted path",
+			command: fmt.Sprintf(physicalDriveDfCommand, "/var/opt/mssql/data/master.mdf"),
+			wantErr: ErrCommandNotAllowed,
+		},
+		{
+			name:    "df of a path breaking out of its quotes",
+			command: fmt.Sprintf(physicalDriveDfCommand, "'/tmp'; reboot; ''"),
+			wantErr: ErrCommandNotAllowed,
+		},
+		{
+			name:    "off-list command",
+			command: "cat /etc/shadow",
+			wantErr: ErrCommandNotAllowed,
+		},
+		{
+			name:    "allowed command with an extra command",
+			command: vmSwappinessCommand + "; rm -rf /",
+			wantErr: ErrCommandNotAllowed,
+		},
+		{
+			name:    "device name with a path",
+			command: dataDiskAllocationUnitsCommand + "../../etc/shadow",
+			wantErr: ErrCommandNotAllowed,
+		},
+		{
+			name:    "empty command",
+			command: "",
+			wantErr: ErrCommandNotAllowed,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := checkLinuxCommand(tc.command); !errors.Is(err, tc.wantErr) {
+				t.Errorf("checkLinuxCommand(%q) = %v, want %v", tc.command, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestRunAllowedCommandOffList(t *testing.T) {
+	called := false
+	exec := func(context.Context, commandlineexecutor.Params) commandlineexecutor.Result {
+		called = true
+		return commandlineexecutor.Result{}
+	}
+	if _, err := runAllowedCommand(context.Background(), "rm -rf /", exec); !errors.Is(err, ErrCommandNotAllowed) {
+		t.Errorf("runAllowedCommand() = %v, want %v", err, ErrCommandNotAllowed)
+	}
+	if called {
+		t.Error("runAllowedCommand() ran a command not in the allowlist")
+	}
+}
+
+func TestRunAllowedRemoteCommandOffList(t *testing.T) {
+	r := &mockRemote{}
+	if _, err := runAllowedRemoteCommand(r, "rm -rf /", nil); !errors.Is(err, ErrCommandNotAllowed) {
+		t.Errorf("runAllowedRemoteCommand() = %v, want %v", err, ErrCommandNotAllowed)
+	}
+	if _, err := runAllowedCommandWithPipes("cat /etc/shadow | grep root", r); !errors.Is(err, ErrCommandNotAllowed) {
+		t.Errorf("runAllowedCommandWithPipes() = %v, want %v", err, ErrCommandNotAllowed)
+	}
+}
+
+func TestRunRemoteCommandOffList(t *testing.T) {
+	r := &physicalDriveRemote{}
+	if _, err := runRemoteCommand("sudo find / -delete", r); !errors.Is(err, ErrCommandNotAllowed) {
+		t.Errorf("runRemoteCommand() = %v, want %v", err, ErrCommandNotAllowed)
+	}
+	if len(r.commands) != 0 {
+		t.Errorf("runRemoteCommand() ran %v, want no command", r.commands)
+	}
+}
+
+func TestLinuxCommands(t *testing.T) {
+	commands := LinuxCommands()
+	if len(commands) != len(allowedLinuxCommands) {
+		t.Fatalf("LinuxCommands() returned %d commands, want %d", len(commands), len(allowedLinuxCommands))
+	}
+	for _, want := range []string{
+		"sudo blockdev --getbsz /dev/<device>",
+		"sudo find <path> -type f -iname <path> -print",
+		"sudo df --output=target <path>",
+		"mount | grep sd",
+	} {
+		found := false
+		for _, c := range commands {
+			if c == want {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("LinuxCommands() = %v, want it to contain %q", commands, want)
+		}
+	}
+}
+
+func TestCheckWMINamespace(t *testing.T) {
+	testcases := []struct {
+		name      string
+		namespace string
+		wantErr   error
+	}{
+		{
+			name:      "allowed namespace",
+			namespace: `root\cimv2`,
+		},
+		{
+			name:      "allowed namespace in another case",
+			namespace: `ROOT\CIMV2\Power`,
+		},
+		{
+			name:      "off-list namespace",
+			namespace: `root\subscription`,
+			wantErr:   ErrNamespaceNotAllowed,
+		},
+		{
+			name:      "child of an allowed namespace",
+			namespace: `root\cimv2\security`,
+			wantErr:   ErrNamespaceNotAllowed,
+		},
+		{
+			name:      "empty namespace",
+			namespace: "",
+			wantErr:   ErrNamespaceNotAllowed,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := checkWMINamespace(tc.namespace); !errors.Is(err, tc.wantErr) {
+				t.Errorf("checkWMINamespace(%q) = %v, want %v", tc.namespace, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestWMINamespaces(t *testing.T) {
+	namespaces := WMINamespaces()
+	if len(namespaces) != len(allowedWMINamespaces) {
+		t.Fatalf("WMINamespaces() returned %d namespaces, want %d", len(namespaces), len(allowedWMINamespaces))
+	}
+	// The returned list is a copy, changing it does not change the allowlist.
+	namespaces[0] = `root\subscription`
+	if err := checkWMINamespace(`root\subscription`); err == nil {
+		t.Errorf("checkWMINamespace() allowed a namespace added to the result of WMINamespaces()")
+	}
+}
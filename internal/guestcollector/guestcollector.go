@@ -38,11 +38,67 @@ var allOSFields = []string{
 	internal.GCBDRAgentRunning,
 }
 
+// linuxAdditionalOsFields are the fields only collected for linux, in addition to allOSFields.
+var linuxAdditionalOsFields = []string{
+	internal.VMSwappinessRule,
+	internal.VMMaxMapCountRule,
+	internal.KernelNumaBalancingRule,
+	internal.NumaNodeCountOSRule,
+	internal.NumaMemoryBalancedRule,
+	internal.IOSchedulerRule,
+	internal.CPUGovernorRule,
+	internal.ClocksourceRule,
+}
+
+// windowsAdditionalOsFields are the fields only collected for windows, in addition to allOSFields.
+var windowsAdditionalOsFields = []string{
+	internal.AntivirusRealtimeEnabledRule,
+	internal.TimeSourceRule,
+	internal.TimeSyncHealthyRule,
+}
+
 // CollectionOSFields returns all expected fields in OS collection
 func CollectionOSFields() []string { return append([]string(nil), allOSFields...) }
 
+// LinuxCollectionOSFields returns all expected fields in linux OS collection
+func LinuxCollectionOSFields() []string {
+	return append(CollectionOSFields(), linuxAdditionalOsFields...)
+}
+
+// WindowsCollectionOSFields returns all expected fields in windows OS collection
+func WindowsCollectionOSFields() []string {
+	return append(CollectionOSFields(), windowsAdditionalOsFields...)
+}
+
 // MarkUnknownOsFields checks the collected os fields; if nil or missing, then the data is marked as unknown
 func MarkUnknownOsFields(details *[]internal.Details) error {
+	return markUnknownFields(details, CollectionOSFields())
+}
+
+// MarkUnknownLinuxOsFields checks the collected linux os fields; if nil or missing, then the data is marked as unknown
+func MarkUnknownLinuxOsFields(details *[]internal.Details) error {
+	return markUnknownFields(details, LinuxCollectionOSFields())
+}
+
+// MarkUnknownWindowsOsFields checks the collected windows os fields; if nil or missing, then the data is marked as unknown
+func MarkUnknownWindowsOsFields(details *[]internal.Details) error {
+	return markUnknownFields(details, WindowsCollectionOSFields())
+}
+
+// MarkUnknownCollectorOsFields marks the fields expected from collector c as unknown if nil or missing.
+func MarkUnknownCollectorOsFields(c GuestCollector, details *[]internal.Details) error {
+	if oc, ok := c.(osFieldsCollector); ok {
+		return markUnknownFields(details, oc.collectionOSFields())
+	}
+	return MarkUnknownOsFields(details)
+}
+
+// osFieldsCollector is implemented by guest collectors which collect platform specific os fields.
+type osFieldsCollector interface {
+	collectionOSFields() []string
+}
+
+func markUnknownFields(details *[]internal.Details, osFields []string) error {
 	if len(*details) != 1 {
 		return fmt.Errorf("CheckOSCollectedMetrics details should have only 1 field for OS collection, got %d", len(*details))
 	}
@@ -55,11 +111,9 @@ func MarkUnknownOsFields(details *[]internal.Details) error {
 	}
 
 	if len(detail.Fields) == 0 {
-		fields := map[string]string{
-			internal.PowerProfileSettingRule:     "unknown",
-			internal.LocalSSDRule:                "unknown",
-			internal.DataDiskAllocationUnitsRule: "unknown",
-			internal.GCBDRAgentRunning:           "unknown",
+		fields := map[string]string{}
+		for _, field := range osFields {
+			fields[field] = "unknown"
 		}
 		(*details)[0].Fields = append((*details)[0].Fields, fields)
 		return nil
@@ -67,7 +121,7 @@ func MarkUnknownOsFields(details *[]internal.Details) error {
 
 	// for os collection, details only has one element and details.Fields only has one element
 	// sql collections is different as there can be multiple details and multiple details.Fields
-	for _, field := range CollectionOSFields() {
+	for _, field := range osFields {
 		_, ok := detail.Fields[0][field]
 		if !ok {
 			(*details)[0].Fields[0][field] = "unknown"
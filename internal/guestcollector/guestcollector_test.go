@@ -184,3 +184,121 @@ func TestCheckOSCollectedMetrics_BadInput(t *testing.T) {
 		})
 	}
 }
+
+func TestMarkUnknownLinuxOsFields(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []internal.Details
+		want  []internal.Details
+	}{
+		{
+			name: "success for empty input",
+			input: []internal.Details{
+				internal.Details{Name: "OS"},
+			},
+			want: []internal.Details{
+				internal.Details{
+					Name: "OS",
+					Fields: []map[string]string{
+						map[string]string{
+							internal.PowerProfileSettingRule:     "unknown",
+							internal.LocalSSDRule:                "unknown",
+							internal.DataDiskAllocationUnitsRule: "unknown",
+							internal.GCBDRAgentRunning:           "unknown",
+							internal.VMSwappinessRule:            "unknown",
+							internal.VMMaxMapCountRule:           "unknown",
+							internal.KernelNumaBalancingRule:     "unknown",
+							internal.NumaNodeCountOSRule:         "unknown",
+							internal.NumaMemoryBalancedRule:      "unknown",
+							internal.IOSchedulerRule:             "unknown",
+							internal.CPUGovernorRule:             "unknown",
+							internal.ClocksourceRule:             "unknown",
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "success for half collected input",
+			input: []internal.Details{
+				internal.Details{
+					Name: "OS",
+					Fields: []map[string]string{
+						map[string]string{
+							internal.PowerProfileSettingRule: "any output",
+							internal.VMSwappinessRule:        "1",
+						},
+					},
+				},
+			},
+			want: []internal.Details{
+				internal.Details{
+					Name: "OS",
+					Fields: []map[string]string{
+						map[string]string{
+							internal.PowerProfileSettingRule:     "any output",
+							internal.LocalSSDRule:                "unknown",
+							internal.DataDiskAllocationUnitsRule: "unknown",
+							internal.GCBDRAgentRunning:           "unknown",
+							internal.VMSwappinessRule:            "1",
+							internal.VMMaxMapCountRule:           "unknown",
+							internal.KernelNumaBalancingRule:     "unknown",
+							internal.NumaNodeCountOSRule:         "unknown",
+							internal.NumaMemoryBalancedRule:      "unknown",
+							internal.IOSchedulerRule:             "unknown",
+							internal.CPUGovernorRule:             "unknown",
+							internal.ClocksourceRule:             "unknown",
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := MarkUnknownLinuxOsFields(&tc.input)
+			if err != nil {
+				t.Fatalf("MarkUnknownLinuxOsFields(%q) unexpected error: %v", tc.input, err)
+			}
+			if diff := cmp.Diff(tc.input, tc.want); diff != "" {
+				t.Errorf("MarkUnknownLinuxOsFields(%q) returned diff (-want +got):\n%s", tc.input, diff)
+			}
+		})
+	}
+}
+
+func TestMarkUnknownWindowsOsFields(t *testing.T) {
+	input := []internal.Details{
+		internal.Details{
+			Name: "OS",
+			Fields: []map[string]string{
+				map[string]string{
+					internal.PowerProfileSettingRule: "Balanced",
+				},
+			},
+		},
+	}
+	want := []internal.Details{
+		internal.Details{
+			Name: "OS",
+			Fields: []map[string]string{
+				map[string]string{
+					internal.PowerProfileSettingRule:      "Balanced",
+					internal.LocalSSDRule:                 "unknown",
+					internal.DataDiskAllocationUnitsRule:  "unknown",
+					internal.GCBDRAgentRunning:            "unknown",
+					internal.AntivirusRealtimeEnabledRule: "unknown",
+					internal.TimeSourceRule:               "unknown",
+					internal.TimeSyncHealthyRule:          "unknown",
+				},
+			},
+		},
+	}
+	if err := MarkUnknownWindowsOsFields(&input); err != nil {
+		t.Fatalf("MarkUnknownWindowsOsFields() unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(input, want); diff != "" {
+		t.Errorf("MarkUnknownWindowsOsFields() returned diff (-want +got):\n%s", diff)
+	}
+}
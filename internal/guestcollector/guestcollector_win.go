@@ -23,15 +23,37 @@ package guestcollector
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"regexp"
+	"strings"
 	"time"
 
 	"github.com/StackExchange/wmi"
 	"github.com/GoogleCloudPlatform/sql-server-agent/internal/agentstatus"
 	"github.com/GoogleCloudPlatform/sql-server-agent/internal"
+	"github.com/GoogleCloudPlatform/workloadagentplatform/integration/common/shared/commandlineexecutor"
 	"github.com/GoogleCloudPlatform/workloadagentplatform/integration/common/shared/log"
 )
 
+const (
+	volumeCompressionQuery = `SELECT caption, compressed FROM win32_volume`
+	// The data deduplication namespace only exists if the data deduplication feature is installed.
+	dedupNamespace   = `root\microsoft\windows\deduplication`
+	dedupVolumeQuery = `SELECT volume, enabled FROM msft_dedupvolume`
+)
+
+// volumeCompressed is a windows volume and whether NTFS compression is enabled on it.
+type volumeCompressed struct {
+	Caption    string
+	Compressed bool
+}
+
+// dedupVolume is a windows volume with data deduplication configured.
+type dedupVolume struct {
+	Volume  string
+	Enabled bool
+}
+
 // WindowsCollector is the collector for windows system.
 type WindowsCollector struct {
 	host                     any
@@ -41,6 +63,9 @@ type WindowsCollector struct {
 	logicalToPhysicalDiskMap map[string]string
 	physicalDiskToTypeMap    map[string]string
 	usageMetricLogger        agentstatus.AgentStatus
+	transport                WindowsTransport
+	winRMExecute             commandlineexecutor.Execute
+	execute                  commandlineexecutor.Execute
 }
 type wmiExecutor struct {
 	namespace   string
@@ -68,6 +93,8 @@ func NewWindowsCollector(host, username, password any, usageMetricLogger agentst
 		logicalToPhysicalDiskMap: map[string]string{},
 		physicalDiskToTypeMap:    map[string]string{},
 		usageMetricLogger:        usageMetricLogger,
+		winRMExecute:             commandlineexecutor.ExecuteCommand,
+		execute:                  commandlineexecutor.ExecuteCommand,
 	}
 	c.guestRuleWMIMap[internal.PowerProfileSettingRule] = wmiExecutor{
 		namespace: `root\cimv2\power`,
@@ -78,7 +105,7 @@ func NewWindowsCollector(host, username, password any, usageMetricLogger agentst
 				ElementName string
 			}
 			// https://learn.microsoft.com/en-us/windows/win32/wmisdk/swbemlocator-connectserver
-			if err := wmi.Query(connArgs.query, &result, connArgs.host, connArgs.namespace, connArgs.username, connArgs.password); err != nil {
+			if err := c.query(connArgs, &result); err != nil {
 				return "", err
 			}
 			return result[0].ElementName, nil
@@ -92,7 +119,7 @@ func NewWindowsCollector(host, username, password any, usageMetricLogger agentst
 				Antecedent string
 				Dependent  string
 			}
-			if err := wmi.Query(connArgs.query, &result, connArgs.host, connArgs.namespace, connArgs.username, connArgs.password); err != nil {
+			if err := c.query(connArgs, &result); err != nil {
 				return "", err
 			}
 			// example output:
@@ -120,7 +147,7 @@ func NewWindowsCollector(host, username, password any, usageMetricLogger agentst
 				Size         int64
 				MediaType    int16
 			}
-			if err := wmi.Query(connArgs.query, &result, connArgs.host, connArgs.namespace, connArgs.username, connArgs.password); err != nil {
+			if err := c.query(connArgs, &result); err != nil {
 				return "", err
 			}
 			for _, v := range result {
@@ -138,7 +165,7 @@ func NewWindowsCollector(host, username, password any, usageMetricLogger agentst
 				BlockSize int64
 				Caption   string
 			}
-			if err := wmi.Query(connArgs.query, &result, connArgs.host, connArgs.namespace, connArgs.username, connArgs.password); err != nil {
+			if err := c.query(connArgs, &result); err != nil {
 				return "", err
 			}
 			re := regexp.MustCompile(`.*Volume{.*}.*`)
@@ -166,7 +193,7 @@ func NewWindowsCollector(host, username, password any, usageMetricLogger agentst
 			var result []struct {
 				Caption string
 			}
-			if err := wmi.Query(connArgs.query, &result, connArgs.host, connArgs.namespace, connArgs.username, connArgs.password); err != nil {
+			if err := c.query(connArgs, &result); err != nil {
 				return "", err
 			}
 			if len(result) == 0 {
@@ -175,9 +202,175 @@ func NewWindowsCollector(host, username, password any, usageMetricLogger agentst
 			return "true", nil
 		},
 	}
+	c.guestRuleWMIMap[internal.AntivirusRealtimeEnabledRule] = wmiExecutor{
+		namespace: `root\SecurityCenter2`,
+		isRule:    true,
+		query:     `SELECT displayname, productstate FROM AntiVirusProduct`,
+		runWMIQuery: func(connArgs wmiConnectionArgs) (string, error) {
+			var result []struct {
+				DisplayName  string
+				ProductState uint32
+			}
+			if err := c.query(connArgs, &result); err != nil {
+				return "", err
+			}
+			for _, v := range result {
+				if AntivirusRealtimeEnabled(v.ProductState) {
+					return "true", nil
+				}
+			}
+			return "false", nil
+		},
+	}
+	c.guestRuleWMIMap[internal.TimeSourceRule] = wmiExecutor{
+		isRule: true,
+		runWMIQuery: func(connArgs wmiConnectionArgs) (string, error) {
+			status, err := c.timeSyncStatus(connArgs)
+			if err != nil {
+				return "", err
+			}
+			return status.Source, nil
+		},
+	}
+	c.guestRuleWMIMap[internal.TimeSyncHealthyRule] = wmiExecutor{
+		isRule: true,
+		runWMIQuery: func(connArgs wmiConnectionArgs) (string, error) {
+			status, err := c.timeSyncStatus(connArgs)
+			if err != nil {
+				return "", err
+			}
+			return status.Healthy, nil
+		},
+	}
 	return &c
 }
 
+// SetTransport sets the transport used to run the WMI queries on the remote instance.
+// Local collection always queries WMI directly.
+func (c *WindowsCollector) SetTransport(transport WindowsTransport) {
+	c.transport = transport
+}
+
+// DataDiskAllocationUnits returns the allocation units of the volumes in the format of the
+// data_disk_allocation_units os field.
+func (c *WindowsCollector) DataDiskAllocationUnits() (string, error) {
+	exe := c.guestRuleWMIMap[internal.DataDiskAllocationUnitsRule]
+	return exe.runWMIQuery(wmiConnectionArgs{
+		host:      c.host,
+		username:  c.username,
+		password:  c.password,
+		namespace: exe.namespace,
+		query:     exe.query,
+	})
+}
+
+// LogicalDiskTypes returns the disk type of the logical disks in the format of the local_ssd os
+// field, e.g. {"D:":"LOCAL-SSD"}.
+func (c *WindowsCollector) LogicalDiskTypes() (string, error) {
+	for _, rule := range []string{internal.LogicalDiskToPartition, internal.PhysicalDiskToType} {
+		exe := c.guestRuleWMIMap[rule]
+		if _, err := exe.runWMIQuery(wmiConnectionArgs{
+			host:      c.host,
+			username:  c.username,
+			password:  c.password,
+			namespace: exe.namespace,
+			query:     exe.query,
+		}); err != nil {
+			return "", err
+		}
+	}
+	details := internal.Details{Fields: []map[string]string{{}}}
+	c.logicalDiskMediaType(&details)
+	return details.Fields[0][internal.LocalSSDRule], nil
+}
+
+// DataVolumeCompression returns the NTFS compression and data deduplication status of the volumes
+// as a JSON list of internal.VolumeCompression.
+func (c *WindowsCollector) DataVolumeCompression() (string, error) {
+	connArgs := wmiConnectionArgs{
+		host:      c.host,
+		username:  c.username,
+		password:  c.password,
+		namespace: `root\cimv2`,
+		query:     volumeCompressionQuery,
+	}
+	var volumes []volumeCompressed
+	if err := c.query(connArgs, &volumes); err != nil {
+		return "", err
+	}
+	connArgs.namespace = dedupNamespace
+	connArgs.query = dedupVolumeQuery
+	var dedupVolumes []dedupVolume
+	if err := c.query(connArgs, &dedupVolumes); err != nil {
+		log.Logger.Debugw("Failed to query the data deduplication volumes, data deduplication is not installed", "error", err)
+	}
+	res, err := json.Marshal(volumeCompression(volumes, dedupVolumes))
+	if err != nil {
+		return "", err
+	}
+	return string(res), nil
+}
+
+// volumeCompression joins the compression status of the volumes with their data deduplication
+// status. Volumes without a drive letter or mount point are skipped.
+func volumeCompression(volumes []volumeCompressed, dedupVolumes []dedupVolume) []internal.VolumeCompression {
+	dedup := map[string]bool{}
+	for _, v := range dedupVolumes {
+		dedup[strings.ToUpper(strings.TrimRight(v.Volume, `\`))] = v.Enabled
+	}
+	re := regexp.MustCompile(`.*Volume{.*}.*`)
+	var res []internal.VolumeCompression
+	for _, v := range volumes {
+		if re.MatchString(v.Caption) {
+			continue
+		}
+		res = append(res, internal.VolumeCompression{
+			Caption:      v.Caption,
+			Compressed:   v.Compressed,
+			DedupEnabled: dedup[strings.ToUpper(strings.TrimRight(v.Caption, `\`))],
+		})
+	}
+	return res
+}
+
+// query runs the WMI query of connArgs and stores the result in dst.
+// Remote queries run through WinRM if it is the transport of the collector, and over DCOM otherwise.
+// Queries of namespaces not in the allowlist are refused.
+func (c *WindowsCollector) query(connArgs wmiConnectionArgs, dst any) error {
+	if err := checkWMINamespace(connArgs.namespace); err != nil {
+		return err
+	}
+	if c.transport == WMITransport || connArgs.host == nil {
+		return wmi.Query(connArgs.query, dst, connArgs.host, connArgs.namespace, connArgs.username, connArgs.password)
+	}
+	return queryWinRM(context.Background(), c.winRMExecute, c.transport, fmt.Sprint(connArgs.host), fmt.Sprint(connArgs.username), fmt.Sprint(connArgs.password), connArgs.namespace, connArgs.query, dst)
+}
+
+// timeSyncStatus returns the time synchronization status reported by w32tm.
+// w32tm only reports the status of the local machine, so the status of remote machines is unknown.
+func (c *WindowsCollector) timeSyncStatus(connArgs wmiConnectionArgs) (TimeSyncStatus, error) {
+	if connArgs.host != nil {
+		return TimeSyncStatus{Source: "unknown", Healthy: "unknown"}, nil
+	}
+	res := c.execute(context.Background(), commandlineexecutor.Params{
+		Executable: "w32tm",
+		Args:       []string{"/query", "/status"},
+	})
+	if res.Error != nil {
+		return TimeSyncStatus{}, fmt.Errorf("failed to query the time sync status: %v, %s", res.Error, res.StdErr)
+	}
+	return ParseW32tmStatus(res.StdOut), nil
+}
+
+// collectionOSFields returns the os fields collected by WindowsCollector.
+func (c *WindowsCollector) collectionOSFields() []string { return WindowsCollectionOSFields() }
+
+// AntivirusRealtimeEnabled reports whether the productState of an AntiVirusProduct has real-time protection on.
+// The second byte of productState holds the scanner state, where 0x10 means enabled.
+func AntivirusRealtimeEnabled(productState uint32) bool {
+	return productState&0x1000 != 0
+}
+
 // LogicalDiskMediaType generates the logicalDrive : mediaType mappings and add the result to details.
 func (c *WindowsCollector) logicalDiskMediaType(details *internal.Details) {
 	logicalToTypeMap := map[string]string{}
@@ -203,13 +396,19 @@ func (c *WindowsCollector) logicalDiskMediaType(details *internal.Details) {
 // CollectGuestRules collects all guest rules. The rules are defined in rules.go.
 func (c *WindowsCollector) CollectGuestRules(ctx context.Context, timeout time.Duration) internal.Details {
 	details := internal.Details{
-		Name: "OS",
+		Name:                 "OS",
+		CollectionDurationMs: map[string]int64{},
 	}
 	fields := map[string]string{}
 	for rule, exe := range c.guestRuleWMIMap {
 		func() {
 			ctxWithTimeout, cancel := context.WithTimeout(ctx, timeout)
 			defer cancel()
+			start := time.Now()
+			defer func() {
+				details.CollectionDurationMs[rule] = time.Since(start).Milliseconds()
+				log.Logger.Debugw("Collected guest rule", "rule", rule, "collection_duration_ms", details.CollectionDurationMs[rule])
+			}()
 			ch := make(chan bool, 1)
 
 			go func() {
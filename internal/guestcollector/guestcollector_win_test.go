@@ -21,12 +21,15 @@ package guestcollector
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"testing"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/GoogleCloudPlatform/sql-server-agent/internal"
+	"github.com/GoogleCloudPlatform/workloadagentplatform/integration/common/shared/commandlineexecutor"
 )
 
 func TestCollectGuestRules(t *testing.T) {
@@ -47,6 +50,9 @@ func TestCollectGuestRules(t *testing.T) {
 						"local_ssd":                  `{"C:":"OTHER"}`,
 						"data_disk_allocation_units": `[{"BlockSize":4096,"Caption":"C:\\"},{"BlockSize":1024,"Caption":"D:\\"}]`,
 						"gcbdr_agent_running":        "false",
+						"antivirus_realtime_enabled": "unknown",
+						"time_source":                "metadata.google.internal",
+						"time_sync_healthy":          "true",
 					},
 				},
 			},
@@ -102,6 +108,48 @@ func TestCollectGuestRules(t *testing.T) {
 				Fields: []map[string]string{map[string]string{"local_ssd": "unknown"}},
 			},
 		},
+		{
+			name:        "antivirus realtime enabled with mocked data",
+			mockRuleMap: true,
+			guestRuleWMIMapMock: map[string]wmiExecutor{
+				internal.AntivirusRealtimeEnabledRule: wmiExecutor{
+					isRule: true,
+					runWMIQuery: func(connArgs wmiConnectionArgs) (string, error) {
+						return "true", nil
+					},
+				},
+			},
+			want: internal.Details{
+				Name: "OS",
+				Fields: []map[string]string{
+					map[string]string{
+						"antivirus_realtime_enabled": "true",
+						"local_ssd":                  "unknown",
+					},
+				},
+			},
+		},
+		{
+			name:        "antivirus query failure returns unknown",
+			mockRuleMap: true,
+			guestRuleWMIMapMock: map[string]wmiExecutor{
+				internal.AntivirusRealtimeEnabledRule: wmiExecutor{
+					isRule: true,
+					runWMIQuery: func(connArgs wmiConnectionArgs) (string, error) {
+						return "", fmt.Errorf("invalid namespace")
+					},
+				},
+			},
+			want: internal.Details{
+				Name: "OS",
+				Fields: []map[string]string{
+					map[string]string{
+						"antivirus_realtime_enabled": "unknown",
+						"local_ssd":                  "unknown",
+					},
+				},
+			},
+		},
 		{
 			name:       "invalid wmi query return unknown result",
 			mockWMIErr: true,
@@ -113,6 +161,9 @@ func TestCollectGuestRules(t *testing.T) {
 						"local_ssd":                  "unknown",
 						"power_profile_setting":      "unknown",
 						"gcbdr_agent_running":        "unknown",
+						"antivirus_realtime_enabled": "unknown",
+						"time_source":                "unknown",
+						"time_sync_healthy":          "unknown",
 					},
 				},
 			},
@@ -122,6 +173,9 @@ func TestCollectGuestRules(t *testing.T) {
 	for _, tc := range testcases {
 		t.Run(tc.name, func(t *testing.T) {
 			collector := NewWindowsCollector(nil, nil, nil, fakeUsageMetricsLogger)
+			collector.execute = func(context.Context, commandlineexecutor.Params) commandlineexecutor.Result {
+				return commandlineexecutor.Result{StdOut: w32tmStatusSynced}
+			}
 			// apply mock rule map
 			if tc.mockRuleMap {
 				collector.guestRuleWMIMap = tc.guestRuleWMIMapMock
@@ -130,11 +184,19 @@ func TestCollectGuestRules(t *testing.T) {
 					m.query = "any query"
 					collector.guestRuleWMIMap[r] = m
 				}
+				collector.execute = func(context.Context, commandlineexecutor.Params) commandlineexecutor.Result {
+					return commandlineexecutor.Result{Error: errors.New("the service has not been started"), ExitCode: 1}
+				}
 			}
 			got := collector.CollectGuestRules(context.Background(), time.Minute)
-			if diff := cmp.Diff(got, tc.want); diff != "" {
+			if diff := cmp.Diff(got, tc.want, cmpopts.IgnoreFields(internal.Details{}, "CollectionDurationMs")); diff != "" {
 				t.Errorf("CollectGuestRules() returned wrong result (-got +want):\n%s", diff)
 			}
+			for rule := range collector.guestRuleWMIMap {
+				if duration, ok := got.CollectionDurationMs[rule]; !ok || duration < 0 {
+					t.Errorf("CollectGuestRules() returned collection duration %d, present: %v for rule %s, want non-negative", duration, ok, rule)
+				}
+			}
 		})
 	}
 }
@@ -199,7 +261,7 @@ func TestLogicalDiskMediaType(t *testing.T) {
 			collector.physicalDiskToTypeMap = tc.physicalDiskToTypeMapMock
 			collector.logicalDiskMediaType(tc.inputDetails)
 			got := tc.inputDetails
-			if diff := cmp.Diff(got, tc.want); diff != "" {
+			if diff := cmp.Diff(got, tc.want, cmpopts.IgnoreFields(internal.Details{}, "CollectionDurationMs")); diff != "" {
 				t.Errorf("LogicalDiskMediaType() returned wrong result (-got +want):\n%s", diff)
 			}
 		})
@@ -261,12 +323,12 @@ func TestFriendlyNameToDiskType(t *testing.T) {
 
 // TestCheckWindowsOsReturnedCount compares the os returned fields for windows_guestcollector with the returned fields for OSCollectorResultFields
 func TestCheckWindowsOsReturnedCount(t *testing.T) {
-	guestCollectorCount := len(allOSFields)
+	guestCollectorCount := len(WindowsCollectionOSFields())
 	// logicalDiskMediaType() accounts for fields[internal.LocalSSDRule] field which isn't explicitly definied in guestRuleWMIMap
 	guestCollectorWinCount := 1
 	testWC := NewWindowsCollector(nil, nil, nil, fakeUsageMetricsLogger)
 
-	for _, field := range allOSFields {
+	for _, field := range WindowsCollectionOSFields() {
 		_, ok := testWC.guestRuleWMIMap[field]
 		if ok {
 			guestCollectorWinCount++
@@ -277,3 +339,185 @@ func TestCheckWindowsOsReturnedCount(t *testing.T) {
 		t.Errorf("guestCollectorWinCount = %d, want %d", guestCollectorWinCount, guestCollectorCount)
 	}
 }
+
+func TestVolumeCompression(t *testing.T) {
+	volumes := []volumeCompressed{
+		{Caption: `C:\`},
+		{Caption: `D:\`, Compressed: true},
+		{Caption: `E:\`},
+		{Caption: `\\?\Volume{6d1c2a3e-0000-0000-0000-100000000000}\`},
+	}
+	dedupVolumes := []dedupVolume{
+		{Volume: "e:", Enabled: true},
+	}
+	want := []internal.VolumeCompression{
+		{Caption: `C:\`},
+		{Caption: `D:\`, Compressed: true},
+		{Caption: `E:\`, DedupEnabled: true},
+	}
+	if diff := cmp.Diff(volumeCompression(volumes, dedupVolumes), want); diff != "" {
+		t.Errorf("volumeCompression() returned wrong result (-got +want):\n%s", diff)
+	}
+}
+
+func TestAntivirusRealtimeEnabled(t *testing.T) {
+	testcases := []struct {
+		name         string
+		productState uint32
+		want         bool
+	}{
+		{
+			name:         "windows defender enabled and up to date",
+			productState: 0x61100,
+			want:         true,
+		},
+		{
+			name:         "windows defender disabled",
+			productState: 0x60100,
+			want:         false,
+		},
+		{
+			name:         "third party product enabled and out of date",
+			productState: 0x41010,
+			want:         true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := AntivirusRealtimeEnabled(tc.productState); got != tc.want {
+				t.Errorf("AntivirusRealtimeEnabled(%#x) = %v, want %v", tc.productState, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestTimeSyncStatus(t *testing.T) {
+	testcases := []struct {
+		name    string
+		host    any
+		result  commandlineexecutor.Result
+		want    TimeSyncStatus
+		wantErr bool
+	}{
+		{
+			name:   "local machine synchronized",
+			result: commandlineexecutor.Result{StdOut: w32tmStatusSynced},
+			want:   TimeSyncStatus{Source: "metadata.google.internal", Healthy: "true"},
+		},
+		{
+			name:    "w32tm failure",
+			result:  commandlineexecutor.Result{Error: errors.New("the service has not been started"), ExitCode: 1},
+			wantErr: true,
+		},
+		{
+			name: "remote machine is unknown",
+			host: "test-host",
+			want: TimeSyncStatus{Source: "unknown", Healthy: "unknown"},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := NewWindowsCollector(tc.host, nil, nil, fakeUsageMetricsLogger)
+			c.execute = func(ctx context.Context, params commandlineexecutor.Params) commandlineexecutor.Result {
+				if tc.host != nil {
+					t.Errorf("timeSyncStatus() ran w32tm for remote host %v", tc.host)
+				}
+				return tc.result
+			}
+			got, err := c.timeSyncStatus(wmiConnectionArgs{host: tc.host})
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Errorf("timeSyncStatus() returned error %v, want error: %v", err, tc.wantErr)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("timeSyncStatus() returned wrong result (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestWindowsCollectorTransport(t *testing.T) {
+	testcases := []struct {
+		name      string
+		host      any
+		transport WindowsTransport
+		wantWinRM bool
+	}{
+		{
+			name:      "remote collection over winrm https",
+			host:      "test-host",
+			transport: WinRMHTTPSTransport,
+			wantWinRM: true,
+		},
+		{
+			name:      "remote collection over winrm http",
+			host:      "test-host",
+			transport: WinRMHTTPTransport,
+			wantWinRM: true,
+		},
+		{
+			name:      "local collection ignores winrm transport",
+			transport: WinRMHTTPSTransport,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := NewWindowsCollector(tc.host, "test-user", "test-password", fakeUsageMetricsLogger)
+			c.SetTransport(tc.transport)
+			gotWinRM := false
+			c.winRMExecute = func(ctx context.Context, params commandlineexecutor.Params) commandlineexecutor.Result {
+				gotWinRM = true
+				return commandlineexecutor.Result{StdOut: `[{"Caption":"udsagent.exe"}]`}
+			}
+			exe := c.guestRuleWMIMap[internal.GCBDRAgentRunning]
+			got, err := exe.runWMIQuery(wmiConnectionArgs{
+				host:      tc.host,
+				username:  "test-user",
+				password:  "test-password",
+				namespace: exe.namespace,
+				query:     exe.query,
+			})
+			if gotWinRM != tc.wantWinRM {
+				t.Errorf("runWMIQuery() ran through WinRM: %v, want: %v", gotWinRM, tc.wantWinRM)
+			}
+			if tc.wantWinRM && (err != nil || got != "true") {
+				t.Errorf("runWMIQuery() = %q, %v, want %q, nil", got, err, "true")
+			}
+		})
+	}
+}
+
+func TestWindowsCollectorNamespacesAllowed(t *testing.T) {
+	c := NewWindowsCollector(nil, nil, nil, fakeUsageMetricsLogger)
+	for rule, exe := range c.guestRuleWMIMap {
+		if err := checkWMINamespace(exe.namespace); err != nil {
+			t.Errorf("namespace %q of rule %s is not in the allowlist: %v", exe.namespace, rule, err)
+		}
+	}
+	if err := checkWMINamespace(dedupNamespace); err != nil {
+		t.Errorf("namespace %q of the data deduplication volumes is not in the allowlist: %v", dedupNamespace, err)
+	}
+}
+
+func TestWindowsCollectorQueryOffListNamespace(t *testing.T) {
+	c := NewWindowsCollector("test-host", "test-user", "test-password", fakeUsageMetricsLogger)
+	c.SetTransport(WinRMHTTPSTransport)
+	called := false
+	c.winRMExecute = func(ctx context.Context, params commandlineexecutor.Params) commandlineexecutor.Result {
+		called = true
+		return commandlineexecutor.Result{StdOut: `[]`}
+	}
+	var dst []struct{ Name string }
+	err := c.query(wmiConnectionArgs{
+		host:      "test-host",
+		username:  "test-user",
+		password:  "test-password",
+		namespace: `root\subscription`,
+		query:     `SELECT name FROM __eventfilter`,
+	}, &dst)
+	if !errors.Is(err, ErrNamespaceNotAllowed) {
+		t.Errorf("query() = %v, want %v", err, ErrNamespaceNotAllowed)
+	}
+	if called {
+		t.Error("query() queried a namespace not in the allowlist")
+	}
+}
@@ -19,16 +19,20 @@ package guestcollector
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/GoogleCloudPlatform/workloadagentplatform/integration/common/shared/commandlineexecutor"
 	"github.com/GoogleCloudPlatform/workloadagentplatform/integration/common/shared/log"
 
+	"go.uber.org/zap/zapcore"
 	"github.com/GoogleCloudPlatform/sql-server-agent/internal/agentstatus"
 	"github.com/GoogleCloudPlatform/sql-server-agent/internal/instanceinfo"
 	"github.com/GoogleCloudPlatform/sql-server-agent/internal"
@@ -40,15 +44,38 @@ The production library uses the ExecuteCommand function from commandlineexecutor
 EvalSymlinks function from filepath.  We need to be able to mock these functions in our unit tests.
 */
 var (
-	symLinkCommand = filepath.EvalSymlinks
+	symLinkCommand     = filepath.EvalSymlinks
+	sysctlExecute      = commandlineexecutor.ExecuteCommand
+	numaExecute        = commandlineexecutor.ExecuteCommand
+	ioSchedulerExecute = commandlineexecutor.ExecuteCommand
+	sysfsExecute       = commandlineexecutor.ExecuteCommand
 )
 
+// ErrToolNotInstalled is returned by guest rules when the tool a rule depends on is not installed
+// or not set up on the target. These failures are expected on some images and are only logged as
+// warnings.
+var ErrToolNotInstalled = errors.New("Check help docs")
+
+// numaMemoryImbalanceThreshold is the max difference of memory between NUMA nodes, as a fraction of
+// the largest node, for the memory to be considered balanced.
+const numaMemoryImbalanceThreshold = 0.1
+
 const (
 	localSSDCommand                = "sudo lshw -class disk -json"
 	localSSDCommandForSuse         = "sudo hwinfo --disk"
 	powerPlanCommand               = "sudo tuned-adm active"
 	dataDiskAllocationUnitsCommand = "sudo blockdev --getbsz /dev/"
 	gcbdrAgentRunningCommand       = "sudo systemctl status udsagent | grep \"Active: \""
+	vmSwappinessCommand            = "sysctl -n vm.swappiness"
+	vmMaxMapCountCommand           = "sysctl -n vm.max_map_count"
+	kernelNumaBalancingCommand     = "sysctl -n kernel.numa_balancing"
+	numaMemInfoCommand             = "cat /sys/devices/system/node/node*/meminfo | grep MemTotal"
+	ioSchedulerCommand             = "cat /sys/block/%s/queue/scheduler"
+	cpuGovernorCommand             = "cat /sys/devices/system/cpu/cpu0/cpufreq/scaling_governor"
+	clocksourceCommand             = "cat /sys/devices/system/clocksource/clocksource0/current_clocksource"
+	physicalDriveFindCommand       = "sudo find %s -type f -iname %s -print"
+	physicalDriveDfCommand         = "sudo df --output=target %s"
+	physicalDriveMountCommand      = "mount | grep sd"
 	persistentDisk                 = "PersistentDisk"
 	ephemeralDisk                  = "EphemeralDisk"
 )
@@ -67,14 +94,17 @@ type LinuxCollector struct {
 	ipaddr                 string
 	username               string
 	privateKeyPath         string
+	hostKeyFingerprints    []string
 	disks                  [](*instanceinfo.Disks)
 	physicalDriveToDiskMap map[string]string
+	physicalDrivesMu       sync.Mutex
 	guestRuleCommandMap    map[string]commandExecutor
 	lshwRegexMapping       map[string]*regexp.Regexp
 	remote                 bool
 	port                   int32
 	remoteRunner           remote.Executor
 	usageMetricsLogger     agentstatus.AgentStatus
+	maxConcurrentRules     int
 }
 
 type commandExecutor struct {
@@ -102,11 +132,14 @@ var lshwFieldsToParse = []string{
 func lshwFields() []string { return lshwFieldsToParse }
 
 // NewLinuxCollector initializes and returns a new LinuxCollector object.
-func NewLinuxCollector(disks []*instanceinfo.Disks, ipAddr, username, privateKeyPath string, isRemote bool, port int32, usageMetricsLogger agentstatus.AgentStatus) *LinuxCollector {
+// hostKeyFingerprints are the accepted SHA256 fingerprints of the host key of a remote machine; if
+// empty, the host key is read from the known_hosts file next to privateKeyPath.
+func NewLinuxCollector(disks []*instanceinfo.Disks, ipAddr, username, privateKeyPath string, hostKeyFingerprints []string, isRemote bool, port int32, usageMetricsLogger agentstatus.AgentStatus) *LinuxCollector {
 	c := LinuxCollector{
 		ipaddr:                 ipAddr,
 		username:               username,
 		privateKeyPath:         privateKeyPath,
+		hostKeyFingerprints:    hostKeyFingerprints,
 		disks:                  disks,
 		guestRuleCommandMap:    map[string]commandExecutor{},
 		physicalDriveToDiskMap: map[string]string{},
@@ -114,10 +147,11 @@ func NewLinuxCollector(disks []*instanceinfo.Disks, ipAddr, username, privateKey
 		remote:                 isRemote,
 		port:                   port,
 		usageMetricsLogger:     usageMetricsLogger,
+		maxConcurrentRules:     1,
 	}
 
 	if c.remote {
-		c.remoteRunner = remote.NewRemote(c.ipaddr, c.username, c.port, c.usageMetricsLogger)
+		c.remoteRunner = remote.NewRemote(c.ipaddr, c.username, c.port, c.hostKeyFingerprints, c.usageMetricsLogger)
 		c.setUpRegex()
 		if err := c.remoteRunner.SetupKeys(c.privateKeyPath); err != nil {
 			log.Logger.Error(err)
@@ -139,9 +173,9 @@ func NewLinuxCollector(disks []*instanceinfo.Disks, ipAddr, username, privateKey
 		},
 		runRemoteCommand: func(ctx context.Context, command string, r remote.Executor) (string, error) {
 			var isLinuxSuse bool
-			lshwResult, err := remote.RunCommandWithPipes(command, r)
+			lshwResult, err := runAllowedCommandWithPipes(command, r)
 			if err != nil {
-				lshwResult, err = remote.RunCommandWithPipes(localSSDCommandForSuse, r)
+				lshwResult, err = runAllowedCommandWithPipes(localSSDCommandForSuse, r)
 				if err != nil {
 					return "", err
 				}
@@ -166,9 +200,10 @@ func NewLinuxCollector(disks []*instanceinfo.Disks, ipAddr, username, privateKey
 				diskType = internal.LocalSSD.String()
 			}
 
+			c.physicalDrivesMu.Lock()
 			c.physicalDriveToDiskMap[lshwFields.LogicalName] = diskType
-
 			res, errMar := json.Marshal(c.physicalDriveToDiskMap)
+			c.physicalDrivesMu.Unlock()
 			if errMar != nil {
 				return "", errMar
 			}
@@ -179,9 +214,9 @@ func NewLinuxCollector(disks []*instanceinfo.Disks, ipAddr, username, privateKey
 		command: powerPlanCommand,
 		isRule:  true,
 		runCommand: func(ctx context.Context, command string) (string, error) {
-			res, err := internal.CommandLineExecutorWrapper(ctx, "/bin/sh", fmt.Sprintf(" -c '%s'", command), commandlineexecutor.ExecuteCommand)
+			res, err := runAllowedCommand(ctx, command, commandlineexecutor.ExecuteCommand)
 			if err != nil {
-				return "", fmt.Errorf("Check help docs, tuned package not installed or no power profile set. " + err.Error())
+				return "", fmt.Errorf("%w, tuned package not installed or no power profile set. %v", ErrToolNotInstalled, err)
 			}
 			return findPowerProfile(res)
 		},
@@ -191,9 +226,9 @@ func NewLinuxCollector(disks []*instanceinfo.Disks, ipAddr, username, privateKey
 				return "", err
 			}
 			defer s.Close()
-			res, err := r.Run(command, s)
+			res, err := runAllowedRemoteCommand(r, command, s)
 			if err != nil {
-				return "", fmt.Errorf("Check help docs, tuned package not installed or no power profile set. " + err.Error())
+				return "", fmt.Errorf("%w, tuned package not installed or no power profile set. %v", ErrToolNotInstalled, err)
 			}
 			return findPowerProfile(res)
 		},
@@ -218,7 +253,7 @@ func NewLinuxCollector(disks []*instanceinfo.Disks, ipAddr, username, privateKey
 					continue
 				}
 				fullCommand := command + disk.Mapping
-				blockSize, err := internal.CommandLineExecutorWrapper(ctx, "/bin/sh", fmt.Sprintf(" -c '%s'", fullCommand), commandlineexecutor.ExecuteCommand)
+				blockSize, err := runAllowedCommand(ctx, fullCommand, commandlineexecutor.ExecuteCommand)
 				if err != nil {
 					return "", err
 				}
@@ -231,7 +266,8 @@ func NewLinuxCollector(disks []*instanceinfo.Disks, ipAddr, username, privateKey
 			return string(res), nil
 		},
 		runRemoteCommand: func(ctx context.Context, command string, r remote.Executor) (string, error) {
-			if c.physicalDriveToDiskMap == nil || len(c.physicalDriveToDiskMap) == 0 {
+			physicalDrives := c.physicalDrives()
+			if len(physicalDrives) == 0 {
 				return "", fmt.Errorf("data disk allocation failed. no disks found")
 			}
 
@@ -241,13 +277,13 @@ func NewLinuxCollector(disks []*instanceinfo.Disks, ipAddr, username, privateKey
 			}
 			var result []resultEle
 
-			for physicalDrive := range c.physicalDriveToDiskMap {
+			for _, physicalDrive := range physicalDrives {
 				fullCommand := command + physicalDrive
 				s, err := r.CreateSession("")
 				if err != nil {
 					return "", err
 				}
-				blockSize, err := r.Run(fullCommand, s)
+				blockSize, err := runAllowedRemoteCommand(r, fullCommand, s)
 				s.Close()
 				if err != nil || blockSize == "" {
 					blockSize = "unknown"
@@ -265,7 +301,7 @@ func NewLinuxCollector(disks []*instanceinfo.Disks, ipAddr, username, privateKey
 		command: gcbdrAgentRunningCommand,
 		isRule:  true,
 		runCommand: func(ctx context.Context, command string) (string, error) {
-			res, err := internal.CommandLineExecutorWrapper(ctx, "/bin/sh", fmt.Sprintf(" -c '%s'", command), commandlineexecutor.ExecuteCommand)
+			res, err := runAllowedCommand(ctx, command, commandlineexecutor.ExecuteCommand)
 			if err != nil || res == "" {
 				return "false", nil
 			}
@@ -277,16 +313,268 @@ func NewLinuxCollector(disks []*instanceinfo.Disks, ipAddr, username, privateKey
 				return "", err
 			}
 			defer s.Close()
-			res, err := r.Run(command, s)
+			res, err := runAllowedRemoteCommand(r, command, s)
 			if err != nil || res == "" {
 				return "false", nil
 			}
 			return c.gcbdrAgentRunning(res)
 		},
 	}
+	c.guestRuleCommandMap[internal.VMSwappinessRule] = sysctlCommandExecutor(vmSwappinessCommand)
+	c.guestRuleCommandMap[internal.VMMaxMapCountRule] = sysctlCommandExecutor(vmMaxMapCountCommand)
+	c.guestRuleCommandMap[internal.KernelNumaBalancingRule] = sysctlCommandExecutor(kernelNumaBalancingCommand)
+	c.guestRuleCommandMap[internal.CPUGovernorRule] = sysfsCommandExecutor(cpuGovernorCommand)
+	c.guestRuleCommandMap[internal.ClocksourceRule] = sysfsCommandExecutor(clocksourceCommand)
+	c.guestRuleCommandMap[internal.NumaNodeCountOSRule] = numaCommandExecutor(func(nodeMemKb map[int]int64) string {
+		return strconv.Itoa(len(nodeMemKb))
+	})
+	c.guestRuleCommandMap[internal.NumaMemoryBalancedRule] = numaCommandExecutor(func(nodeMemKb map[int]int64) string {
+		return strconv.FormatBool(NumaMemoryBalanced(nodeMemKb))
+	})
+	c.guestRuleCommandMap[internal.IOSchedulerRule] = commandExecutor{
+		command: ioSchedulerCommand,
+		isRule:  true,
+		runCommand: func(ctx context.Context, command string) (string, error) {
+			if c.disks == nil || len(c.disks) == 0 {
+				return "", fmt.Errorf("io scheduler failed. no disks found")
+			}
+			result := map[string]string{}
+			for _, disk := range c.disks {
+				if disk.Mapping == "" {
+					continue
+				}
+				res, err := runAllowedCommand(ctx, fmt.Sprintf(command, disk.Mapping), ioSchedulerExecute)
+				result[disk.Mapping] = "unknown"
+				if err == nil {
+					result[disk.Mapping] = ParseIOScheduler(res)
+				}
+			}
+			res, err := json.Marshal(result)
+			if err != nil {
+				return "", err
+			}
+			return string(res), nil
+		},
+		runRemoteCommand: func(ctx context.Context, command string, r remote.Executor) (string, error) {
+			physicalDrives := c.physicalDrives()
+			if len(physicalDrives) == 0 {
+				return "", fmt.Errorf("io scheduler failed. no disks found")
+			}
+			result := map[string]string{}
+			for _, physicalDrive := range physicalDrives {
+				s, err := r.CreateSession("")
+				if err != nil {
+					return "", err
+				}
+				res, err := runAllowedRemoteCommand(r, fmt.Sprintf(command, physicalDrive), s)
+				s.Close()
+				result[physicalDrive] = "unknown"
+				if err == nil {
+					result[physicalDrive] = ParseIOScheduler(res)
+				}
+			}
+			res, err := json.Marshal(result)
+			if err != nil {
+				return "", err
+			}
+			return string(res), nil
+		},
+	}
 	return &c
 }
 
+// physicalDrives returns the physical drives found by the remote LocalSSDRule. The LocalSSDRule
+// may still be running after it timed out, so the map is only read under physicalDrivesMu.
+func (c *LinuxCollector) physicalDrives() []string {
+	c.physicalDrivesMu.Lock()
+	defer c.physicalDrivesMu.Unlock()
+	var physicalDrives []string
+	for physicalDrive := range c.physicalDriveToDiskMap {
+		physicalDrives = append(physicalDrives, physicalDrive)
+	}
+	sort.Strings(physicalDrives)
+	return physicalDrives
+}
+
+// numaCommandExecutor returns the executor of a rule derived from the memory of each NUMA node.
+func numaCommandExecutor(result func(nodeMemKb map[int]int64) string) commandExecutor {
+	return commandExecutor{
+		command: numaMemInfoCommand,
+		isRule:  true,
+		runCommand: func(ctx context.Context, command string) (string, error) {
+			res, err := runAllowedCommand(ctx, command, numaExecute)
+			if err != nil {
+				return "", err
+			}
+			nodeMemKb, err := ParseNumaMemInfo(res)
+			if err != nil {
+				return "", err
+			}
+			return result(nodeMemKb), nil
+		},
+		runRemoteCommand: func(ctx context.Context, command string, r remote.Executor) (string, error) {
+			s, err := r.CreateSession("")
+			if err != nil {
+				return "", err
+			}
+			defer s.Close()
+			res, err := runAllowedRemoteCommand(r, command, s)
+			if err != nil {
+				return "", err
+			}
+			nodeMemKb, err := ParseNumaMemInfo(res)
+			if err != nil {
+				return "", err
+			}
+			return result(nodeMemKb), nil
+		},
+	}
+}
+
+// ParseIOScheduler returns the active scheduler of a block device queue, e.g. "mq-deadline" for
+// "[mq-deadline] kyber bfq none". Returns unknown if no scheduler is found.
+func ParseIOScheduler(scheduler string) string {
+	fields := strings.Fields(scheduler)
+	if len(fields) == 1 {
+		return strings.Trim(fields[0], "[]")
+	}
+	for _, f := range fields {
+		if strings.HasPrefix(f, "[") && strings.HasSuffix(f, "]") {
+			return strings.Trim(f, "[]")
+		}
+	}
+	return "unknown"
+}
+
+// ParseNumaMemInfo parses the MemTotal lines of the NUMA nodes meminfo, e.g.
+// "Node 0 MemTotal:       16370756 kB", and returns the memory in kB keyed by node.
+func ParseNumaMemInfo(memInfo string) (map[int]int64, error) {
+	re := regexp.MustCompile(`Node\s+(\d+)\s+MemTotal:\s+(\d+)\s+kB`)
+	nodeMemKb := map[int]int64{}
+	for _, match := range re.FindAllStringSubmatch(memInfo, -1) {
+		node, err := strconv.Atoi(match[1])
+		if err != nil {
+			return nil, err
+		}
+		memKb, err := strconv.ParseInt(match[2], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		nodeMemKb[node] = memKb
+	}
+	if len(nodeMemKb) == 0 {
+		return nil, fmt.Errorf("no NUMA node found in meminfo %q", memInfo)
+	}
+	return nodeMemKb, nil
+}
+
+// NumaMemoryBalanced returns true if the memory of every NUMA node is within 10% of the
+// largest node.
+func NumaMemoryBalanced(nodeMemKb map[int]int64) bool {
+	var maxMemKb int64
+	for _, memKb := range nodeMemKb {
+		maxMemKb = max(maxMemKb, memKb)
+	}
+	for _, memKb := range nodeMemKb {
+		if float64(maxMemKb-memKb) > float64(maxMemKb)*numaMemoryImbalanceThreshold {
+			return false
+		}
+	}
+	return true
+}
+
+// LocalNumaNodeCount returns the number of NUMA nodes of the local linux machine.
+func LocalNumaNodeCount(ctx context.Context) (int, error) {
+	res, err := runAllowedCommand(ctx, numaMemInfoCommand, numaExecute)
+	if err != nil {
+		return 0, err
+	}
+	nodeMemKb, err := ParseNumaMemInfo(res)
+	if err != nil {
+		return 0, err
+	}
+	return len(nodeMemKb), nil
+}
+
+// AddNumaAlignment compares the NUMA node count reported by SQL Server in INSTANCE_METRICS with
+// the NUMA node count of the OS, and adds the result as the "numa_aligned" field.
+// A negative osNodeCount means the OS NUMA node count is unknown.
+func AddNumaAlignment(details []internal.Details, osNodeCount int) {
+	for _, detail := range details {
+		if detail.Name != "INSTANCE_METRICS" {
+			continue
+		}
+		for _, field := range detail.Fields {
+			field[internal.NumaNodeCountOSRule] = "unknown"
+			field["numa_aligned"] = "unknown"
+			if osNodeCount < 0 {
+				continue
+			}
+			field[internal.NumaNodeCountOSRule] = strconv.Itoa(osNodeCount)
+			sqlNodeCount, err := strconv.Atoi(field["numa_node_count"])
+			if err != nil {
+				continue
+			}
+			field["numa_aligned"] = strconv.FormatBool(sqlNodeCount == osNodeCount)
+		}
+	}
+}
+
+// sysctlCommandExecutor returns the executor of a rule reading a kernel parameter with sysctl.
+func sysctlCommandExecutor(command string) commandExecutor {
+	return valueCommandExecutor(command, func(ctx context.Context, params commandlineexecutor.Params) commandlineexecutor.Result {
+		return sysctlExecute(ctx, params)
+	})
+}
+
+// sysfsCommandExecutor returns the executor of a rule reading a setting from sysfs. The rule fails
+// if the setting does not exist, e.g. the cpufreq settings on machines without frequency scaling.
+func sysfsCommandExecutor(command string) commandExecutor {
+	return valueCommandExecutor(command, func(ctx context.Context, params commandlineexecutor.Params) commandlineexecutor.Result {
+		return sysfsExecute(ctx, params)
+	})
+}
+
+// valueCommandExecutor returns the executor of a rule whose value is the output of command.
+// exec runs the command locally.
+func valueCommandExecutor(command string, exec commandlineexecutor.Execute) commandExecutor {
+	return commandExecutor{
+		command: command,
+		isRule:  true,
+		runCommand: func(ctx context.Context, command string) (string, error) {
+			res, err := runAllowedCommand(ctx, command, exec)
+			if err != nil {
+				return "", err
+			}
+			return strings.TrimSpace(res), nil
+		},
+		runRemoteCommand: func(ctx context.Context, command string, r remote.Executor) (string, error) {
+			s, err := r.CreateSession("")
+			if err != nil {
+				return "", err
+			}
+			defer s.Close()
+			res, err := runAllowedRemoteCommand(r, command, s)
+			if err != nil {
+				return "", err
+			}
+			return strings.TrimSpace(res), nil
+		},
+	}
+}
+
+// collectionOSFields returns the os fields collected by LinuxCollector.
+func (c *LinuxCollector) collectionOSFields() []string { return LinuxCollectionOSFields() }
+
+// SetMaxConcurrentRules sets how many guest rules are collected in parallel.
+// Rules are collected sequentially if n is less than 2.
+func (c *LinuxCollector) SetMaxConcurrentRules(n int) {
+	if n < 1 {
+		n = 1
+	}
+	c.maxConcurrentRules = n
+}
+
 // setUpRegex initializes the needed regex's to parse output of a remote lshw and hwinfo call
 func (c *LinuxCollector) setUpRegex() {
 	for _, field := range lshwFields() {
@@ -430,7 +718,7 @@ func findPowerProfile(powerProfileFull string) (string, error) {
 	powerProfile := strings.Split(powerProfileFull, ": ")
 
 	if len(powerProfile) < 2 || powerProfile[0] != "Current active profile" {
-		return "", fmt.Errorf(`Check help docs. Expected power profile format to be  "Current active profile: <profile>. Actual result: ` + powerProfileFull)
+		return "", fmt.Errorf(`%w. Expected power profile format to be "Current active profile: <profile>". Actual result: %s`, ErrToolNotInstalled, powerProfileFull)
 	}
 	if HighPerformanceProfiles()[powerProfile[1]] {
 		return "High performance", nil
@@ -442,16 +730,25 @@ func findPowerProfile(powerProfileFull string) (string, error) {
 // CollectGuestRules collects os guest os rules
 func (c *LinuxCollector) CollectGuestRules(ctx context.Context, timeout time.Duration) internal.Details {
 	details := internal.Details{
-		Name: "OS",
+		Name:                 "OS",
+		CollectionDurationMs: map[string]int64{},
 	}
 	fields := map[string]string{}
 
 	if !c.remote {
 		ctxWithTimeout, cancel := context.WithTimeout(ctx, timeout)
 		defer cancel()
+		// DiskToDiskType works on copies of the fields and disks, since it may still be running after
+		// it timed out while the other rules use them.
+		diskTypeFields := map[string]string{}
+		disks := make([]*instanceinfo.Disks, len(c.disks))
+		for i, d := range c.disks {
+			disk := *d
+			disks[i] = &disk
+		}
 		ch := make(chan bool, 1)
 		go func() {
-			DiskToDiskType(fields, c.disks, c.usageMetricsLogger)
+			DiskToDiskType(diskTypeFields, disks, c.usageMetricsLogger)
 			ch <- true
 		}()
 		select {
@@ -459,6 +756,12 @@ func (c *LinuxCollector) CollectGuestRules(ctx context.Context, timeout time.Dur
 			log.Logger.Errorf("DiskToDiskType() for local linux disktype timeout")
 			c.usageMetricsLogger.Error(agentstatus.MappingLocalLinuxDiskTypeTimeout)
 		case <-ch:
+			for k, v := range diskTypeFields {
+				fields[k] = v
+			}
+			for i, d := range disks {
+				c.disks[i].Mapping = d.Mapping
+			}
 		}
 
 	} else {
@@ -476,65 +779,157 @@ func (c *LinuxCollector) CollectGuestRules(ctx context.Context, timeout time.Dur
 		}()
 	}
 
-	for _, rule := range CollectionOSFields() {
-		exe := c.guestRuleCommandMap[rule]
-		func() {
-			ctxWithTimeout, cancel := context.WithTimeout(ctx, timeout)
-			defer cancel()
-			ch := make(chan bool, 1)
-			go func() {
-				if c.remote {
-					res, err := exe.runRemoteCommand(ctx, exe.command, c.remoteRunner)
-					if err != nil {
-						if strings.Contains(err.Error(), "Check help docs") {
-							log.Logger.Warnw("Failed to run remote command. Install command on linux vm to collect more data", "command", exe.command, "error", err)
-						} else {
-							log.Logger.Errorw("Failed to run remote command", "command", exe.command, "error", err)
-							c.usageMetricsLogger.Error(agentstatus.RemoteCommandExecutionError)
-						}
-						fields[rule] = "unknown"
-						ch <- false
-						return
-					} else if res == "null" {
-						fields[rule] = "unknown"
-						ch <- false
-						return
-					}
-					fields[rule] = res
-				} else if exe.isRule { // local calls are only made if isrule is true
-					res, err := exe.runCommand(ctx, exe.command)
-					if err != nil {
-						if strings.Contains(err.Error(), "Check help docs") {
-							log.Logger.Warnw("Failed to run remote command. Install command on linux vm to collect more data", "command", exe.command, "error", err)
-						} else {
-							log.Logger.Errorw("Failed to run command", "command", exe.command, "error", err)
-							c.usageMetricsLogger.Error(agentstatus.CommandExecutionError)
-						}
-						fields[rule] = "unknown"
-						ch <- false
-						return
-					} else if res == "null" {
-						fields[rule] = "unknown"
-						ch <- false
-						return
-					}
-					fields[rule] = res
-				}
-				ch <- true
-			}()
+	// guestRules holds the collected fields and durations shared by the rule goroutines.
+	guestRules := &guestRuleResults{fields: fields, durations: details.CollectionDurationMs}
+	// LocalSSDRule is collected before the other rules since the remote DataDiskAllocationUnitsRule
+	// depends on the physical drives it discovers.
+	c.collectGuestRule(ctx, timeout, internal.LocalSSDRule, guestRules)
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, c.maxConcurrentRules)
+	for _, rule := range LinuxCollectionOSFields() {
+		if rule == internal.LocalSSDRule {
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(rule string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			c.collectGuestRule(ctx, timeout, rule, guestRules)
+		}(rule)
+	}
+	wg.Wait()
+
+	details.Fields = append(details.Fields, guestRules.snapshot())
+	return details
+}
+
+// guestRuleResults synchronizes access to the fields and durations collected by guest rules
+// running in parallel.
+type guestRuleResults struct {
+	mu        sync.Mutex
+	fields    map[string]string
+	durations map[string]int64
+}
+
+func (r *guestRuleResults) setField(rule, value string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fields[rule] = value
+}
 
-			select {
-			case <-ctxWithTimeout.Done():
-				log.Logger.Errorf("Running linux guest rule %s timeout", rule)
-				c.usageMetricsLogger.Error(agentstatus.LinuxGuestCollectionTimeout)
-			case <-ch:
+func (r *guestRuleResults) setDuration(rule string, duration int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.durations[rule] = duration
+}
+
+// snapshot returns a copy of the collected fields, so rules that timed out and complete later do
+// not modify the returned details.
+func (r *guestRuleResults) snapshot() map[string]string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	fields := make(map[string]string, len(r.fields))
+	for k, v := range r.fields {
+		fields[k] = v
+	}
+	return fields
+}
+
+// collectGuestRule runs a single guest rule within the given timeout and stores its result.
+func (c *LinuxCollector) collectGuestRule(ctx context.Context, timeout time.Duration, rule string, results *guestRuleResults) {
+	exe := c.guestRuleCommandMap[rule]
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	start := time.Now()
+	defer func() {
+		duration := time.Since(start).Milliseconds()
+		results.setDuration(rule, duration)
+		log.Logger.Debugw("Collected guest rule", "rule", rule, "collection_duration_ms", duration)
+	}()
+	ch := make(chan bool, 1)
+	go func() {
+		if c.remote {
+			res, err := exe.runRemoteCommand(ctx, exe.command, c.remoteRunner)
+			if err != nil {
+				if guestRuleErrorLevel(err) == zapcore.WarnLevel {
+					log.Logger.Warnw("Failed to run remote command. Install command on linux vm to collect more data", "command", exe.command, "error", err)
+				} else {
+					log.Logger.Errorw("Failed to run remote command", "command", exe.command, "error", err)
+					c.usageMetricsLogger.Error(guestRuleErrorCode(err, true))
+				}
+				results.setField(rule, "unknown")
+				ch <- false
+				return
+			} else if res == "null" {
+				results.setField(rule, "unknown")
+				ch <- false
+				return
 			}
+			results.setField(rule, res)
+		} else if exe.isRule { // local calls are only made if isrule is true
+			res, err := exe.runCommand(ctx, exe.command)
+			if err != nil {
+				if guestRuleErrorLevel(err) == zapcore.WarnLevel {
+					log.Logger.Warnw("Failed to run remote command. Install command on linux vm to collect more data", "command", exe.command, "error", err)
+				} else {
+					log.Logger.Errorw("Failed to run command", "command", exe.command, "error", err)
+					c.usageMetricsLogger.Error(guestRuleErrorCode(err, false))
+				}
+				results.setField(rule, "unknown")
+				ch <- false
+				return
+			} else if res == "null" {
+				results.setField(rule, "unknown")
+				ch <- false
+				return
+			}
+			results.setField(rule, res)
+		}
+		ch <- true
+	}()
+
+	select {
+	case <-ctxWithTimeout.Done():
+		log.Logger.Errorf("Running linux guest rule %s timeout", rule)
+		c.usageMetricsLogger.Error(agentstatus.LinuxGuestCollectionTimeout)
+	case <-ch:
+	}
+}
 
-		}()
+// guestRuleErrorLevel returns the level a failed guest rule is logged at.
+func guestRuleErrorLevel(err error) zapcore.Level {
+	if errors.Is(err, ErrToolNotInstalled) {
+		return zapcore.WarnLevel
+	}
+	return zapcore.ErrorLevel
+}
 
+// permissionDeniedMessages are the messages in the output of a command that failed because the
+// agent lacks a permission on the guest.
+var permissionDeniedMessages = []string{
+	"permission denied",
+	"a password is required",
+	"a terminal is required",
+	"is not in the sudoers file",
+}
+
+// guestRuleErrorCode returns the agent status error code of a failed guest rule.
+func guestRuleErrorCode(err error, remote bool) int {
+	if errors.Is(err, ErrCommandNotAllowed) {
+		return agentstatus.CommandNotAllowedError
 	}
-	details.Fields = append(details.Fields, fields)
-	return details
+	msg := strings.ToLower(err.Error())
+	for _, m := range permissionDeniedMessages {
+		if strings.Contains(msg, m) {
+			return agentstatus.CommandPermissionDeniedError
+		}
+	}
+	if remote {
+		return agentstatus.RemoteCommandExecutionError
+	}
+	return agentstatus.CommandExecutionError
 }
 
 func (c *LinuxCollector) gcbdrAgentRunning(cmdOutput string) (string, error) {
@@ -545,3 +940,61 @@ func (c *LinuxCollector) gcbdrAgentRunning(cmdOutput string) (string, error) {
 	}
 	return strconv.FormatBool(match[1] == "active (running)"), nil
 }
+
+// PhysicalDriveFromPathRemote gets the physical drive associated with a file path on a remote
+// linux instance. The path is shell-quoted since the commands run in a shell on the remote.
+func PhysicalDriveFromPathRemote(r remote.Executor, path string) (string, error) {
+	dir, filename := filepath.Split(path)
+	findCommand := fmt.Sprintf(physicalDriveFindCommand, shellQuote(dir), shellQuote(internal.EscapeGlob(filename)))
+	filePath, err := runRemoteCommand(findCommand, r)
+	if err != nil {
+		return "", fmt.Errorf("failed to run cmd %v: %v", findCommand, err)
+	}
+	filePath, _, _ = strings.Cut(filePath, "\n")
+
+	dfCommand := fmt.Sprintf(physicalDriveDfCommand, shellQuote(filePath))
+	physicalPathMount, err := runRemoteCommand(dfCommand, r)
+	if err != nil {
+		return "", fmt.Errorf("failed to run cmd %v: %v", dfCommand, err)
+	}
+	lines := strings.Split(strings.TrimRight(physicalPathMount, "\n"), "\n")
+	physicalPathMount = lines[len(lines)-1]
+
+	resultMount, err := runAllowedCommandWithPipes(physicalDriveMountCommand, r)
+	if err != nil {
+		return "", fmt.Errorf("failed to run cmd %v: %v", physicalDriveMountCommand, err)
+	}
+
+	allMounts := strings.TrimSuffix(resultMount, "\n")
+	physicalDriveHelper := regexp.MustCompile(` `+regexp.QuoteMeta(physicalPathMount)+` `).Split(allMounts, -1)
+
+	physicalDrives := []string{}
+	for i := 0; i < len(physicalDriveHelper)-1; i++ {
+		splitStr := regexp.MustCompile("\n| |/").Split(physicalDriveHelper[i], -1)
+		if len(splitStr) < 2 {
+			log.Logger.Warn("regex for linux error. Unable to find physical drive associated with mount.")
+			continue
+		}
+		physicalDrives = append(physicalDrives, splitStr[len(splitStr)-2])
+	}
+	return strings.Join(physicalDrives, ", "), nil
+}
+
+// runRemoteCommand runs a single allowlisted command on the remote machine. Unlike
+// runAllowedCommandWithPipes, the command is not split on "|", so quoted arguments may contain it.
+func runRemoteCommand(command string, r remote.Executor) (string, error) {
+	if err := checkLinuxCommand(command); err != nil {
+		return "", err
+	}
+	s, err := r.CreateSession("")
+	if err != nil {
+		return "", fmt.Errorf("Failed to create a session. %v", err)
+	}
+	defer s.Close()
+	return r.Run(command, s)
+}
+
+// shellQuote quotes s as a single argument of a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -21,11 +21,17 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/GoogleCloudPlatform/workloadagentplatform/integration/common/shared/commandlineexecutor"
+	"go.uber.org/zap/zapcore"
 	"golang.org/x/crypto/ssh"
+	"github.com/GoogleCloudPlatform/sql-server-agent/internal/agentstatus"
 	"github.com/GoogleCloudPlatform/sql-server-agent/internal/instanceinfo"
 	"github.com/GoogleCloudPlatform/sql-server-agent/internal"
 	"github.com/GoogleCloudPlatform/sql-server-agent/internal/remote"
@@ -112,6 +118,20 @@ func (m *mockRemote) Run(cmd string, session remote.SSHSessionInterface) (string
 		return m.powerPlanInput, nil
 	case dataDiskAllocationUnitsCommand:
 		return "", nil
+	case vmSwappinessCommand:
+		return "60", nil
+	case vmMaxMapCountCommand:
+		return "65530", nil
+	case kernelNumaBalancingCommand:
+		return "1", nil
+	case numaMemInfoCommand:
+		return "Node 0 MemTotal:       16370756 kB\nNode 1 MemTotal:       16512000 kB\n", nil
+	case fmt.Sprintf(ioSchedulerCommand, "sda"):
+		return "[mq-deadline] kyber bfq none\n", nil
+	case cpuGovernorCommand:
+		return "performance\n", nil
+	case clocksourceCommand:
+		return "tsc\n", nil
 	default:
 		return "unknown", nil
 	}
@@ -204,13 +224,44 @@ func TestPhysicalDriveToDiskType(t *testing.T) {
 			DiskToDiskType(tc.inputDetails, tc.disks, fakeUsageMetricsLogger)
 			got := tc.inputDetails
 
-			if diff := cmp.Diff(got, tc.want); diff != "" {
+			if diff := cmp.Diff(got, tc.want, cmpopts.IgnoreFields(internal.Details{}, "CollectionDurationMs")); diff != "" {
 				t.Errorf("DiskToDiskType() returned wrong result (-got +want):\n%s", diff)
 			}
 		})
 	}
 }
 
+func fakeSysctlExecute(ctx context.Context, params commandlineexecutor.Params) commandlineexecutor.Result {
+	switch {
+	case strings.Contains(params.ArgsToSplit, vmSwappinessCommand):
+		return commandlineexecutor.Result{StdOut: "60\n"}
+	case strings.Contains(params.ArgsToSplit, vmMaxMapCountCommand):
+		return commandlineexecutor.Result{StdOut: "65530\n"}
+	case strings.Contains(params.ArgsToSplit, kernelNumaBalancingCommand):
+		return commandlineexecutor.Result{StdOut: "1\n"}
+	default:
+		return commandlineexecutor.Result{StdErr: "unknown key", Error: errors.New("exit status 255")}
+	}
+}
+
+func fakeNumaExecute(ctx context.Context, params commandlineexecutor.Params) commandlineexecutor.Result {
+	if strings.Contains(params.ArgsToSplit, numaMemInfoCommand) {
+		return commandlineexecutor.Result{StdOut: "Node 0 MemTotal:       16370756 kB\n"}
+	}
+	return commandlineexecutor.Result{StdErr: "No such file or directory", Error: errors.New("exit status 1")}
+}
+
+func fakeSysfsExecute(ctx context.Context, params commandlineexecutor.Params) commandlineexecutor.Result {
+	switch {
+	case strings.Contains(params.ArgsToSplit, cpuGovernorCommand):
+		return commandlineexecutor.Result{StdOut: "powersave\n"}
+	case strings.Contains(params.ArgsToSplit, clocksourceCommand):
+		return commandlineexecutor.Result{StdOut: "kvm-clock\n"}
+	default:
+		return commandlineexecutor.Result{StdErr: "No such file or directory", Error: errors.New("exit status 1")}
+	}
+}
+
 func TestCollectLinuxGuestRules(t *testing.T) {
 	testcases := []struct {
 		name                   string
@@ -230,6 +281,14 @@ func TestCollectLinuxGuestRules(t *testing.T) {
 						"local_ssd":                  "unknown",
 						"power_profile_setting":      "unknown",
 						"gcbdr_agent_running":        "false",
+						"vm_swappiness":              "60",
+						"vm_max_map_count":           "65530",
+						"kernel_numa_balancing":      "1",
+						"numa_node_count_os":         "1",
+						"numa_memory_balanced":       "true",
+						"io_scheduler":               "unknown",
+						"cpu_governor":               "powersave",
+						"clocksource":                "kvm-clock",
 					},
 				},
 			},
@@ -312,15 +371,30 @@ func TestCollectLinuxGuestRules(t *testing.T) {
 						"local_ssd":                  "unknown",
 						"power_profile_setting":      "unknown",
 						"gcbdr_agent_running":        "false",
+						"vm_swappiness":              "unknown",
+						"vm_max_map_count":           "unknown",
+						"kernel_numa_balancing":      "unknown",
+						"numa_node_count_os":         "unknown",
+						"numa_memory_balanced":       "unknown",
+						"io_scheduler":               "unknown",
+						"cpu_governor":               "unknown",
+						"clocksource":                "unknown",
 					},
 				},
 			},
 		},
 	}
 
+	defer func(f commandlineexecutor.Execute) { sysctlExecute = f }(sysctlExecute)
+	sysctlExecute = fakeSysctlExecute
+	defer func(f commandlineexecutor.Execute) { numaExecute = f }(numaExecute)
+	numaExecute = fakeNumaExecute
+	defer func(f commandlineexecutor.Execute) { sysfsExecute = f }(sysfsExecute)
+	sysfsExecute = fakeSysfsExecute
+
 	for _, tc := range testcases {
 		t.Run(tc.name, func(t *testing.T) {
-			collector := NewLinuxCollector(nil, "", "", "", false, 22, fakeUsageMetricsLogger)
+			collector := NewLinuxCollector(nil, "", "", "", nil, false, 22, fakeUsageMetricsLogger)
 			if tc.mockRuleMap {
 				collector.guestRuleCommandMap = tc.commandExecutorMapMock
 			} else if tc.mockWMIErr {
@@ -330,7 +404,7 @@ func TestCollectLinuxGuestRules(t *testing.T) {
 				}
 			}
 			got := collector.CollectGuestRules(context.Background(), time.Minute)
-			if diff := cmp.Diff(got, tc.want); diff != "" {
+			if diff := cmp.Diff(got, tc.want, cmpopts.IgnoreFields(internal.Details{}, "CollectionDurationMs")); diff != "" {
 				t.Errorf("CollectGuestRules() returned wrong result (-got +want):\n%s", diff)
 			}
 		})
@@ -359,6 +433,14 @@ func TestCollectLinuxGuestRulesRemote(t *testing.T) {
 					"local_ssd":                  `{"sda":"PERSISTENT-SSD"}`,
 					"power_profile_setting":      "High performance",
 					"gcbdr_agent_running":        "unknown",
+					"vm_swappiness":              "60",
+					"vm_max_map_count":           "65530",
+					"kernel_numa_balancing":      "1",
+					"numa_node_count_os":         "2",
+					"numa_memory_balanced":       "true",
+					"io_scheduler":               `{"sda":"mq-deadline"}`,
+					"cpu_governor":               "performance",
+					"clocksource":                "tsc",
 				}},
 			},
 		},
@@ -373,6 +455,14 @@ func TestCollectLinuxGuestRulesRemote(t *testing.T) {
 					"local_ssd":                  `{"sda":"PERSISTENT-SSD"}`,
 					"power_profile_setting":      "High performance",
 					"gcbdr_agent_running":        "unknown",
+					"vm_swappiness":              "60",
+					"vm_max_map_count":           "65530",
+					"kernel_numa_balancing":      "1",
+					"numa_node_count_os":         "2",
+					"numa_memory_balanced":       "true",
+					"io_scheduler":               `{"sda":"mq-deadline"}`,
+					"cpu_governor":               "performance",
+					"clocksource":                "tsc",
 				}},
 			},
 		},
@@ -386,6 +476,14 @@ func TestCollectLinuxGuestRulesRemote(t *testing.T) {
 					"local_ssd":                  `{"sda":"PERSISTENT-SSD"}`,
 					"power_profile_setting":      "balanced",
 					"gcbdr_agent_running":        "unknown",
+					"vm_swappiness":              "60",
+					"vm_max_map_count":           "65530",
+					"kernel_numa_balancing":      "1",
+					"numa_node_count_os":         "2",
+					"numa_memory_balanced":       "true",
+					"io_scheduler":               `{"sda":"mq-deadline"}`,
+					"cpu_governor":               "performance",
+					"clocksource":                "tsc",
 				}},
 			},
 		},
@@ -399,6 +497,14 @@ func TestCollectLinuxGuestRulesRemote(t *testing.T) {
 					"local_ssd":                  `{"sda":"PERSISTENT-SSD"}`,
 					"power_profile_setting":      "unknown",
 					"gcbdr_agent_running":        "unknown",
+					"vm_swappiness":              "60",
+					"vm_max_map_count":           "65530",
+					"kernel_numa_balancing":      "1",
+					"numa_node_count_os":         "2",
+					"numa_memory_balanced":       "true",
+					"io_scheduler":               `{"sda":"mq-deadline"}`,
+					"cpu_governor":               "performance",
+					"clocksource":                "tsc",
 				}},
 			},
 		},
@@ -413,6 +519,14 @@ func TestCollectLinuxGuestRulesRemote(t *testing.T) {
 						"local_ssd":                  "unknown",
 						"power_profile_setting":      "unknown",
 						"gcbdr_agent_running":        "false",
+						"vm_swappiness":              "unknown",
+						"vm_max_map_count":           "unknown",
+						"kernel_numa_balancing":      "unknown",
+						"numa_node_count_os":         "unknown",
+						"numa_memory_balanced":       "unknown",
+						"io_scheduler":               "unknown",
+						"cpu_governor":               "unknown",
+						"clocksource":                "unknown",
 					},
 				},
 			},
@@ -428,6 +542,14 @@ func TestCollectLinuxGuestRulesRemote(t *testing.T) {
 						"local_ssd":                  "unknown",
 						"power_profile_setting":      "unknown",
 						"gcbdr_agent_running":        "unknown",
+						"vm_swappiness":              "unknown",
+						"vm_max_map_count":           "unknown",
+						"kernel_numa_balancing":      "unknown",
+						"numa_node_count_os":         "unknown",
+						"numa_memory_balanced":       "unknown",
+						"io_scheduler":               "unknown",
+						"cpu_governor":               "unknown",
+						"clocksource":                "unknown",
 					},
 				},
 			},
@@ -448,6 +570,14 @@ func TestCollectLinuxGuestRulesRemote(t *testing.T) {
 				internal.PowerProfileSettingRule:     commandExecutor{runRemoteCommand: func(ctx context.Context, command string, r remote.Executor) (string, error) { return "null", nil }},
 				internal.DataDiskAllocationUnitsRule: commandExecutor{runRemoteCommand: func(ctx context.Context, command string, r remote.Executor) (string, error) { return "null", nil }},
 				internal.GCBDRAgentRunning:           commandExecutor{runRemoteCommand: func(ctx context.Context, command string, r remote.Executor) (string, error) { return "null", nil }},
+				internal.VMSwappinessRule:            commandExecutor{runRemoteCommand: func(ctx context.Context, command string, r remote.Executor) (string, error) { return "null", nil }},
+				internal.VMMaxMapCountRule:           commandExecutor{runRemoteCommand: func(ctx context.Context, command string, r remote.Executor) (string, error) { return "null", nil }},
+				internal.KernelNumaBalancingRule:     commandExecutor{runRemoteCommand: func(ctx context.Context, command string, r remote.Executor) (string, error) { return "null", nil }},
+				internal.NumaNodeCountOSRule:         commandExecutor{runRemoteCommand: func(ctx context.Context, command string, r remote.Executor) (string, error) { return "null", nil }},
+				internal.NumaMemoryBalancedRule:      commandExecutor{runRemoteCommand: func(ctx context.Context, command string, r remote.Executor) (string, error) { return "null", nil }},
+				internal.IOSchedulerRule:             commandExecutor{runRemoteCommand: func(ctx context.Context, command string, r remote.Executor) (string, error) { return "null", nil }},
+				internal.CPUGovernorRule:             commandExecutor{runRemoteCommand: func(ctx context.Context, command string, r remote.Executor) (string, error) { return "null", nil }},
+				internal.ClocksourceRule:             commandExecutor{runRemoteCommand: func(ctx context.Context, command string, r remote.Executor) (string, error) { return "null", nil }},
 			},
 			want: internal.Details{
 				Name: "OS",
@@ -455,6 +585,14 @@ func TestCollectLinuxGuestRulesRemote(t *testing.T) {
 					"local_ssd":                  "unknown",
 					"data_disk_allocation_units": "unknown",
 					"gcbdr_agent_running":        "unknown",
+					"vm_swappiness":              "unknown",
+					"vm_max_map_count":           "unknown",
+					"kernel_numa_balancing":      "unknown",
+					"numa_node_count_os":         "unknown",
+					"numa_memory_balanced":       "unknown",
+					"io_scheduler":               "unknown",
+					"cpu_governor":               "unknown",
+					"clocksource":                "unknown",
 					"power_profile_setting":      "unknown",
 				}},
 			},
@@ -462,7 +600,7 @@ func TestCollectLinuxGuestRulesRemote(t *testing.T) {
 	}
 	for _, tc := range testcases {
 		t.Run(tc.name, func(t *testing.T) {
-			collector := NewLinuxCollector(nil, "", "", "", true, 22, fakeUsageMetricsLogger)
+			collector := NewLinuxCollector(nil, "", "", "", nil, true, 22, fakeUsageMetricsLogger)
 			if tc.mockRuleMap {
 				collector.guestRuleCommandMap = tc.commandExecutorMapMock
 			}
@@ -472,7 +610,7 @@ func TestCollectLinuxGuestRulesRemote(t *testing.T) {
 				collector.remoteRunner = nil
 			}
 			got := collector.CollectGuestRules(context.Background(), time.Minute)
-			if diff := cmp.Diff(got, tc.want); diff != "" {
+			if diff := cmp.Diff(got, tc.want, cmpopts.IgnoreFields(internal.Details{}, "CollectionDurationMs")); diff != "" {
 				t.Errorf("CollectGuestRules() returned wrong result (-got +want):\n%s", diff)
 			}
 		})
@@ -480,13 +618,122 @@ func TestCollectLinuxGuestRulesRemote(t *testing.T) {
 }
 
 // TestCheckLinusOsReturnedCount compares the os returned fields for linux_guestcollector with the returned fields for OSCollectorResultFields
+func TestCollectLinuxGuestRulesDuration(t *testing.T) {
+	collector := NewLinuxCollector(nil, "", "", "", nil, false, 22, fakeUsageMetricsLogger)
+	collector.guestRuleCommandMap = map[string]commandExecutor{
+		internal.PowerProfileSettingRule: commandExecutor{
+			isRule: true,
+			runCommand: func(ctx context.Context, command string) (string, error) {
+				return "testvalue", nil
+			},
+		},
+	}
+
+	got := collector.CollectGuestRules(context.Background(), time.Minute)
+	for _, rule := range LinuxCollectionOSFields() {
+		duration, ok := got.CollectionDurationMs[rule]
+		if !ok {
+			t.Errorf("CollectGuestRules() returned no collection duration for rule %s", rule)
+			continue
+		}
+		if duration < 0 {
+			t.Errorf("CollectGuestRules() returned collection duration %d for rule %s, want non-negative", duration, rule)
+		}
+	}
+}
+
+func TestCollectLinuxGuestRulesConcurrent(t *testing.T) {
+	const delay = 200 * time.Millisecond
+	slowCommand := func(value string) commandExecutor {
+		return commandExecutor{
+			isRule: true,
+			runCommand: func(ctx context.Context, command string) (string, error) {
+				time.Sleep(delay)
+				return value, nil
+			},
+		}
+	}
+	want := internal.Details{
+		Name: "OS",
+		Fields: []map[string]string{
+			map[string]string{
+				internal.PowerProfileSettingRule:     "High performance",
+				internal.DataDiskAllocationUnitsRule: "4096",
+				internal.GCBDRAgentRunning:           "true",
+				internal.LocalSSDRule:                "unknown",
+			},
+		},
+	}
+
+	testcases := []struct {
+		name               string
+		maxConcurrentRules int
+		minDuration        time.Duration
+		maxDuration        time.Duration
+	}{
+		{
+			name:               "sequential",
+			maxConcurrentRules: 1,
+			minDuration:        3 * delay,
+			maxDuration:        time.Minute,
+		},
+		{
+			name:               "parallel",
+			maxConcurrentRules: 3,
+			minDuration:        delay,
+			maxDuration:        2 * delay,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			collector := NewLinuxCollector(nil, "", "", "", nil, false, 22, fakeUsageMetricsLogger)
+			collector.SetMaxConcurrentRules(tc.maxConcurrentRules)
+			collector.guestRuleCommandMap = map[string]commandExecutor{
+				internal.PowerProfileSettingRule:     slowCommand("High performance"),
+				internal.DataDiskAllocationUnitsRule: slowCommand("4096"),
+				internal.GCBDRAgentRunning:           slowCommand("true"),
+			}
+
+			start := time.Now()
+			got := collector.CollectGuestRules(context.Background(), time.Minute)
+			elapsed := time.Since(start)
+			if diff := cmp.Diff(got, want, cmpopts.IgnoreFields(internal.Details{}, "CollectionDurationMs")); diff != "" {
+				t.Errorf("CollectGuestRules() returned wrong result (-got +want):\n%s", diff)
+			}
+			if elapsed < tc.minDuration || elapsed >= tc.maxDuration {
+				t.Errorf("CollectGuestRules() took %v, want between %v and %v", elapsed, tc.minDuration, tc.maxDuration)
+			}
+		})
+	}
+}
+
+func TestSetMaxConcurrentRules(t *testing.T) {
+	tests := []struct {
+		input int
+		want  int
+	}{
+		{input: 4, want: 4},
+		{input: 1, want: 1},
+		{input: 0, want: 1},
+		{input: -1, want: 1},
+	}
+	for _, tc := range tests {
+		collector := NewLinuxCollector(nil, "", "", "", nil, false, 22, fakeUsageMetricsLogger)
+		collector.SetMaxConcurrentRules(tc.input)
+		if collector.maxConcurrentRules != tc.want {
+			t.Errorf("SetMaxConcurrentRules(%d) set maxConcurrentRules = %d, want %d", tc.input, collector.maxConcurrentRules, tc.want)
+		}
+	}
+}
+
 func TestCheckLinusOsReturnedCount(t *testing.T) {
-	guestCollectorCount := len(allOSFields)
+	guestCollectorCount := len(LinuxCollectionOSFields())
 	guestCollectorLinuxCount := 0
 
-	testLC := NewLinuxCollector(nil, "", "", "", false, 22, fakeUsageMetricsLogger)
+	testLC := NewLinuxCollector(nil, "", "", "", nil, false, 22, fakeUsageMetricsLogger)
 
-	for _, field := range allOSFields {
+	for _, field := range LinuxCollectionOSFields() {
 		_, ok := testLC.guestRuleCommandMap[field]
 		if ok {
 			guestCollectorLinuxCount++
@@ -581,7 +828,7 @@ func TestFindLshwFields(t *testing.T) {
 	}
 	for _, tc := range testcases {
 		t.Run(tc.name, func(t *testing.T) {
-			c := NewLinuxCollector(nil, "", "", "", true, 22, fakeUsageMetricsLogger)
+			c := NewLinuxCollector(nil, "", "", "", nil, true, 22, fakeUsageMetricsLogger)
 			test, err := c.findLshwFields(tc.lshwInput)
 			if err != nil {
 				t.Errorf("findLshwFields() returned error: %v", err)
@@ -636,7 +883,7 @@ func TestFindHwinfoFields(t *testing.T) {
 	}
 	for _, tc := range testcases {
 		t.Run(tc.name, func(t *testing.T) {
-			c := NewLinuxCollector(nil, "", "", "", true, 22, fakeUsageMetricsLogger)
+			c := NewLinuxCollector(nil, "", "", "", nil, true, 22, fakeUsageMetricsLogger)
 			test, err := c.findHwinfoFields(tc.lshwInput)
 			if err != nil {
 				t.Errorf("findHwinfoFields() returned error: %v", err)
@@ -673,7 +920,7 @@ func TestFindHwinfoFields_BadInput(t *testing.T) {
 	}
 	for _, tc := range testcases {
 		t.Run(tc.name, func(t *testing.T) {
-			c := NewLinuxCollector(nil, "", "", "", true, 22, fakeUsageMetricsLogger)
+			c := NewLinuxCollector(nil, "", "", "", nil, true, 22, fakeUsageMetricsLogger)
 			_, err := c.findHwinfoFields(tc.lshwInput)
 			if err == nil {
 				t.Errorf("findHwinfoFields() returned nil error, want error")
@@ -707,7 +954,7 @@ func TestFindLshwField_BadInput(t *testing.T) {
 	}
 	for _, tc := range testcases {
 		t.Run(tc.name, func(t *testing.T) {
-			c := NewLinuxCollector(nil, "", "", "", true, 22, fakeUsageMetricsLogger)
+			c := NewLinuxCollector(nil, "", "", "", nil, true, 22, fakeUsageMetricsLogger)
 			_, err := c.findLshwFields(tc.lshwInput)
 			if err == nil {
 				t.Errorf("findLshwFields() returned nil error, want error")
@@ -747,7 +994,7 @@ func TestFindLshwFieldString(t *testing.T) {
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			c := NewLinuxCollector(nil, "", "", "", true, 22, fakeUsageMetricsLogger)
+			c := NewLinuxCollector(nil, "", "", "", nil, true, 22, fakeUsageMetricsLogger)
 			got, err := c.findLshwFieldString(tc.lshwResult, tc.field)
 			if err != nil {
 				t.Errorf("findLshwFieldString(%v, %v) returned an unexpected error: %v", tc.lshwResult, tc.field, err)
@@ -787,7 +1034,7 @@ func TestFindLshwFieldString_BadInput(t *testing.T) {
 
 	for _, tc := range tests {
 		t.Run(tc.lshwResult, func(t *testing.T) {
-			c := NewLinuxCollector(nil, "", "", "", true, 22, fakeUsageMetricsLogger)
+			c := NewLinuxCollector(nil, "", "", "", nil, true, 22, fakeUsageMetricsLogger)
 			_, err := c.findLshwFieldString(tc.lshwResult, tc.field)
 			if err == nil {
 				t.Errorf("findLshwFieldString(%v, %v) returned an unexpected error: %v", tc.lshwResult, tc.field, err)
@@ -827,7 +1074,7 @@ func TestFindLshwFieldInt(t *testing.T) {
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			c := NewLinuxCollector(nil, "", "", "", true, 22, fakeUsageMetricsLogger)
+			c := NewLinuxCollector(nil, "", "", "", nil, true, 22, fakeUsageMetricsLogger)
 			got, err := c.findLshwFieldInt(tc.lshwResult, tc.field)
 			if err != nil {
 				t.Errorf("findLshwFieldInt(%v, %v) returned an unexpected error: %v", tc.lshwResult, tc.field, err)
@@ -866,7 +1113,7 @@ func TestFindLshwFieldInt_BadInput(t *testing.T) {
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			c := NewLinuxCollector(nil, "", "", "", true, 22, fakeUsageMetricsLogger)
+			c := NewLinuxCollector(nil, "", "", "", nil, true, 22, fakeUsageMetricsLogger)
 			_, err := c.findLshwFieldInt(tc.lshwResult, tc.field)
 			if err == nil {
 				t.Errorf("findLshwFieldInt(%v, %v) returned an unexpected error: %v", tc.lshwResult, tc.field, err)
@@ -972,3 +1219,436 @@ func TestGcbdrAgentRunning(t *testing.T) {
 		}
 	}
 }
+
+func TestGuestRuleErrorLevel(t *testing.T) {
+	_, powerProfileErr := findPowerProfile("any input without correct format")
+	tests := []struct {
+		name string
+		err  error
+		want zapcore.Level
+	}{
+		{
+			name: "tool not installed",
+			err:  ErrToolNotInstalled,
+			want: zapcore.WarnLevel,
+		},
+		{
+			name: "wrapped tool not installed",
+			err:  fmt.Errorf("%w, tuned package not installed or no power profile set. %v", ErrToolNotInstalled, errors.New("exit status 1")),
+			want: zapcore.WarnLevel,
+		},
+		{
+			name: "unexpected power profile format",
+			err:  powerProfileErr,
+			want: zapcore.WarnLevel,
+		},
+		{
+			name: "other error",
+			err:  errors.New("Check help docs"),
+			want: zapcore.ErrorLevel,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := guestRuleErrorLevel(tc.err); got != tc.want {
+				t.Errorf("guestRuleErrorLevel(%v) = %v, want: %v", tc.err, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestGuestRuleErrorCode(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		remote bool
+		want   int
+	}{
+		{
+			name: "command not allowed",
+			err:  checkLinuxCommand("cat /etc/shadow"),
+			want: agentstatus.CommandNotAllowedError,
+		},
+		{
+			name: "local permission denied",
+			err:  errors.New("Error when running CommandLineExecutor: cat: /sys/block/sda/queue/scheduler: Permission denied"),
+			want: agentstatus.CommandPermissionDeniedError,
+		},
+		{
+			name:   "remote sudo password required",
+			err:    errors.New("An error occurred while running the cmd sudo tuned-adm active, sudo: a password is required"),
+			remote: true,
+			want:   agentstatus.CommandPermissionDeniedError,
+		},
+		{
+			name: "local command failure",
+			err:  errors.New("Error when running CommandLineExecutor: exit status 1"),
+			want: agentstatus.CommandExecutionError,
+		},
+		{
+			name:   "remote command failure",
+			err:    errors.New("An error occurred while running the cmd sysctl -n vm.swappiness, exit status 1"),
+			remote: true,
+			want:   agentstatus.RemoteCommandExecutionError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := guestRuleErrorCode(tc.err, tc.remote); got != tc.want {
+				t.Errorf("guestRuleErrorCode(%v, %v) = %d, want: %d", tc.err, tc.remote, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseNumaMemInfo(t *testing.T) {
+	testcases := []struct {
+		name    string
+		memInfo string
+		want    map[int]int64
+		wantErr bool
+	}{
+		{
+			name:    "single node",
+			memInfo: "Node 0 MemTotal:       16370756 kB\n",
+			want:    map[int]int64{0: 16370756},
+		},
+		{
+			name:    "multiple nodes",
+			memInfo: "Node 0 MemTotal:       16370756 kB\nNode 1 MemTotal:       16512000 kB\n",
+			want:    map[int]int64{0: 16370756, 1: 16512000},
+		},
+		{
+			name:    "no node",
+			memInfo: "cat: /sys/devices/system/node/node*/meminfo: No such file or directory",
+			wantErr: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParseNumaMemInfo(tc.memInfo)
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Fatalf("ParseNumaMemInfo(%q) returned error %v, want error presence %v", tc.memInfo, err, tc.wantErr)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("ParseNumaMemInfo(%q) returned diff (-want +got):\n%s", tc.memInfo, diff)
+			}
+		})
+	}
+}
+
+func TestNumaMemoryBalanced(t *testing.T) {
+	testcases := []struct {
+		name      string
+		nodeMemKb map[int]int64
+		want      bool
+	}{
+		{
+			name:      "single node",
+			nodeMemKb: map[int]int64{0: 16370756},
+			want:      true,
+		},
+		{
+			name:      "nodes within 10%",
+			nodeMemKb: map[int]int64{0: 16370756, 1: 16512000},
+			want:      true,
+		},
+		{
+			name:      "nodes differ by more than 10%",
+			nodeMemKb: map[int]int64{0: 8000000, 1: 16000000},
+			want:      false,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := NumaMemoryBalanced(tc.nodeMemKb); got != tc.want {
+				t.Errorf("NumaMemoryBalanced(%v) = %v, want %v", tc.nodeMemKb, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestAddNumaAlignment(t *testing.T) {
+	testcases := []struct {
+		name        string
+		details     []internal.Details
+		osNodeCount int
+		want        []internal.Details
+	}{
+		{
+			name: "aligned",
+			details: []internal.Details{
+				{Name: "INSTANCE_METRICS", Fields: []map[string]string{{"numa_node_count": "2"}}},
+				{Name: "DB_MAX_PARALLELISM", Fields: []map[string]string{{"maxDegreeOfParallelism": "0"}}},
+			},
+			osNodeCount: 2,
+			want: []internal.Details{
+				{Name: "INSTANCE_METRICS", Fields: []map[string]string{{"numa_node_count": "2", "numa_node_count_os": "2", "numa_aligned": "true"}}},
+				{Name: "DB_MAX_PARALLELISM", Fields: []map[string]string{{"maxDegreeOfParallelism": "0"}}},
+			},
+		},
+		{
+			name: "not aligned",
+			details: []internal.Details{
+				{Name: "INSTANCE_METRICS", Fields: []map[string]string{{"numa_node_count": "1"}}},
+			},
+			osNodeCount: 2,
+			want: []internal.Details{
+				{Name: "INSTANCE_METRICS", Fields: []map[string]string{{"numa_node_count": "1", "numa_node_count_os": "2", "numa_aligned": "false"}}},
+			},
+		},
+		{
+			name: "unknown sql numa node count",
+			details: []internal.Details{
+				{Name: "INSTANCE_METRICS", Fields: []map[string]string{{"numa_node_count": "unknown"}}},
+			},
+			osNodeCount: 2,
+			want: []internal.Details{
+				{Name: "INSTANCE_METRICS", Fields: []map[string]string{{"numa_node_count": "unknown", "numa_node_count_os": "2", "numa_aligned": "unknown"}}},
+			},
+		},
+		{
+			name: "unknown os numa node count",
+			details: []internal.Details{
+				{Name: "INSTANCE_METRICS", Fields: []map[string]string{{"numa_node_count": "2"}}},
+			},
+			osNodeCount: -1,
+			want: []internal.Details{
+				{Name: "INSTANCE_METRICS", Fields: []map[string]string{{"numa_node_count": "2", "numa_node_count_os": "unknown", "numa_aligned": "unknown"}}},
+			},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			AddNumaAlignment(tc.details, tc.osNodeCount)
+			if diff := cmp.Diff(tc.want, tc.details); diff != "" {
+				t.Errorf("AddNumaAlignment() returned diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseIOScheduler(t *testing.T) {
+	tests := []struct {
+		scheduler string
+		want      string
+	}{
+		{scheduler: "[mq-deadline] kyber bfq none\n", want: "mq-deadline"},
+		{scheduler: "mq-deadline kyber bfq [none]", want: "none"},
+		{scheduler: "[none]", want: "none"},
+		{scheduler: "none", want: "none"},
+		{scheduler: "mq-deadline none", want: "unknown"},
+		{scheduler: "", want: "unknown"},
+	}
+	for _, tc := range tests {
+		if got := ParseIOScheduler(tc.scheduler); got != tc.want {
+			t.Errorf("ParseIOScheduler(%q) = %q, want %q", tc.scheduler, got, tc.want)
+		}
+	}
+}
+
+func TestIOSchedulerRuleLocal(t *testing.T) {
+	defer func(f commandlineexecutor.Execute) { ioSchedulerExecute = f }(ioSchedulerExecute)
+	ioSchedulerExecute = func(ctx context.Context, params commandlineexecutor.Params) commandlineexecutor.Result {
+		switch {
+		case strings.Contains(params.ArgsToSplit, fmt.Sprintf(ioSchedulerCommand, "sdb")):
+			return commandlineexecutor.Result{StdOut: "[mq-deadline] kyber bfq none\n"}
+		case strings.Contains(params.ArgsToSplit, fmt.Sprintf(ioSchedulerCommand, "nvme0n1")):
+			return commandlineexecutor.Result{StdOut: "[none] mq-deadline\n"}
+		default:
+			return commandlineexecutor.Result{StdErr: "No such file or directory", Error: errors.New("exit status 1")}
+		}
+	}
+	collector := NewLinuxCollector([]*instanceinfo.Disks{
+		&instanceinfo.Disks{DeviceName: "data", Mapping: "sdb"},
+		&instanceinfo.Disks{DeviceName: "local-ssd", Mapping: "nvme0n1"},
+		&instanceinfo.Disks{DeviceName: "removed", Mapping: "sdc"},
+		&instanceinfo.Disks{DeviceName: "unmapped", Mapping: ""},
+	}, "", "", "", nil, false, 22, fakeUsageMetricsLogger)
+	exe := collector.guestRuleCommandMap[internal.IOSchedulerRule]
+
+	got, err := exe.runCommand(context.Background(), exe.command)
+	if err != nil {
+		t.Fatalf("runCommand() returned error: %v", err)
+	}
+	want := `{"nvme0n1":"none","sdb":"mq-deadline","sdc":"unknown"}`
+	if got != want {
+		t.Errorf("runCommand() = %s, want %s", got, want)
+	}
+}
+
+// physicalDriveRemote answers the commands of PhysicalDriveFromPathRemote as if the file were on
+// /dev/sdb1 mounted at /var/opt/mssql.
+type physicalDriveRemote struct {
+	mockRemote
+	commands []string
+}
+
+func (m *physicalDriveRemote) Run(cmd string, session remote.SSHSessionInterface) (string, error) {
+	m.commands = append(m.commands, cmd)
+	switch {
+	case strings.HasPrefix(cmd, "sudo find "):
+		return "/var/opt/mssql/data/found.mdf\n", nil
+	case strings.HasPrefix(cmd, "sudo df "):
+		return "Mounted on\n/var/opt/mssql\n", nil
+	case cmd == "mount ":
+		return "/dev/sdb1 on /var/opt/mssql type xfs (rw)\n/dev/sda1 on / type ext4 (rw)\n", nil
+	case cmd == " grep sd":
+		return "/dev/sdb1 on /var/opt/mssql type xfs (rw)\n/dev/sda1 on / type ext4 (rw)\n", nil
+	default:
+		return "", fmt.Errorf("unexpected command %q", cmd)
+	}
+}
+
+func TestPhysicalDriveFromPathRemote(t *testing.T) {
+	testcases := []struct {
+		name     string
+		path     string
+		wantFind string
+	}{
+		{
+			name:     "plain path",
+			path:     "/var/opt/mssql/data/master.mdf",
+			wantFind: `sudo find '/var/opt/mssql/data/' -type f -iname 'master.mdf' -print`,
+		},
+		{
+			name:     "spaces",
+			path:     "/var/opt/mssql/my data/my db.mdf",
+			wantFind: `sudo find '/var/opt/mssql/my data/' -type f -iname 'my db.mdf' -print`,
+		},
+		{
+			name:     "quotes",
+			path:     `/var/opt/mssql/it's/"db".mdf`,
+			wantFind: `sudo find '/var/opt/mssql/it'\''s/' -type f -iname '"db".mdf' -print`,
+		},
+		{
+			name:     "glob characters",
+			path:     "/var/opt/mssql/data/db[1]*?.mdf",
+			wantFind: `sudo find '/var/opt/mssql/data/' -type f -iname 'db\[1\]\*\?.mdf' -print`,
+		},
+		{
+			name:     "shell metacharacters",
+			path:     "/var/opt/mssql/$(reboot)/a;b|c`d`.mdf",
+			wantFind: "sudo find '/var/opt/mssql/$(reboot)/' -type f -iname 'a;b|c`d`.mdf' -print",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &physicalDriveRemote{}
+			got, err := PhysicalDriveFromPathRemote(r, tc.path)
+			if err != nil {
+				t.Fatalf("PhysicalDriveFromPathRemote(%q) returned error: %v", tc.path, err)
+			}
+			if got != "sdb1" {
+				t.Errorf("PhysicalDriveFromPathRemote(%q) = %q, want %q", tc.path, got, "sdb1")
+			}
+			if len(r.commands) < 2 {
+				t.Fatalf("PhysicalDriveFromPathRemote(%q) ran %v, want find and df", tc.path, r.commands)
+			}
+			if r.commands[0] != tc.wantFind {
+				t.Errorf("PhysicalDriveFromPathRemote(%q) ran %q, want %q", tc.path, r.commands[0], tc.wantFind)
+			}
+			wantDf := `sudo df --output=target '/var/opt/mssql/data/found.mdf'`
+			if r.commands[1] != wantDf {
+				t.Errorf("PhysicalDriveFromPathRemote(%q) ran %q, want %q", tc.path, r.commands[1], wantDf)
+			}
+		})
+	}
+}
+
+func TestPhysicalDriveFromPathRemoteError(t *testing.T) {
+	if _, err := PhysicalDriveFromPathRemote(newMockRemote(false, true, false, ""), "/var/opt/mssql/data/master.mdf"); err == nil {
+		t.Error("PhysicalDriveFromPathRemote() returned nil error, want error")
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	testcases := []struct {
+		in   string
+		want string
+	}{
+		{in: "", want: "''"},
+		{in: "/var/opt/mssql/my db.mdf", want: `'/var/opt/mssql/my db.mdf'`},
+		{in: "it's", want: `'it'\''s'`},
+		{in: "$(reboot);`id`|x", want: "'$(reboot);`id`|x'"},
+	}
+	for _, tc := range testcases {
+		if got := shellQuote(tc.in); got != tc.want {
+			t.Errorf("shellQuote(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+// slowLocalSSDRemote holds the lshw command of the LocalSSDRule until the rules collected in
+// parallel after it have started, so it completes after its timeout while they run.
+type slowLocalSSDRemote struct {
+	*mockRemote
+	release chan struct{}
+	once    sync.Once
+}
+
+func (m *slowLocalSSDRemote) Run(cmd string, session remote.SSHSessionInterface) (string, error) {
+	if cmd == localSSDCommand {
+		<-m.release
+	} else if strings.HasPrefix(cmd, dataDiskAllocationUnitsCommand) || strings.HasSuffix(cmd, "/queue/scheduler") {
+		m.once.Do(func() { close(m.release) })
+		time.Sleep(10 * time.Millisecond)
+	}
+	return m.mockRemote.Run(cmd, session)
+}
+
+func TestCollectLinuxGuestRulesRemoteLocalSSDTimeout(t *testing.T) {
+	collector := NewLinuxCollector(nil, "", "", "", nil, true, 22, fakeUsageMetricsLogger)
+	collector.physicalDriveToDiskMap = map[string]string{"sdb": internal.PersistentSSD.String()}
+	collector.remoteRunner = &slowLocalSSDRemote{
+		mockRemote: newMockRemote(false, false, false, ""),
+		release:    make(chan struct{}),
+	}
+
+	got := collector.CollectGuestRules(context.Background(), 50*time.Millisecond)
+	if got.Fields[0][internal.IOSchedulerRule] == "" {
+		t.Errorf("CollectGuestRules() returned no %s field, want a value", internal.IOSchedulerRule)
+	}
+
+	// Wait for the timed out LocalSSDRule to add its drive, so it does not outlive the test.
+	deadline := time.Now().Add(10 * time.Second)
+	for len(collector.physicalDrives()) < 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("physicalDrives() = %v, want the drive found by the timed out LocalSSDRule", collector.physicalDrives())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestCollectLinuxGuestRulesLocalDiskTypeTimeout(t *testing.T) {
+	release := make(chan struct{})
+	returned := make(chan struct{})
+	var once sync.Once
+	defer func(f func(string) (string, error)) { symLinkCommand = f }(symLinkCommand)
+	symLinkCommand = func(path string) (string, error) {
+		defer close(returned)
+		<-release
+		return "/dev/sdb", nil
+	}
+	defer func(f commandlineexecutor.Execute) { ioSchedulerExecute = f }(ioSchedulerExecute)
+	ioSchedulerExecute = func(ctx context.Context, params commandlineexecutor.Params) commandlineexecutor.Result {
+		once.Do(func() { close(release) })
+		time.Sleep(10 * time.Millisecond)
+		return commandlineexecutor.Result{StdOut: "[none] mq-deadline\n"}
+	}
+	collector := NewLinuxCollector([]*instanceinfo.Disks{
+		&instanceinfo.Disks{DeviceName: "data", Mapping: "sdb"},
+	}, "", "", "", nil, false, 22, fakeUsageMetricsLogger)
+	collector.guestRuleCommandMap = map[string]commandExecutor{
+		internal.IOSchedulerRule: collector.guestRuleCommandMap[internal.IOSchedulerRule],
+	}
+
+	got := collector.CollectGuestRules(context.Background(), 50*time.Millisecond)
+	if _, ok := got.Fields[0][internal.LocalSSDRule]; ok {
+		t.Errorf("CollectGuestRules() returned %s field %q after DiskToDiskType timed out, want none", internal.LocalSSDRule, got.Fields[0][internal.LocalSSDRule])
+	}
+	<-returned
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package guestcollector
+
+import (
+	"strings"
+)
+
+// unsyncedTimeSources are the sources w32tm reports when the clock is not synchronized to a time server.
+var unsyncedTimeSources = map[string]bool{
+	"local cmos clock":          true,
+	"free-running system clock": true,
+}
+
+// TimeSyncStatus is the time synchronization status of a windows machine.
+type TimeSyncStatus struct {
+	// Source is the time source the clock synchronizes to, e.g. metadata.google.internal.
+	Source string
+	// Healthy is "true" if the clock is synchronized to the source, "false" if not, and "unknown"
+	// if the status could not be determined.
+	Healthy string
+}
+
+// ParseW32tmStatus parses the output of "w32tm /query /status". The clock is not synchronized if
+// the leap indicator is 3 or the source is the local clock.
+func ParseW32tmStatus(output string) TimeSyncStatus {
+	status := TimeSyncStatus{Source: "unknown", Healthy: "unknown"}
+	leapIndicator := ""
+	for _, line := range strings.Split(output, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.TrimSpace(key) {
+		case "Leap Indicator":
+			leapIndicator = value
+		case "Source":
+			// The flags of the NTP server are appended to its name, e.g. time.windows.com,0x9.
+			if source, _, _ := strings.Cut(value, ",0x"); source != "" {
+				status.Source = source
+			}
+		}
+	}
+	if leapIndicator == "" || status.Source == "unknown" {
+		return status
+	}
+	status.Healthy = "true"
+	if strings.HasPrefix(leapIndicator, "3") || unsyncedTimeSources[strings.ToLower(status.Source)] {
+		status.Healthy = "false"
+	}
+	return status
+}
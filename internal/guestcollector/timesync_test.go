@@ -0,0 +1,75 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package guestcollector
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const w32tmStatusSynced = `Leap Indicator: 0(no warning)
+Stratum: 4 (secondary reference - syncd by (S)NTP)
+Precision: -23 (119.209ns per tick)
+Root Delay: 0.0312500s
+Root Dispersion: 7.7756691s
+ReferenceId: 0xA9FEA9FE (source IP:  169.254.169.254)
+Last Successful Sync Time: 10/16/2026 10:00:00 AM
+Source: metadata.google.internal,0x1
+Poll Interval: 10 (1024s)
+`
+
+func TestParseW32tmStatus(t *testing.T) {
+	testcases := []struct {
+		name   string
+		output string
+		want   TimeSyncStatus
+	}{
+		{
+			name:   "synchronized to an ntp server",
+			output: w32tmStatusSynced,
+			want:   TimeSyncStatus{Source: "metadata.google.internal", Healthy: "true"},
+		},
+		{
+			name: "not synchronized",
+			output: `Leap Indicator: 3(not synchronized)
+Stratum: 0 (unspecified)
+Source: time.windows.com,0x9
+`,
+			want: TimeSyncStatus{Source: "time.windows.com", Healthy: "false"},
+		},
+		{
+			name: "local clock",
+			output: "Leap Indicator: 0(no warning)\r\n" +
+				"Stratum: 1 (primary reference - syncd by radio clock)\r\n" +
+				"Source: Local CMOS Clock\r\n",
+			want: TimeSyncStatus{Source: "Local CMOS Clock", Healthy: "false"},
+		},
+		{
+			name:   "unexpected output",
+			output: "The following error occurred: The service has not been started. (0x80070426)",
+			want:   TimeSyncStatus{Source: "unknown", Healthy: "unknown"},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, ParseW32tmStatus(tc.output)); diff != "" {
+				t.Errorf("ParseW32tmStatus() returned wrong result (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package guestcollector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/workloadagentplatform/integration/common/shared/commandlineexecutor"
+)
+
+// WindowsTransport is the transport used to run the WMI queries of remote windows guest collection.
+type WindowsTransport int
+
+const (
+	// WMITransport runs the WMI queries over DCOM.
+	WMITransport WindowsTransport = iota
+	// WinRMHTTPTransport runs the WMI queries on the remote instance through WinRM over HTTP (port 5985).
+	WinRMHTTPTransport
+	// WinRMHTTPSTransport runs the WMI queries on the remote instance through WinRM over HTTPS (port 5986).
+	WinRMHTTPSTransport
+)
+
+// winRMScript runs a WMI query on the remote instance through PowerShell remoting and writes the
+// properties of the result objects as a JSON array. The password is read from stdin so it does not
+// appear on the command line.
+const winRMScript = `$ErrorActionPreference = 'Stop'
+$password = ConvertTo-SecureString ([Console]::In.ReadLine()) -AsPlainText -Force
+$credential = New-Object System.Management.Automation.PSCredential(%s, $password)
+Invoke-Command -ComputerName %s -Port %d %s-Credential $credential -ArgumentList %s, %s -ScriptBlock {
+	param($namespace, $query)
+	$result = @(Get-WmiObject -Namespace $namespace -Query $query | ForEach-Object {
+		$properties = @{}
+		foreach ($p in $_.Properties) { $properties[$p.Name] = $p.Value }
+		$properties
+	})
+	ConvertTo-Json -InputObject $result -Compress
+}`
+
+// ParseWindowsTransport returns the WindowsTransport of the transport name set in the configuration.
+// An empty name defaults to WMITransport.
+func ParseWindowsTransport(name string) (WindowsTransport, error) {
+	switch strings.ToUpper(name) {
+	case "", "WMI":
+		return WMITransport, nil
+	case "WINRM_HTTP":
+		return WinRMHTTPTransport, nil
+	case "WINRM_HTTPS":
+		return WinRMHTTPSTransport, nil
+	}
+	return WMITransport, fmt.Errorf("unsupported windows transport %q", name)
+}
+
+// winRMParams returns the params of the powershell command running query in namespace on host.
+func winRMParams(transport WindowsTransport, host, username, password, namespace, query string) commandlineexecutor.Params {
+	port, useSSL := 5985, ""
+	if transport == WinRMHTTPSTransport {
+		port, useSSL = 5986, "-UseSSL "
+	}
+	script := fmt.Sprintf(winRMScript, quotePowerShell(username), quotePowerShell(host), port, useSSL, quotePowerShell(namespace), quotePowerShell(query))
+	return commandlineexecutor.Params{
+		Executable: "powershell",
+		Args:       []string{"-NoProfile", "-NonInteractive", "-Command", script},
+		Stdin:      password + "\n",
+	}
+}
+
+// queryWinRM runs query in namespace on host through WinRM and unmarshals the result objects to dst,
+// which must be a pointer to a slice of structs as used by wmi.Query.
+func queryWinRM(ctx context.Context, exec commandlineexecutor.Execute, transport WindowsTransport, host, username, password, namespace, query string, dst any) error {
+	result := exec(ctx, winRMParams(transport, host, username, password, namespace, query))
+	if result.Error != nil {
+		return fmt.Errorf("failed to run %q on %s through WinRM: %v, stderr: %s", query, host, result.Error, result.StdErr)
+	}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(result.StdOut)), dst); err != nil {
+		return fmt.Errorf("failed to parse the result of %q on %s through WinRM: %v", query, host, err)
+	}
+	return nil
+}
+
+// quotePowerShell returns s as a single quoted PowerShell string literal.
+func quotePowerShell(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package guestcollector
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/GoogleCloudPlatform/workloadagentplatform/integration/common/shared/commandlineexecutor"
+)
+
+func TestParseWindowsTransport(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    WindowsTransport
+		wantErr bool
+	}{
+		{name: "", want: WMITransport},
+		{name: "WMI", want: WMITransport},
+		{name: "WINRM_HTTP", want: WinRMHTTPTransport},
+		{name: "winrm_https", want: WinRMHTTPSTransport},
+		{name: "SSH", want: WMITransport, wantErr: true},
+	}
+	for _, tc := range tests {
+		got, err := ParseWindowsTransport(tc.name)
+		if gotErr := err != nil; gotErr != tc.wantErr {
+			t.Errorf("ParseWindowsTransport(%q) returned error: %v, want error: %v", tc.name, err, tc.wantErr)
+		}
+		if got != tc.want {
+			t.Errorf("ParseWindowsTransport(%q) = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestWinRMParams(t *testing.T) {
+	tests := []struct {
+		name       string
+		transport  WindowsTransport
+		wantInArgs []string
+		notInArgs  []string
+	}{
+		{
+			name:       "http",
+			transport:  WinRMHTTPTransport,
+			wantInArgs: []string{"-ComputerName 'test-host' -Port 5985 -Credential", `PSCredential('dom\o''brien', $password)`},
+			notInArgs:  []string{"-UseSSL", "test-password"},
+		},
+		{
+			name:       "https",
+			transport:  WinRMHTTPSTransport,
+			wantInArgs: []string{"-ComputerName 'test-host' -Port 5986 -UseSSL -Credential"},
+			notInArgs:  []string{"test-password"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := winRMParams(tc.transport, "test-host", `dom\o'brien`, "test-password", `root\cimv2`, `SELECT caption FROM Win32_Process WHERE Name="udsagent.exe"`)
+			if got.Executable != "powershell" {
+				t.Errorf("winRMParams() executable = %q, want %q", got.Executable, "powershell")
+			}
+			if got.Stdin != "test-password\n" {
+				t.Errorf("winRMParams() stdin = %q, want the password", got.Stdin)
+			}
+			args := strings.Join(got.Args, " ")
+			want := append(tc.wantInArgs, `-ArgumentList 'root\cimv2', 'SELECT caption FROM Win32_Process WHERE Name="udsagent.exe"'`)
+			for _, w := range want {
+				if !strings.Contains(args, w) {
+					t.Errorf("winRMParams() args = %q, want to contain %q", args, w)
+				}
+			}
+			for _, n := range tc.notInArgs {
+				if strings.Contains(args, n) {
+					t.Errorf("winRMParams() args = %q, want not to contain %q", args, n)
+				}
+			}
+		})
+	}
+}
+
+func TestQueryWinRM(t *testing.T) {
+	type volume struct {
+		BlockSize int64
+		Caption   string
+	}
+	tests := []struct {
+		name    string
+		result  commandlineexecutor.Result
+		want    []volume
+		wantErr bool
+	}{
+		{
+			name:   "success",
+			result: commandlineexecutor.Result{StdOut: `[{"BlockSize":4096,"Caption":"C:\\"},{"blocksize":1024,"caption":"D:\\"}]` + "\r\n"},
+			want:   []volume{{BlockSize: 4096, Caption: `C:\`}, {BlockSize: 1024, Caption: `D:\`}},
+		},
+		{
+			name:   "no result",
+			result: commandlineexecutor.Result{StdOut: "[]"},
+			want:   []volume{},
+		},
+		{
+			name:    "command failure",
+			result:  commandlineexecutor.Result{Error: fmt.Errorf("exit status 1"), StdErr: "WinRM cannot complete the operation"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid output",
+			result:  commandlineexecutor.Result{StdOut: "not json"},
+			wantErr: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			exec := func(ctx context.Context, params commandlineexecutor.Params) commandlineexecutor.Result {
+				return tc.result
+			}
+			var got []volume
+			err := queryWinRM(context.Background(), exec, WinRMHTTPSTransport, "test-host", "test-user", "test-password", `root\cimv2`, `SELECT caption, blocksize FROM win32_volume`, &got)
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Fatalf("queryWinRM() returned error: %v, want error: %v", err, tc.wantErr)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("queryWinRM() returned diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -19,10 +19,14 @@ package instanceinfo
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"strings"
 
+	backoff "github.com/cenkalti/backoff/v4"
 	compute "google.golang.org/api/compute/v1"
+	"google.golang.org/api/googleapi"
 	"github.com/GoogleCloudPlatform/sql-server-agent/internal"
 )
 
@@ -61,7 +65,10 @@ func New(gceService gceInterface) *Reader {
 func (r *Reader) AllDisks(ctx context.Context, projectID, zone, instanceID string) ([]*Disks, error) {
 	instance, err := r.gceService.GetInstance(projectID, zone, instanceID)
 	if err != nil {
-		return nil, fmt.Errorf("missing Compute Viewer IAM role for the Service Account. project %v, zone %v, instanceId %v", projectID, zone, instanceID)
+		if permanentError(err) {
+			return nil, fmt.Errorf("missing Compute Viewer IAM role for the Service Account. project %v, zone %v, instanceId %v: %w", projectID, zone, instanceID, err)
+		}
+		return nil, fmt.Errorf("failed to get the compute instance. project %v, zone %v, instanceId %v: %w", projectID, zone, instanceID, err)
 	}
 	allDisks := make([]*Disks, 0)
 	for _, disks := range instance.Disks {
@@ -72,6 +79,38 @@ func (r *Reader) AllDisks(ctx context.Context, projectID, zone, instanceID strin
 	return allDisks, nil
 }
 
+// AllDisksWithRetry returns all possible disks like AllDisks, retrying transient compute API errors
+// with the backoff policy b until ctx is done. Permission errors fail without retries.
+func (r *Reader) AllDisksWithRetry(ctx context.Context, projectID, zone, instanceID string, b backoff.BackOff) ([]*Disks, error) {
+	var disks []*Disks
+	err := backoff.Retry(func() error {
+		var err error
+		disks, err = r.AllDisks(ctx, projectID, zone, instanceID)
+		if err != nil && permanentError(err) {
+			return backoff.Permanent(err)
+		}
+		return err
+	}, backoff.WithContext(b, ctx))
+	if err != nil {
+		return nil, err
+	}
+	return disks, nil
+}
+
+// permanentError reports whether err is a compute API error which does not succeed on retries,
+// such as missing permissions or a nonexistent instance.
+func permanentError(err error) bool {
+	var gErr *googleapi.Error
+	if !errors.As(err, &gErr) {
+		return false
+	}
+	switch gErr.Code {
+	case http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound:
+		return true
+	}
+	return false
+}
+
 // DeviceType returns a formatted device type for a given disk type and name.
 // The returned device type will be formatted as: "LOCAL-SSD" or "PERSISTENT-SSD". "OTHER" if another disk type
 func DeviceType(diskType string) string {
@@ -18,10 +18,14 @@ package instanceinfo
 
 import (
 	"context"
+	"errors"
+	"net/http"
 	"testing"
 
+	backoff "github.com/cenkalti/backoff/v4"
 	"github.com/google/go-cmp/cmp"
 	compute "google.golang.org/api/compute/v1"
+	"google.golang.org/api/googleapi"
 	"github.com/GoogleCloudPlatform/workloadagentplatform/integration/common/shared/gce/fake"
 )
 
@@ -151,3 +155,95 @@ func TestAllDisks(t *testing.T) {
 		}
 	}
 }
+
+// countingGCE counts the GetInstance calls of the fake compute service.
+type countingGCE struct {
+	*fake.TestGCE
+	calls int
+}
+
+func (c *countingGCE) GetInstance(project, zone, instance string) (*compute.Instance, error) {
+	c.calls++
+	return c.TestGCE.GetInstance(project, zone, instance)
+}
+
+func TestAllDisksWithRetry(t *testing.T) {
+	instance := &compute.Instance{
+		Disks: []*compute.AttachedDisk{
+			{
+				DeviceName: "disk-device-name",
+				Type:       "PERSISTENT",
+			},
+		},
+	}
+	unavailable := &googleapi.Error{Code: http.StatusServiceUnavailable}
+	tests := []struct {
+		name      string
+		resp      []*compute.Instance
+		errs      []error
+		want      []*Disks
+		wantErr   bool
+		wantCalls int
+	}{
+		{
+			name:      "success",
+			resp:      []*compute.Instance{instance},
+			errs:      []error{nil},
+			want:      []*Disks{{DeviceName: "disk-device-name", DiskType: "PERSISTENT-SSD"}},
+			wantCalls: 1,
+		},
+		{
+			name:      "transient errors then success",
+			resp:      []*compute.Instance{nil, nil, instance},
+			errs:      []error{unavailable, errors.New("connection reset by peer"), nil},
+			want:      []*Disks{{DeviceName: "disk-device-name", DiskType: "PERSISTENT-SSD"}},
+			wantCalls: 3,
+		},
+		{
+			name:      "permission error fails fast",
+			resp:      []*compute.Instance{nil, instance},
+			errs:      []error{&googleapi.Error{Code: http.StatusForbidden}, nil},
+			wantErr:   true,
+			wantCalls: 1,
+		},
+		{
+			name:      "transient errors exceed max retries",
+			resp:      []*compute.Instance{nil},
+			errs:      []error{unavailable},
+			wantErr:   true,
+			wantCalls: 4,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			gceService := &countingGCE{TestGCE: &fake.TestGCE{GetInstanceResp: tc.resp, GetInstanceErr: tc.errs}}
+			r := NewReader(gceService)
+			got, err := r.AllDisksWithRetry(context.Background(), "test-project", "test-zone", "test-instance", backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 3))
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Errorf("AllDisksWithRetry() returned error: %v, want error: %v", err, tc.wantErr)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("AllDisksWithRetry() returned an unexpected diff (-want +got): %v", diff)
+			}
+			if gceService.calls != tc.wantCalls {
+				t.Errorf("AllDisksWithRetry() called GetInstance %d times, want %d", gceService.calls, tc.wantCalls)
+			}
+		})
+	}
+}
+
+func TestAllDisksWithRetryCancelledContext(t *testing.T) {
+	gceService := &countingGCE{TestGCE: &fake.TestGCE{
+		GetInstanceResp: []*compute.Instance{nil},
+		GetInstanceErr:  []error{&googleapi.Error{Code: http.StatusServiceUnavailable}},
+	}}
+	r := NewReader(gceService)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := r.AllDisksWithRetry(ctx, "test-project", "test-zone", "test-instance", backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 3)); err == nil {
+		t.Errorf("AllDisksWithRetry() with cancelled context returned no error, want error")
+	}
+	if gceService.calls > 1 {
+		t.Errorf("AllDisksWithRetry() with cancelled context called GetInstance %d times, want at most 1", gceService.calls)
+	}
+}
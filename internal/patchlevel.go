@@ -0,0 +1,194 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cumulativeUpdate is a release of SQL Server, identified by its product version.
+type cumulativeUpdate struct {
+	version  string
+	released string
+}
+
+// cumulativeUpdates are releases of each major version of SQL Server, in ascending order. The last
+// release of a major version is the latest one the agent knows. To update the table, append the
+// new cumulative updates with their product version and release date from
+// https://learn.microsoft.com/en-us/troubleshoot/sql/releases/download-and-install-latest-updates
+var cumulativeUpdates = map[int][]cumulativeUpdate{
+	// SQL Server 2016
+	13: {
+		{version: "13.0.1601.5", released: "2016-06-01"},
+		{version: "13.0.4001.0", released: "2016-11-16"},
+		{version: "13.0.5026.0", released: "2018-04-24"},
+		{version: "13.0.6300.2", released: "2021-09-15"},
+	},
+	// SQL Server 2017
+	14: {
+		{version: "14.0.1000.169", released: "2017-10-02"},
+		{version: "14.0.3223.3", released: "2019-07-29"},
+		{version: "14.0.3381.3", released: "2021-02-10"},
+		{version: "14.0.3456.2", released: "2022-09-20"},
+	},
+	// SQL Server 2019
+	15: {
+		{version: "15.0.2000.5", released: "2019-11-04"},
+		{version: "15.0.4073.23", released: "2021-04-06"},
+		{version: "15.0.4198.2", released: "2022-01-27"},
+		{version: "15.0.4312.2", released: "2023-04-13"},
+		{version: "15.0.4355.3", released: "2024-02-15"},
+		{version: "15.0.4405.4", released: "2024-10-31"},
+	},
+	// SQL Server 2022
+	16: {
+		{version: "16.0.1000.6", released: "2022-11-16"},
+		{version: "16.0.4003.1", released: "2023-02-16"},
+		{version: "16.0.4035.4", released: "2023-05-11"},
+		{version: "16.0.4065.3", released: "2023-08-10"},
+		{version: "16.0.4095.4", released: "2023-11-16"},
+		{version: "16.0.4115.5", released: "2024-03-14"},
+		{version: "16.0.4135.4", released: "2024-07-23"},
+		{version: "16.0.4165.4", released: "2024-11-14"},
+		{version: "16.0.4175.1", released: "2025-01-16"},
+	},
+}
+
+// staleAfterMonths is the number of months an instance may be behind the latest release of its
+// major version before its patch level is stale. Cumulative updates are released every two months.
+const staleAfterMonths = 6
+
+// AddPatchLevel appends the INSTANCE_PATCH_LEVEL details derived from the product version in
+// INSTANCE_METRICS. It flags the instances running a build released more than six months before the
+// latest release of their major version.
+func AddPatchLevel(details []Details) []Details {
+	var fields []map[string]string
+	for _, detail := range details {
+		if detail.Name != "INSTANCE_METRICS" {
+			continue
+		}
+		for _, field := range detail.Fields {
+			fields = append(fields, patchLevelFields(field["product_version"]))
+		}
+	}
+	if len(fields) == 0 {
+		return details
+	}
+	return append(details, Details{Name: "INSTANCE_PATCH_LEVEL", Fields: fields})
+}
+
+// patchLevelFields returns the patch level of an instance of the given product version.
+func patchLevelFields(productVersion string) map[string]string {
+	res := map[string]string{
+		"product_version":      productVersion,
+		"build_number":         "unknown",
+		"latest_build_number":  "unknown",
+		"months_behind_latest": "unknown",
+		"patch_level_stale":    "unknown",
+	}
+	version, ok := parseProductVersion(productVersion)
+	if !ok {
+		return res
+	}
+	res["build_number"] = strconv.Itoa(version[2])
+	months, latest, ok := monthsBehindLatest(version)
+	if !ok {
+		return res
+	}
+	res["latest_build_number"] = strconv.Itoa(latest[2])
+	res["months_behind_latest"] = strconv.Itoa(months)
+	res["patch_level_stale"] = strconv.FormatBool(months > staleAfterMonths)
+	return res
+}
+
+// monthsBehindLatest returns the number of months between the release of the given version and the
+// latest release of its major version, with the latest version. Returns false if the major version
+// is not in the table or the version predates all its releases.
+func monthsBehindLatest(version [4]int) (int, [4]int, bool) {
+	updates := cumulativeUpdates[version[0]]
+	if len(updates) == 0 {
+		return 0, [4]int{}, false
+	}
+	latest := updates[len(updates)-1]
+	latestVersion, _ := parseProductVersion(latest.version)
+	// The version runs the last release at or below its build, e.g. a security update on top of it.
+	var current *cumulativeUpdate
+	for i := range updates {
+		v, _ := parseProductVersion(updates[i].version)
+		if compareVersions(v, version) > 0 {
+			break
+		}
+		current = &updates[i]
+	}
+	if current == nil {
+		return 0, [4]int{}, false
+	}
+	currentReleased, err := time.Parse(time.DateOnly, current.released)
+	if err != nil {
+		return 0, [4]int{}, false
+	}
+	latestReleased, err := time.Parse(time.DateOnly, latest.released)
+	if err != nil {
+		return 0, [4]int{}, false
+	}
+	return monthsBetween(currentReleased, latestReleased), latestVersion, true
+}
+
+// monthsBetween returns the number of whole months from start to end.
+func monthsBetween(start, end time.Time) int {
+	months := (end.Year()-start.Year())*12 + int(end.Month()) - int(start.Month())
+	if end.Day() < start.Day() {
+		months--
+	}
+	if months < 0 {
+		return 0
+	}
+	return months
+}
+
+// parseProductVersion parses a product version, e.g. 16.0.4135.4, into its major, minor, build and
+// revision numbers.
+func parseProductVersion(productVersion string) ([4]int, bool) {
+	var version [4]int
+	parts := strings.Split(strings.TrimSpace(productVersion), ".")
+	if len(parts) != len(version) {
+		return version, false
+	}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return version, false
+		}
+		version[i] = n
+	}
+	return version, true
+}
+
+// compareVersions returns -1, 0 or 1 if a is lower than, equal to or higher than b.
+func compareVersions(a, b [4]int) int {
+	for i := range a {
+		switch {
+		case a[i] < b[i]:
+			return -1
+		case a[i] > b[i]:
+			return 1
+		}
+	}
+	return 0
+}
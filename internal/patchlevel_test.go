@@ -0,0 +1,174 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestAddPatchLevel(t *testing.T) {
+	testcases := []struct {
+		name    string
+		version string
+		want    map[string]string
+	}{
+		{
+			name:    "latest release",
+			version: "16.0.4175.1",
+			want: map[string]string{
+				"product_version":      "16.0.4175.1",
+				"build_number":         "4175",
+				"latest_build_number":  "4175",
+				"months_behind_latest": "0",
+				"patch_level_stale":    "false",
+			},
+		},
+		{
+			name:    "release within six months of the latest",
+			version: "16.0.4135.4",
+			want: map[string]string{
+				"product_version":      "16.0.4135.4",
+				"build_number":         "4135",
+				"latest_build_number":  "4175",
+				"months_behind_latest": "5",
+				"patch_level_stale":    "false",
+			},
+		},
+		{
+			name:    "security update on top of a release",
+			version: "16.0.4140.3",
+			want: map[string]string{
+				"product_version":      "16.0.4140.3",
+				"build_number":         "4140",
+				"latest_build_number":  "4175",
+				"months_behind_latest": "5",
+				"patch_level_stale":    "false",
+			},
+		},
+		{
+			name:    "stale release",
+			version: "15.0.4312.2",
+			want: map[string]string{
+				"product_version":      "15.0.4312.2",
+				"build_number":         "4312",
+				"latest_build_number":  "4405",
+				"months_behind_latest": "18",
+				"patch_level_stale":    "true",
+			},
+		},
+		{
+			name:    "major version not in the table",
+			version: "12.0.6024.0",
+			want: map[string]string{
+				"product_version":      "12.0.6024.0",
+				"build_number":         "6024",
+				"latest_build_number":  "unknown",
+				"months_behind_latest": "unknown",
+				"patch_level_stale":    "unknown",
+			},
+		},
+		{
+			name:    "build older than all releases",
+			version: "16.0.900.0",
+			want: map[string]string{
+				"product_version":      "16.0.900.0",
+				"build_number":         "900",
+				"latest_build_number":  "unknown",
+				"months_behind_latest": "unknown",
+				"patch_level_stale":    "unknown",
+			},
+		},
+		{
+			name:    "unknown version",
+			version: "unknown",
+			want: map[string]string{
+				"product_version":      "unknown",
+				"build_number":         "unknown",
+				"latest_build_number":  "unknown",
+				"months_behind_latest": "unknown",
+				"patch_level_stale":    "unknown",
+			},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			metrics := Details{Name: "INSTANCE_METRICS", Fields: []map[string]string{{"product_version": tc.version}}}
+			want := []Details{metrics, {Name: "INSTANCE_PATCH_LEVEL", Fields: []map[string]string{tc.want}}}
+			if diff := cmp.Diff(want, AddPatchLevel([]Details{metrics})); diff != "" {
+				t.Errorf("AddPatchLevel() returned an unexpected diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAddPatchLevelWithoutInstanceMetrics(t *testing.T) {
+	details := []Details{{Name: "DB_LOG_DISK_SEPARATION", Fields: []map[string]string{{"db_name": "db1"}}}}
+	if diff := cmp.Diff(details, AddPatchLevel(details)); diff != "" {
+		t.Errorf("AddPatchLevel() returned an unexpected diff (-want +got):\n%s", diff)
+	}
+}
+
+// TestCumulativeUpdates verifies the table stays valid as releases are added.
+func TestCumulativeUpdates(t *testing.T) {
+	for major, updates := range cumulativeUpdates {
+		var previous [4]int
+		var previousReleased time.Time
+		for i, u := range updates {
+			v, ok := parseProductVersion(u.version)
+			if !ok {
+				t.Errorf("release %q of major version %d is not a product version", u.version, major)
+				continue
+			}
+			if v[0] != major {
+				t.Errorf("release %q is listed in major version %d", u.version, major)
+			}
+			released, err := time.Parse(time.DateOnly, u.released)
+			if err != nil {
+				t.Errorf("release %q has an invalid release date %q: %v", u.version, u.released, err)
+				continue
+			}
+			if i > 0 && (compareVersions(previous, v) >= 0 || released.Before(previousReleased)) {
+				t.Errorf("release %q of major version %d is not in ascending order", u.version, major)
+			}
+			previous, previousReleased = v, released
+		}
+	}
+}
+
+func TestMonthsBetween(t *testing.T) {
+	testcases := []struct {
+		start string
+		end   string
+		want  int
+	}{
+		{start: "2024-01-15", end: "2024-01-20", want: 0},
+		{start: "2024-01-15", end: "2024-02-14", want: 0},
+		{start: "2024-01-15", end: "2024-02-15", want: 1},
+		{start: "2023-11-30", end: "2024-05-01", want: 5},
+		{start: "2024-05-01", end: "2024-01-01", want: 0},
+	}
+	for _, tc := range testcases {
+		start, _ := time.Parse(time.DateOnly, tc.start)
+		end, _ := time.Parse(time.DateOnly, tc.end)
+		if got := monthsBetween(start, end); got != tc.want {
+			t.Errorf("monthsBetween(%s, %s) = %d, want %d", tc.start, tc.end, got, tc.want)
+		}
+	}
+}
@@ -24,6 +24,7 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -55,14 +56,85 @@ type Executor interface {
 	Close() error
 }
 
+// Algorithms are the key exchange, cipher and MAC algorithms allowed in the ssh connections to
+// remote machines, in preference order.
+type Algorithms struct {
+	KeyExchanges []string
+	Ciphers      []string
+	MACs         []string
+}
+
+// DefaultAlgorithms are the algorithms allowed by default, which exclude the SHA-1 key exchanges,
+// the CBC and RC4 ciphers and the SHA-1 MACs flagged as weak by security audits.
+var DefaultAlgorithms = Algorithms{
+	KeyExchanges: []string{
+		"curve25519-sha256", "curve25519-sha256@libssh.org",
+		"ecdh-sha2-nistp256", "ecdh-sha2-nistp384", "ecdh-sha2-nistp521",
+		"diffie-hellman-group14-sha256", "diffie-hellman-group16-sha512",
+	},
+	Ciphers: []string{
+		"aes128-gcm@openssh.com", "aes256-gcm@openssh.com", "chacha20-poly1305@openssh.com",
+		"aes128-ctr", "aes192-ctr", "aes256-ctr",
+	},
+	MACs: []string{
+		"hmac-sha2-256-etm@openssh.com", "hmac-sha2-512-etm@openssh.com", "hmac-sha2-256", "hmac-sha2-512",
+	},
+}
+
+// supportedAlgorithms are all the algorithms supported by the ssh package.
+var supportedAlgorithms = Algorithms{
+	KeyExchanges: append([]string{"diffie-hellman-group14-sha1", "diffie-hellman-group1-sha1"}, DefaultAlgorithms.KeyExchanges...),
+	Ciphers:      append([]string{"arcfour256", "arcfour128", "arcfour", "aes128-cbc", "3des-cbc"}, DefaultAlgorithms.Ciphers...),
+	MACs:         append([]string{"hmac-sha1", "hmac-sha1-96"}, DefaultAlgorithms.MACs...),
+}
+
+// algorithms are the algorithms allowed in the ssh connections, set by SetAlgorithms.
+var algorithms = DefaultAlgorithms
+
+// SetAlgorithms sets the algorithms allowed in the ssh connections. Empty lists keep the default
+// algorithms. Returns error and keeps the current algorithms if an algorithm is not supported.
+func SetAlgorithms(a Algorithms) error {
+	if err := checkAlgorithms("key exchange", a.KeyExchanges, supportedAlgorithms.KeyExchanges); err != nil {
+		return err
+	}
+	if err := checkAlgorithms("cipher", a.Ciphers, supportedAlgorithms.Ciphers); err != nil {
+		return err
+	}
+	if err := checkAlgorithms("MAC", a.MACs, supportedAlgorithms.MACs); err != nil {
+		return err
+	}
+	algorithms = DefaultAlgorithms
+	if len(a.KeyExchanges) > 0 {
+		algorithms.KeyExchanges = a.KeyExchanges
+	}
+	if len(a.Ciphers) > 0 {
+		algorithms.Ciphers = a.Ciphers
+	}
+	if len(a.MACs) > 0 {
+		algorithms.MACs = a.MACs
+	}
+	return nil
+}
+
+// checkAlgorithms returns error if one of the configured algorithms of the kind is not supported.
+func checkAlgorithms(kind string, configured, supported []string) error {
+	for _, algo := range configured {
+		if !slices.Contains(supported, algo) {
+			return fmt.Errorf("unsupported ssh %s algorithm %q", kind, algo)
+		}
+	}
+	return nil
+}
+
 // remote contains the key for remote ssh'ing
 type remote struct {
-	user               string
-	ip                 string
-	port               int32
-	key                *key
-	client             SSHClientInterface
-	usageMetricsLogger agentstatus.AgentStatus
+	user                string
+	ip                  string
+	port                int32
+	key                 *key
+	hostKeyFingerprints []string
+	client              SSHClientInterface
+	usageMetricsLogger  agentstatus.AgentStatus
 }
 
 type key struct {
@@ -71,22 +143,29 @@ type key struct {
 	knownHostsPath string
 }
 
-// NewRemote attempts to find connect to remote ssh server with private key
-func NewRemote(ipaddr, user string, port int32, usageMetricsLogger agentstatus.AgentStatus) Executor {
+// NewRemote attempts to find connect to remote ssh server with private key.
+// If hostKeyFingerprints is not empty, the key of the server must match one of the SHA256
+// fingerprints instead of the key of the host in the known_hosts file.
+func NewRemote(ipaddr, user string, port int32, hostKeyFingerprints []string, usageMetricsLogger agentstatus.AgentStatus) Executor {
 	return &remote{
-		ip:                 ipaddr,
-		port:               port,
-		user:               user,
-		key:                &key{},
-		usageMetricsLogger: usageMetricsLogger,
+		ip:                  ipaddr,
+		port:                port,
+		user:                user,
+		key:                 &key{},
+		hostKeyFingerprints: hostKeyFingerprints,
+		usageMetricsLogger:  usageMetricsLogger,
 	}
 }
 
 // SetupKeys load the key from given path and returns error if it failed to read the key file.
+// The known_hosts file is not read if host key fingerprints are configured.
 func (r *remote) SetupKeys(privateKeyPath string) error {
 	if err := r.privateKey(privateKeyPath); err != nil {
 		return err
 	}
+	if len(r.hostKeyFingerprints) > 0 {
+		return nil
+	}
 	knownHostsPath := filepath.Join(filepath.Dir(privateKeyPath), "known_hosts")
 	if err := r.publicKey(r.ip, knownHostsPath); err != nil {
 		return err
@@ -144,19 +223,13 @@ func (r *remote) publicKey(host, knownHostsPath string) error {
 
 // CreateClient creates ssh client based on private key and public key from Remote struct.
 func (r *remote) CreateClient() error {
-	if r.key.PublicKey == nil {
+	if r.key.PublicKey == nil && len(r.hostKeyFingerprints) == 0 {
 		return fmt.Errorf("no public key found. please make sure SetupKeys() is called before calling CreateClient()")
 	}
 	if r.key.PrivateKey == nil {
 		return fmt.Errorf("no private key found. please make sure SetupKeys() is called before calling CreateClient()")
 	}
-	c, err := ssh.Dial("tcp", net.JoinHostPort(r.ip, strconv.FormatInt(int64(r.port), 10)), &ssh.ClientConfig{
-		User:            r.user,
-		HostKeyCallback: ssh.FixedHostKey(r.key.PublicKey),
-		Auth: []ssh.AuthMethod{
-			ssh.PublicKeys(r.key.PrivateKey),
-		},
-	})
+	c, err := ssh.Dial("tcp", net.JoinHostPort(r.ip, strconv.FormatInt(int64(r.port), 10)), r.clientConfig())
 	if err != nil {
 		return fmt.Errorf("an error occurred while ssh dialing. %v", err)
 	}
@@ -164,6 +237,45 @@ func (r *remote) CreateClient() error {
 	return nil
 }
 
+// clientConfig returns the config of the ssh client, which only allows the configured algorithms.
+func (r *remote) clientConfig() *ssh.ClientConfig {
+	return &ssh.ClientConfig{
+		Config: ssh.Config{
+			KeyExchanges: algorithms.KeyExchanges,
+			Ciphers:      algorithms.Ciphers,
+			MACs:         algorithms.MACs,
+		},
+		User:            r.user,
+		HostKeyCallback: r.hostKeyCallback(),
+		Auth: []ssh.AuthMethod{
+			ssh.PublicKeys(r.key.PrivateKey),
+		},
+	}
+}
+
+// hostKeyCallback returns the callback verifying the key of the server against the configured
+// fingerprints, or against the key of the known_hosts file if no fingerprint is configured.
+func (r *remote) hostKeyCallback() ssh.HostKeyCallback {
+	if len(r.hostKeyFingerprints) == 0 {
+		return ssh.FixedHostKey(r.key.PublicKey)
+	}
+	return fingerprintHostKeyCallback(r.hostKeyFingerprints)
+}
+
+// fingerprintHostKeyCallback returns a callback accepting the host keys whose SHA256 fingerprint is
+// one of fingerprints.
+func fingerprintHostKeyCallback(fingerprints []string) ssh.HostKeyCallback {
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		fingerprint := ssh.FingerprintSHA256(key)
+		for _, f := range fingerprints {
+			if strings.TrimSpace(f) == fingerprint {
+				return nil
+			}
+		}
+		return fmt.Errorf("the host key of %s with fingerprint %s does not match the configured host key fingerprints", hostname, fingerprint)
+	}
+}
+
 // CreateSession creates ssh session.
 func (r *remote) CreateSession(input string) (SSHSessionInterface, error) {
 	if r.client == nil {
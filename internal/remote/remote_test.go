@@ -18,12 +18,15 @@ package remote
 
 import (
 	"bytes"
+	"crypto/ed25519"
 	"errors"
 	"fmt"
 	"net"
 	"os"
+	"strings"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
 	"golang.org/x/crypto/ssh"
 )
 
@@ -476,3 +479,169 @@ func TestRunCommandWithPipes(t *testing.T) {
 		})
 	}
 }
+
+func TestSetAlgorithms(t *testing.T) {
+	testcases := []struct {
+		name    string
+		input   Algorithms
+		want    Algorithms
+		wantErr bool
+	}{
+		{
+			name:  "empty lists keep the defaults",
+			input: Algorithms{},
+			want:  DefaultAlgorithms,
+		},
+		{
+			name: "configured lists",
+			input: Algorithms{
+				KeyExchanges: []string{"curve25519-sha256"},
+				Ciphers:      []string{"aes256-gcm@openssh.com", "aes256-ctr"},
+				MACs:         []string{"hmac-sha2-512-etm@openssh.com"},
+			},
+			want: Algorithms{
+				KeyExchanges: []string{"curve25519-sha256"},
+				Ciphers:      []string{"aes256-gcm@openssh.com", "aes256-ctr"},
+				MACs:         []string{"hmac-sha2-512-etm@openssh.com"},
+			},
+		},
+		{
+			name: "weak algorithms can be allowed explicitly",
+			input: Algorithms{
+				MACs: []string{"hmac-sha2-256", "hmac-sha1"},
+			},
+			want: Algorithms{
+				KeyExchanges: DefaultAlgorithms.KeyExchanges,
+				Ciphers:      DefaultAlgorithms.Ciphers,
+				MACs:         []string{"hmac-sha2-256", "hmac-sha1"},
+			},
+		},
+		{
+			name:    "unsupported key exchange",
+			input:   Algorithms{KeyExchanges: []string{"sntrup761x25519-sha512@openssh.com"}},
+			want:    DefaultAlgorithms,
+			wantErr: true,
+		},
+		{
+			name:    "unsupported cipher",
+			input:   Algorithms{Ciphers: []string{"aes128-ctr", "blowfish-cbc"}},
+			want:    DefaultAlgorithms,
+			wantErr: true,
+		},
+		{
+			name:    "unsupported mac",
+			input:   Algorithms{MACs: []string{"hmac-md5"}},
+			want:    DefaultAlgorithms,
+			wantErr: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Cleanup(func() { algorithms = DefaultAlgorithms })
+			err := SetAlgorithms(tc.input)
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Errorf("SetAlgorithms(%v) returned error %v, want error presence = %v", tc.input, err, tc.wantErr)
+			}
+			if diff := cmp.Diff(tc.want, algorithms); diff != "" {
+				t.Errorf("SetAlgorithms(%v) set wrong algorithms (-want +got):\n%s", tc.input, diff)
+			}
+		})
+	}
+}
+
+func TestSetupKeysHostKeyFingerprints(t *testing.T) {
+	tmpKeyPath := t.TempDir() + "/privatekey"
+	if err := os.WriteFile(tmpKeyPath, []byte(DummyKey), 0666); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	// No known_hosts file is next to the private key.
+	r := &remote{ip: "127.0.0.1", key: &key{}, hostKeyFingerprints: []string{"SHA256:test-fingerprint"}}
+	if err := r.SetupKeys(tmpKeyPath); err != nil {
+		t.Errorf("SetupKeys() returned unexpected error: %v", err)
+	}
+}
+
+func TestHostKeyCallback(t *testing.T) {
+	hostKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(strings.TrimPrefix(DummyKnownHost, "127.0.0.1 ")))
+	if err != nil {
+		t.Fatalf("ssh.ParseAuthorizedKey() returned unexpected error: %v", err)
+	}
+	otherPub, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("ed25519.GenerateKey() returned unexpected error: %v", err)
+	}
+	otherKey, err := ssh.NewPublicKey(otherPub)
+	if err != nil {
+		t.Fatalf("ssh.NewPublicKey() returned unexpected error: %v", err)
+	}
+	addr := &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 22}
+
+	testcases := []struct {
+		name         string
+		fingerprints []string
+		knownHostKey ssh.PublicKey
+		wantErr      bool
+	}{
+		{
+			name:         "matching fingerprint",
+			fingerprints: []string{ssh.FingerprintSHA256(hostKey)},
+		},
+		{
+			name:         "one of the fingerprints matches",
+			fingerprints: []string{ssh.FingerprintSHA256(otherKey), " " + ssh.FingerprintSHA256(hostKey) + " "},
+		},
+		{
+			name:         "mismatching fingerprint",
+			fingerprints: []string{ssh.FingerprintSHA256(otherKey)},
+			wantErr:      true,
+		},
+		{
+			name:         "matching known host key",
+			knownHostKey: hostKey,
+		},
+		{
+			name:         "mismatching known host key",
+			knownHostKey: otherKey,
+			wantErr:      true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &remote{key: &key{PublicKey: tc.knownHostKey}, hostKeyFingerprints: tc.fingerprints}
+			err := r.hostKeyCallback()("127.0.0.1:22", addr, hostKey)
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Errorf("hostKeyCallback() returned error %v, want error: %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestClientConfig(t *testing.T) {
+	t.Cleanup(func() { algorithms = DefaultAlgorithms })
+	want := Algorithms{
+		KeyExchanges: []string{"ecdh-sha2-nistp256"},
+		Ciphers:      []string{"aes128-gcm@openssh.com"},
+		MACs:         []string{"hmac-sha2-256-etm@openssh.com"},
+	}
+	if err := SetAlgorithms(want); err != nil {
+		t.Fatalf("SetAlgorithms(%v) returned unexpected error: %v", want, err)
+	}
+	r := &remote{user: "test-user", key: &key{}}
+	tmpKeyPath := t.TempDir() + "/privatekey"
+	if err := os.WriteFile(tmpKeyPath, []byte(DummyKey), 0666); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if err := r.privateKey(tmpKeyPath); err != nil {
+		t.Fatalf("privateKey() returned unexpected error: %v", err)
+	}
+	r.key.PublicKey = r.key.PrivateKey.PublicKey()
+
+	got := r.clientConfig()
+	if got.User != "test-user" {
+		t.Errorf("clientConfig().User = %q, want %q", got.User, "test-user")
+	}
+	gotAlgorithms := Algorithms{KeyExchanges: got.KeyExchanges, Ciphers: got.Ciphers, MACs: got.MACs}
+	if diff := cmp.Diff(want, gotAlgorithms); diff != "" {
+		t.Errorf("clientConfig() returned wrong algorithms (-want +got):\n%s", diff)
+	}
+}
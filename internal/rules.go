@@ -16,6 +16,11 @@ limitations under the License.
 
 package internal
 
+import (
+	"encoding/json"
+	"strconv"
+)
+
 const (
 	// PowerProfileSettingRule used for power profile of machine.
 	PowerProfileSettingRule = "power_profile_setting"
@@ -29,12 +34,37 @@ const (
 	DataDiskAllocationUnitsRule = "data_disk_allocation_units"
 	// GCBDRAgentRunning used for checking if GCBDRAgentRunning is running on the target.
 	GCBDRAgentRunning = "gcbdr_agent_running"
+	// VMSwappinessRule used for the vm.swappiness kernel parameter on linux.
+	VMSwappinessRule = "vm_swappiness"
+	// VMMaxMapCountRule used for the vm.max_map_count kernel parameter on linux.
+	VMMaxMapCountRule = "vm_max_map_count"
+	// KernelNumaBalancingRule used for the kernel.numa_balancing kernel parameter on linux.
+	KernelNumaBalancingRule = "kernel_numa_balancing"
+	// NumaNodeCountOSRule used for the number of NUMA nodes reported by linux.
+	NumaNodeCountOSRule = "numa_node_count_os"
+	// NumaMemoryBalancedRule used for checking if memory is evenly distributed across the NUMA nodes on linux.
+	NumaMemoryBalancedRule = "numa_memory_balanced"
+	// IOSchedulerRule used for the I/O scheduler of each data disk on linux.
+	IOSchedulerRule = "io_scheduler"
+	// CPUGovernorRule used for the CPU frequency scaling governor on linux.
+	CPUGovernorRule = "cpu_governor"
+	// ClocksourceRule used for the current clocksource of the kernel on linux.
+	ClocksourceRule = "clocksource"
+	// AntivirusRealtimeEnabledRule used for checking if real-time antivirus protection is active on windows.
+	AntivirusRealtimeEnabledRule = "antivirus_realtime_enabled"
+	// TimeSourceRule used for the time source the clock synchronizes to on windows.
+	TimeSourceRule = "time_source"
+	// TimeSyncHealthyRule used for checking if the clock is synchronized to its time source on windows.
+	TimeSyncHealthyRule = "time_sync_healthy"
 )
 
 // Details represents collected details results.
 type Details struct {
-	Name   string
-	Fields []map[string]string
+	Name   string              `json:"name"`
+	Fields []map[string]string `json:"fields"`
+	// CollectionDurationMs records how long each rule took to collect in milliseconds, keyed by rule name.
+	// The durations of sql rules are keyed by "host:port/rule" once their instance is stamped.
+	CollectionDurationMs map[string]int64 `json:"collection_duration_ms,omitempty"`
 }
 
 // MasterRuleStruct defines the data struct of sql server master rules.
@@ -46,6 +76,93 @@ type MasterRuleStruct struct {
 	// Fields returns the <key, value> of collected columns and values. Different rules query
 	// different tables and columns.
 	Fields func([][]any) []map[string]string
+	// OptIn rules are only collected if they are enabled in the configuration, e.g. because they
+	// are expensive on large instances.
+	OptIn bool
+}
+
+const (
+	// maxVLFCount is the number of VLFs above which the log of a database is excessively fragmented.
+	maxVLFCount = 1000
+	// maxVLFsPerGB is the number of VLFs per GB of log above which the log of a database is
+	// excessively fragmented, for logs with more than minVLFCountForRatio VLFs.
+	maxVLFsPerGB        = 100
+	minVLFCountForRatio = 100
+)
+
+// VLFCountExcessive reports whether a log with vlfCount VLFs of logSizeMB MB in total is
+// excessively fragmented, which slows down the recovery and the backups of the database.
+// Returns "unknown" if the VLF count is unknown.
+func VLFCountExcessive(vlfCount, logSizeMB any) string {
+	count, err := strconv.ParseInt(HandleNilInt(vlfCount), 10, 64)
+	if err != nil {
+		return "unknown"
+	}
+	if count > maxVLFCount {
+		return "true"
+	}
+	size, ok := logSizeMB.(float64)
+	if !ok || size <= 0 || count <= minVLFCountForRatio {
+		return "false"
+	}
+	return strconv.FormatBool(float64(count)/(size/1024) > maxVLFsPerGB)
+}
+
+// maxHoursSinceLogBackup is the age in hours of the last log backup above which the log of a
+// database in FULL or BULK_LOGGED recovery is at risk of growing unbounded.
+const maxHoursSinceLogBackup = 24
+
+// LogBackupRisk reports whether the log of a database in recoveryModel is at risk of growing
+// unbounded, because it is in FULL or BULK_LOGGED recovery and has no log backup in the last
+// maxHoursSinceLogBackup hours. A nil hoursSinceLogBackup means the database has no log backup.
+func LogBackupRisk(recoveryModel, hoursSinceLogBackup any) string {
+	if recoveryModel == nil {
+		return "unknown"
+	}
+	if model := HandleNilString(recoveryModel); model != "FULL" && model != "BULK_LOGGED" {
+		return "false"
+	}
+	if hoursSinceLogBackup == nil {
+		return "true"
+	}
+	hours, err := strconv.ParseInt(HandleNilInt(hoursSinceLogBackup), 10, 64)
+	if err != nil {
+		return "unknown"
+	}
+	return strconv.FormatBool(hours > maxHoursSinceLogBackup)
+}
+
+const (
+	// minDataGrowthMB and minLogGrowthMB are the fixed autogrowth increments in MB below which the
+	// data and log files grow in many small steps, which fragments them.
+	minDataGrowthMB = 64
+	minLogGrowthMB  = 256
+)
+
+// AutogrowthIncrement returns the fixed autogrowth increment in MB of a database file of fileType,
+// whose growth is in 8KB pages, and whether it is too small. Files growing by a percentage have an
+// unknown increment and files without autogrowth are never too small.
+func AutogrowthIncrement(fileType, growth, isPercentGrowth any) (growthMB, tooSmall string) {
+	pages, err := strconv.ParseInt(HandleNilInt(growth), 10, 64)
+	percent, ok := isPercentGrowth.(bool)
+	if err != nil || !ok {
+		return "unknown", "unknown"
+	}
+	if percent {
+		return "unknown", "false"
+	}
+	mb := float64(pages) * 8 / 1024
+	growthMB = strconv.FormatFloat(mb, 'f', -1, 64)
+	if pages == 0 {
+		return growthMB, "false"
+	}
+	switch HandleNilInt(fileType) {
+	case "0":
+		return growthMB, strconv.FormatBool(mb < minDataGrowthMB)
+	case "1":
+		return growthMB, strconv.FormatBool(mb < minLogGrowthMB)
+	}
+	return growthMB, "false"
 }
 
 // MasterRules defines the rules the agent will collect from sql server.
@@ -58,6 +175,7 @@ var MasterRules = []MasterRuleStruct{
 		Fields: func(fields [][]any) []map[string]string {
 			res := []map[string]string{}
 			for _, f := range fields {
+				growthMB, growthTooSmall := AutogrowthIncrement(f[0], f[5], f[6])
 				res = append(res, map[string]string{
 					"db_name":           HandleNilString(f[1]),
 					"filetype":          HandleNilInt(f[0]),
@@ -67,6 +185,8 @@ var MasterRules = []MasterRuleStruct{
 					"size":              HandleNilInt(f[4]),
 					"growth":            HandleNilInt(f[5]),
 					"is_percent_growth": HandleNilBool(f[6]),
+					"growth_mb":         growthMB,
+					"growth_too_small":  growthTooSmall,
 				})
 			}
 			return res
@@ -143,6 +263,7 @@ var MasterRules = []MasterRuleStruct{
 					"vlf_size_in_mb":        HandleNilFloat64(f[2]),
 					"active_vlf_count":      HandleNilInt(f[3]),
 					"active_vlf_size_in_mb": HandleNilFloat64(f[4]),
+					"vlf_count_excessive":   VLFCountExcessive(f[1], f[2]),
 				})
 			}
 			return res
@@ -287,4 +408,743 @@ var MasterRules = []MasterRuleStruct{
 			return res
 		},
 	},
+	{
+		Name: "INSTANCE_FILL_FACTOR",
+		Query: `SELECT value_in_use AS defaultFillFactor
+						FROM sys.configurations
+						WHERE name = 'fill factor (%)'`,
+		Fields: func(fields [][]any) []map[string]string {
+			res := []map[string]string{}
+			for _, f := range fields {
+				res = append(res, map[string]string{
+					"default_fill_factor": HandleNilInt(f[0]),
+				})
+			}
+			return res
+		},
+	},
+	{
+		Name: "INSTANCE_MAX_WORKER_THREADS",
+		Query: `SELECT [value], [value_in_use]
+						FROM sys.configurations
+						WHERE [name] = 'max worker threads'`,
+		Fields: func(fields [][]any) []map[string]string {
+			res := []map[string]string{}
+			for _, f := range fields {
+				res = append(res, map[string]string{
+					"max_worker_threads":        HandleNilInt(f[0]),
+					"max_worker_threads_in_use": HandleNilInt(f[1]),
+				})
+			}
+			return res
+		},
+	},
+	{
+		Name: "INSTANCE_LINKED_SERVERS",
+		Query: `SELECT name, product, provider, data_source
+						FROM sys.servers
+						WHERE is_linked = 1`,
+		Fields: func(fields [][]any) []map[string]string {
+			type linkedServer struct {
+				Name       string `json:"name"`
+				Product    string `json:"product"`
+				Provider   string `json:"provider"`
+				DataSource string `json:"data_source"`
+			}
+			linkedServers := []linkedServer{}
+			for _, f := range fields {
+				linkedServers = append(linkedServers, linkedServer{
+					Name:       HandleNilString(f[0]),
+					Product:    HandleNilString(f[1]),
+					Provider:   HandleNilString(f[2]),
+					DataSource: HandleNilString(f[3]),
+				})
+			}
+			res, err := json.Marshal(linkedServers)
+			if err != nil {
+				return []map[string]string{{"linked_servers": "unknown"}}
+			}
+			return []map[string]string{{"linked_servers": string(res)}}
+		},
+	},
+	{
+		Name: "DB_TRUSTWORTHY",
+		Query: `SELECT name, is_trustworthy_on
+						FROM sys.databases
+						WHERE name <> 'msdb'`,
+		Fields: func(fields [][]any) []map[string]string {
+			res := []map[string]string{}
+			for _, f := range fields {
+				res = append(res, map[string]string{
+					"db_name":     HandleNilString(f[0]),
+					"trustworthy": HandleNilBool(f[1]),
+				})
+			}
+			return res
+		},
+	},
+	{
+		Name: "INSTANCE_BACKUP_COMPRESSION",
+		Query: `SELECT value_in_use AS backupCompressionDefault
+						FROM sys.configurations
+						WHERE name = 'backup compression default'`,
+		Fields: func(fields [][]any) []map[string]string {
+			res := []map[string]string{}
+			for _, f := range fields {
+				res = append(res, map[string]string{
+					"backup_compression_default": HandleNilInt(f[0]),
+				})
+			}
+			return res
+		},
+	},
+	{
+		Name: "DB_DELAYED_DURABILITY",
+		Query: `SELECT name, delayed_durability_desc
+						FROM sys.databases`,
+		Fields: func(fields [][]any) []map[string]string {
+			res := []map[string]string{}
+			for _, f := range fields {
+				res = append(res, map[string]string{
+					"db_name":            HandleNilString(f[0]),
+					"delayed_durability": HandleNilString(f[1]),
+				})
+			}
+			return res
+		},
+	},
+	{
+		// DB_TEMPDB_CONTENTION is a point-in-time snapshot of the tasks waiting on the tempdb PFS and
+		// SGAM allocation pages when the rule runs. It does not report contention between collections.
+		Name: "DB_TEMPDB_CONTENTION",
+		Query: `SELECT session_id, wait_type, resource_description
+						FROM sys.dm_os_waiting_tasks
+						WHERE wait_type LIKE 'PAGELATCH_%'
+							AND (resource_description LIKE '2:%:1' OR resource_description LIKE '2:%:3')`,
+		Fields: func(fields [][]any) []map[string]string {
+			return []map[string]string{{
+				"tempdb_allocation_contention":       strconv.FormatBool(len(fields) > 0),
+				"tempdb_allocation_contention_count": strconv.Itoa(len(fields)),
+			}}
+		},
+	},
+	{
+		Name: "INSTANCE_OPTIMIZE_ADHOC",
+		Query: `SELECT value_in_use AS optimizeForAdhoc
+						FROM sys.configurations
+						WHERE name = 'optimize for ad hoc workloads'`,
+		Fields: func(fields [][]any) []map[string]string {
+			res := []map[string]string{}
+			for _, f := range fields {
+				res = append(res, map[string]string{
+					"optimize_for_adhoc": HandleNilInt(f[0]),
+				})
+			}
+			return res
+		},
+	},
+	{
+		// Databases without a database encryption key have no row in sys.dm_database_encryption_keys
+		// and are reported with encryption_state 0 (no encryption key present) and not encrypted.
+		Name: "DB_ENCRYPTION",
+		Query: `SELECT d.name,
+							ISNULL(dek.encryption_state, 0) AS encryptionState,
+							CAST(CASE WHEN dek.encryption_state = 3 THEN 1 ELSE 0 END AS BIT) AS isEncrypted
+						FROM sys.databases d
+						LEFT JOIN sys.dm_database_encryption_keys dek ON d.database_id = dek.database_id`,
+		Fields: func(fields [][]any) []map[string]string {
+			res := []map[string]string{}
+			for _, f := range fields {
+				if f[1] == nil {
+					res = append(res, map[string]string{
+						"db_name":          HandleNilString(f[0]),
+						"encryption_state": "0",
+						"is_encrypted":     "false",
+					})
+					continue
+				}
+				res = append(res, map[string]string{
+					"db_name":          HandleNilString(f[0]),
+					"encryption_state": HandleNilInt(f[1]),
+					"is_encrypted":     HandleNilBool(f[2]),
+				})
+			}
+			return res
+		},
+	},
+	{
+		// DB_IO_STALL reports the io stalls accumulated by each database file since the SQL Server
+		// instance started. The values are cumulative and are not reset between collections.
+		Name: "DB_IO_STALL",
+		Query: `SELECT DB_NAME(vfs.database_id) AS dbName,
+							mf.type_desc AS fileType,
+							vfs.io_stall_read_ms,
+							vfs.io_stall_write_ms
+						FROM sys.dm_io_virtual_file_stats(NULL, NULL) vfs
+						JOIN sys.master_files mf ON vfs.database_id = mf.database_id AND vfs.file_id = mf.file_id`,
+		Fields: func(fields [][]any) []map[string]string {
+			res := []map[string]string{}
+			for _, f := range fields {
+				res = append(res, map[string]string{
+					"db_name":           HandleNilString(f[0]),
+					"file_type":         HandleNilString(f[1]),
+					"io_stall_read_ms":  HandleNilInt(f[2]),
+					"io_stall_write_ms": HandleNilInt(f[3]),
+				})
+			}
+			return res
+		},
+	},
+	{
+		// DB_BACKUP_HISTORY reports the finish time of the last full, differential and log backup of
+		// each database in ISO 8601 format, or none if the database has no backup of the type.
+		Name: "DB_BACKUP_HISTORY",
+		Query: `SELECT d.name,
+							CONVERT(VARCHAR(33), MAX(CASE WHEN b.type = 'D' THEN b.backup_finish_date END), 126) AS lastFullBackup,
+							CONVERT(VARCHAR(33), MAX(CASE WHEN b.type = 'I' THEN b.backup_finish_date END), 126) AS lastDiffBackup,
+							CONVERT(VARCHAR(33), MAX(CASE WHEN b.type = 'L' THEN b.backup_finish_date END), 126) AS lastLogBackup
+						FROM sys.databases d
+						LEFT JOIN msdb.dbo.backupset b ON d.name = b.database_name
+						WHERE d.name <> 'tempdb'
+						GROUP BY d.name`,
+		Fields: func(fields [][]any) []map[string]string {
+			lastBackup := func(data any) string {
+				if data == nil {
+					return "none"
+				}
+				return HandleNilString(data)
+			}
+			res := []map[string]string{}
+			for _, f := range fields {
+				res = append(res, map[string]string{
+					"db_name":          HandleNilString(f[0]),
+					"last_full_backup": lastBackup(f[1]),
+					"last_diff_backup": lastBackup(f[2]),
+					"last_log_backup":  lastBackup(f[3]),
+				})
+			}
+			return res
+		},
+	},
+	{
+		Name: "INSTANCE_DEFAULT_PATHS",
+		Query: `DECLARE @backupDirectory NVARCHAR(4000);
+						EXEC master.dbo.xp_instance_regread N'HKEY_LOCAL_MACHINE', N'Software\Microsoft\MSSQLServer\MSSQLServer', N'BackupDirectory', @backupDirectory OUTPUT;
+						SELECT CAST(SERVERPROPERTY('InstanceDefaultDataPath') AS NVARCHAR(4000)) AS defaultDataPath,
+							CAST(SERVERPROPERTY('InstanceDefaultLogPath') AS NVARCHAR(4000)) AS defaultLogPath,
+							@backupDirectory AS defaultBackupPath`,
+		Fields: func(fields [][]any) []map[string]string {
+			res := []map[string]string{}
+			for _, f := range fields {
+				res = append(res, map[string]string{
+					"default_data_path":   HandleNilString(f[0]),
+					"default_log_path":    HandleNilString(f[1]),
+					"default_backup_path": HandleNilString(f[2]),
+				})
+			}
+			return res
+		},
+	},
+	{
+		Name: "INSTANCE_AFFINITY",
+		Query: `SELECT MAX(CASE WHEN name = 'affinity mask' THEN CAST(value_in_use AS BIGINT) END) AS affinityMask,
+							MAX(CASE WHEN name = 'affinity64 mask' THEN CAST(value_in_use AS BIGINT) END) AS affinity64Mask,
+							MAX(CASE WHEN name = 'affinity I/O mask' THEN CAST(value_in_use AS BIGINT) END) AS affinityIOMask
+						FROM sys.configurations
+						WHERE name IN ('affinity mask', 'affinity64 mask', 'affinity I/O mask')`,
+		Fields: func(fields [][]any) []map[string]string {
+			res := []map[string]string{}
+			for _, f := range fields {
+				res = append(res, map[string]string{
+					"affinity_mask":    HandleNilInt(f[0]),
+					"affinity64_mask":  HandleNilInt(f[1]),
+					"affinity_io_mask": HandleNilInt(f[2]),
+				})
+			}
+			return res
+		},
+	},
+	{
+		// INSTANCE_AGENT_JOB_FAILURES reports the SQL Agent jobs whose last run failed within the last 24 hours.
+		// The job history is read from msdb, so no job is reported if msdb is not accessible or SQL Agent does not run.
+		Name: "INSTANCE_AGENT_JOB_FAILURES",
+		Query: `IF HAS_DBACCESS('msdb') = 1
+							SELECT j.name
+							FROM msdb.dbo.sysjobs j
+							CROSS APPLY (
+								SELECT TOP 1 h.run_status, msdb.dbo.agent_datetime(h.run_date, h.run_time) AS run_datetime
+								FROM msdb.dbo.sysjobhistory h
+								WHERE h.job_id = j.job_id AND h.step_id = 0
+								ORDER BY h.instance_id DESC
+							) last_run
+							WHERE last_run.run_status = 0
+								AND last_run.run_datetime >= DATEADD(HOUR, -24, GETDATE())
+						ELSE
+							SELECT CAST(NULL AS SYSNAME) AS name WHERE 1 = 0`,
+		Fields: func(fields [][]any) []map[string]string {
+			failedJobs := []string{}
+			for _, f := range fields {
+				failedJobs = append(failedJobs, HandleNilString(f[0]))
+			}
+			res, err := json.Marshal(failedJobs)
+			if err != nil {
+				return []map[string]string{{"failed_job_count": strconv.Itoa(len(failedJobs)), "failed_jobs": "unknown"}}
+			}
+			return []map[string]string{{"failed_job_count": strconv.Itoa(len(failedJobs)), "failed_jobs": string(res)}}
+		},
+	},
+	{
+		Name: "INSTANCE_COST_THRESHOLD",
+		Query: `SELECT CAST(value_in_use AS INT) AS costThresholdForParallelism
+						FROM sys.configurations
+						WHERE name = 'cost threshold for parallelism'`,
+		Fields: func(fields [][]any) []map[string]string {
+			res := []map[string]string{}
+			for _, f := range fields {
+				res = append(res, map[string]string{
+					"cost_threshold_parallelism": HandleNilInt(f[0]),
+				})
+			}
+			return res
+		},
+	},
+	{
+		Name: "DB_PARAMETERIZATION",
+		Query: `SELECT name, is_parameterization_forced
+						FROM sys.databases`,
+		Fields: func(fields [][]any) []map[string]string {
+			res := []map[string]string{}
+			for _, f := range fields {
+				res = append(res, map[string]string{
+					"db_name":                 HandleNilString(f[0]),
+					"forced_parameterization": HandleNilBool(f[1]),
+				})
+			}
+			return res
+		},
+	},
+	{
+		// INSTANCE_DATABASE_SUMMARY reports the number of user databases and the size of their data
+		// and log files. The size of sys.master_files is in 8 KB pages.
+		Name: "INSTANCE_DATABASE_SUMMARY",
+		Query: `SELECT (SELECT COUNT(*) FROM sys.databases WHERE database_id > 4) AS userDatabaseCount,
+							SUM(CASE WHEN type = 0 THEN CAST(size AS BIGINT) ELSE 0 END) * 8 / 1024 AS totalDataSizeMb,
+							SUM(CASE WHEN type = 1 THEN CAST(size AS BIGINT) ELSE 0 END) * 8 / 1024 AS totalLogSizeMb
+						FROM sys.master_files
+						WHERE database_id > 4`,
+		Fields: func(fields [][]any) []map[string]string {
+			res := []map[string]string{}
+			for _, f := range fields {
+				res = append(res, map[string]string{
+					"user_database_count": HandleNilInt(f[0]),
+					"total_data_size_mb":  HandleNilInt(f[1]),
+					"total_log_size_mb":   HandleNilInt(f[2]),
+				})
+			}
+			return res
+		},
+	},
+	{
+		// AGENT_LOGIN_PERMISSIONS reports the permissions of the login the agent connects with.
+		// Rules querying DMVs return unknown without VIEW SERVER STATE.
+		Name: "AGENT_LOGIN_PERMISSIONS",
+		Query: `SELECT CAST(IS_SRVROLEMEMBER('sysadmin') AS BIT) AS isSysadmin,
+							CAST(HAS_PERMS_BY_NAME(NULL, NULL, 'VIEW SERVER STATE') AS BIT) AS hasViewServerState`,
+		Fields: func(fields [][]any) []map[string]string {
+			res := []map[string]string{}
+			for _, f := range fields {
+				res = append(res, map[string]string{
+					"agent_login_is_sysadmin":           HandleNilBool(f[0]),
+					"agent_login_has_view_server_state": HandleNilBool(f[1]),
+				})
+			}
+			return res
+		},
+	},
+	{
+		// DB_BLOCKING is a snapshot of the blocking chains at the time of the collection. Blocks that
+		// start and end between two collections are not reported.
+		Name: "DB_BLOCKING",
+		Query: `SELECT COUNT(DISTINCT r.session_id) AS blockedSessionCount,
+							ISNULL(MAX(wt.wait_duration_ms), 0) AS longestBlockMs
+						FROM sys.dm_exec_requests r
+						LEFT JOIN sys.dm_os_waiting_tasks wt
+							ON wt.session_id = r.session_id AND wt.blocking_session_id = r.blocking_session_id
+						WHERE r.blocking_session_id > 0 AND r.blocking_session_id <> r.session_id`,
+		Fields: func(fields [][]any) []map[string]string {
+			res := []map[string]string{}
+			for _, f := range fields {
+				res = append(res, map[string]string{
+					"blocked_session_count": HandleNilInt(f[0]),
+					"longest_block_ms":      HandleNilInt(f[1]),
+				})
+			}
+			return res
+		},
+	},
+	{
+		// INSTANCE_LOCK_PAGES reports the memory model of the instance. LOCK_PAGES and LARGE_PAGES
+		// mean Lock Pages in Memory is effective.
+		Name:  "INSTANCE_LOCK_PAGES",
+		Query: `SELECT sql_memory_model_desc FROM sys.dm_os_sys_info`,
+		Fields: func(fields [][]any) []map[string]string {
+			res := []map[string]string{}
+			for _, f := range fields {
+				res = append(res, map[string]string{
+					"memory_model": HandleNilString(f[0]),
+				})
+			}
+			return res
+		},
+	},
+	{
+		// DB_SCOPED_MAXDOP reports the database scoped MAXDOP of the user databases. Database scoped
+		// configurations are available on SQL Server 2016 and later, so no database is reported on
+		// older versions. A scoped_maxdop of 0 means the database uses the max degree of parallelism
+		// of the instance.
+		Name: "DB_SCOPED_MAXDOP",
+		Query: `IF CAST(SERVERPROPERTY('ProductMajorVersion') AS INT) >= 13
+						BEGIN
+							DECLARE @sql NVARCHAR(MAX);
+							SELECT @sql = COALESCE(@sql + N' UNION ALL ', N'')
+								+ N'SELECT ' + QUOTENAME(name, '''') + N' AS db_name, CAST(value AS INT) AS scoped_maxdop FROM '
+								+ QUOTENAME(name) + N'.sys.database_scoped_configurations WHERE name = ''MAXDOP'''
+							FROM sys.databases
+							WHERE database_id > 4 AND state = 0 AND HAS_DBACCESS(name) = 1;
+							IF @sql IS NULL
+								SELECT CAST(NULL AS SYSNAME) AS db_name, CAST(NULL AS INT) AS scoped_maxdop WHERE 1 = 0
+							ELSE
+								EXEC sp_executesql @sql
+						END
+						ELSE
+							SELECT CAST(NULL AS SYSNAME) AS db_name, CAST(NULL AS INT) AS scoped_maxdop WHERE 1 = 0`,
+		Fields: func(fields [][]any) []map[string]string {
+			res := []map[string]string{}
+			for _, f := range fields {
+				res = append(res, map[string]string{
+					"db_name":       HandleNilString(f[0]),
+					"scoped_maxdop": HandleNilInt(f[1]),
+				})
+			}
+			return res
+		},
+	},
+	{
+		// DB_LOG_BACKUP_RISK reports the user databases whose log is at risk of growing unbounded,
+		// because they are in FULL or BULK_LOGGED recovery without a recent log backup.
+		// hours_since_log_backup is none if the database has no log backup.
+		Name: "DB_LOG_BACKUP_RISK",
+		Query: `SELECT d.name, d.recovery_model_desc,
+							DATEDIFF(HOUR, MAX(b.backup_finish_date), GETDATE()) AS hoursSinceLogBackup
+						FROM sys.databases d
+						LEFT JOIN msdb.dbo.backupset b ON b.database_name = d.name AND b.type = 'L'
+						WHERE d.database_id > 4
+						GROUP BY d.name, d.recovery_model_desc`,
+		Fields: func(fields [][]any) []map[string]string {
+			res := []map[string]string{}
+			for _, f := range fields {
+				hoursSinceLogBackup := "none"
+				if f[2] != nil {
+					hoursSinceLogBackup = HandleNilInt(f[2])
+				}
+				res = append(res, map[string]string{
+					"db_name":                HandleNilString(f[0]),
+					"recovery_model":         HandleNilString(f[1]),
+					"hours_since_log_backup": hoursSinceLogBackup,
+					"log_backup_risk":        LogBackupRisk(f[1], f[2]),
+				})
+			}
+			return res
+		},
+	},
+	{
+		// INSTANCE_SP_CONFIGURE reports the value_in_use of the sp_configure settings relevant to
+		// the performance and security of the instance in one query, as a JSON map of name to value.
+		Name: "INSTANCE_SP_CONFIGURE",
+		Query: `SELECT name, CAST(value_in_use AS BIGINT) AS valueInUse
+						FROM sys.configurations
+						WHERE name IN ('backup compression default', 'optimize for ad hoc workloads',
+							'cost threshold for parallelism', 'max degree of parallelism', 'max worker threads',
+							'max server memory (MB)', 'min server memory (MB)', 'fill factor (%)',
+							'remote admin connections', 'clr enabled', 'xp_cmdshell', 'Database Mail XPs',
+							'Ad Hoc Distributed Queries', 'priority boost', 'lightweight pooling', 'show advanced options')`,
+		Fields: func(fields [][]any) []map[string]string {
+			settings := map[string]string{}
+			for _, f := range fields {
+				settings[HandleNilString(f[0])] = HandleNilInt(f[1])
+			}
+			res, err := json.Marshal(settings)
+			if err != nil {
+				return []map[string]string{{"sp_configure": "unknown"}}
+			}
+			return []map[string]string{{"sp_configure": string(res)}}
+		},
+	},
+	{
+		Name: "INSTANCE_MIN_SERVER_MEMORY",
+		Query: `SELECT CAST(value AS BIGINT) AS minServerMemory, CAST(value_in_use AS BIGINT) AS minServerMemoryInUse
+						FROM sys.configurations
+						WHERE name = 'min server memory (MB)'`,
+		Fields: func(fields [][]any) []map[string]string {
+			res := []map[string]string{}
+			for _, f := range fields {
+				res = append(res, map[string]string{
+					"min_server_memory":        HandleNilInt(f[0]),
+					"min_server_memory_in_use": HandleNilInt(f[1]),
+				})
+			}
+			return res
+		},
+	},
+	{
+		// INSTANCE_TEMPDB_MEMOPT reports whether the tempdb metadata is memory-optimized. The property
+		// exists on SQL Server 2019 and later; older versions return NULL, reported as unknown.
+		Name:  "INSTANCE_TEMPDB_MEMOPT",
+		Query: `SELECT CAST(SERVERPROPERTY('IsTempdbMetadataMemoryOptimized') AS BIT) AS isTempdbMetadataMemoryOptimized`,
+		Fields: func(fields [][]any) []map[string]string {
+			res := []map[string]string{}
+			for _, f := range fields {
+				res = append(res, map[string]string{
+					"tempdb_metadata_memory_optimized": HandleNilBool(f[0]),
+				})
+			}
+			return res
+		},
+	},
+	{
+		// INSTANCE_SURFACE_AREA reports the running values of the surface area configuration options
+		// which expose the instance to the execution of external code or ad hoc remote data.
+		Name: "INSTANCE_SURFACE_AREA",
+		Query: `SELECT
+							CAST(MAX(CASE WHEN name = 'clr enabled' THEN CAST(value_in_use AS INT) END) AS BIT) AS clrEnabled,
+							CAST(MAX(CASE WHEN name = 'Ole Automation Procedures' THEN CAST(value_in_use AS INT) END) AS BIT) AS oleAutomationProcedures,
+							CAST(MAX(CASE WHEN name = 'xp_cmdshell' THEN CAST(value_in_use AS INT) END) AS BIT) AS xpCmdshell,
+							CAST(MAX(CASE WHEN name = 'Ad Hoc Distributed Queries' THEN CAST(value_in_use AS INT) END) AS BIT) AS adHocDistributedQueries
+						FROM sys.configurations
+						WHERE name IN ('clr enabled', 'Ole Automation Procedures', 'xp_cmdshell', 'Ad Hoc Distributed Queries')`,
+		Fields: func(fields [][]any) []map[string]string {
+			res := []map[string]string{}
+			for _, f := range fields {
+				res = append(res, map[string]string{
+					"clr_enabled":                HandleNilBool(f[0]),
+					"ole_automation_procedures":  HandleNilBool(f[1]),
+					"xp_cmdshell":                HandleNilBool(f[2]),
+					"ad_hoc_distributed_queries": HandleNilBool(f[3]),
+				})
+			}
+			return res
+		},
+	},
+	{
+		// INSTANCE_DATABASE_MAIL reports whether Database Mail is enabled and the number of mails
+		// which failed to send in the last 24 hours. recent_mail_failures is unknown if Database Mail
+		// is not enabled or the login can not read the failed mails in msdb.
+		Name: "INSTANCE_DATABASE_MAIL",
+		Query: `DECLARE @enabled INT = (SELECT CAST(value_in_use AS INT) FROM sys.configurations WHERE name = 'Database Mail XPs');
+						DECLARE @failures INT = NULL;
+						IF @enabled = 1 AND HAS_PERMS_BY_NAME('msdb.dbo.sysmail_faileditems', 'OBJECT', 'SELECT') = 1
+							SELECT @failures = COUNT(*)
+							FROM msdb.dbo.sysmail_faileditems
+							WHERE last_mod_date >= DATEADD(HOUR, -24, GETDATE());
+						SELECT CAST(@enabled AS BIT) AS databaseMailEnabled, @failures AS recentMailFailures`,
+		Fields: func(fields [][]any) []map[string]string {
+			res := []map[string]string{}
+			for _, f := range fields {
+				res = append(res, map[string]string{
+					"database_mail_enabled": HandleNilBool(f[0]),
+					"recent_mail_failures":  HandleNilInt(f[1]),
+				})
+			}
+			return res
+		},
+	},
+	{
+		// DB_ADR reports whether accelerated database recovery is enabled on the user databases. The
+		// setting exists on SQL Server 2019 and later; older versions report adr_enabled as unknown.
+		Name: "DB_ADR",
+		Query: `IF CAST(SERVERPROPERTY('ProductMajorVersion') AS INT) >= 15
+							EXEC sp_executesql N'SELECT name, is_accelerated_database_recovery_on FROM sys.databases WHERE database_id > 4'
+						ELSE
+							SELECT name, CAST(NULL AS BIT) AS is_accelerated_database_recovery_on FROM sys.databases WHERE database_id > 4`,
+		Fields: func(fields [][]any) []map[string]string {
+			res := []map[string]string{}
+			for _, f := range fields {
+				res = append(res, map[string]string{
+					"db_name":     HandleNilString(f[0]),
+					"adr_enabled": HandleNilBool(f[1]),
+				})
+			}
+			return res
+		},
+	},
+	{
+		// INSTANCE_SEVERE_EVENTS reports the number of events of severity 21 or higher recorded in the
+		// default trace since the instance started. Only the events retained by the current trace file
+		// are counted. severe_events is unknown if the default trace is disabled or the login lacks
+		// the ALTER TRACE permission to read it.
+		Name: "INSTANCE_SEVERE_EVENTS",
+		Query: `DECLARE @path NVARCHAR(260) = (SELECT path FROM sys.traces WHERE is_default = 1);
+						DECLARE @events INT = NULL;
+						IF @path IS NOT NULL AND HAS_PERMS_BY_NAME(NULL, NULL, 'ALTER TRACE') = 1
+							SELECT @events = COUNT(*)
+							FROM sys.fn_trace_gettable(@path, DEFAULT)
+							WHERE Severity >= 21 AND StartTime >= (SELECT sqlserver_start_time FROM sys.dm_os_sys_info);
+						SELECT CAST(CASE WHEN @path IS NULL THEN 0 ELSE 1 END AS BIT) AS defaultTraceEnabled, @events AS severeEvents`,
+		Fields: func(fields [][]any) []map[string]string {
+			res := []map[string]string{}
+			for _, f := range fields {
+				res = append(res, map[string]string{
+					"default_trace_enabled": HandleNilBool(f[0]),
+					"severe_events":         HandleNilInt(f[1]),
+				})
+			}
+			return res
+		},
+	},
+	{
+		// DB_HEAP_TABLES reports the number of large heaps, tables without a clustered index holding
+		// 1,000,000 rows or more, of each user database. It reads the partitions of every table, so it
+		// is only collected if enabled in enabled_sql_rules.
+		Name: "DB_HEAP_TABLES",
+		Query: `DECLARE @sql NVARCHAR(MAX);
+						SELECT @sql = COALESCE(@sql + N' UNION ALL ', N'')
+							+ N'SELECT ' + QUOTENAME(name, '''') + N' AS db_name, COUNT(*) AS large_heap_count FROM ('
+							+ N'SELECT p.object_id FROM ' + QUOTENAME(name) + N'.sys.partitions p'
+							+ N' JOIN ' + QUOTENAME(name) + N'.sys.tables t ON t.object_id = p.object_id'
+							+ N' WHERE p.index_id = 0 AND t.is_ms_shipped = 0'
+							+ N' GROUP BY p.object_id HAVING SUM(p.rows) >= 1000000) AS heaps'
+						FROM sys.databases
+						WHERE database_id > 4 AND state = 0 AND HAS_DBACCESS(name) = 1;
+						IF @sql IS NULL
+							SELECT CAST(NULL AS SYSNAME) AS db_name, CAST(NULL AS INT) AS large_heap_count WHERE 1 = 0
+						ELSE
+							EXEC sp_executesql @sql`,
+		Fields: func(fields [][]any) []map[string]string {
+			res := []map[string]string{}
+			for _, f := range fields {
+				res = append(res, map[string]string{
+					"db_name":          HandleNilString(f[0]),
+					"large_heap_count": HandleNilInt(f[1]),
+				})
+			}
+			return res
+		},
+		OptIn: true,
+	},
+	{
+		// DB_FORCED_PLANS reports the number of plans forced in the Query Store of each user database.
+		// Many forced plans hint at ongoing performance firefighting. forced_plan_count is unknown if
+		// the Query Store of the database is off or the version predates SQL Server 2016.
+		Name: "DB_FORCED_PLANS",
+		Query: `IF CAST(SERVERPROPERTY('ProductMajorVersion') AS INT) >= 13
+						BEGIN
+							DECLARE @sql NVARCHAR(MAX);
+							SELECT @sql = COALESCE(@sql + N' UNION ALL ', N'')
+								+ N'SELECT ' + QUOTENAME(name, '''') + N' AS db_name,'
+								+ N' CASE WHEN o.actual_state = 0 THEN NULL ELSE'
+								+ N' (SELECT COUNT(*) FROM ' + QUOTENAME(name) + N'.sys.query_store_plan WHERE is_forced_plan = 1) END AS forced_plan_count'
+								+ N' FROM ' + QUOTENAME(name) + N'.sys.database_query_store_options o'
+							FROM sys.databases
+							WHERE database_id > 4 AND state = 0 AND HAS_DBACCESS(name) = 1;
+							IF @sql IS NULL
+								SELECT CAST(NULL AS SYSNAME) AS db_name, CAST(NULL AS INT) AS forced_plan_count WHERE 1 = 0
+							ELSE
+								EXEC sp_executesql @sql
+						END
+						ELSE
+							SELECT name, CAST(NULL AS INT) AS forced_plan_count FROM sys.databases WHERE database_id > 4`,
+		Fields: func(fields [][]any) []map[string]string {
+			res := []map[string]string{}
+			for _, f := range fields {
+				res = append(res, map[string]string{
+					"db_name":           HandleNilString(f[0]),
+					"forced_plan_count": HandleNilInt(f[1]),
+				})
+			}
+			return res
+		},
+	},
+	{
+		// INSTANCE_XEVENTS reports whether the system_health extended events session is running and the
+		// default trace is enabled, which observability and troubleshooting rely on.
+		Name: "INSTANCE_XEVENTS",
+		Query: `SELECT
+							CAST(CASE WHEN EXISTS (SELECT 1 FROM sys.dm_xe_sessions WHERE name = 'system_health') THEN 1 ELSE 0 END AS BIT) AS systemHealthRunning,
+							(SELECT CAST(value_in_use AS BIT) FROM sys.configurations WHERE name = 'default trace enabled') AS defaultTraceEnabled`,
+		Fields: func(fields [][]any) []map[string]string {
+			res := []map[string]string{}
+			for _, f := range fields {
+				res = append(res, map[string]string{
+					"system_health_running": HandleNilBool(f[0]),
+					"default_trace_enabled": HandleNilBool(f[1]),
+				})
+			}
+			return res
+		},
+	},
+	{
+		// DB_AG_REPLICA_CONFIG reports the availability, failover and seeding mode of each replica of
+		// the availability groups of the instance. Standalone instances, without Always On enabled,
+		// report no replicas. The seeding mode exists on SQL Server 2016 and later; older versions
+		// report it as unknown.
+		Name: "DB_AG_REPLICA_CONFIG",
+		Query: `IF CAST(ISNULL(SERVERPROPERTY('IsHadrEnabled'), 0) AS INT) = 0
+							SELECT CAST(NULL AS SYSNAME) AS ag_name, CAST(NULL AS NVARCHAR(256)) AS replica_server_name,
+								CAST(NULL AS NVARCHAR(60)) AS availability_mode_desc, CAST(NULL AS NVARCHAR(60)) AS failover_mode_desc,
+								CAST(NULL AS NVARCHAR(60)) AS seeding_mode_desc
+							WHERE 1 = 0
+						ELSE IF CAST(SERVERPROPERTY('ProductMajorVersion') AS INT) >= 13
+							EXEC sp_executesql N'SELECT ag.name, ar.replica_server_name, ar.availability_mode_desc, ar.failover_mode_desc, ar.seeding_mode_desc
+								FROM sys.availability_groups ag
+									INNER JOIN sys.availability_replicas ar ON ag.group_id = ar.group_id'
+						ELSE
+							SELECT ag.name, ar.replica_server_name, ar.availability_mode_desc, ar.failover_mode_desc, CAST(NULL AS NVARCHAR(60)) AS seeding_mode_desc
+							FROM sys.availability_groups ag
+								INNER JOIN sys.availability_replicas ar ON ag.group_id = ar.group_id`,
+		Fields: func(fields [][]any) []map[string]string {
+			res := []map[string]string{}
+			for _, f := range fields {
+				res = append(res, map[string]string{
+					"ag_name":             HandleNilString(f[0]),
+					"replica_server_name": HandleNilString(f[1]),
+					"availability_mode":   HandleNilString(f[2]),
+					"failover_mode":       HandleNilString(f[3]),
+					"seeding_mode":        HandleNilString(f[4]),
+				})
+			}
+			return res
+		},
+	},
+	{
+		// DB_LEGACY_CE reports whether each user database is pinned to the legacy cardinality
+		// estimator through its database scoped configuration. Database scoped configurations exist on
+		// SQL Server 2016 and later; older versions report legacy_ce as unknown.
+		Name: "DB_LEGACY_CE",
+		Query: `IF CAST(SERVERPROPERTY('ProductMajorVersion') AS INT) >= 13
+						BEGIN
+							DECLARE @sql NVARCHAR(MAX);
+							SELECT @sql = COALESCE(@sql + N' UNION ALL ', N'')
+								+ N'SELECT ' + QUOTENAME(name, '''') + N' AS db_name, CAST(value AS BIT) AS legacy_ce'
+								+ N' FROM ' + QUOTENAME(name) + N'.sys.database_scoped_configurations'
+								+ N' WHERE name = ''LEGACY_CARDINALITY_ESTIMATION'''
+							FROM sys.databases
+							WHERE database_id > 4 AND state = 0 AND HAS_DBACCESS(name) = 1;
+							IF @sql IS NULL
+								SELECT CAST(NULL AS SYSNAME) AS db_name, CAST(NULL AS BIT) AS legacy_ce WHERE 1 = 0
+							ELSE
+								EXEC sp_executesql @sql
+						END
+						ELSE
+							SELECT name, CAST(NULL AS BIT) AS legacy_ce FROM sys.databases WHERE database_id > 4`,
+		Fields: func(fields [][]any) []map[string]string {
+			res := []map[string]string{}
+			for _, f := range fields {
+				res = append(res, map[string]string{
+					"db_name":   HandleNilString(f[0]),
+					"legacy_ce": HandleNilBool(f[1]),
+				})
+			}
+			return res
+		},
+	},
 }
@@ -41,6 +41,15 @@ func TestFields(t *testing.T) {
 					int64(0),
 					true,
 				},
+				{
+					int64(1),
+					"test_db_name",
+					"C:\\test_log_name",
+					int64(0),
+					int64(1024),
+					int64(128),
+					false,
+				},
 			},
 			want: []map[string]string{
 				{
@@ -52,6 +61,20 @@ func TestFields(t *testing.T) {
 					"size":              "0",
 					"growth":            "0",
 					"is_percent_growth": "true",
+					"growth_mb":         "unknown",
+					"growth_too_small":  "false",
+				},
+				{
+					"db_name":           "test_db_name",
+					"filetype":          "1",
+					"physical_name":     "C:\\test_log_name",
+					"physical_drive":    "unknown",
+					"state":             "0",
+					"size":              "1024",
+					"growth":            "128",
+					"is_percent_growth": "false",
+					"growth_mb":         "1",
+					"growth_too_small":  "true",
 				},
 			},
 		},
@@ -107,6 +130,7 @@ func TestFields(t *testing.T) {
 					"vlf_size_in_mb":        "1.000000",
 					"active_vlf_count":      "0",
 					"active_vlf_size_in_mb": "1.000000",
+					"vlf_count_excessive":   "false",
 				},
 			},
 		},
@@ -216,11 +240,1024 @@ func TestFields(t *testing.T) {
 				},
 			},
 		},
-	}
-	for idx, tc := range testcases {
-		got := MasterRules[idx].Fields(tc.input)
-		if diff := cmp.Diff(got, tc.want); diff != "" {
-			t.Errorf("Fields() for rule %s returned wrong result (-got +want):\n%s", MasterRules[idx].Name, diff)
-		}
+		{
+			name: "INSTANCE_FILL_FACTOR",
+			input: [][]any{
+				{
+					int64(80),
+				},
+			},
+			want: []map[string]string{
+				{
+					"default_fill_factor": "80",
+				},
+			},
+		},
+		{
+			name: "INSTANCE_MAX_WORKER_THREADS",
+			input: [][]any{
+				{
+					int64(0),
+					int64(576),
+				},
+			},
+			want: []map[string]string{
+				{
+					"max_worker_threads":        "0",
+					"max_worker_threads_in_use": "576",
+				},
+			},
+		},
+		{
+			name: "INSTANCE_LINKED_SERVERS",
+			input: [][]any{
+				{
+					"REMOTE_SQL",
+					"SQL Server",
+					"SQLNCLI",
+					"remote-host",
+				},
+				{
+					"ORACLE_LINK",
+					"Oracle",
+					"OraOLEDB.Oracle",
+					nil,
+				},
+			},
+			want: []map[string]string{
+				{
+					"linked_servers": `[{"name":"REMOTE_SQL","product":"SQL Server","provider":"SQLNCLI","data_source":"remote-host"},{"name":"ORACLE_LINK","product":"Oracle","provider":"OraOLEDB.Oracle","data_source":"unknown"}]`,
+				},
+			},
+		},
+		{
+			name: "DB_TRUSTWORTHY",
+			input: [][]any{
+				{
+					"master",
+					false,
+				},
+				{
+					"app_db",
+					true,
+				},
+			},
+			want: []map[string]string{
+				{
+					"db_name":     "master",
+					"trustworthy": "false",
+				},
+				{
+					"db_name":     "app_db",
+					"trustworthy": "true",
+				},
+			},
+		},
+		{
+			name: "INSTANCE_BACKUP_COMPRESSION",
+			input: [][]any{
+				{
+					int64(1),
+				},
+			},
+			want: []map[string]string{
+				{
+					"backup_compression_default": "1",
+				},
+			},
+		},
+		{
+			name: "DB_DELAYED_DURABILITY",
+			input: [][]any{
+				{
+					"master",
+					"DISABLED",
+				},
+				{
+					"app_db",
+					"FORCED",
+				},
+			},
+			want: []map[string]string{
+				{
+					"db_name":            "master",
+					"delayed_durability": "DISABLED",
+				},
+				{
+					"db_name":            "app_db",
+					"delayed_durability": "FORCED",
+				},
+			},
+		},
+		{
+			name: "DB_TEMPDB_CONTENTION",
+			input: [][]any{
+				{
+					int64(53),
+					"PAGELATCH_UP",
+					"2:1:1",
+				},
+				{
+					int64(61),
+					"PAGELATCH_EX",
+					"2:3:3",
+				},
+			},
+			want: []map[string]string{
+				{
+					"tempdb_allocation_contention":       "true",
+					"tempdb_allocation_contention_count": "2",
+				},
+			},
+		},
+		{
+			name: "INSTANCE_OPTIMIZE_ADHOC",
+			input: [][]any{
+				{
+					int64(1),
+				},
+			},
+			want: []map[string]string{
+				{
+					"optimize_for_adhoc": "1",
+				},
+			},
+		},
+		{
+			name: "DB_ENCRYPTION",
+			input: [][]any{
+				{
+					"app_db",
+					int64(3),
+					true,
+				},
+				{
+					"tempdb",
+					int64(2),
+					false,
+				},
+				{
+					"master",
+					nil,
+					nil,
+				},
+			},
+			want: []map[string]string{
+				{
+					"db_name":          "app_db",
+					"encryption_state": "3",
+					"is_encrypted":     "true",
+				},
+				{
+					"db_name":          "tempdb",
+					"encryption_state": "2",
+					"is_encrypted":     "false",
+				},
+				{
+					"db_name":          "master",
+					"encryption_state": "0",
+					"is_encrypted":     "false",
+				},
+			},
+		},
+		{
+			name: "DB_IO_STALL",
+			input: [][]any{
+				{
+					"master",
+					"ROWS",
+					int64(1523),
+					int64(87),
+				},
+				{
+					"master",
+					"LOG",
+					int64(12),
+					int64(4096),
+				},
+				{
+					"app_db",
+					"ROWS",
+					nil,
+					nil,
+				},
+			},
+			want: []map[string]string{
+				{
+					"db_name":           "master",
+					"file_type":         "ROWS",
+					"io_stall_read_ms":  "1523",
+					"io_stall_write_ms": "87",
+				},
+				{
+					"db_name":           "master",
+					"file_type":         "LOG",
+					"io_stall_read_ms":  "12",
+					"io_stall_write_ms": "4096",
+				},
+				{
+					"db_name":           "app_db",
+					"file_type":         "ROWS",
+					"io_stall_read_ms":  "unknown",
+					"io_stall_write_ms": "unknown",
+				},
+			},
+		},
+		{
+			name: "DB_BACKUP_HISTORY",
+			input: [][]any{
+				{
+					"app_db",
+					"2026-10-11T01:00:12.000",
+					"2026-10-15T01:00:05.000",
+					"2026-10-15T23:45:00.000",
+				},
+				{
+					"simple_db",
+					"2026-10-14T02:30:00.000",
+					nil,
+					nil,
+				},
+				{
+					"new_db",
+					nil,
+					nil,
+					nil,
+				},
+			},
+			want: []map[string]string{
+				{
+					"db_name":          "app_db",
+					"last_full_backup": "2026-10-11T01:00:12.000",
+					"last_diff_backup": "2026-10-15T01:00:05.000",
+					"last_log_backup":  "2026-10-15T23:45:00.000",
+				},
+				{
+					"db_name":          "simple_db",
+					"last_full_backup": "2026-10-14T02:30:00.000",
+					"last_diff_backup": "none",
+					"last_log_backup":  "none",
+				},
+				{
+					"db_name":          "new_db",
+					"last_full_backup": "none",
+					"last_diff_backup": "none",
+					"last_log_backup":  "none",
+				},
+			},
+		},
+		{
+			name: "INSTANCE_DEFAULT_PATHS",
+			input: [][]any{
+				{
+					`D:\MSSQL\Data\`,
+					nil,
+					nil,
+				},
+			},
+			want: []map[string]string{
+				{
+					"default_data_path":   `D:\MSSQL\Data\`,
+					"default_log_path":    "unknown",
+					"default_backup_path": "unknown",
+				},
+			},
+		},
+		{
+			name: "INSTANCE_AFFINITY",
+			input: [][]any{
+				{
+					int64(0),
+					int64(15),
+					nil,
+				},
+			},
+			want: []map[string]string{
+				{
+					"affinity_mask":    "0",
+					"affinity64_mask":  "15",
+					"affinity_io_mask": "unknown",
+				},
+			},
+		},
+		{
+			name: "INSTANCE_AGENT_JOB_FAILURES",
+			input: [][]any{
+				{"nightly backup"},
+				{"index maintenance"},
+			},
+			want: []map[string]string{
+				{
+					"failed_job_count": "2",
+					"failed_jobs":      `["nightly backup","index maintenance"]`,
+				},
+			},
+		},
+		{
+			name: "INSTANCE_COST_THRESHOLD",
+			input: [][]any{
+				{
+					int64(50),
+				},
+			},
+			want: []map[string]string{
+				{
+					"cost_threshold_parallelism": "50",
+				},
+			},
+		},
+		{
+			name: "DB_PARAMETERIZATION",
+			input: [][]any{
+				{
+					"master",
+					false,
+				},
+				{
+					"app_db",
+					true,
+				},
+			},
+			want: []map[string]string{
+				{
+					"db_name":                 "master",
+					"forced_parameterization": "false",
+				},
+				{
+					"db_name":                 "app_db",
+					"forced_parameterization": "true",
+				},
+			},
+		},
+		{
+			name: "INSTANCE_DATABASE_SUMMARY",
+			input: [][]any{
+				{
+					int64(3),
+					int64(20480),
+					nil,
+				},
+			},
+			want: []map[string]string{
+				{
+					"user_database_count": "3",
+					"total_data_size_mb":  "20480",
+					"total_log_size_mb":   "unknown",
+				},
+			},
+		},
+		{
+			name: "AGENT_LOGIN_PERMISSIONS",
+			input: [][]any{
+				{
+					false,
+					true,
+				},
+			},
+			want: []map[string]string{
+				{
+					"agent_login_is_sysadmin":           "false",
+					"agent_login_has_view_server_state": "true",
+				},
+			},
+		},
+		{
+			name: "DB_BLOCKING",
+			input: [][]any{
+				{
+					int64(3),
+					int64(45210),
+				},
+			},
+			want: []map[string]string{
+				{
+					"blocked_session_count": "3",
+					"longest_block_ms":      "45210",
+				},
+			},
+		},
+		{
+			name: "INSTANCE_LOCK_PAGES",
+			input: [][]any{
+				{
+					"LOCK_PAGES",
+				},
+			},
+			want: []map[string]string{
+				{
+					"memory_model": "LOCK_PAGES",
+				},
+			},
+		},
+		{
+			name: "DB_SCOPED_MAXDOP",
+			input: [][]any{
+				{
+					"db1",
+					int64(0),
+				},
+				{
+					"db2",
+					int64(4),
+				},
+			},
+			want: []map[string]string{
+				{
+					"db_name":       "db1",
+					"scoped_maxdop": "0",
+				},
+				{
+					"db_name":       "db2",
+					"scoped_maxdop": "4",
+				},
+			},
+		},
+		{
+			name: "DB_LOG_BACKUP_RISK",
+			input: [][]any{
+				{
+					"db1",
+					"FULL",
+					int64(48),
+				},
+				{
+					"db2",
+					"BULK_LOGGED",
+					nil,
+				},
+				{
+					"db3",
+					"SIMPLE",
+					nil,
+				},
+			},
+			want: []map[string]string{
+				{
+					"db_name":                "db1",
+					"recovery_model":         "FULL",
+					"hours_since_log_backup": "48",
+					"log_backup_risk":        "true",
+				},
+				{
+					"db_name":                "db2",
+					"recovery_model":         "BULK_LOGGED",
+					"hours_since_log_backup": "none",
+					"log_backup_risk":        "true",
+				},
+				{
+					"db_name":                "db3",
+					"recovery_model":         "SIMPLE",
+					"hours_since_log_backup": "none",
+					"log_backup_risk":        "false",
+				},
+			},
+		},
+		{
+			name: "INSTANCE_SP_CONFIGURE",
+			input: [][]any{
+				{
+					"max degree of parallelism",
+					int64(8),
+				},
+				{
+					"cost threshold for parallelism",
+					int64(50),
+				},
+				{
+					"optimize for ad hoc workloads",
+					int64(1),
+				},
+				{
+					"backup compression default",
+					int64(0),
+				},
+				{
+					"max worker threads",
+					nil,
+				},
+			},
+			want: []map[string]string{
+				{
+					"sp_configure": `{"backup compression default":"0","cost threshold for parallelism":"50","max degree of parallelism":"8","max worker threads":"unknown","optimize for ad hoc workloads":"1"}`,
+				},
+			},
+		},
+		{
+			name: "INSTANCE_MIN_SERVER_MEMORY",
+			input: [][]any{
+				{
+					int64(4096),
+					int64(16),
+				},
+			},
+			want: []map[string]string{
+				{
+					"min_server_memory":        "4096",
+					"min_server_memory_in_use": "16",
+				},
+			},
+		},
+		{
+			name: "INSTANCE_TEMPDB_MEMOPT",
+			input: [][]any{
+				{
+					true,
+				},
+				{
+					nil,
+				},
+			},
+			want: []map[string]string{
+				{
+					"tempdb_metadata_memory_optimized": "true",
+				},
+				{
+					"tempdb_metadata_memory_optimized": "unknown",
+				},
+			},
+		},
+		{
+			name: "INSTANCE_SURFACE_AREA",
+			input: [][]any{
+				{
+					true,
+					false,
+					true,
+					nil,
+				},
+			},
+			want: []map[string]string{
+				{
+					"clr_enabled":                "true",
+					"ole_automation_procedures":  "false",
+					"xp_cmdshell":                "true",
+					"ad_hoc_distributed_queries": "unknown",
+				},
+			},
+		},
+		{
+			name: "INSTANCE_DATABASE_MAIL",
+			input: [][]any{
+				{
+					true,
+					int64(3),
+				},
+				{
+					false,
+					nil,
+				},
+			},
+			want: []map[string]string{
+				{
+					"database_mail_enabled": "true",
+					"recent_mail_failures":  "3",
+				},
+				{
+					"database_mail_enabled": "false",
+					"recent_mail_failures":  "unknown",
+				},
+			},
+		},
+		{
+			name: "DB_ADR",
+			input: [][]any{
+				{
+					"db1",
+					true,
+				},
+				{
+					"db2",
+					nil,
+				},
+			},
+			want: []map[string]string{
+				{
+					"db_name":     "db1",
+					"adr_enabled": "true",
+				},
+				{
+					"db_name":     "db2",
+					"adr_enabled": "unknown",
+				},
+			},
+		},
+		{
+			name: "INSTANCE_SEVERE_EVENTS",
+			input: [][]any{
+				{
+					true,
+					int64(2),
+				},
+				{
+					false,
+					nil,
+				},
+			},
+			want: []map[string]string{
+				{
+					"default_trace_enabled": "true",
+					"severe_events":         "2",
+				},
+				{
+					"default_trace_enabled": "false",
+					"severe_events":         "unknown",
+				},
+			},
+		},
+		{
+			name: "DB_HEAP_TABLES",
+			input: [][]any{
+				{
+					"db1",
+					int64(0),
+				},
+				{
+					"db2",
+					int64(3),
+				},
+			},
+			want: []map[string]string{
+				{
+					"db_name":          "db1",
+					"large_heap_count": "0",
+				},
+				{
+					"db_name":          "db2",
+					"large_heap_count": "3",
+				},
+			},
+		},
+		{
+			name: "DB_FORCED_PLANS",
+			input: [][]any{
+				{
+					"db1",
+					int64(4),
+				},
+				{
+					"db2",
+					nil,
+				},
+			},
+			want: []map[string]string{
+				{
+					"db_name":           "db1",
+					"forced_plan_count": "4",
+				},
+				{
+					"db_name":           "db2",
+					"forced_plan_count": "unknown",
+				},
+			},
+		},
+		{
+			name: "INSTANCE_XEVENTS",
+			input: [][]any{
+				{
+					true,
+					true,
+				},
+				{
+					false,
+					nil,
+				},
+			},
+			want: []map[string]string{
+				{
+					"system_health_running": "true",
+					"default_trace_enabled": "true",
+				},
+				{
+					"system_health_running": "false",
+					"default_trace_enabled": "unknown",
+				},
+			},
+		},
+		{
+			name: "DB_AG_REPLICA_CONFIG",
+			input: [][]any{
+				{
+					"ag1",
+					"node1",
+					"SYNCHRONOUS_COMMIT",
+					"AUTOMATIC",
+					"AUTOMATIC",
+				},
+				{
+					"ag1",
+					"node2",
+					"ASYNCHRONOUS_COMMIT",
+					"MANUAL",
+					nil,
+				},
+			},
+			want: []map[string]string{
+				{
+					"ag_name":             "ag1",
+					"replica_server_name": "node1",
+					"availability_mode":   "SYNCHRONOUS_COMMIT",
+					"failover_mode":       "AUTOMATIC",
+					"seeding_mode":        "AUTOMATIC",
+				},
+				{
+					"ag_name":             "ag1",
+					"replica_server_name": "node2",
+					"availability_mode":   "ASYNCHRONOUS_COMMIT",
+					"failover_mode":       "MANUAL",
+					"seeding_mode":        "unknown",
+				},
+			},
+		},
+		{
+			name: "DB_LEGACY_CE",
+			input: [][]any{
+				{
+					"db1",
+					true,
+				},
+				{
+					"db2",
+					false,
+				},
+				{
+					"db3",
+					nil,
+				},
+			},
+			want: []map[string]string{
+				{
+					"db_name":   "db1",
+					"legacy_ce": "true",
+				},
+				{
+					"db_name":   "db2",
+					"legacy_ce": "false",
+				},
+				{
+					"db_name":   "db3",
+					"legacy_ce": "unknown",
+				},
+			},
+		},
+	}
+	for idx, tc := range testcases {
+		got := MasterRules[idx].Fields(tc.input)
+		if diff := cmp.Diff(got, tc.want); diff != "" {
+			t.Errorf("Fields() for rule %s returned wrong result (-got +want):\n%s", MasterRules[idx].Name, diff)
+		}
+	}
+}
+
+func TestLinkedServersFieldsEmpty(t *testing.T) {
+	for _, rule := range MasterRules {
+		if rule.Name != "INSTANCE_LINKED_SERVERS" {
+			continue
+		}
+		got := rule.Fields([][]any{})
+		want := []map[string]string{{"linked_servers": "[]"}}
+		if diff := cmp.Diff(got, want); diff != "" {
+			t.Errorf("Fields() for rule %s with no linked servers returned wrong result (-got +want):\n%s", rule.Name, diff)
+		}
+		return
+	}
+	t.Fatal("rule INSTANCE_LINKED_SERVERS not found in MasterRules")
+}
+
+func TestTempdbContentionFieldsEmpty(t *testing.T) {
+	for _, rule := range MasterRules {
+		if rule.Name != "DB_TEMPDB_CONTENTION" {
+			continue
+		}
+		got := rule.Fields(nil)
+		want := []map[string]string{{
+			"tempdb_allocation_contention":       "false",
+			"tempdb_allocation_contention_count": "0",
+		}}
+		if diff := cmp.Diff(got, want); diff != "" {
+			t.Errorf("Fields() for rule %s with no waiting tasks returned wrong result (-got +want):\n%s", rule.Name, diff)
+		}
+		return
+	}
+	t.Fatal("rule DB_TEMPDB_CONTENTION not found in MasterRules")
+}
+
+func TestAgentJobFailuresFieldsEmpty(t *testing.T) {
+	for _, rule := range MasterRules {
+		if rule.Name != "INSTANCE_AGENT_JOB_FAILURES" {
+			continue
+		}
+		got := rule.Fields([][]any{})
+		want := []map[string]string{{"failed_job_count": "0", "failed_jobs": "[]"}}
+		if diff := cmp.Diff(got, want); diff != "" {
+			t.Errorf("Fields() for rule %s with no failed jobs returned wrong result (-got +want):\n%s", rule.Name, diff)
+		}
+		return
+	}
+	t.Fatal("rule INSTANCE_AGENT_JOB_FAILURES not found in MasterRules")
+}
+
+func TestVLFCountExcessive(t *testing.T) {
+	testcases := []struct {
+		name      string
+		vlfCount  any
+		logSizeMB any
+		want      string
+	}{
+		{
+			name:      "few vlfs",
+			vlfCount:  int64(16),
+			logSizeMB: float64(1024),
+			want:      "false",
+		},
+		{
+			name:      "more vlfs than the max count",
+			vlfCount:  int64(1001),
+			logSizeMB: float64(512000),
+			want:      "true",
+		},
+		{
+			name:      "too many vlfs for the log size",
+			vlfCount:  int64(400),
+			logSizeMB: float64(1024),
+			want:      "true",
+		},
+		{
+			name:      "vlfs proportionate to the log size",
+			vlfCount:  int64(400),
+			logSizeMB: float64(8192),
+			want:      "false",
+		},
+		{
+			name:      "small log below the ratio count",
+			vlfCount:  int64(100),
+			logSizeMB: float64(10),
+			want:      "false",
+		},
+		{
+			name:      "int32 vlf count",
+			vlfCount:  int32(2000),
+			logSizeMB: nil,
+			want:      "true",
+		},
+		{
+			name:      "unknown vlf count",
+			vlfCount:  nil,
+			logSizeMB: float64(1024),
+			want:      "unknown",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := VLFCountExcessive(tc.vlfCount, tc.logSizeMB); got != tc.want {
+				t.Errorf("VLFCountExcessive(%v, %v) = %q, want %q", tc.vlfCount, tc.logSizeMB, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestLogBackupRisk(t *testing.T) {
+	testcases := []struct {
+		name                string
+		recoveryModel       any
+		hoursSinceLogBackup any
+		want                string
+	}{
+		{
+			name:                "full recovery with a recent log backup",
+			recoveryModel:       "FULL",
+			hoursSinceLogBackup: int64(1),
+			want:                "false",
+		},
+		{
+			name:                "full recovery at the max age",
+			recoveryModel:       "FULL",
+			hoursSinceLogBackup: int64(24),
+			want:                "false",
+		},
+		{
+			name:                "full recovery with an old log backup",
+			recoveryModel:       "FULL",
+			hoursSinceLogBackup: int32(25),
+			want:                "true",
+		},
+		{
+			name:          "full recovery without log backup",
+			recoveryModel: "FULL",
+			want:          "true",
+		},
+		{
+			name:                "bulk logged recovery with an old log backup",
+			recoveryModel:       "BULK_LOGGED",
+			hoursSinceLogBackup: int64(100),
+			want:                "true",
+		},
+		{
+			name:          "simple recovery without log backup",
+			recoveryModel: "SIMPLE",
+			want:          "false",
+		},
+		{
+			name:                "unknown recovery model",
+			hoursSinceLogBackup: int64(100),
+			want:                "unknown",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := LogBackupRisk(tc.recoveryModel, tc.hoursSinceLogBackup); got != tc.want {
+				t.Errorf("LogBackupRisk(%v, %v) = %q, want %q", tc.recoveryModel, tc.hoursSinceLogBackup, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestAutogrowthIncrement(t *testing.T) {
+	testcases := []struct {
+		name            string
+		fileType        any
+		growth          any
+		isPercentGrowth any
+		wantGrowthMB    string
+		wantTooSmall    string
+	}{
+		{
+			name:            "data file growing by 1MB",
+			fileType:        int64(0),
+			growth:          int64(128),
+			isPercentGrowth: false,
+			wantGrowthMB:    "1",
+			wantTooSmall:    "true",
+		},
+		{
+			name:            "data file growing by 64MB",
+			fileType:        int64(0),
+			growth:          int64(8192),
+			isPercentGrowth: false,
+			wantGrowthMB:    "64",
+			wantTooSmall:    "false",
+		},
+		{
+			name:            "log file growing by 128MB",
+			fileType:        int64(1),
+			growth:          int32(16384),
+			isPercentGrowth: false,
+			wantGrowthMB:    "128",
+			wantTooSmall:    "true",
+		},
+		{
+			name:            "log file growing by 256MB",
+			fileType:        int64(1),
+			growth:          int64(32768),
+			isPercentGrowth: false,
+			wantGrowthMB:    "256",
+			wantTooSmall:    "false",
+		},
+		{
+			name:            "data file growing by 0.5MB",
+			fileType:        int64(0),
+			growth:          int64(64),
+			isPercentGrowth: false,
+			wantGrowthMB:    "0.5",
+			wantTooSmall:    "true",
+		},
+		{
+			name:            "autogrowth disabled",
+			fileType:        int64(0),
+			growth:          int64(0),
+			isPercentGrowth: false,
+			wantGrowthMB:    "0",
+			wantTooSmall:    "false",
+		},
+		{
+			name:            "percent growth",
+			fileType:        int64(1),
+			growth:          int64(10),
+			isPercentGrowth: true,
+			wantGrowthMB:    "unknown",
+			wantTooSmall:    "false",
+		},
+		{
+			name:            "filestream file",
+			fileType:        int64(2),
+			growth:          int64(128),
+			isPercentGrowth: false,
+			wantGrowthMB:    "1",
+			wantTooSmall:    "false",
+		},
+		{
+			name:         "unknown growth",
+			fileType:     int64(0),
+			wantGrowthMB: "unknown",
+			wantTooSmall: "unknown",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			gotGrowthMB, gotTooSmall := AutogrowthIncrement(tc.fileType, tc.growth, tc.isPercentGrowth)
+			if gotGrowthMB != tc.wantGrowthMB || gotTooSmall != tc.wantTooSmall {
+				t.Errorf("AutogrowthIncrement(%v, %v, %v) = (%q, %q), want (%q, %q)", tc.fileType, tc.growth, tc.isPercentGrowth, gotGrowthMB, gotTooSmall, tc.wantGrowthMB, tc.wantTooSmall)
+			}
+		})
 	}
 }
@@ -0,0 +1,196 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretmanager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Names of the secret providers.
+const (
+	ProviderSecretManager = "secret_manager"
+	ProviderFile          = "file"
+	ProviderEnv           = "env"
+	ProviderVault         = "vault"
+)
+
+// SecretProvider returns the value of a secret from a secret store.
+type SecretProvider interface {
+	SecretValue(ctx context.Context, projectID, secretName string) (string, error)
+}
+
+// Options configures the secret providers.
+type Options struct {
+	// FileDirectory is the directory relative secret names of the file provider are resolved against.
+	FileDirectory string
+	// VaultAddress is the address of the Vault server, e.g. "https://vault.example.com:8200".
+	VaultAddress string
+	// VaultMount is the mount path of the KV version 2 secrets engine. Defaults to "secret".
+	VaultMount string
+	// VaultKey is the key of the secret value in the Vault secret. Defaults to "password".
+	VaultKey string
+	// VaultTokenFile is the file holding the Vault token. Defaults to the VAULT_TOKEN environment variable.
+	VaultTokenFile string
+}
+
+// NewProvider returns the secret provider of the given name. An empty name is Secret Manager.
+func NewProvider(name string, opts Options) (SecretProvider, error) {
+	switch strings.ToLower(name) {
+	case "", ProviderSecretManager:
+		return GoogleProvider{}, nil
+	case ProviderFile:
+		return FileProvider{Dir: opts.FileDirectory}, nil
+	case ProviderEnv:
+		return EnvProvider{}, nil
+	case ProviderVault:
+		if opts.VaultAddress == "" {
+			return nil, fmt.Errorf("the vault secret provider requires a vault address")
+		}
+		return VaultProvider{
+			Address:   opts.VaultAddress,
+			Mount:     opts.VaultMount,
+			Key:       opts.VaultKey,
+			TokenFile: opts.VaultTokenFile,
+			Client:    http.DefaultClient,
+		}, nil
+	}
+	return nil, fmt.Errorf("invalid secret provider %q, supported providers are %s, %s, %s and %s", name, ProviderSecretManager, ProviderFile, ProviderEnv, ProviderVault)
+}
+
+// GoogleProvider reads the latest version of the secrets from Google Cloud Secret Manager.
+type GoogleProvider struct{}
+
+// SecretValue returns the latest version of secretName in projectID.
+func (GoogleProvider) SecretValue(ctx context.Context, projectID, secretName string) (string, error) {
+	client, err := NewClient(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer client.Close()
+	return client.GetSecretValue(ctx, projectID, secretName)
+}
+
+// FileProvider reads the secrets from files, e.g. mounted by a container orchestrator.
+type FileProvider struct {
+	Dir string
+}
+
+// SecretValue returns the content of the file secretName without its trailing newline.
+// A relative secretName is resolved against the directory of the provider.
+func (p FileProvider) SecretValue(_ context.Context, _, secretName string) (string, error) {
+	path := secretName
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(p.Dir, path)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the secret file %s: %v", path, err)
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
+
+// EnvProvider reads the secrets from environment variables.
+type EnvProvider struct{}
+
+// SecretValue returns the value of the environment variable secretName.
+func (EnvProvider) SecretValue(_ context.Context, _, secretName string) (string, error) {
+	v, ok := os.LookupEnv(secretName)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s of the secret is not set", secretName)
+	}
+	return v, nil
+}
+
+// VaultProvider reads the secrets from the KV version 2 secrets engine of HashiCorp Vault.
+type VaultProvider struct {
+	Address   string
+	Mount     string
+	Key       string
+	TokenFile string
+	Client    *http.Client
+}
+
+// vaultResponse is the response of reading a KV version 2 secret.
+type vaultResponse struct {
+	Data struct {
+		Data map[string]any `json:"data"`
+	} `json:"data"`
+}
+
+// SecretValue returns the value of the key of the provider in the Vault secret at path secretName.
+func (p VaultProvider) SecretValue(ctx context.Context, _, secretName string) (string, error) {
+	token, err := p.token()
+	if err != nil {
+		return "", err
+	}
+	mount, key := p.Mount, p.Key
+	if mount == "" {
+		mount = "secret"
+	}
+	if key == "" {
+		key = "password"
+	}
+	u, err := url.JoinPath(p.Address, "v1", mount, "data", secretName)
+	if err != nil {
+		return "", fmt.Errorf("invalid vault address %q: %v", p.Address, err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to read the vault secret %s: %s", secretName, resp.Status)
+	}
+	var r vaultResponse
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return "", fmt.Errorf("invalid response for the vault secret %s: %v", secretName, err)
+	}
+	v, ok := r.Data.Data[key].(string)
+	if !ok {
+		return "", fmt.Errorf("vault secret %s has no string key %q", secretName, key)
+	}
+	return v, nil
+}
+
+// token returns the Vault token read from the token file, or from VAULT_TOKEN if the provider has
+// no token file.
+func (p VaultProvider) token() (string, error) {
+	if p.TokenFile == "" {
+		if t := os.Getenv("VAULT_TOKEN"); t != "" {
+			return t, nil
+		}
+		return "", fmt.Errorf("no vault token, set the vault token file or VAULT_TOKEN")
+	}
+	b, err := os.ReadFile(p.TokenFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the vault token file: %v", err)
+	}
+	return strings.TrimSpace(string(b)), nil
+}
@@ -0,0 +1,206 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package secretmanager
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewProvider(t *testing.T) {
+	testcases := []struct {
+		name    string
+		opts    Options
+		want    SecretProvider
+		wantErr bool
+	}{
+		{name: "", want: GoogleProvider{}},
+		{name: "secret_manager", want: GoogleProvider{}},
+		{name: "file", opts: Options{FileDirectory: "/secrets"}, want: FileProvider{Dir: "/secrets"}},
+		{name: "ENV", want: EnvProvider{}},
+		{
+			name: "vault",
+			opts: Options{VaultAddress: "https://vault:8200", VaultMount: "kv", VaultKey: "pw", VaultTokenFile: "/token"},
+			want: VaultProvider{Address: "https://vault:8200", Mount: "kv", Key: "pw", TokenFile: "/token", Client: http.DefaultClient},
+		},
+		{name: "vault", wantErr: true},
+		{name: "unknown", wantErr: true},
+	}
+	for _, tc := range testcases {
+		got, err := NewProvider(tc.name, tc.opts)
+		if gotErr := err != nil; gotErr != tc.wantErr {
+			t.Errorf("NewProvider(%q) returned error: %v, want error: %v", tc.name, err, tc.wantErr)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("NewProvider(%q) = %#v, want %#v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestFileProvider(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "sql-password"), []byte("pswd\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	p := FileProvider{Dir: dir}
+	testcases := []struct {
+		name       string
+		secretName string
+		want       string
+		wantErr    bool
+	}{
+		{name: "relative name", secretName: "sql-password", want: "pswd"},
+		{name: "absolute name", secretName: filepath.Join(dir, "sql-password"), want: "pswd"},
+		{name: "missing file", secretName: "missing", wantErr: true},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := p.SecretValue(context.Background(), "project", tc.secretName)
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Fatalf("SecretValue(%q) returned error: %v, want error: %v", tc.secretName, err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Errorf("SecretValue(%q) = %q, want %q", tc.secretName, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestEnvProvider(t *testing.T) {
+	t.Setenv("SQL_SERVER_AGENT_TEST_SECRET", "pswd")
+	got, err := EnvProvider{}.SecretValue(context.Background(), "project", "SQL_SERVER_AGENT_TEST_SECRET")
+	if err != nil || got != "pswd" {
+		t.Errorf("SecretValue() = %q, %v, want %q, nil", got, err, "pswd")
+	}
+	if _, err := (EnvProvider{}).SecretValue(context.Background(), "project", "SQL_SERVER_AGENT_TEST_UNSET"); err == nil {
+		t.Error("SecretValue() of an unset variable returned nil error, want error")
+	}
+}
+
+func TestVaultProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/sql/prod":
+			w.Write([]byte(`{"data":{"data":{"password":"pswd","port":1433}}}`))
+		case "/v1/kv/data/sql/prod":
+			w.Write([]byte(`{"data":{"data":{"pw":"kvpswd"}}}`))
+		case "/v1/secret/data/invalid":
+			w.Write([]byte(`not json`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("token\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	testcases := []struct {
+		name       string
+		provider   VaultProvider
+		secretName string
+		want       string
+		wantErr    bool
+	}{
+		{
+			name:       "default mount and key",
+			provider:   VaultProvider{TokenFile: tokenFile},
+			secretName: "sql/prod",
+			want:       "pswd",
+		},
+		{
+			name:       "custom mount and key",
+			provider:   VaultProvider{Mount: "kv", Key: "pw", TokenFile: tokenFile},
+			secretName: "sql/prod",
+			want:       "kvpswd",
+		},
+		{
+			name:       "key is not a string",
+			provider:   VaultProvider{Key: "port", TokenFile: tokenFile},
+			secretName: "sql/prod",
+			wantErr:    true,
+		},
+		{
+			name:       "missing key",
+			provider:   VaultProvider{Key: "user", TokenFile: tokenFile},
+			secretName: "sql/prod",
+			wantErr:    true,
+		},
+		{
+			name:       "missing secret",
+			provider:   VaultProvider{TokenFile: tokenFile},
+			secretName: "sql/dev",
+			wantErr:    true,
+		},
+		{
+			name:       "invalid response",
+			provider:   VaultProvider{TokenFile: tokenFile},
+			secretName: "invalid",
+			wantErr:    true,
+		},
+		{
+			name:       "missing token file",
+			provider:   VaultProvider{TokenFile: filepath.Join(t.TempDir(), "missing")},
+			secretName: "sql/prod",
+			wantErr:    true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.provider.Address = server.URL
+			tc.provider.Client = server.Client()
+			got, err := tc.provider.SecretValue(context.Background(), "project", tc.secretName)
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Fatalf("SecretValue(%q) returned error: %v, want error: %v", tc.secretName, err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Errorf("SecretValue(%q) = %q, want %q", tc.secretName, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestVaultProviderTokenFromEnv(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "envtoken" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(`{"data":{"data":{"password":"pswd"}}}`))
+	}))
+	defer server.Close()
+	p := VaultProvider{Address: server.URL, Client: server.Client()}
+
+	t.Setenv("VAULT_TOKEN", "")
+	if _, err := p.SecretValue(context.Background(), "project", "sql"); err == nil {
+		t.Error("SecretValue() without a token returned nil error, want error")
+	}
+	t.Setenv("VAULT_TOKEN", "envtoken")
+	if got, err := p.SecretValue(context.Background(), "project", "sql"); err != nil || got != "pswd" {
+		t.Errorf("SecretValue() = %q, %v, want %q, nil", got, err, "pswd")
+	}
+}
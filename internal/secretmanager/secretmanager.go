@@ -39,8 +39,9 @@ type Client struct {
 // NewClient create and return an instance of SecretManagerClient.
 // Returns nil if there is an error during the NewClient.
 func NewClient(ctx context.Context) (*Client, error) {
-	// Create the client.
-	client, err := secretmanager.NewClient(ctx)
+	// Create the client. The REST client is used so that connections go through the
+	// http.DefaultTransport based transport and respect its TLS configuration.
+	client, err := secretmanager.NewRESTClient(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,82 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package selfmetrics measures the resource usage of the agent process, so operators can verify the
+// agent itself stays lightweight.
+package selfmetrics
+
+import (
+	"fmt"
+	"runtime"
+	"time"
+)
+
+// Usage is the resource usage of the agent process.
+type Usage struct {
+	// PeakRSSBytes is the peak resident set size of the process, its peak working set on windows.
+	PeakRSSBytes uint64
+	// Goroutines is the number of goroutines of the process.
+	Goroutines int
+	// CPUTime is the user and system CPU time of the process since it started, or during the cycle
+	// for the usage returned by Cycle.End.
+	CPUTime time.Duration
+}
+
+// Fields returns the usage as the fields of a detail.
+func (u Usage) Fields() map[string]string {
+	return map[string]string{
+		"agent_peak_rss_bytes": fmt.Sprintf("%d", u.PeakRSSBytes),
+		"agent_goroutines":     fmt.Sprintf("%d", u.Goroutines),
+		"agent_cpu_time_ms":    fmt.Sprintf("%d", u.CPUTime.Milliseconds()),
+	}
+}
+
+// Current returns the resource usage of the process since it started.
+func Current() (Usage, error) {
+	peakRSS, cpu, err := processUsage()
+	if err != nil {
+		return Usage{}, fmt.Errorf("failed to get the resource usage of the process: %w", err)
+	}
+	return Usage{PeakRSSBytes: peakRSS, Goroutines: runtime.NumGoroutine(), CPUTime: cpu}, nil
+}
+
+// Cycle measures the resource usage of the process during a collection cycle.
+// The guest os and sql collection services run in the same process, so the CPU time of a cycle
+// includes the CPU time of the other service during the cycle.
+type Cycle struct {
+	start Usage
+	err   error
+}
+
+// StartCycle starts measuring a cycle.
+func StartCycle() *Cycle {
+	start, err := Current()
+	return &Cycle{start: start, err: err}
+}
+
+// End returns the usage of the process at the end of the cycle, with the CPU time spent during the
+// cycle.
+func (c *Cycle) End() (Usage, error) {
+	if c.err != nil {
+		return Usage{}, c.err
+	}
+	u, err := Current()
+	if err != nil {
+		return Usage{}, err
+	}
+	u.CPUTime -= c.start.CPUTime
+	return u, nil
+}
@@ -0,0 +1,32 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package selfmetrics
+
+import (
+	"syscall"
+	"time"
+)
+
+// processUsage returns the peak resident set size and the CPU time of the process.
+func processUsage() (uint64, time.Duration, error) {
+	var ru syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &ru); err != nil {
+		return 0, 0, err
+	}
+	// Maxrss is in kilobytes on linux.
+	return uint64(ru.Maxrss) * 1024, time.Duration(ru.Utime.Nano() + ru.Stime.Nano()), nil
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package selfmetrics
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCurrent(t *testing.T) {
+	got, err := Current()
+	if err != nil {
+		t.Fatalf("Current() returned unexpected error: %v", err)
+	}
+	// The test binary holds at least a megabyte resident.
+	if got.PeakRSSBytes < 1<<20 {
+		t.Errorf("Current() returned PeakRSSBytes %d, want at least %d", got.PeakRSSBytes, 1<<20)
+	}
+	if got.Goroutines < 1 {
+		t.Errorf("Current() returned Goroutines %d, want at least 1", got.Goroutines)
+	}
+	if got.CPUTime <= 0 {
+		t.Errorf("Current() returned CPUTime %v, want positive", got.CPUTime)
+	}
+}
+
+func TestCycle(t *testing.T) {
+	c := StartCycle()
+	start := time.Now()
+	// Spend some CPU time in the cycle.
+	for x := 0; time.Since(start) < 100*time.Millisecond; x++ {
+	}
+	wall := time.Since(start)
+
+	got, err := c.End()
+	if err != nil {
+		t.Fatalf("End() returned unexpected error: %v", err)
+	}
+	if got.CPUTime <= 0 {
+		t.Errorf("End() returned CPUTime %v, want positive", got.CPUTime)
+	}
+	// The cycle can not use more CPU time than all the CPUs during the cycle.
+	if max := wall * time.Duration(runtime.NumCPU()) * 2; got.CPUTime > max {
+		t.Errorf("End() returned CPUTime %v, want at most %v", got.CPUTime, max)
+	}
+	if got.Goroutines < 1 {
+		t.Errorf("End() returned Goroutines %d, want at least 1", got.Goroutines)
+	}
+}
+
+func TestUsageFields(t *testing.T) {
+	u := Usage{PeakRSSBytes: 52428800, Goroutines: 12, CPUTime: 1500 * time.Millisecond}
+	want := map[string]string{
+		"agent_peak_rss_bytes": "52428800",
+		"agent_goroutines":     "12",
+		"agent_cpu_time_ms":    "1500",
+	}
+	if diff := cmp.Diff(want, u.Fields()); diff != "" {
+		t.Errorf("Fields() returned an unexpected diff (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sqlcollector
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+
+	mssql "github.com/microsoft/go-mssqldb"
+	"golang.org/x/oauth2/google"
+	"github.com/GoogleCloudPlatform/sql-server-agent/internal/agentstatus"
+)
+
+// accessTokenScope is the scope of the access token obtained from the default credentials.
+const accessTokenScope = "https://www.googleapis.com/auth/cloud-platform"
+
+// NewV1AccessToken initializes a V1 instance authenticating with the access token returned by
+// tokenProvider instead of a user name and password. tokenProvider is called for each new
+// connection. conn must not set the user id and password.
+func NewV1AccessToken(conn string, tokenProvider func() (string, error), windows bool, usageMetricsLogger agentstatus.AgentStatus) (*V1, error) {
+	connector, err := mssql.NewAccessTokenConnector(conn, tokenProvider)
+	if err != nil {
+		return nil, err
+	}
+	return &V1{dbConn: sql.OpenDB(connector), windows: windows, usageMetricsLogger: usageMetricsLogger, maxConcurrentRules: 1, failureLogLimit: 1}, nil
+}
+
+// AccessTokenProvider returns a token provider reading the access token from tokenFile, so it can
+// be refreshed by another process. If tokenFile is empty the provider returns the access token of
+// the default credentials of the agent, which is the service account of the instance on Compute
+// Engine.
+func AccessTokenProvider(ctx context.Context, tokenFile string) func() (string, error) {
+	if tokenFile != "" {
+		return func() (string, error) {
+			return readAccessToken(tokenFile)
+		}
+	}
+	return func() (string, error) {
+		ts, err := google.DefaultTokenSource(ctx, accessTokenScope)
+		if err != nil {
+			return "", fmt.Errorf("failed to get the default credentials: %v", err)
+		}
+		token, err := ts.Token()
+		if err != nil {
+			return "", fmt.Errorf("failed to get the access token: %v", err)
+		}
+		return token.AccessToken, nil
+	}
+}
+
+// readAccessToken returns the access token stored in tokenFile.
+func readAccessToken(tokenFile string) (string, error) {
+	b, err := os.ReadFile(tokenFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the access token file: %v", err)
+	}
+	token := strings.TrimSpace(string(b))
+	if token == "" {
+		return "", fmt.Errorf("access token file %s is empty", tokenFile)
+	}
+	return token, nil
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sqlcollector
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewV1AccessToken(t *testing.T) {
+	tokenProvider := func() (string, error) { return "token", nil }
+	testcases := []struct {
+		name          string
+		conn          string
+		tokenProvider func() (string, error)
+		wantErr       bool
+	}{
+		{
+			name:          "success",
+			conn:          "server=test-host;port=1433;",
+			tokenProvider: tokenProvider,
+		},
+		{
+			name:    "nil token provider",
+			conn:    "server=test-host;port=1433;",
+			wantErr: true,
+		},
+		{
+			name:          "invalid connection string",
+			conn:          "server=test-host;port=invalid;",
+			tokenProvider: tokenProvider,
+			wantErr:       true,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := NewV1AccessToken(tc.conn, tc.tokenProvider, true, fakeUsageMetricsLogger)
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Fatalf("NewV1AccessToken() = %v, want error presence = %v", err, tc.wantErr)
+			}
+			if c != nil {
+				c.Close()
+			}
+		})
+	}
+}
+
+func TestAccessTokenProviderFile(t *testing.T) {
+	dir := t.TempDir()
+	tokenFile := filepath.Join(dir, "token")
+	emptyFile := filepath.Join(dir, "empty")
+	if err := os.WriteFile(tokenFile, []byte("test-token\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(emptyFile, []byte(" \n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	testcases := []struct {
+		name      string
+		tokenFile string
+		want      string
+		wantErr   bool
+	}{
+		{
+			name:      "token read from file",
+			tokenFile: tokenFile,
+			want:      "test-token",
+		},
+		{
+			name:      "empty token file",
+			tokenFile: emptyFile,
+			wantErr:   true,
+		},
+		{
+			name:      "missing token file",
+			tokenFile: filepath.Join(dir, "missing"),
+			wantErr:   true,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := AccessTokenProvider(context.Background(), tc.tokenFile)()
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Fatalf("AccessTokenProvider(%q)() = %v, want error presence = %v", tc.tokenFile, err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Errorf("AccessTokenProvider(%q)() = %q, want %q", tc.tokenFile, got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sqlcollector
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/GoogleCloudPlatform/sql-server-agent/internal"
+	"github.com/GoogleCloudPlatform/workloadagentplatform/integration/common/shared/log"
+)
+
+// ruleFailureLog collapses the identical failures of the rules of a collection, e.g. the
+// permission errors of a least-privilege login. The first limit failures with the same error are
+// logged in detail, the others at debug level and in a summary at the end of the collection.
+// A limit of 0 logs all failures in detail.
+type ruleFailureLog struct {
+	limit int
+
+	mu sync.Mutex
+	// counts are the numbers of failures keyed by error.
+	counts map[string]int
+	// suppressed are the rules whose failures were not logged in detail, keyed by error.
+	suppressed map[string][]string
+}
+
+func newRuleFailureLog(limit int) *ruleFailureLog {
+	return &ruleFailureLog{limit: limit, counts: map[string]int{}, suppressed: map[string][]string{}}
+}
+
+// failed logs the failure of rule with err.
+func (l *ruleFailureLog) failed(rule internal.MasterRuleStruct, err error) {
+	key := err.Error()
+	l.mu.Lock()
+	l.counts[key]++
+	detailed := l.limit <= 0 || l.counts[key] <= l.limit
+	if !detailed {
+		l.suppressed[key] = append(l.suppressed[key], rule.Name)
+	}
+	l.mu.Unlock()
+
+	if detailed {
+		log.Logger.Errorw("Failed to run sql query", "rule", rule.Name, "query", rule.Query, "error", err)
+		return
+	}
+	log.Logger.Debugw("Failed to run sql query", "rule", rule.Name, "error", err)
+}
+
+// summarize logs the number of failures not logged in detail for each error.
+func (l *ruleFailureLog) summarize() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var keys []string
+	for key := range l.suppressed {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		rules := append([]string(nil), l.suppressed[key]...)
+		sort.Strings(rules)
+		log.Logger.Errorw(fmt.Sprintf("%d more sql rule failures with the same error, see the first occurrence", len(rules)), "error", key, "rules", rules)
+	}
+}
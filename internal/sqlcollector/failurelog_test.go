@@ -0,0 +1,129 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sqlcollector
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"github.com/GoogleCloudPlatform/sql-server-agent/internal"
+	"github.com/GoogleCloudPlatform/workloadagentplatform/integration/common/shared/log"
+)
+
+// loggedEntry is a log entry without its time and caller.
+type loggedEntry struct {
+	Level   zapcore.Level
+	Message string
+	Rule    any
+}
+
+func TestRuleFailureLog(t *testing.T) {
+	permissionErr := errors.New("VIEW SERVER STATE permission was denied on object 'server', database 'master'.")
+	timeoutErr := errors.New("context deadline exceeded")
+	rules := []internal.MasterRuleStruct{
+		{Name: "RULE_1", Query: "query1"},
+		{Name: "RULE_2", Query: "query2"},
+		{Name: "RULE_3", Query: "query3"},
+		{Name: "RULE_4", Query: "query4"},
+	}
+	testcases := []struct {
+		name  string
+		limit int
+		want  []loggedEntry
+	}{
+		{
+			name:  "repeated failures are collapsed",
+			limit: 1,
+			want: []loggedEntry{
+				{Level: zapcore.ErrorLevel, Message: "Failed to run sql query", Rule: "RULE_1"},
+				{Level: zapcore.DebugLevel, Message: "Failed to run sql query", Rule: "RULE_2"},
+				{Level: zapcore.ErrorLevel, Message: "Failed to run sql query", Rule: "RULE_3"},
+				{Level: zapcore.DebugLevel, Message: "Failed to run sql query", Rule: "RULE_4"},
+				{Level: zapcore.ErrorLevel, Message: "2 more sql rule failures with the same error, see the first occurrence"},
+			},
+		},
+		{
+			name:  "failures within the limit are logged in detail",
+			limit: 2,
+			want: []loggedEntry{
+				{Level: zapcore.ErrorLevel, Message: "Failed to run sql query", Rule: "RULE_1"},
+				{Level: zapcore.ErrorLevel, Message: "Failed to run sql query", Rule: "RULE_2"},
+				{Level: zapcore.ErrorLevel, Message: "Failed to run sql query", Rule: "RULE_3"},
+				{Level: zapcore.DebugLevel, Message: "Failed to run sql query", Rule: "RULE_4"},
+				{Level: zapcore.ErrorLevel, Message: "1 more sql rule failures with the same error, see the first occurrence"},
+			},
+		},
+		{
+			name:  "no limit",
+			limit: 0,
+			want: []loggedEntry{
+				{Level: zapcore.ErrorLevel, Message: "Failed to run sql query", Rule: "RULE_1"},
+				{Level: zapcore.ErrorLevel, Message: "Failed to run sql query", Rule: "RULE_2"},
+				{Level: zapcore.ErrorLevel, Message: "Failed to run sql query", Rule: "RULE_3"},
+				{Level: zapcore.ErrorLevel, Message: "Failed to run sql query", Rule: "RULE_4"},
+			},
+		},
+	}
+	defer func(l *zap.SugaredLogger) { log.Logger = l }(log.Logger)
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			core, logs := observer.New(zapcore.DebugLevel)
+			log.Logger = zap.New(core).Sugar()
+
+			l := newRuleFailureLog(tc.limit)
+			l.failed(rules[0], permissionErr)
+			l.failed(rules[1], permissionErr)
+			l.failed(rules[2], timeoutErr)
+			l.failed(rules[3], permissionErr)
+			l.summarize()
+
+			var got []loggedEntry
+			for _, e := range logs.All() {
+				got = append(got, loggedEntry{Level: e.Level, Message: e.Message, Rule: e.ContextMap()["rule"]})
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("ruleFailureLog logged diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRuleFailureLogSummary(t *testing.T) {
+	defer func(l *zap.SugaredLogger) { log.Logger = l }(log.Logger)
+	core, logs := observer.New(zapcore.ErrorLevel)
+	log.Logger = zap.New(core).Sugar()
+
+	l := newRuleFailureLog(1)
+	err := errors.New("login failed")
+	for _, name := range []string{"RULE_C", "RULE_A", "RULE_B"} {
+		l.failed(internal.MasterRuleStruct{Name: name}, err)
+	}
+	l.summarize()
+
+	summaries := logs.FilterMessageSnippet("more sql rule failures").All()
+	if len(summaries) != 1 {
+		t.Fatalf("summarize() logged %d summaries, want 1", len(summaries))
+	}
+	want := map[string]any{"error": "login failed", "rules": []any{"RULE_A", "RULE_B"}}
+	if diff := cmp.Diff(want, summaries[0].ContextMap()); diff != "" {
+		t.Errorf("summarize() logged context diff (-want +got):\n%s", diff)
+	}
+}
@@ -19,18 +19,52 @@ package sqlcollector
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"sync"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
 	"github.com/GoogleCloudPlatform/sql-server-agent/internal/agentstatus"
 	"github.com/GoogleCloudPlatform/sql-server-agent/internal"
+	"github.com/GoogleCloudPlatform/sql-server-agent/internal/tracing"
 	"github.com/GoogleCloudPlatform/workloadagentplatform/integration/common/shared/log"
 )
 
+// permissionDeniedErrorNumbers are the sql server error numbers returned when the login lacks a
+// permission required by a query.
+var permissionDeniedErrorNumbers = map[int32]bool{
+	229: true, // The permission was denied on the object.
+	230: true, // The permission was denied on the column.
+	262: true, // The permission was denied in the database.
+	297: true, // The user does not have permission to perform this action.
+	300: true, // The server level permission was denied.
+	916: true, // The server principal is not able to access the database.
+}
+
+// connectionErrorNumbers are the sql server error numbers returned when the login cannot connect.
+var connectionErrorNumbers = map[int32]bool{
+	4060:  true, // Cannot open the database requested by the login.
+	18456: true, // Login failed.
+}
+
+// sqlServerError is implemented by the errors the sql server driver returns for server messages.
+type sqlServerError interface {
+	SQLErrorNumber() int32
+}
+
 // V1 that execute cmd and connect to SQL server.
 type V1 struct {
 	dbConn             *sql.DB
 	windows            bool
 	usageMetricsLogger agentstatus.AgentStatus
+	maxConcurrentRules int
+	failureLogLimit    int
+	prepareStatements  bool
+	enabledRules       map[string]bool
+	stmtsMu            sync.Mutex
+	stmts              map[string]*sql.Stmt
 }
 
 // NewV1 initializes a V1 instance.
@@ -39,59 +73,235 @@ func NewV1(driver, conn string, windows bool, usageMetricsLogger agentstatus.Age
 	if err != nil {
 		return nil, err
 	}
-	return &V1{dbConn: dbConn, windows: windows, usageMetricsLogger: usageMetricsLogger}, nil
+	return &V1{dbConn: dbConn, windows: windows, usageMetricsLogger: usageMetricsLogger, maxConcurrentRules: 1, failureLogLimit: 1}, nil
+}
+
+// SetMaxConcurrentRules sets how many master rules are collected in parallel and limits the
+// open connections of the pool to the same number.
+// Rules are collected sequentially if n is less than 2.
+func (c *V1) SetMaxConcurrentRules(n int) {
+	if n < 1 {
+		n = 1
+	}
+	c.maxConcurrentRules = n
+	c.dbConn.SetMaxOpenConns(n)
+}
+
+// SetFailureLogLimit sets how many identical rule failures of a collection are logged in detail.
+// Further identical failures are summarized at the end of the collection.
+// All failures are logged in detail if n is less than 1.
+func (c *V1) SetFailureLogLimit(n int) {
+	c.failureLogLimit = max(n, 0)
+}
+
+// SetPrepareStatements sets whether the rule queries are run as prepared statements, which are
+// kept by the collector and reused by the following collections of the same collector.
+// The "sqlserver" driver prepares the statements on the client only and still sends the text of
+// a parameterless query as a batch, so the server parses the queries and caches their plans in
+// both cases. The benefit is the connections of the kept collector, which are reused instead of
+// logging in to the instance on every collection.
+func (c *V1) SetPrepareStatements(prepare bool) {
+	c.prepareStatements = prepare
+}
+
+// SetEnabledRules sets the opt-in master rules collected in addition to the other master rules.
+func (c *V1) SetEnabledRules(names []string) {
+	c.enabledRules = map[string]bool{}
+	for _, name := range names {
+		c.enabledRules[name] = true
+	}
 }
 
 // CollectMasterRules collects master rules from target sql server.
-// Master rules are defined in rules.go file.
+// Master rules are defined in rules.go file. Opt-in rules are skipped unless they are enabled.
+// Each rule is bound by the timeout. The whole batch is bound by the time the rules take when each
+// of them runs into the timeout, the timeout times the number of rules run one after another:
+// rules still running when it expires fail, rules waiting to run are skipped, and the details of
+// the rules collected so far are returned.
 func (c *V1) CollectMasterRules(ctx context.Context, timeout time.Duration) []internal.Details {
+	maxConcurrentRules := c.maxConcurrentRules
+	if maxConcurrentRules < 1 {
+		maxConcurrentRules = 1
+	}
+	var rules []int
+	for i, rule := range internal.MasterRules {
+		if rule.OptIn && !c.enabledRules[rule.Name] {
+			log.Logger.Debugw("Skipping sql rule, the rule is not enabled", "rule", rule.Name)
+			continue
+		}
+		rules = append(rules, i)
+	}
+	ctx, cancel := context.WithTimeout(ctx, batchTimeout(timeout, len(rules), maxConcurrentRules))
+	defer cancel()
+	// results keeps the collected details in the order of the master rules.
+	results := make([][]internal.Details, len(internal.MasterRules))
+	failures := newRuleFailureLog(c.failureLogLimit)
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxConcurrentRules)
+	for _, i := range rules {
+		rule := internal.MasterRules[i]
+		sem <- struct{}{}
+		if err := ctx.Err(); err != nil {
+			<-sem
+			log.Logger.Errorw("Skipping sql rule, collection timed out or is cancelled", "rule", rule.Name, "error", err)
+			continue
+		}
+		wg.Add(1)
+		go func(i int, rule internal.MasterRuleStruct) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = c.collectMasterRule(ctx, timeout, rule, failures)
+		}(i, rule)
+	}
+	wg.Wait()
+	failures.summarize()
+
 	var details []internal.Details
-	for _, rule := range internal.MasterRules {
-		func() {
-			ctxWithTimeout, cancel := context.WithTimeout(ctx, timeout)
-			defer cancel()
-			queryResult, err := c.executeSQL(ctxWithTimeout, rule.Query)
-			if err != nil {
-				log.Logger.Errorw("Failed to run sql query", "query", rule.Query, "error", err)
-				c.usageMetricsLogger.Error(agentstatus.SQLQueryExecutionError)
-				return
-			}
-			// queryResult is a 2d array and for most rules there is only one row in the query result.
-			// For InstanceMetrics, the query result is in one row and we need to append the os type to the row in queryResult.
-			if rule.Name == "INSTANCE_METRICS" {
-				if queryResult == nil || len(queryResult) == 0 {
-					log.Logger.Errorw("Empty query result", "query", rule.Query)
-					c.usageMetricsLogger.Error(agentstatus.SQLQueryExecutionError)
-					return
-				}
-				os := "windows"
-				if !c.windows {
-					os = "linux"
-				}
-				queryResult[0] = append(queryResult[0], os)
-			}
-			details = append(details, internal.Details{
-				Name:   rule.Name,
-				Fields: rule.Fields(queryResult),
-			})
-		}()
+	for _, res := range results {
+		details = append(details, res...)
 	}
 	return details
 }
 
+// batchTimeout returns the timeout of a batch of rules run at most concurrency at a time, each
+// bound by timeout.
+func batchTimeout(timeout time.Duration, rules, concurrency int) time.Duration {
+	rounds := (rules + concurrency - 1) / concurrency
+	if rounds < 1 {
+		rounds = 1
+	}
+	return timeout * time.Duration(rounds)
+}
+
+// collectMasterRule runs a single master rule within the given timeout.
+// Returns nil if the rule fails, logging the failure to failures.
+func (c *V1) collectMasterRule(ctx context.Context, timeout time.Duration, rule internal.MasterRuleStruct, failures *ruleFailureLog) []internal.Details {
+	ctx, span := tracing.Start(ctx, "collectMasterRule", attribute.String("rule", rule.Name))
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	start := time.Now()
+	queryResult, err := c.executeSQL(ctxWithTimeout, rule.Query)
+	tracing.End(span, err)
+	duration := time.Since(start).Milliseconds()
+	log.Logger.Debugw("Collected sql rule", "rule", rule.Name, "collection_duration_ms", duration)
+	if err != nil {
+		failures.failed(rule, err)
+		c.usageMetricsLogger.Error(sqlErrorCode(err))
+		return nil
+	}
+	// queryResult is a 2d array and for most rules there is only one row in the query result.
+	// For InstanceMetrics, the query result is in one row and we need to append the os type to the row in queryResult.
+	if rule.Name == "INSTANCE_METRICS" {
+		if queryResult == nil || len(queryResult) == 0 {
+			log.Logger.Errorw("Empty query result", "query", rule.Query)
+			c.usageMetricsLogger.Error(agentstatus.SQLQueryExecutionError)
+			return nil
+		}
+		os := "windows"
+		if !c.windows {
+			os = "linux"
+		}
+		queryResult[0] = append(queryResult[0], os)
+	}
+	return []internal.Details{
+		{
+			Name:                 rule.Name,
+			Fields:               rule.Fields(queryResult),
+			CollectionDurationMs: map[string]int64{rule.Name: duration},
+		},
+	}
+}
+
+// sqlErrorCode returns the agent status error code of a failed sql rule.
+func sqlErrorCode(err error) int {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return agentstatus.SQLQueryTimeoutError
+	}
+	var serverErr sqlServerError
+	if errors.As(err, &serverErr) {
+		switch {
+		case permissionDeniedErrorNumbers[serverErr.SQLErrorNumber()]:
+			return agentstatus.SQLPermissionDeniedError
+		case connectionErrorNumbers[serverErr.SQLErrorNumber()]:
+			return agentstatus.SQLConnectionError
+		}
+		return agentstatus.SQLQueryExecutionError
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return agentstatus.SQLQueryTimeoutError
+		}
+		return agentstatus.SQLConnectionError
+	}
+	if errors.Is(err, driver.ErrBadConn) {
+		return agentstatus.SQLConnectionError
+	}
+	return agentstatus.SQLQueryExecutionError
+}
+
 // Close closes the database collection.
 func (c *V1) Close() error {
+	c.closeStatements()
 	return c.dbConn.Close()
 }
 
-func (c *V1) executeSQL(ctx context.Context, query string) ([][]any, error) {
-	err := c.dbConn.PingContext(ctx)
+// statement returns the prepared statement of query, preparing it on first use.
+func (c *V1) statement(ctx context.Context, query string) (*sql.Stmt, error) {
+	c.stmtsMu.Lock()
+	defer c.stmtsMu.Unlock()
+	if stmt, ok := c.stmts[query]; ok {
+		return stmt, nil
+	}
+	stmt, err := c.dbConn.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	if c.stmts == nil {
+		c.stmts = map[string]*sql.Stmt{}
+	}
+	c.stmts[query] = stmt
+	return stmt, nil
+}
+
+// closeStatements closes the prepared statements, which are prepared again when next used.
+func (c *V1) closeStatements() {
+	c.stmtsMu.Lock()
+	defer c.stmtsMu.Unlock()
+	for query, stmt := range c.stmts {
+		if err := stmt.Close(); err != nil {
+			log.Logger.Debugw("Failed to close the prepared statement", "error", err)
+		}
+		delete(c.stmts, query)
+	}
+}
+
+// query runs query, as a prepared statement if the collector prepares the statements.
+func (c *V1) query(ctx context.Context, query string) (*sql.Rows, error) {
+	if !c.prepareStatements {
+		return c.dbConn.QueryContext(ctx, query)
+	}
+	stmt, err := c.statement(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return stmt.QueryContext(ctx)
+}
+
+func (c *V1) executeSQL(ctx context.Context, query string) (_ [][]any, err error) {
+	// The statements prepared before a connection failure are prepared again once reconnected.
+	defer func() {
+		if err != nil && c.prepareStatements && sqlErrorCode(err) == agentstatus.SQLConnectionError {
+			c.closeStatements()
+		}
+	}()
+	err = c.dbConn.PingContext(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	// Execute query
-	rows, err := c.dbConn.QueryContext(ctx, query)
+	rows, err := c.query(ctx, query)
 	if err != nil {
 		return nil, err
 	}
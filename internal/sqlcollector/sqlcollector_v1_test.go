@@ -19,12 +19,22 @@ package sqlcollector
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
-	_ "github.com/microsoft/go-mssqldb"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	mssql "github.com/microsoft/go-mssqldb"
 	"github.com/DATA-DOG/go-sqlmock"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
 	"github.com/GoogleCloudPlatform/sql-server-agent/internal/agentstatus"
 	"github.com/GoogleCloudPlatform/sql-server-agent/internal"
 )
@@ -205,13 +215,427 @@ func TestCollectMasterRules(t *testing.T) {
 			}
 
 			r := c.CollectMasterRules(ctx, time.Second)
-			if diff := cmp.Diff(r, test.want); diff != "" {
+			if diff := cmp.Diff(r, test.want, cmpopts.IgnoreFields(internal.Details{}, "CollectionDurationMs")); diff != "" {
 				t.Errorf("CollectMasterRules returned wrong result (-got +want):\n%s", diff)
 			}
 		})
 	}
 }
 
+func TestCollectMasterRulesDuration(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New() returned an unexpected error: %v", err)
+	}
+	defer db.Close()
+	c := V1{dbConn: db, usageMetricsLogger: fakeUsageMetricsLogger}
+	defer func(rules []internal.MasterRuleStruct) { internal.MasterRules = rules }(internal.MasterRules)
+	internal.MasterRules = []internal.MasterRuleStruct{
+		{
+			Name:  "testRule",
+			Query: "testQuery",
+			Fields: func(fields [][]any) []map[string]string {
+				return []map[string]string{map[string]string{"col1": internal.HandleNilString(fields[0][0])}}
+			},
+		},
+	}
+	mock.ExpectQuery("testQuery").WillReturnRows(sqlmock.NewRows([]string{"col1"}).AddRow("row1"))
+
+	details := c.CollectMasterRules(context.Background(), time.Second)
+	if len(details) != 1 {
+		t.Fatalf("CollectMasterRules() returned %d details, want 1", len(details))
+	}
+	duration, ok := details[0].CollectionDurationMs["testRule"]
+	if !ok {
+		t.Fatalf("CollectMasterRules() returned no collection duration for rule testRule: %v", details[0].CollectionDurationMs)
+	}
+	if duration < 0 {
+		t.Errorf("CollectMasterRules() returned collection duration %d for rule testRule, want non-negative", duration)
+	}
+}
+
+func TestCollectMasterRulesTracing(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	defer func(tp trace.TracerProvider) { otel.SetTracerProvider(tp) }(otel.GetTracerProvider())
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New() returned an unexpected error: %v", err)
+	}
+	defer db.Close()
+	c := V1{dbConn: db, usageMetricsLogger: fakeUsageMetricsLogger}
+	defer func(rules []internal.MasterRuleStruct) { internal.MasterRules = rules }(internal.MasterRules)
+	internal.MasterRules = []internal.MasterRuleStruct{
+		{
+			Name:   "rule1",
+			Query:  "rule1Query",
+			Fields: func(fields [][]any) []map[string]string { return nil },
+		},
+		{
+			Name:   "rule2",
+			Query:  "rule2Query",
+			Fields: func(fields [][]any) []map[string]string { return nil },
+		},
+	}
+	mock.ExpectQuery("rule1Query").WillReturnRows(sqlmock.NewRows([]string{"col1"}).AddRow("row1"))
+	mock.ExpectQuery("rule2Query").WillReturnError(errors.New("query failed"))
+
+	c.CollectMasterRules(context.Background(), time.Second)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("CollectMasterRules() created %d spans, want 2", len(spans))
+	}
+	wantStatus := map[string]codes.Code{"rule1": codes.Unset, "rule2": codes.Error}
+	for _, span := range spans {
+		if span.Name != "collectMasterRule" {
+			t.Errorf("CollectMasterRules() created span %q, want %q", span.Name, "collectMasterRule")
+		}
+		var rule string
+		for _, attr := range span.Attributes {
+			if attr.Key == attribute.Key("rule") {
+				rule = attr.Value.AsString()
+			}
+		}
+		want, ok := wantStatus[rule]
+		if !ok {
+			t.Errorf("CollectMasterRules() created span for unexpected rule %q", rule)
+			continue
+		}
+		if span.Status.Code != want {
+			t.Errorf("CollectMasterRules() span of rule %q has status %v, want %v", rule, span.Status.Code, want)
+		}
+	}
+}
+
+func TestCollectMasterRulesConcurrent(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New() returned an unexpected error: %v", err)
+	}
+	defer db.Close()
+	mock.MatchExpectationsInOrder(false)
+	c := V1{dbConn: db, usageMetricsLogger: fakeUsageMetricsLogger}
+	c.SetMaxConcurrentRules(3)
+	defer func(rules []internal.MasterRuleStruct) { internal.MasterRules = rules }(internal.MasterRules)
+	internal.MasterRules = nil
+	var want []internal.Details
+	for _, name := range []string{"rule1", "rule2", "rule3"} {
+		internal.MasterRules = append(internal.MasterRules, internal.MasterRuleStruct{
+			Name:  name,
+			Query: name + "Query",
+			Fields: func(fields [][]any) []map[string]string {
+				return []map[string]string{map[string]string{"col1": internal.HandleNilString(fields[0][0])}}
+			},
+		})
+		mock.ExpectQuery(name + "Query").WillReturnRows(sqlmock.NewRows([]string{"col1"}).AddRow(name + "Value")).WillDelayFor(500 * time.Millisecond)
+		want = append(want, internal.Details{
+			Name:   name,
+			Fields: []map[string]string{map[string]string{"col1": name + "Value"}},
+		})
+	}
+
+	start := time.Now()
+	got := c.CollectMasterRules(context.Background(), 5*time.Second)
+	elapsed := time.Since(start)
+	if diff := cmp.Diff(got, want, cmpopts.IgnoreFields(internal.Details{}, "CollectionDurationMs")); diff != "" {
+		t.Errorf("CollectMasterRules() returned wrong result (-got +want):\n%s", diff)
+	}
+	// Sequential collection takes at least 1.5 seconds.
+	if elapsed >= 1500*time.Millisecond {
+		t.Errorf("CollectMasterRules() took %v, want less than 1.5s when rules are collected concurrently", elapsed)
+	}
+}
+
+func TestCollectMasterRulesRuleTimeout(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New() returned an unexpected error: %v", err)
+	}
+	defer db.Close()
+	mock.MatchExpectationsInOrder(false)
+	c := V1{dbConn: db, usageMetricsLogger: fakeUsageMetricsLogger}
+	c.SetMaxConcurrentRules(1)
+	defer func(rules []internal.MasterRuleStruct) { internal.MasterRules = rules }(internal.MasterRules)
+	internal.MasterRules = nil
+	// rule2 exceeds the timeout, the other rules run well within it. Together they take longer than
+	// a single timeout, which must not cut off the rules after rule2.
+	const timeout = 300 * time.Millisecond
+	delays := map[string]time.Duration{
+		"rule1": 100 * time.Millisecond,
+		"rule2": 2 * time.Second,
+		"rule3": 100 * time.Millisecond,
+		"rule4": 100 * time.Millisecond,
+	}
+	for _, name := range []string{"rule1", "rule2", "rule3", "rule4"} {
+		internal.MasterRules = append(internal.MasterRules, internal.MasterRuleStruct{
+			Name:  name,
+			Query: name + "Query",
+			Fields: func(fields [][]any) []map[string]string {
+				return []map[string]string{map[string]string{"col1": internal.HandleNilString(fields[0][0])}}
+			},
+		})
+		mock.ExpectQuery(name + "Query").WillReturnRows(sqlmock.NewRows([]string{"col1"}).AddRow("value")).WillDelayFor(delays[name])
+	}
+
+	start := time.Now()
+	got := c.CollectMasterRules(context.Background(), timeout)
+	elapsed := time.Since(start)
+	want := []internal.Details{
+		{Name: "rule1", Fields: []map[string]string{map[string]string{"col1": "value"}}},
+		{Name: "rule3", Fields: []map[string]string{map[string]string{"col1": "value"}}},
+		{Name: "rule4", Fields: []map[string]string{map[string]string{"col1": "value"}}},
+	}
+	if diff := cmp.Diff(want, got, cmpopts.IgnoreFields(internal.Details{}, "CollectionDurationMs")); diff != "" {
+		t.Errorf("CollectMasterRules() returned wrong result (-want +got):\n%s", diff)
+	}
+	if max := batchTimeout(timeout, len(delays), 1); elapsed >= max {
+		t.Errorf("CollectMasterRules() took %v, want less than %v", elapsed, max)
+	}
+}
+
+func TestBatchTimeout(t *testing.T) {
+	tests := []struct {
+		name        string
+		rules       int
+		concurrency int
+		want        time.Duration
+	}{
+		{name: "sequential", rules: 44, concurrency: 1, want: 440 * time.Second},
+		{name: "concurrent", rules: 44, concurrency: 4, want: 110 * time.Second},
+		{name: "partial round", rules: 45, concurrency: 4, want: 120 * time.Second},
+		{name: "more concurrency than rules", rules: 3, concurrency: 8, want: 10 * time.Second},
+		{name: "no rules", rules: 0, concurrency: 1, want: 10 * time.Second},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := batchTimeout(10*time.Second, tc.rules, tc.concurrency); got != tc.want {
+				t.Errorf("batchTimeout(10s, %d, %d) = %v, want %v", tc.rules, tc.concurrency, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCollectMasterRulesCancelled(t *testing.T) {
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New() returned an unexpected error: %v", err)
+	}
+	defer db.Close()
+	c := V1{dbConn: db, usageMetricsLogger: fakeUsageMetricsLogger}
+	c.SetMaxConcurrentRules(2)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if got := c.CollectMasterRules(ctx, time.Minute); len(got) != 0 {
+		t.Errorf("CollectMasterRules() = %v, want no details", got)
+	}
+}
+
+func TestSQLErrorCode(t *testing.T) {
+	testcases := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{
+			name: "query timeout",
+			err:  fmt.Errorf("query failed: %w", context.DeadlineExceeded),
+			want: agentstatus.SQLQueryTimeoutError,
+		},
+		{
+			name: "permission denied on object",
+			err:  mssql.Error{Number: 229, Message: "The SELECT permission was denied on the object."},
+			want: agentstatus.SQLPermissionDeniedError,
+		},
+		{
+			name: "view server state permission denied",
+			err:  mssql.Error{Number: 300, Message: "VIEW SERVER STATE permission was denied."},
+			want: agentstatus.SQLPermissionDeniedError,
+		},
+		{
+			name: "login failed",
+			err:  mssql.Error{Number: 18456, Message: "Login failed for user."},
+			want: agentstatus.SQLConnectionError,
+		},
+		{
+			name: "other sql server error",
+			err:  mssql.Error{Number: 208, Message: "Invalid object name."},
+			want: agentstatus.SQLQueryExecutionError,
+		},
+		{
+			name: "network error",
+			err:  &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")},
+			want: agentstatus.SQLConnectionError,
+		},
+		{
+			name: "unknown error",
+			err:  errors.New("unknown error"),
+			want: agentstatus.SQLQueryExecutionError,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := sqlErrorCode(tc.err); got != tc.want {
+				t.Errorf("sqlErrorCode(%v) = %d, want %d", tc.err, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSetMaxConcurrentRules(t *testing.T) {
+	testcases := []struct {
+		name string
+		n    int
+		want int
+	}{
+		{
+			name: "concurrent",
+			n:    4,
+			want: 4,
+		},
+		{
+			name: "sequential",
+			n:    1,
+			want: 1,
+		},
+		{
+			name: "invalid value falls back to sequential",
+			n:    0,
+			want: 1,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := NewV1("sqlserver", "", true, fakeUsageMetricsLogger)
+			if err != nil {
+				t.Fatalf("NewV1() = %v, want nil", err)
+			}
+			defer c.Close()
+			c.SetMaxConcurrentRules(tc.n)
+			if c.maxConcurrentRules != tc.want {
+				t.Errorf("SetMaxConcurrentRules(%d) set maxConcurrentRules = %d, want %d", tc.n, c.maxConcurrentRules, tc.want)
+			}
+			if got := c.dbConn.Stats().MaxOpenConnections; got != tc.want {
+				t.Errorf("SetMaxConcurrentRules(%d) set MaxOpenConnections = %d, want %d", tc.n, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSetFailureLogLimit(t *testing.T) {
+	c, err := NewV1("sqlserver", "", true, fakeUsageMetricsLogger)
+	if err != nil {
+		t.Fatalf("NewV1() = %v, want nil", err)
+	}
+	defer c.Close()
+	if c.failureLogLimit != 1 {
+		t.Errorf("NewV1() set failureLogLimit = %d, want 1", c.failureLogLimit)
+	}
+	for _, tc := range []struct{ n, want int }{{n: 3, want: 3}, {n: 0, want: 0}, {n: -1, want: 0}} {
+		c.SetFailureLogLimit(tc.n)
+		if c.failureLogLimit != tc.want {
+			t.Errorf("SetFailureLogLimit(%d) set failureLogLimit = %d, want %d", tc.n, c.failureLogLimit, tc.want)
+		}
+	}
+}
+
+func TestCollectMasterRulesOptIn(t *testing.T) {
+	fields := func(fields [][]any) []map[string]string {
+		return []map[string]string{map[string]string{"col1": internal.HandleNilString(fields[0][0])}}
+	}
+	defer func(rules []internal.MasterRuleStruct) { internal.MasterRules = rules }(internal.MasterRules)
+	internal.MasterRules = []internal.MasterRuleStruct{
+		{Name: "defaultRule", Query: "defaultQuery", Fields: fields},
+		{Name: "optInRule", Query: "optInQuery", Fields: fields, OptIn: true},
+	}
+	testcases := []struct {
+		name         string
+		enabledRules []string
+		want         []string
+	}{
+		{
+			name: "opt-in rule skipped by default",
+			want: []string{"defaultRule"},
+		},
+		{
+			name:         "opt-in rule enabled",
+			enabledRules: []string{"optInRule"},
+			want:         []string{"defaultRule", "optInRule"},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("sqlmock.New() returned an unexpected error: %v", err)
+			}
+			defer db.Close()
+			c := V1{dbConn: db, usageMetricsLogger: fakeUsageMetricsLogger}
+			c.SetEnabledRules(tc.enabledRules)
+			for _, rule := range tc.want {
+				mock.ExpectQuery(strings.TrimSuffix(rule, "Rule") + "Query").WillReturnRows(sqlmock.NewRows([]string{"col1"}).AddRow("row1"))
+			}
+
+			var got []string
+			for _, detail := range c.CollectMasterRules(context.Background(), time.Second) {
+				got = append(got, detail.Name)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("CollectMasterRules() collected wrong rules (-want +got):\n%s", diff)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("CollectMasterRules() did not run the expected queries: %v", err)
+			}
+		})
+	}
+}
+
+func TestCollectMasterRulesPreparedStatements(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New() returned an unexpected error: %v", err)
+	}
+	defer db.Close()
+	c := V1{dbConn: db, usageMetricsLogger: fakeUsageMetricsLogger}
+	c.SetPrepareStatements(true)
+	defer func(rules []internal.MasterRuleStruct) { internal.MasterRules = rules }(internal.MasterRules)
+	internal.MasterRules = []internal.MasterRuleStruct{
+		{
+			Name:  "testRule",
+			Query: "testQuery",
+			Fields: func(fields [][]any) []map[string]string {
+				return []map[string]string{map[string]string{"col1": internal.HandleNilString(fields[0][0])}}
+			},
+		},
+	}
+	// The statement is prepared once and reused by the following collections.
+	prepare := mock.ExpectPrepare("testQuery")
+	prepare.ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"col1"}).AddRow("row1"))
+	prepare.ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"col1"}).AddRow("row2"))
+	// A connection failure closes the statement, which is prepared again by the next collection.
+	prepare.ExpectQuery().WillReturnError(&net.OpError{Op: "read", Err: errors.New("connection reset")})
+	prepare.WillBeClosed()
+	mock.ExpectPrepare("testQuery").ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"col1"}).AddRow("row3"))
+
+	want := []string{"row1", "row2", "", "row3"}
+	for i, w := range want {
+		details := c.CollectMasterRules(context.Background(), time.Second)
+		got := ""
+		if len(details) > 0 {
+			got = details[0].Fields[0]["col1"]
+		}
+		if got != w {
+			t.Errorf("CollectMasterRules() collection %d returned col1 %q, want %q", i, got, w)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("CollectMasterRules() did not reuse the prepared statements: %v", err)
+	}
+}
+
 func TestNewV1(t *testing.T) {
 	testcases := []struct {
 		name    string
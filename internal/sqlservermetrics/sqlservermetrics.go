@@ -18,32 +18,48 @@ limitations under the License.
 package sqlservermetrics
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"os"
 	"path/filepath"
-	"regexp"
+	"runtime"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/GoogleCloudPlatform/workloadagentplatform/integration/common/shared/gce/metadataserver"
 
 	backoff "github.com/cenkalti/backoff/v4"
+	"github.com/jonboulle/clockwork"
+	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"github.com/GoogleCloudPlatform/sql-server-agent/internal/activation"
 	"github.com/GoogleCloudPlatform/sql-server-agent/internal/agentstatus"
+	"github.com/GoogleCloudPlatform/sql-server-agent/internal/checkpoint"
+	"github.com/GoogleCloudPlatform/sql-server-agent/internal/collectiondiff"
+	"github.com/GoogleCloudPlatform/sql-server-agent/internal/collectonce"
 	"github.com/GoogleCloudPlatform/sql-server-agent/internal/configuration"
+	"github.com/GoogleCloudPlatform/sql-server-agent/internal/debugdump"
 	"github.com/GoogleCloudPlatform/sql-server-agent/internal/flags"
 	"github.com/GoogleCloudPlatform/sql-server-agent/internal/guestcollector"
 	"github.com/GoogleCloudPlatform/sql-server-agent/internal/instanceinfo"
 	"github.com/GoogleCloudPlatform/sql-server-agent/internal"
 	"github.com/GoogleCloudPlatform/sql-server-agent/internal/remote"
 	"github.com/GoogleCloudPlatform/sql-server-agent/internal/secretmanager"
+	"github.com/GoogleCloudPlatform/sql-server-agent/internal/selfmetrics"
 	"github.com/GoogleCloudPlatform/sql-server-agent/internal/sqlcollector"
+	"github.com/GoogleCloudPlatform/sql-server-agent/internal/tracing"
 	"github.com/GoogleCloudPlatform/sql-server-agent/internal/wlm"
 	configpb "github.com/GoogleCloudPlatform/sql-server-agent/protos/sqlserveragentconfig"
 	"github.com/GoogleCloudPlatform/workloadagentplatform/integration/common/shared/commandlineexecutor"
 	"github.com/GoogleCloudPlatform/workloadagentplatform/integration/common/shared/gce"
 	"github.com/GoogleCloudPlatform/workloadagentplatform/integration/common/shared/log"
+	"github.com/GoogleCloudPlatform/workloadagentplatform/integration/common/shared/usagemetrics"
 )
 
 const (
@@ -60,9 +76,9 @@ const (
 	// AgentVersion .
 	AgentVersion = internal.AgentVersion
 	driver       = "sqlserver"
-	commandFind  = `sudo find %s -type f -iname "%s" -print`
-	commandDf    = "sudo df --output=target %s | tail -n 1"
-	commandMount = "mount | grep sd"
+
+	allDisksMaxRetries = 4
+	allDisksTimeout    = 2 * time.Minute
 )
 
 // CollectionType represents the enums of collection types.
@@ -89,16 +105,115 @@ type InstanceProperties struct {
 // UsageMetricsLogger logs usage metrics.
 var UsageMetricsLogger agentstatus.AgentStatus = UsageMetricsLoggerInit(internal.ServiceName, internal.AgentVersion, internal.AgentUsageLogPrefix, true)
 
-// SIP is the source instance properties.
-var SIP InstanceProperties = sourceInstanceProperties()
+// SIP is the source instance properties. It is set by SetSourceInstanceProperties once the
+// configuration is loaded.
+var SIP InstanceProperties
+
+// JSONStdout makes onetime collections keep the collected details for WriteOnetimeDetails
+// instead of saving them to files.
+var JSONStdout bool
+
+// onetimeDetails are the details collected in onetime mode when JSONStdout is set.
+var onetimeDetails []internal.Details
+
+// wlmSendStatus is the status of the requests sent to workloadmanager by the guest and sql collections.
+var wlmSendStatus = &wlm.SendStatus{}
+
+// logLevel is the log level of the agent logger. It is updated when the log level of the configuration changes.
+var logLevel = zap.NewAtomicLevel()
+
+// agentFlags are the flags of the agent parsed by Init.
+var agentFlags = &flags.AgentFlags{}
 
 // Init parses flags and execute if certain flags are enabled.
 func Init() (*flags.AgentFlags, string, bool) {
-	f := flags.NewAgentFlags(SIP.ProjectID, SIP.Zone, SIP.Instance, SIP.ProjectNumber, SIP.Image)
+	var f *flags.AgentFlags
+	f = flags.NewAgentFlags(func() *usagemetrics.CloudProperties {
+		path := ConfigPath()
+		if f.ConfigStdin {
+			path = StdinConfigPath
+		}
+		cfg, _ := LoadConfiguration(path)
+		p := sourceInstanceProperties(cfg)
+		return agentstatus.NewCloudProperties(p.ProjectID, p.Zone, p.Instance, p.ProjectNumber, p.Image)
+	})
 	output, proceed := f.Execute()
+	agentFlags = f
 	return f, output, proceed
 }
 
+// logDir and outputDir are the directories of the log file and of the onetime collection results
+// set by SetOutputPaths. Empty until they are set.
+var logDir, outputDir string
+
+// SetOutputPaths sets the directories of the log file and of the onetime collection results from,
+// in order of precedence, the flags, the environment, cfg and the defaults. Returns an error if a
+// directory the run writes to is not writable: the log directory unless the logs are written to
+// stderr, and the output directory of onetime collections writing files.
+func SetOutputPaths(f *flags.AgentFlags, cfg *configpb.Configuration) error {
+	logDir = configuration.LogDirectory(f.LogDir, cfg, filepath.Dir(defaultLogPrefix()))
+	outputDir = configuration.OnetimeOutputDirectory(f.OutputDir, cfg, logDir)
+	if f.JSONStdout {
+		return nil
+	}
+	if err := configuration.WritableDirectory(logDir); err != nil {
+		return fmt.Errorf("the log directory %q is not writable, set a writable directory with flag -log-dir, environment variable %s or \"log_directory\": %v", logDir, configuration.LogDirectoryEnv, err)
+	}
+	if f.Onetime {
+		if err := configuration.WritableDirectory(outputDir); err != nil {
+			return fmt.Errorf("the onetime output directory %q is not writable, set a writable directory with flag -output-dir, environment variable %s or \"onetime_output_directory\": %v", outputDir, configuration.OnetimeOutputDirectoryEnv, err)
+		}
+	}
+	return nil
+}
+
+// LogPrefix is the log prefix of the agent, in the log directory set by SetOutputPaths.
+func LogPrefix() string {
+	if logDir == "" {
+		return defaultLogPrefix()
+	}
+	return filepath.Join(logDir, filepath.Base(defaultLogPrefix()))
+}
+
+// onetimeOutputDir returns the directory of the onetime collection results set by SetOutputPaths,
+// or the directory of logPrefix if it is not set.
+func onetimeOutputDir(logPrefix string) string {
+	if outputDir == "" {
+		return filepath.Dir(logPrefix)
+	}
+	return outputDir
+}
+
+// WriteDebugDump writes a zip archive for support to path, with the redacted configuration, the
+// recent logs, the results of the last onetime collections and the metadata of the agent.
+func WriteDebugDump(path string, cfg *configpb.Configuration) error {
+	dir := onetimeOutputDir(LogPrefix())
+	var results []string
+	for _, pattern := range []string{"*-guest.json", "*-sql.json"} {
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return err
+		}
+		results = append(results, matches...)
+	}
+	return debugdump.Write(path, debugdump.Contents{
+		Configuration: cfg,
+		LogFile:       LogPrefix() + ".log",
+		ResultFiles:   results,
+		Metadata: map[string]string{
+			"agent_version": AgentVersion,
+			"os":            runtime.GOOS,
+			"arch":          runtime.GOARCH,
+			"project_id":    SIP.ProjectID,
+			"zone":          SIP.Zone,
+			"instance":      SIP.Instance,
+			"instance_id":   SIP.InstanceID,
+			"image":         SIP.Image,
+			"dump_time":     time.Now().Format(time.RFC3339),
+		},
+	})
+}
+
 // LoggingSetup initialize the agent logging level.
 func LoggingSetup(ctx context.Context, logPrefix string, cfg *configpb.Configuration) {
 	lp := log.Parameters{
@@ -107,18 +222,28 @@ func LoggingSetup(ctx context.Context, logPrefix string, cfg *configpb.Configura
 		CloudLogName:       "google-cloud-sql-server-agent",
 		CloudLoggingClient: log.CloudLoggingClient(ctx, SIP.ProjectID),
 	}
-	logLevel := map[string]zapcore.Level{
-		"DEBUG":   zapcore.DebugLevel,
-		"INFO":    zapcore.InfoLevel,
-		"WARNING": zapcore.WarnLevel,
-		"ERROR":   zapcore.ErrorLevel,
-	}
-	if _, ok := logLevel[cfg.GetLogLevel()]; !ok {
-		lp.Level = zapcore.InfoLevel
-	} else {
-		lp.Level = logLevel[cfg.GetLogLevel()]
-	}
+	// The logger writes all levels and logLevel filters the entries, so that the log level can
+	// change while the service runs.
+	lp.Level = zapcore.DebugLevel
+	logLevel.SetLevel(configLogLevel(cfg))
 	log.SetupLogging(lp)
+	log.Logger = internal.WithLevel(log.Logger, logLevel)
+}
+
+// LoggingSetupStderr initializes the agent logging to only write to stderr.
+// It keeps stdout clean for the collected data in json stdout mode.
+func LoggingSetupStderr(cfg *configpb.Configuration) {
+	config := zap.NewProductionEncoderConfig()
+	config.EncodeTime = zapcore.ISO8601TimeEncoder
+	config.TimeKey = "timestamp"
+	logLevel.SetLevel(configLogLevel(cfg))
+	core := zapcore.NewCore(zapcore.NewConsoleEncoder(config), zapcore.Lock(os.Stderr), logLevel)
+	log.Logger = zap.New(core, zap.AddCaller()).Sugar()
+}
+
+// configLogLevel wraps the function LogLevel in configuration package.
+func configLogLevel(cfg *configpb.Configuration) zapcore.Level {
+	return configuration.LogLevel(cfg)
 }
 
 // LoggingSetupDefault wraps LoggingSetupDefault function from agent_shared.go.
@@ -131,6 +256,73 @@ func LoggingSetupDefault(ctx context.Context, prefix string) {
 	log.SetupLogging(lp)
 }
 
+// SetMinTLSVersion sets the minimum TLS version of connections to Google Cloud APIs.
+// The Secret Manager, WLM and GCE clients use transports cloned from http.DefaultTransport.
+func SetMinTLSVersion(cfg *configpb.Configuration) {
+	version, err := configuration.MinTLSVersion(cfg)
+	if err != nil {
+		log.Logger.Errorw("Invalid minimum TLS version. Using TLS 1.2", "error", err)
+		UsageMetricsLogger.Error(agentstatus.InvalidConfigurationsError)
+	}
+	t, ok := http.DefaultTransport.(*http.Transport)
+	if !ok {
+		log.Logger.Warn("Failed to set the minimum TLS version. The default http transport is overridden")
+		return
+	}
+	internal.SetMinTLSVersion(t, version)
+}
+
+// SetSSHAlgorithms sets the algorithms allowed in the ssh connections to remote linux machines.
+// The default algorithms are kept if the configured algorithms are invalid.
+func SetSSHAlgorithms(cfg *configpb.Configuration) {
+	a := cfg.GetSshAlgorithms()
+	if err := remote.SetAlgorithms(remote.Algorithms{
+		KeyExchanges: a.GetKeyExchanges(),
+		Ciphers:      a.GetCiphers(),
+		MACs:         a.GetMacs(),
+	}); err != nil {
+		log.Logger.Errorw("Invalid ssh algorithms. Using the default algorithms", "error", err)
+		UsageMetricsLogger.Error(agentstatus.InvalidConfigurationsError)
+	}
+}
+
+// SetClientCertificate sets the client certificate of mutual TLS connections to Google Cloud APIs
+// if "client_cert_path" and "client_key_path" are set.
+// The Secret Manager, WLM and GCE clients use transports cloned from http.DefaultTransport.
+func SetClientCertificate(cfg *configpb.Configuration) {
+	cert, err := configuration.ClientCertificate(cfg)
+	if err != nil {
+		log.Logger.Errorw("Invalid client certificate. Connecting without a client certificate", "error", err)
+		UsageMetricsLogger.Error(agentstatus.InvalidConfigurationsError)
+		return
+	}
+	if cert == nil {
+		return
+	}
+	t, ok := http.DefaultTransport.(*http.Transport)
+	if !ok {
+		log.Logger.Warn("Failed to set the client certificate. The default http transport is overridden")
+		return
+	}
+	internal.SetClientCertificate(t, *cert)
+}
+
+// SetupTracing exports the traces of the collection cycles to "otel_endpoint" if it is set.
+// The returned function flushes the pending traces and stops the export.
+func SetupTracing(ctx context.Context, cfg *configpb.Configuration) func() {
+	shutdown, err := tracing.Setup(ctx, cfg.GetOtelEndpoint())
+	if err != nil {
+		log.Logger.Errorw("Failed to set up tracing. Tracing is disabled", "error", err)
+		UsageMetricsLogger.Error(agentstatus.InvalidConfigurationsError)
+		return func() {}
+	}
+	return func() {
+		if err := shutdown(ctx); err != nil {
+			log.Logger.Warnw("Failed to flush the traces", "error", err)
+		}
+	}
+}
+
 // UsageMetricsLoggerInit initializes and returns usage metrics logger.
 func UsageMetricsLoggerInit(logName, logVersion, logPrefix string, logUsage bool) agentstatus.AgentStatus {
 	ap := agentstatus.NewAgentProperties(logName, logVersion, logPrefix, logUsage)
@@ -138,47 +330,139 @@ func UsageMetricsLoggerInit(logName, logVersion, logPrefix string, logUsage bool
 	return agentstatus.NewUsageMetricsLogger(ap, cp, []string{})
 }
 
-// LoadConfiguration loads configuration from given path.
+// StdinConfigPath is the configuration path which reads the configuration from stdin.
+const StdinConfigPath = "-"
+
+// stdinConfig is the configuration read from stdin. Stdin can only be read once, so the
+// configuration is parsed again from it for every load.
+var stdinConfig struct {
+	once sync.Once
+	b    []byte
+	err  error
+}
+
+// LoadConfiguration loads configuration from given path, or from stdin if the path is StdinConfigPath.
+// Flag -log-level wins over the log level of the configuration, so it is kept when the
+// configuration is reloaded by the collection service.
 func LoadConfiguration(path string) (*configpb.Configuration, error) {
-	return configuration.LoadConfiguration(path)
+	cfg, err := loadConfiguration(path)
+	return agentFlags.OverrideLogLevel(cfg), err
+}
+
+// loadConfiguration loads configuration from given path, or from stdin if the path is StdinConfigPath.
+func loadConfiguration(path string) (*configpb.Configuration, error) {
+	if path != StdinConfigPath {
+		return configuration.LoadConfiguration(path)
+	}
+	stdinConfig.once.Do(func() {
+		stdinConfig.b, stdinConfig.err = io.ReadAll(os.Stdin)
+	})
+	if stdinConfig.err != nil {
+		return nil, fmt.Errorf("failed to read the configuration from stdin: %v", stdinConfig.err)
+	}
+	// A relative credential configuration file is resolved against the working directory.
+	return configuration.ParseConfiguration(bytes.NewReader(stdinConfig.b), ".")
+}
+
+// FilterCredentialConfiguration wraps FilterCredentialConfiguration from configuration package.
+func FilterCredentialConfiguration(cfg *configpb.Configuration, target string) (*configpb.Configuration, error) {
+	return configuration.FilterCredentialConfiguration(cfg, target)
 }
 
 // CollectionService runs the passed in collection as a service.
-func CollectionService(p string, collection func(cfg *configpb.Configuration, onetime bool) error, collectionType CollectionType) {
-	for {
-		cfg, err := LoadConfiguration(p)
-		if cfg == nil {
-			log.Logger.Errorw("Failed to load configuration", "error", err)
-			UsageMetricsLogger.Error(agentstatus.ProtoJSONUnmarshalError)
-			time.Sleep(time.Duration(time.Hour))
-			continue
-		}
-		// Init UsageMetricsLogger for each collection cycle.
-		UsageMetricsLogger = UsageMetricsLoggerInit(internal.ServiceName, internal.AgentVersion, internal.AgentUsageLogPrefix, !cfg.GetDisableLogUsage())
-		// Set onetime to false for running collection as service
-		if err := collection(cfg, false); err != nil {
-			log.Logger.Errorw("Failed to run collection", "collection type", collectionType, "error", err)
-			if collectionType == OS {
-				UsageMetricsLogger.Error(agentstatus.GuestCollectionFailure)
-			} else {
-				UsageMetricsLogger.Error(agentstatus.SQLCollectionFailure)
-			}
-			time.Sleep(time.Duration(time.Hour))
-			continue
-		}
-		// Sleep for collection interval.
+// The first collection starts after "startup_delay_seconds".
+// If once is set, it runs a single collection cycle of the service without the delay and returns.
+func CollectionService(p string, collection func(cfg *configpb.Configuration, onetime bool) error, collectionType CollectionType, once bool) {
+	var startupDelay time.Duration
+	if !once {
+		cfg, _ := LoadConfiguration(p)
+		startupDelay = time.Duration(cfg.GetStartupDelaySeconds()) * time.Second
+		jitter := internal.Jitter(rand.New(rand.NewSource(time.Now().UnixNano())), time.Duration(cfg.GetCollectionJitterSeconds())*time.Second)
+		log.Logger.Debugw("Delaying the first collection", "collection type", collectionType, "startup delay", startupDelay, "jitter", jitter)
+		startupDelay += jitter
+	}
+	watcher := configuration.NewWatcher(logLevel)
+	internal.RunCollectionService(clockwork.NewRealClock(), startupDelay, once, func() time.Duration {
+		return collectionServiceCycle(p, collection, collectionType, watcher)
+	})
+}
+
+// collectionServiceCycle runs one cycle of the collection service.
+// Returns the time to wait before the next cycle.
+// Changes of the configuration since the previous cycle are logged and the changed log level is applied.
+func collectionServiceCycle(p string, collection func(cfg *configpb.Configuration, onetime bool) error, collectionType CollectionType, watcher *configuration.Watcher) time.Duration {
+	cycle := selfmetrics.StartCycle()
+	defer logResourceUsage(cycle, collectionType)
+	cfg, err := LoadConfiguration(p)
+	if cfg == nil {
+		log.Logger.Errorw("Failed to load configuration", "error", err)
+		UsageMetricsLogger.Error(agentstatus.ProtoJSONUnmarshalError)
+		return time.Hour
+	}
+	if changed := watcher.Update(cfg); len(changed) > 0 {
+		log.Logger.Infow("Configuration changed", "collection type", collectionType, "changed fields", changed)
+	}
+	// Init UsageMetricsLogger for each collection cycle.
+	UsageMetricsLogger = UsageMetricsLoggerInit(internal.ServiceName, internal.AgentVersion, internal.AgentUsageLogPrefix, !cfg.GetDisableLogUsage())
+	if err := SetSecretProvider(cfg); err != nil {
+		log.Logger.Errorw("Failed to run collection", "collection type", collectionType, "error", err)
+		UsageMetricsLogger.Error(agentstatus.InvalidConfigurationsError)
+		return time.Hour
+	}
+	if collectionType == OS && configuration.SendHeartbeat(cfg) {
+		sendHeartbeat(context.Background(), cfg)
+	}
+	// Set onetime to false for running collection as service
+	// The failed targets are logged by the collection and collected again in the next cycle, the
+	// collection of the others completed.
+	var targetErrs *internal.TargetErrors
+	if err := collection(cfg, false); err != nil && !errors.As(err, &targetErrs) {
+		log.Logger.Errorw("Failed to run collection", "collection type", collectionType, "error", err)
 		if collectionType == OS {
-			time.Sleep(time.Duration(cfg.GetCollectionConfiguration().GetGuestOsMetricsCollectionIntervalInSeconds()) * time.Second)
-		} else if collectionType == SQL {
-			time.Sleep(time.Duration(cfg.GetCollectionConfiguration().GetSqlMetricsCollectionIntervalInSeconds()) * time.Second)
+			UsageMetricsLogger.Error(agentstatus.GuestCollectionFailure)
+		} else {
+			UsageMetricsLogger.Error(agentstatus.SQLCollectionFailure)
 		}
+		return time.Hour
+	}
+	// Sleep for collection interval.
+	if collectionType == OS {
+		return time.Duration(cfg.GetCollectionConfiguration().GetGuestOsMetricsCollectionIntervalInSeconds()) * time.Second
+	}
+	return time.Duration(cfg.GetCollectionConfiguration().GetSqlMetricsCollectionIntervalInSeconds()) * time.Second
+}
+
+// logResourceUsage logs the resource usage of the agent at the end of a collection cycle.
+func logResourceUsage(cycle *selfmetrics.Cycle, collectionType CollectionType) {
+	usage, err := cycle.End()
+	if err != nil {
+		log.Logger.Debugw("Failed to get the resource usage of the agent", "collection type", collectionType, "error", err)
+		return
 	}
+	log.Logger.Infow("Agent resource usage", "collection type", collectionType, "peak_rss_bytes", usage.PeakRSSBytes, "goroutines", usage.Goroutines, "cycle_cpu_ms", usage.CPUTime.Milliseconds())
+}
+
+// SetSourceInstanceProperties sets SIP from the loaded configuration, and re-initializes the
+// usage metrics logger with it.
+func SetSourceInstanceProperties(cfg *configpb.Configuration) {
+	SIP = sourceInstanceProperties(cfg)
+	UsageMetricsLogger = UsageMetricsLoggerInit(internal.ServiceName, internal.AgentVersion, internal.AgentUsageLogPrefix, !cfg.GetDisableLogUsage())
 }
 
 // sourceInstanceProperties returns properties of the instance the agent is running on.
-func sourceInstanceProperties() InstanceProperties {
-	properties := metadataserver.ReadCloudPropertiesWithRetry(backoff.NewConstantBackOff(30 * time.Second))
-	location := string(properties.Zone[0:strings.LastIndex(properties.Zone, "-")])
+// On-prem agents read them from the configuration instead of the metadata server.
+func sourceInstanceProperties(cfg *configpb.Configuration) InstanceProperties {
+	return instanceProperties(configuration.CloudProperties(cfg, func() *metadataserver.CloudProperties {
+		return metadataserver.ReadCloudPropertiesWithRetry(backoff.NewConstantBackOff(30 * time.Second))
+	}))
+}
+
+// instanceProperties returns the instance properties of the cloud properties of an instance.
+func instanceProperties(properties *metadataserver.CloudProperties) InstanceProperties {
+	location := properties.Zone
+	if i := strings.LastIndex(properties.Zone, "-"); i >= 0 {
+		location = properties.Zone[0:i]
+	}
 	name := fmt.Sprintf("projects/%s/locations/%s", properties.ProjectID, location)
 	return InstanceProperties{
 		Name:          name,
@@ -191,16 +475,53 @@ func sourceInstanceProperties() InstanceProperties {
 	}
 }
 
+// secondaryWLMLocation returns the name of the secondary workloadmanager location of the
+// configuration. Returns empty if it is not set.
+func secondaryWLMLocation(cfg *configpb.Configuration) string {
+	if cfg.GetSecondaryWlmLocation() == "" {
+		return ""
+	}
+	return fmt.Sprintf("projects/%s/locations/%s", SIP.ProjectID, cfg.GetSecondaryWlmLocation())
+}
+
 // initCollection executes steps for initializing a collection.
 // The func is called at the beginning of every guest and sql collection.
-func initCollection(ctx context.Context) (*wlm.WLM, error) {
+func initCollection(ctx context.Context, cfg *configpb.Configuration) (*wlm.WLM, error) {
 	wlm, err := wlm.NewWorkloadManager(ctx)
 	if err != nil {
 		return nil, err
 	}
+	wlm.SetRequestTimeout(time.Duration(cfg.GetWlmRequestTimeoutSeconds()) * time.Second)
 	return wlm, nil
 }
 
+// sendHeartbeat sends a heartbeat of the instance to workloadmanager, so the agent does not appear
+// offline while its collections are disabled.
+func sendHeartbeat(ctx context.Context, cfg *configpb.Configuration) {
+	w, err := initCollection(ctx, cfg)
+	if err != nil {
+		log.Logger.Errorw("Failed to initialize the heartbeat", "error", err)
+		return
+	}
+	if err := checkAgentStatus(w, AgentFilePath()); err != nil {
+		log.Logger.Errorw("Failed to activate the agent for the heartbeat", "error", err)
+		return
+	}
+	var fields map[string]string
+	if cfg.GetReportAgentResourceUsage() {
+		if usage, err := selfmetrics.Current(); err != nil {
+			log.Logger.Warnw("Failed to get the resource usage of the agent for the heartbeat", "error", err)
+		} else {
+			fields = usage.Fields()
+		}
+	}
+	w.UpdateRequest(wlm.HeartbeatRequest(SIP.ProjectID, SIP.Instance, SIP.InstanceID, fields))
+	interval := time.Duration(cfg.GetRetryIntervalInSeconds()) * time.Second
+	if err := sendRequestToWLM(ctx, w, SIP.Name, secondaryWLMLocation(cfg), cfg.GetMaxRetries(), interval); err == nil {
+		log.Logger.Debug("Sent the heartbeat to workload manager.")
+	}
+}
+
 // checkAgentStatus checks agent status. Return error if it failed to activate.
 func checkAgentStatus(wlm wlm.WorkloadManagerService, path string) error {
 	agentStatus := activation.NewV1()
@@ -230,26 +551,135 @@ func validateCredCfgGuest(remote, windows bool, guestCfg *configuration.GuestCon
 	return configuration.ValidateCredCfgGuest(remote, windows, guestCfg, instanceID, instanceName)
 }
 
-// runSQLCollection starts running sql collection based on given connection string.
-func runSQLCollection(ctx context.Context, conn string, timeout time.Duration, windows bool) ([]internal.Details, error) {
-	c, err := sqlcollector.NewV1(driver, conn, windows, UsageMetricsLogger)
+// sqlPassword returns the password of the sql instance stored in secret manager.
+// Returns empty if the agent authenticates to the instance with an access token.
+func sqlPassword(ctx context.Context, sqlCfg *configuration.SQLConfig, agentProjectID string) (string, error) {
+	if configuration.UsesAccessToken(sqlCfg) {
+		return "", nil
+	}
+	return secretValue(ctx, secretProjectID(sqlCfg.SecretProjectID, agentProjectID), sqlCfg.SecretName)
+}
+
+// sqlAccessTokenProvider returns the access token provider of the sql instance.
+// Returns nil if the agent authenticates to the instance with a user name and password.
+// The provider is not bound to the context of a collection, as the collectors preparing their
+// statements keep it for the connections of the following collection cycles.
+func sqlAccessTokenProvider(sqlCfg *configuration.SQLConfig) func() (string, error) {
+	if !configuration.UsesAccessToken(sqlCfg) {
+		return nil
+	}
+	return sqlcollector.AccessTokenProvider(context.Background(), sqlCfg.AccessTokenFile)
+}
+
+// sqlTarget returns the key of the sql instance of sqlCfg among the collectors kept across the
+// collection cycles, its connection string without the password.
+func sqlTarget(sqlCfg *configuration.SQLConfig) string {
+	return sqlConnectionString(sqlCfg, "")
+}
+
+// runSQLCollection starts running sql collection based on given connection string, with the rule
+// concurrency, timeout, failure logging and opt-in rules of cfg.
+// The collection authenticates with the access token of tokenProvider if it is not nil.
+// If "prepare_sql_statements" is set, the rule queries run as prepared statements of a collector kept
+// open for the next collections of target.
+func runSQLCollection(ctx context.Context, cfg *configpb.Configuration, target, conn string, tokenProvider func() (string, error), windows bool) (_ []internal.Details, err error) {
+	ctx, span := tracing.Start(ctx, "runSQLCollection")
+	defer func() { tracing.End(span, err) }()
+	prepare := cfg.GetPrepareSqlStatements()
+	var c *sqlcollector.V1
+	if prepare {
+		c, err = preparedSQLCollector(target, conn, tokenProvider, windows)
+	} else {
+		closePreparedSQLCollector(target)
+		c, err = newSQLCollector(conn, tokenProvider, windows)
+	}
 	if err != nil {
 		return nil, err
 	}
-	defer c.Close()
+	if !prepare {
+		defer c.Close()
+	}
+	c.SetMaxConcurrentRules(int(cfg.GetSqlRuleConcurrency()))
+	c.SetFailureLogLimit(ruleFailureLogLimit(cfg))
+	c.SetEnabledRules(cfg.GetEnabledSqlRules())
 	// Start db collection.
 	log.Logger.Debug("Collecting SQL Server rules.")
-	details := c.CollectMasterRules(ctx, timeout)
+	details := c.CollectMasterRules(ctx, sqlCollectionTimeout(cfg))
 	log.Logger.Debug("Collecting SQL Server rules completes.")
 	return details, nil
 }
 
+// newSQLCollector returns a sql collector of conn, authenticating with the access token of
+// tokenProvider if it is not nil.
+func newSQLCollector(conn string, tokenProvider func() (string, error), windows bool) (*sqlcollector.V1, error) {
+	if tokenProvider != nil {
+		return sqlcollector.NewV1AccessToken(conn, tokenProvider, windows, UsageMetricsLogger)
+	}
+	return sqlcollector.NewV1(driver, conn, windows, UsageMetricsLogger)
+}
+
+// cachedSQLCollector is a sql collector preparing its statements and the connection string it
+// connects with.
+type cachedSQLCollector struct {
+	conn string
+	c    *sqlcollector.V1
+}
+
+// preparedSQLCollectors are the sql collectors preparing their statements, kept open across the
+// collection cycles by target.
+var (
+	preparedSQLCollectorsMu sync.Mutex
+	preparedSQLCollectors   = map[string]cachedSQLCollector{}
+)
+
+// preparedSQLCollector returns the sql collector of target preparing its statements, creating it on
+// the first collection of target. The collector of a previous connection string of target, e.g.
+// before the password was rotated, is closed and replaced.
+func preparedSQLCollector(target, conn string, tokenProvider func() (string, error), windows bool) (*sqlcollector.V1, error) {
+	preparedSQLCollectorsMu.Lock()
+	defer preparedSQLCollectorsMu.Unlock()
+	if p, ok := preparedSQLCollectors[target]; ok {
+		if p.conn == conn {
+			return p.c, nil
+		}
+		closeSQLCollector(p.c)
+		delete(preparedSQLCollectors, target)
+	}
+	c, err := newSQLCollector(conn, tokenProvider, windows)
+	if err != nil {
+		return nil, err
+	}
+	c.SetPrepareStatements(true)
+	preparedSQLCollectors[target] = cachedSQLCollector{conn: conn, c: c}
+	return c, nil
+}
+
+// closePreparedSQLCollector closes the sql collector of target kept across the collection cycles,
+// if any, once "prepare_sql_statements" is turned off.
+func closePreparedSQLCollector(target string) {
+	preparedSQLCollectorsMu.Lock()
+	defer preparedSQLCollectorsMu.Unlock()
+	if p, ok := preparedSQLCollectors[target]; ok {
+		closeSQLCollector(p.c)
+		delete(preparedSQLCollectors, target)
+	}
+}
+
+// closeSQLCollector closes c, logging the failure.
+func closeSQLCollector(c *sqlcollector.V1) {
+	if err := c.Close(); err != nil {
+		log.Logger.Debugw("Failed to close the sql collector", "error", err)
+	}
+}
+
 // runOSCollection starts running os collection.
 func runOSCollection(ctx context.Context, c guestcollector.GuestCollector, timeout time.Duration) []internal.Details {
+	ctx, span := tracing.Start(ctx, "runOSCollection")
+	defer span.End()
 	details := []internal.Details{}
 	log.Logger.Debug("Collecting guest rules")
 	details = append(details, c.CollectGuestRules(ctx, timeout))
-	err := guestcollector.MarkUnknownOsFields(&details)
+	err := guestcollector.MarkUnknownCollectorOsFields(c, &details)
 	if err != nil {
 		log.Logger.Warnf("RunOSCollection: Failed to mark unknown collected fields. error: %v", err)
 	}
@@ -258,15 +688,36 @@ func runOSCollection(ctx context.Context, c guestcollector.GuestCollector, timeo
 	return details
 }
 
-// secretValue gets secret value from Secret Manager.
-func secretValue(ctx context.Context, projectID string, secretName string) (string, error) {
-	log.Logger.Debug("Getting secret.")
-	smClient, err := secretmanager.NewClient(ctx)
+// secretProvider is the store of the secrets, set by SetSecretProvider. The os and sql collection
+// services set it from the configuration they reload every cycle, so it is guarded by
+// secretProviderMu.
+var (
+	secretProviderMu sync.Mutex
+	secretProvider   secretmanager.SecretProvider = secretmanager.GoogleProvider{}
+)
+
+// SetSecretProvider sets the store of the secrets to the "secret_provider" of cfg.
+// Returns error if the provider is invalid, the store of the secrets is left unchanged.
+func SetSecretProvider(cfg *configpb.Configuration) error {
+	p, err := configuration.SecretProvider(cfg)
 	if err != nil {
-		return "", err
+		return fmt.Errorf("invalid secret provider: %w", err)
 	}
-	defer smClient.Close()
-	pswd, err := smClient.GetSecretValue(ctx, projectID, secretName)
+	secretProviderMu.Lock()
+	defer secretProviderMu.Unlock()
+	secretProvider = p
+	return nil
+}
+
+// secretValue gets secret value from the secret provider.
+func secretValue(ctx context.Context, projectID string, secretName string) (_ string, err error) {
+	ctx, span := tracing.Start(ctx, "secretValue")
+	defer func() { tracing.End(span, err) }()
+	log.Logger.Debug("Getting secret.")
+	secretProviderMu.Lock()
+	p := secretProvider
+	secretProviderMu.Unlock()
+	pswd, err := p.SecretValue(ctx, projectID, secretName)
 	if err != nil {
 		return "", err
 	}
@@ -275,43 +726,155 @@ func secretValue(ctx context.Context, projectID string, secretName string) (stri
 }
 
 // allDisks attempts to call compute api to return all possible disks.
+// Transient errors are retried up to allDisksMaxRetries times within allDisksTimeout.
 func allDisks(ctx context.Context, ip InstanceProperties) ([]*instanceinfo.Disks, error) {
 	tempGCE, err := gce.NewGCEClient(ctx)
 	if err != nil {
 		return nil, err
 	}
 
+	ctx, cancel := context.WithTimeout(ctx, allDisksTimeout)
+	defer cancel()
 	r := instanceinfo.New(tempGCE)
-	return r.AllDisks(ctx, ip.ProjectID, ip.Zone, ip.InstanceID)
+	b := backoff.WithMaxRetries(backoff.NewExponentialBackOff(), allDisksMaxRetries)
+	return r.AllDisksWithRetry(ctx, ip.ProjectID, ip.Zone, ip.InstanceID, b)
 }
 
 // updateCollectedData constructs writeinsightrequest from given collected details.
 // The func will be called by both guest and sql collections.
+// The status of the last request sent to workloadmanager and the collection durations of the rules
+// are reported along with the details.
 func updateCollectedData(wlmService wlm.WorkloadManagerService, sourceProps, targetProps InstanceProperties, details []internal.Details) {
-	sqlservervalidation := wlm.InitializeSQLServerValidation(sourceProps.ProjectID, targetProps.Instance)
-	sqlservervalidation = wlm.UpdateValidationDetails(sqlservervalidation, details)
-	writeInsightRequest := wlm.InitializeWriteInsightRequest(sqlservervalidation, targetProps.InstanceID)
-	writeInsightRequest.Insight.SentTime = time.Now().Format(time.RFC3339)
+	reported := append([]internal.Details{}, details...)
+	reported = append(reported, wlmSendStatus.Details())
+	writeInsightRequest := wlm.CollectedDataRequest(sourceProps.ProjectID, targetProps.Instance, targetProps.InstanceID, reported)
 	// update wlmService.Request to writeInsightRequest
 	wlmService.UpdateRequest(writeInsightRequest)
 }
 
 // sendRequestToWLM sends request to workloadmanager.
-func sendRequestToWLM(wlmService wlm.WorkloadManagerService, location string, retries int32, interval time.Duration) {
-	sendRequest := func() bool {
-		_, err := wlmService.SendRequest(location)
-		if err != nil {
-			log.Logger.Errorw("Failed to send request to workload manager", "error", err)
-			UsageMetricsLogger.Error(agentstatus.WorkloadManagerConnectionError)
-			return false
+// If the request could not be sent to location within the given retries, it is sent to
+// fallbackLocation the same way. fallbackLocation is ignored if empty.
+// Returns error if the request could not be sent within the given retries.
+func sendRequestToWLM(ctx context.Context, wlmService wlm.WorkloadManagerService, location, fallbackLocation string, retries int32, interval time.Duration) (err error) {
+	_, span := tracing.Start(ctx, "sendRequestToWLM")
+	defer func() { tracing.End(span, err) }()
+	sendToLocation := func(location string) error {
+		sendRequest := func() bool {
+			_, err := wlmService.SendRequest(location)
+			wlmSendStatus.RecordAttempt(err)
+			if err != nil {
+				log.Logger.Errorw("Failed to send request to workload manager", "location", location, "error", err)
+				UsageMetricsLogger.Error(agentstatus.WorkloadManagerConnectionError)
+				return false
+			}
+			return true
 		}
-		return true
+		return retry(sendRequest, retries, interval)
 	}
 
-	if err := retry(sendRequest, retries, interval); err != nil {
+	err = wlm.SendWithFallback(location, fallbackLocation, sendToLocation)
+	wlmSendStatus.Finish(err == nil)
+	if err != nil {
 		log.Logger.Errorw("Failed to retry sending request to workload manager", "error", err)
 		UsageMetricsLogger.Error(agentstatus.WorkloadManagerConnectionError)
+		return err
+	}
+	return nil
+}
+
+// loadCheckpoint returns the checkpoint of the current collection cycle.
+// Returns nil if checkpointing is disabled or the collection runs in onetime mode.
+func loadCheckpoint(cfg *configpb.Configuration, path string, collectionType CollectionType, onetime bool) *checkpoint.Checkpoint {
+	if onetime || !cfg.GetEnableCollectionCheckpoint() {
+		return nil
+	}
+	name := "google-cloud-sql-server-agent-sql.checkpoint"
+	interval := time.Duration(cfg.GetCollectionConfiguration().GetSqlMetricsCollectionIntervalInSeconds()) * time.Second
+	if collectionType == OS {
+		name = "google-cloud-sql-server-agent-guest.checkpoint"
+		interval = time.Duration(cfg.GetCollectionConfiguration().GetGuestOsMetricsCollectionIntervalInSeconds()) * time.Second
+	}
+	cp, err := checkpoint.Load(filepath.Join(filepath.Dir(path), name), interval, time.Now())
+	if err != nil {
+		log.Logger.Warnw("Failed to load the collection checkpoint. Starting a new cycle", "error", err)
+	}
+	return cp
+}
+
+// loadCollectionState loads the record of the rules sent to workload manager if only the changed
+// rules are sent. Returns nil otherwise, which sends all rules.
+func loadCollectionState(cfg *configpb.Configuration, path string, collectionType CollectionType, onetime bool) *collectiondiff.State {
+	if onetime || !cfg.GetSendChangedRulesOnly() {
+		return nil
 	}
+	name := "google-cloud-sql-server-agent-sql.state"
+	if collectionType == OS {
+		name = "google-cloud-sql-server-agent-guest.state"
+	}
+	s, err := collectiondiff.Load(filepath.Join(filepath.Dir(path), name))
+	if err != nil {
+		log.Logger.Warnw("Failed to load the collection state. Sending all rules", "error", err)
+	}
+	return s
+}
+
+// changedDetails returns the details of target to send to workload manager and whether they are
+// all of the details.
+func changedDetails(cfg *configpb.Configuration, s *collectiondiff.State, target string, details []internal.Details) ([]internal.Details, bool) {
+	if s == nil {
+		return details, true
+	}
+	return s.Changed(target, details, time.Now(), configuration.FullRefreshInterval(cfg))
+}
+
+// recordSentDetails records the details sent to workload manager for target.
+func recordSentDetails(s *collectiondiff.State, target string, details []internal.Details, full bool) {
+	if s == nil {
+		return
+	}
+	if err := s.Record(target, details, full, time.Now()); err != nil {
+		log.Logger.Warnw("Failed to save the collection state", "target", target, "error", err)
+	}
+}
+
+// checkpointTarget returns the key of the credential configuration in the checkpoint.
+func checkpointTarget(idx int, credentialCfg *configpb.CredentialConfiguration) string {
+	return fmt.Sprintf("%d:%s", idx, credentialCfg.GetInstanceName())
+}
+
+// skipCheckpointedTarget returns true if the target was already collected in the current cycle.
+func skipCheckpointedTarget(cp *checkpoint.Checkpoint, target string) bool {
+	if cp == nil || !cp.Collected(target) {
+		return false
+	}
+	log.Logger.Infow("Skipping the target already collected in the current collection cycle", "target", target)
+	return true
+}
+
+// markCheckpointedTarget records the target as collected in the current cycle.
+func markCheckpointedTarget(cp *checkpoint.Checkpoint, target string) {
+	if cp == nil {
+		return
+	}
+	if err := cp.MarkCollected(target); err != nil {
+		log.Logger.Warnw("Failed to save the collection checkpoint", "target", target, "error", err)
+	}
+}
+
+// persistOnetimeData persists the data collected in onetime mode.
+// If JSONStdout is set, the details are kept for WriteOnetimeDetails instead of being saved to path.
+func persistOnetimeData(wlm *wlm.WLM, details []internal.Details, path string) error {
+	if JSONStdout {
+		onetimeDetails = append(onetimeDetails, details...)
+		return nil
+	}
+	return persistCollectedData(wlm, path)
+}
+
+// WriteOnetimeDetails writes the details collected in onetime mode as JSON to w.
+func WriteOnetimeDetails(w io.Writer) error {
+	return internal.WriteDetailsJSON(w, onetimeDetails)
 }
 
 // persistCollectedData persists collected data in the file system.
@@ -355,7 +918,7 @@ func addPhysicalDriveRemoteLinux(details []internal.Details, cred *configuration
 	port := cred.GuestPortNumber
 	ip := cred.ServerName
 	// We need to call NewRemote, SetupKeys and CreateClient respectively to set up the remote correctly.
-	r := remote.NewRemote(ip, user, port, UsageMetricsLogger)
+	r := remote.NewRemote(ip, user, port, cred.HostKeyFingerprints, UsageMetricsLogger)
 	if err := r.SetupKeys(cred.LinuxSSHPrivateKeyPath); err != nil {
 		log.Logger.Errorw("Failed to setup keys.", "error", err)
 		UsageMetricsLogger.Error(agentstatus.SetupSSHKeysError)
@@ -377,63 +940,80 @@ func addPhysicalDriveRemoteLinux(details []internal.Details, cred *configuration
 				log.Logger.Warn("physical_name field for DB_LOG_DISK_SEPERATION does not exist")
 				continue
 			}
-			dir, filePath := filepath.Split(physicalPath)
-			findCommand := fmt.Sprintf(commandFind, dir, filePath)
-
-			filePath, filePathErr := remote.RunCommandWithPipes(findCommand, r)
-			if filePathErr != nil {
-				log.Logger.Warnf("Failed to run cmd %v. error: %v", findCommand, filePathErr)
-				continue
-			}
-			filePath = strings.TrimSuffix(filePath, "\n")
-
-			command := fmt.Sprintf(commandDf, filePath)
-			physicalPathMount, physicalPathErr := remote.RunCommandWithPipes(command, r)
-			if physicalPathErr != nil {
-				log.Logger.Warnf("Failed to run cmd %v. error: %v", command, physicalPathErr)
-				continue
-			}
-			physicalPathMount = strings.TrimSuffix(physicalPathMount, "\n")
-
-			resultMount, mountErr := remote.RunCommandWithPipes(commandMount, r)
-			if mountErr != nil {
-				log.Logger.Warnf("Failed to run cmd %v. error: %v", commandMount, mountErr)
+			physicalDrive, err := guestcollector.PhysicalDriveFromPathRemote(r, physicalPath)
+			if err != nil {
+				log.Logger.Warn(err)
 				continue
 			}
-
-			allMounts := strings.TrimSuffix(resultMount, "\n")
-			physicalDriveHelper := regexp.MustCompile(` `+physicalPathMount+` `).Split(allMounts, -1)
-
-			physicalDrives := []string{}
-			for i := 0; i < len(physicalDriveHelper)-1; i++ {
-				splitStr := regexp.MustCompile("\n| |/").Split(physicalDriveHelper[i], -1)
-				if len(splitStr) < 2 {
-					log.Logger.Warn("regex for linux error. Unable to find physical drive associated with mount.")
-					continue
-				}
-				physicalDrives = append(physicalDrives, splitStr[len(splitStr)-2])
-			}
-			physicalDrive := strings.Join(physicalDrives, ", ")
 			field["physical_drive"] = physicalDrive
 		}
 	}
 }
 
-// addPhysicalDriveLocal starts physical drive to physical path mapping
-func addPhysicalDriveLocal(ctx context.Context, details []internal.Details, windows bool) {
-	for _, detail := range details {
-		if detail.Name != "DB_LOG_DISK_SEPARATION" {
-			continue
-		}
-		for _, field := range detail.Fields {
-			physicalPath, pathExists := field["physical_name"]
-			if !pathExists {
-				log.Logger.Warn("physical_name field for DB_LOG_DISK_SEPARATION does not exist")
+// CollectOnce runs the guest os and sql collections enabled in cfg once on the instance of cp and
+// returns the collected details. Unlike OSCollection and SQLCollection, it does not send the
+// details to workload manager or write any file, so the collections can be embedded in other tools.
+// If cp is nil, the properties of the instance are read like the agent does, from the
+// "instance_properties" of an on_prem cfg or from the metadata server. The secrets are read from
+// the "secret_provider" of cfg.
+// The details of the targets which were collected are returned along with the errors of the failed ones.
+func CollectOnce(ctx context.Context, cfg *configpb.Configuration, cp *metadataserver.CloudProperties) (osDetails, sqlDetails []internal.Details, err error) {
+	if runtime.GOOS == "linux" && cfg.GetRemoteCollection() {
+		return nil, nil, fmt.Errorf("remote collection from a linux vm is not supported")
+	}
+	if err := SetSecretProvider(cfg); err != nil {
+		return nil, nil, err
+	}
+	var ip InstanceProperties
+	if cp != nil {
+		ip = instanceProperties(cp)
+	} else {
+		ip = sourceInstanceProperties(cfg)
+	}
+	osDetails, sqlDetails, err = collectonce.Collect(ctx, cfg, collectonce.Collectors{
+		OS: func(ctx context.Context, credentialCfg *configpb.CredentialConfiguration) ([]internal.Details, error) {
+			return collectTargetOS(ctx, cfg, credentialCfg, ip)
+		},
+		SQL: func(ctx context.Context, credentialCfg *configpb.CredentialConfiguration, sqlCfg *configuration.SQLConfig) ([]internal.Details, error) {
+			return collectInstanceSQL(ctx, cfg, credentialCfg, sqlCfg, ip)
+		},
+	})
+	hashDatabaseNames(cfg, sqlDetails, ip)
+	return osDetails, sqlDetails, err
+}
+
+// addPhysicalDriveLocal wraps the function AddPhysicalDrive in internal package.
+func addPhysicalDriveLocal(ctx context.Context, details []internal.Details, windows, collect bool) {
+	internal.AddPhysicalDrive(ctx, details, windows, collect, commandlineexecutor.ExecuteCommand)
+}
+
+// collectTargetOSWithinCycle collects the guest os details of the target of credentialCfg, cut off
+// after the maximum cycle duration.
+func collectTargetOSWithinCycle(ctx context.Context, cfg *configpb.Configuration, credentialCfg *configpb.CredentialConfiguration, ip InstanceProperties) ([]internal.Details, error) {
+	return internal.CollectWithinCycle(ctx, maxCycleDuration(cfg), func(ctx context.Context) ([]internal.Details, error) {
+		return collectTargetOS(ctx, cfg, credentialCfg, ip)
+	})
+}
+
+// collectTargetSQL collects the sql details of all the instances of the target of credentialCfg,
+// merged by rule and cut off after the maximum cycle duration. A failing instance does not stop the
+// collection of the others, the details of the collected instances are returned with the errors of
+// the failed ones.
+func collectTargetSQL(ctx context.Context, cfg *configpb.Configuration, credentialCfg *configpb.CredentialConfiguration, ip InstanceProperties) ([]internal.Details, error) {
+	return internal.CollectWithinCycle(ctx, maxCycleDuration(cfg), func(ctx context.Context) ([]internal.Details, error) {
+		validationDetails := initDetails()
+		var errs []error
+		for _, sqlCfg := range sqlConfigFromCredential(credentialCfg) {
+			details, err := collectInstanceSQL(ctx, cfg, credentialCfg, sqlCfg, ip)
+			if err != nil {
+				log.Logger.Errorw("SQL collection failed", "instance", sqlHostName(sqlCfg), "error", err)
+				errs = append(errs, fmt.Errorf("instance %s: %w", sqlHostName(sqlCfg), err))
 				continue
 			}
-			field["physical_drive"] = internal.GetPhysicalDriveFromPath(ctx, physicalPath, windows, commandlineexecutor.ExecuteCommand)
+			validationDetails = internal.MergeDetails(validationDetails, details)
 		}
-	}
+		return validationDetails, errors.Join(errs...)
+	})
 }
 
 // initDetails returns empty array of internal.Details
@@ -446,6 +1026,75 @@ func sqlConfigFromCredential(cred *configpb.CredentialConfiguration) []*configur
 	return configuration.SQLConfigFromCredential(cred)
 }
 
+// sqlConnectionString wraps the function SQLConnectionString in configuration package.
+func sqlConnectionString(sqlCfg *configuration.SQLConfig, password string) string {
+	return configuration.SQLConnectionString(sqlCfg, password)
+}
+
+// sqlHostName wraps the function SQLHostName in configuration package.
+func sqlHostName(sqlCfg *configuration.SQLConfig) string {
+	return configuration.SQLHostName(sqlCfg)
+}
+
+// secretProjectID wraps the function SecretProjectID in configuration package.
+func secretProjectID(projectID, agentProjectID string) string {
+	return configuration.SecretProjectID(projectID, agentProjectID)
+}
+
+// collectPhysicalDriveMapping wraps the function CollectPhysicalDriveMapping in configuration package.
+func collectPhysicalDriveMapping(cfg *configpb.Configuration) bool {
+	return configuration.CollectPhysicalDriveMapping(cfg)
+}
+
+// addEditionLimits wraps the function AddEditionLimits in internal package.
+func addEditionLimits(details []internal.Details) []internal.Details {
+	return internal.AddEditionLimits(details)
+}
+
+// addPatchLevel wraps the function AddPatchLevel in internal package.
+func addPatchLevel(details []internal.Details) []internal.Details {
+	return internal.AddPatchLevel(details)
+}
+
+// hashDatabaseNames replaces the database names of details with their hash salted with the
+// project id of the agent if "hash_database_names" is set.
+func hashDatabaseNames(cfg *configpb.Configuration, details []internal.Details, ip InstanceProperties) {
+	if !cfg.GetHashDatabaseNames() {
+		return
+	}
+	internal.HashDatabaseNames(details, ip.ProjectID)
+}
+
+// addHostFields wraps the function AddHostFields in internal package.
+func addHostFields(details []internal.Details, hostName string, portNumber int32) {
+	internal.AddHostFields(details, hostName, portNumber)
+}
+
+// addCollectionSource stamps how the details of the target were collected into the details.
+func addCollectionSource(details []internal.Details, remote, linuxRemote bool) {
+	internal.AddCollectionSource(details, internal.CollectionSource(remote, linuxRemote))
+}
+
+// ruleFailureLogLimit wraps the function RuleFailureLogLimit in configuration package.
+func ruleFailureLogLimit(cfg *configpb.Configuration) int {
+	return configuration.RuleFailureLogLimit(cfg)
+}
+
+// sqlCollectionTimeout wraps the function SQLCollectionTimeout in configuration package.
+func sqlCollectionTimeout(cfg *configpb.Configuration) time.Duration {
+	return configuration.SQLCollectionTimeout(cfg)
+}
+
+// maxCycleDuration wraps the function MaxCycleDuration in configuration package.
+func maxCycleDuration(cfg *configpb.Configuration) time.Duration {
+	return configuration.MaxCycleDuration(cfg)
+}
+
+// osCollectionTimeout wraps the function OSCollectionTimeout in configuration package.
+func osCollectionTimeout(cfg *configpb.Configuration) time.Duration {
+	return configuration.OSCollectionTimeout(cfg)
+}
+
 // guestConfigFromCredential wraps the function GuestConfigFromCredential in configuration package.
 func guestConfigFromCredential(cred *configpb.CredentialConfiguration) *configuration.GuestConfig {
 	return configuration.GuestConfigFromCredential(cred)
@@ -18,18 +18,21 @@ package sqlservermetrics
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"path/filepath"
 	"time"
 
 	"github.com/GoogleCloudPlatform/sql-server-agent/internal/agentstatus"
+	"github.com/GoogleCloudPlatform/sql-server-agent/internal/configuration"
 	"github.com/GoogleCloudPlatform/sql-server-agent/internal/guestcollector"
+	"github.com/GoogleCloudPlatform/sql-server-agent/internal"
 	configpb "github.com/GoogleCloudPlatform/sql-server-agent/protos/sqlserveragentconfig"
 	"github.com/GoogleCloudPlatform/workloadagentplatform/integration/common/shared/log"
 )
 
-// LogPrefix is the log prefix for linux.
-func LogPrefix() string {
+// defaultLogPrefix is the default log prefix for linux.
+func defaultLogPrefix() string {
 	return "/var/log/google-cloud-sql-server-agent"
 }
 
@@ -57,7 +60,7 @@ func OSCollection(ctx context.Context, path, logPrefix string, cfg *configpb.Con
 		return fmt.Errorf("empty credentials")
 	}
 
-	wlm, err := initCollection(ctx)
+	wlm, err := initCollection(ctx, cfg)
 	if err != nil {
 		return err
 	}
@@ -71,30 +74,28 @@ func OSCollection(ctx context.Context, path, logPrefix string, cfg *configpb.Con
 	// only local collection is supported for linux binary.
 	// therefore we only get the first credential from cred list and ignore the followings.
 	credentialCfg := cfg.GetCredentialConfiguration()[0]
-	guestCfg := guestConfigFromCredential(credentialCfg)
-	if err := validateCredCfgGuest(false, !guestCfg.LinuxRemote, guestCfg, credentialCfg.GetInstanceId(), credentialCfg.GetInstanceName()); err != nil {
-		return err
-	}
-
 	sourceInstanceProps := SIP
-	targetInstanceProps := sourceInstanceProps
-	disks, err := allDisks(ctx, targetInstanceProps)
+	details, err := collectTargetOSWithinCycle(ctx, cfg, credentialCfg, sourceInstanceProps)
 	if err != nil {
-		return fmt.Errorf("failed to collect disk info: %w", err)
+		return err
 	}
 
-	c := guestcollector.NewLinuxCollector(disks, "", "", "", false, 22, UsageMetricsLogger)
-	timeout := time.Duration(cfg.GetCollectionTimeoutSeconds()) * time.Second
-	details := runOSCollection(ctx, c, timeout)
-	updateCollectedData(wlm, sourceInstanceProps, targetInstanceProps, details)
+	targetInstanceProps := sourceInstanceProps
+	state := loadCollectionState(cfg, path, OS, onetime)
+	sent, full := changedDetails(cfg, state, checkpointTarget(0, credentialCfg), details)
+	updateCollectedData(wlm, sourceInstanceProps, targetInstanceProps, sent)
 
 	if onetime {
 		target := "localhost"
-		persistCollectedData(wlm, filepath.Join(filepath.Dir(logPrefix), fmt.Sprintf("%s-%s.json", target, "guest")))
+		if err := persistOnetimeData(wlm, details, filepath.Join(onetimeOutputDir(logPrefix), fmt.Sprintf("%s-%s.json", target, "guest"))); err != nil {
+			return fmt.Errorf("failed to persist the collected data: %w", err)
+		}
 	} else {
 		log.Logger.Debugf("Source vm %s is sending os collected data on target machine, %s, to workload manager.", sourceInstanceProps.Instance, targetInstanceProps.Instance)
 		interval := time.Duration(cfg.GetRetryIntervalInSeconds()) * time.Second
-		sendRequestToWLM(wlm, sourceInstanceProps.Name, cfg.GetMaxRetries(), interval)
+		if err := sendRequestToWLM(ctx, wlm, sourceInstanceProps.Name, secondaryWLMLocation(cfg), cfg.GetMaxRetries(), interval); err == nil {
+			recordSentDetails(state, checkpointTarget(0, credentialCfg), sent, full)
+		}
 	}
 	log.Logger.Info("Guest os rules collection ends.")
 	return nil
@@ -112,7 +113,7 @@ func SQLCollection(ctx context.Context, path, logPrefix string, cfg *configpb.Co
 		return fmt.Errorf("empty credentials")
 	}
 
-	wlm, err := initCollection(ctx)
+	wlm, err := initCollection(ctx, cfg)
 	if err != nil {
 		return err
 	}
@@ -123,61 +124,112 @@ func SQLCollection(ctx context.Context, path, logPrefix string, cfg *configpb.Co
 		}
 	}
 
+	cp := loadCheckpoint(cfg, path, SQL, onetime)
+	state := loadCollectionState(cfg, path, SQL, onetime)
+
 	log.Logger.Info("Sql rules collection starts.")
-	for _, credentialCfg := range cfg.GetCredentialConfiguration() {
-		validationDetails := initDetails()
-		sourceInstanceProps := SIP
-		guestCfg := guestConfigFromCredential(credentialCfg)
-		for _, sqlCfg := range sqlConfigFromCredential(credentialCfg) {
-			if err := validateCredCfgSQL(false, !guestCfg.LinuxRemote, sqlCfg, guestCfg, credentialCfg.GetInstanceId(), credentialCfg.GetInstanceName()); err != nil {
-				log.Logger.Errorw("Invalid credential configuration", "error", err)
-				UsageMetricsLogger.Error(agentstatus.InvalidConfigurationsError)
-				continue
-			}
-			pswd, err := secretValue(ctx, sourceInstanceProps.ProjectID, sqlCfg.SecretName)
-			if err != nil {
-				log.Logger.Errorw("Failed to get secret value", "error", err)
-				UsageMetricsLogger.Error(agentstatus.SecretValueError)
-				continue
-			}
-			conn := fmt.Sprintf("server=%s;user id=%s;password=%s;port=%d;", sqlCfg.Host, sqlCfg.Username, pswd, sqlCfg.PortNumber)
-			timeout := time.Duration(cfg.GetCollectionTimeoutSeconds()) * time.Second
-			details, err := runSQLCollection(ctx, conn, timeout, false)
-			if err != nil {
-				log.Logger.Errorw("Failed to run sql collection", "error", err)
-				UsageMetricsLogger.Error(agentstatus.SQLCollectionFailure)
-				continue
-			}
-			for _, detail := range details {
-				for _, field := range detail.Fields {
-					field["host_name"] = sqlCfg.Host
-					field["port_number"] = fmt.Sprintf("%d", sqlCfg.PortNumber)
-				}
-			}
-			addPhysicalDriveLocal(ctx, details, false)
-
-			for i, detail := range details {
-				for _, vd := range validationDetails {
-					if detail.Name == vd.Name {
-						detail.Fields = append(vd.Fields, detail.Fields...)
-						details[i] = detail
-						break
-					}
-				}
-			}
-			validationDetails = details
+	targets := 0
+	var errs []error
+	for i, credentialCfg := range cfg.GetCredentialConfiguration() {
+		if skipCheckpointedTarget(cp, checkpointTarget(i, credentialCfg)) {
+			continue
 		}
+		targets++
+		sourceInstanceProps := SIP
+		validationDetails, collectErr := collectTargetSQL(ctx, cfg, credentialCfg, sourceInstanceProps)
+		hashDatabaseNames(cfg, validationDetails, sourceInstanceProps)
 		targetInstanceProps := sourceInstanceProps
-		updateCollectedData(wlm, sourceInstanceProps, targetInstanceProps, validationDetails)
+		sent, full := changedDetails(cfg, state, checkpointTarget(i, credentialCfg), validationDetails)
+		updateCollectedData(wlm, sourceInstanceProps, targetInstanceProps, sent)
 
+		var persistErr error
 		if onetime {
-			persistCollectedData(wlm, filepath.Join(filepath.Dir(logPrefix), fmt.Sprintf("%s-%s.json", targetInstanceProps.Instance, "sql")))
+			persistErr = persistOnetimeData(wlm, validationDetails, filepath.Join(onetimeOutputDir(logPrefix), fmt.Sprintf("%s-%s.json", targetInstanceProps.Instance, "sql")))
 		} else {
 			log.Logger.Debugf("Source vm %s is sending collected sql data on target machine, %s, to workload manager.", sourceInstanceProps.Instance, targetInstanceProps.Instance)
 			interval := time.Duration(cfg.GetRetryIntervalInSeconds()) * time.Second
-			sendRequestToWLM(wlm, sourceInstanceProps.Name, cfg.GetMaxRetries(), interval)
+			if err := sendRequestToWLM(ctx, wlm, sourceInstanceProps.Name, secondaryWLMLocation(cfg), cfg.GetMaxRetries(), interval); err == nil {
+				markCheckpointedTarget(cp, checkpointTarget(i, credentialCfg))
+				recordSentDetails(state, checkpointTarget(i, credentialCfg), sent, full)
+			}
+		}
+		if err := errors.Join(collectErr, persistErr); err != nil {
+			errs = append(errs, fmt.Errorf("target %s: %w", targetInstanceProps.Instance, err))
 		}
 	}
 	log.Logger.Info("Sql rules collection ends.")
-	return nil
+	return internal.NewTargetErrors(targets, errs)
+}
+
+// collectTargetOS collects the guest os details of the local machine.
+func collectTargetOS(ctx context.Context, cfg *configpb.Configuration, credentialCfg *configpb.CredentialConfiguration, ip InstanceProperties) ([]internal.Details, error) {
+	guestCfg := guestConfigFromCredential(credentialCfg)
+	if err := validateCredCfgGuest(false, !guestCfg.LinuxRemote, guestCfg, credentialCfg.GetInstanceId(), credentialCfg.GetInstanceName()); err != nil {
+		return nil, err
+	}
+	disks, err := allDisks(ctx, ip)
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect disk info: %w", err)
+	}
+
+	c := guestcollector.NewLinuxCollector(disks, "", "", "", nil, false, 22, UsageMetricsLogger)
+	c.SetMaxConcurrentRules(int(cfg.GetGuestRuleConcurrency()))
+	details := runOSCollection(ctx, c, osCollectionTimeout(cfg))
+	addCollectionSource(details, false, false)
+	return details, nil
+}
+
+// collectInstanceSQL collects the sql details of the local instance of sqlCfg.
+func collectInstanceSQL(ctx context.Context, cfg *configpb.Configuration, credentialCfg *configpb.CredentialConfiguration, sqlCfg *configuration.SQLConfig, ip InstanceProperties) ([]internal.Details, error) {
+	guestCfg := guestConfigFromCredential(credentialCfg)
+	if err := validateCredCfgSQL(false, !guestCfg.LinuxRemote, sqlCfg, guestCfg, credentialCfg.GetInstanceId(), credentialCfg.GetInstanceName()); err != nil {
+		UsageMetricsLogger.Error(agentstatus.InvalidConfigurationsError)
+		return nil, fmt.Errorf("invalid credential configuration: %w", err)
+	}
+	pswd, err := sqlPassword(ctx, sqlCfg, ip.ProjectID)
+	if err != nil {
+		UsageMetricsLogger.Error(agentstatus.SecretValueError)
+		return nil, fmt.Errorf("failed to get secret value: %w", err)
+	}
+	conn := sqlConnectionString(sqlCfg, pswd)
+	details, err := runSQLCollection(ctx, cfg, sqlTarget(sqlCfg), conn, sqlAccessTokenProvider(sqlCfg), false)
+	if err != nil {
+		UsageMetricsLogger.Error(agentstatus.SQLCollectionFailure)
+		return nil, fmt.Errorf("failed to run sql collection: %w", err)
+	}
+	details = addEditionLimits(details)
+	details = addPatchLevel(details)
+	addHostFields(details, sqlHostName(sqlCfg), sqlCfg.PortNumber)
+	addCollectionSource(details, false, false)
+	addPhysicalDriveLocal(ctx, details, false, collectPhysicalDriveMapping(cfg))
+	addNumaAlignmentLocal(ctx, details)
+	if collectPhysicalDriveMapping(cfg) {
+		addTempdbOnLocalSSDLocal(ctx, details, ip)
+	}
+	return details, nil
+}
+
+// addNumaAlignmentLocal checks if the NUMA node count of SQL Server matches the local linux machine.
+func addNumaAlignmentLocal(ctx context.Context, details []internal.Details) {
+	osNodeCount, err := guestcollector.LocalNumaNodeCount(ctx)
+	if err != nil {
+		log.Logger.Warnw("Failed to get the NUMA node count of the OS", "error", err)
+		osNodeCount = -1
+	}
+	guestcollector.AddNumaAlignment(details, osNodeCount)
+}
+
+// addTempdbOnLocalSSDLocal verifies the tempdb data files reside on the local SSDs of the local
+// linux machine.
+func addTempdbOnLocalSSDLocal(ctx context.Context, details []internal.Details, ip InstanceProperties) {
+	diskTypes := "unknown"
+	disks, err := allDisks(ctx, ip)
+	if err != nil {
+		log.Logger.Warnw("Failed to get the disks of the instance", "error", err)
+	} else {
+		fields := map[string]string{}
+		guestcollector.DiskToDiskType(fields, disks, UsageMetricsLogger)
+		diskTypes = fields[internal.LocalSSDRule]
+	}
+	internal.AddTempdbOnLocalSSD(details, diskTypes)
 }
@@ -0,0 +1,120 @@
+/*
+Copyright 2023 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sqlservermetrics
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/GoogleCloudPlatform/sql-server-agent/internal/configuration"
+	"github.com/GoogleCloudPlatform/sql-server-agent/internal/secretmanager"
+	configpb "github.com/GoogleCloudPlatform/sql-server-agent/protos/sqlserveragentconfig"
+)
+
+func TestSetSourceInstanceProperties(t *testing.T) {
+	cfg := &configpb.Configuration{
+		Environment: "on_prem",
+		InstanceProperties: &configpb.InstanceProperties{
+			ProjectId:    "test-project",
+			Zone:         "us-central1-a",
+			InstanceName: "test-instance",
+		},
+	}
+	want := InstanceProperties{
+		Name:      "projects/test-project/locations/us-central1",
+		ProjectID: "test-project",
+		// The instance id defaults to the instance name.
+		InstanceID: "test-instance",
+		Instance:   "test-instance",
+		Zone:       "us-central1-a",
+		Image:      "unknown",
+	}
+	t.Cleanup(func() { SIP = InstanceProperties{} })
+
+	SetSourceInstanceProperties(cfg)
+	if diff := cmp.Diff(want, SIP); diff != "" {
+		t.Errorf("SetSourceInstanceProperties() returned unexpected diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestSetSecretProvider(t *testing.T) {
+	t.Cleanup(func() { secretProvider = secretmanager.GoogleProvider{} })
+	if err := SetSecretProvider(&configpb.Configuration{SecretProvider: "env"}); err != nil {
+		t.Fatalf("SetSecretProvider(env) returned error: %v", err)
+	}
+	if _, ok := secretProvider.(secretmanager.EnvProvider); !ok {
+		t.Errorf("SetSecretProvider(env) set the secret provider to %T, want secretmanager.EnvProvider", secretProvider)
+	}
+
+	tests := []struct {
+		name string
+		cfg  *configpb.Configuration
+	}{
+		{
+			name: "unknown secret provider",
+			cfg:  &configpb.Configuration{SecretProvider: "vualt"},
+		},
+		{
+			name: "vault secret provider without vault address",
+			cfg:  &configpb.Configuration{SecretProvider: "vault"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := SetSecretProvider(tc.cfg); err == nil {
+				t.Errorf("SetSecretProvider(%v) returned nil error, want error", tc.cfg)
+			}
+			if _, ok := secretProvider.(secretmanager.EnvProvider); !ok {
+				t.Errorf("SetSecretProvider(%v) changed the secret provider to %T, want it unchanged", tc.cfg, secretProvider)
+			}
+		})
+	}
+}
+
+func TestPreparedSQLCollector(t *testing.T) {
+	t.Cleanup(func() {
+		for target := range preparedSQLCollectors {
+			closePreparedSQLCollector(target)
+		}
+	})
+	sqlCfg := &configuration.SQLConfig{Host: "test-host", Username: "test-user", PortNumber: 1433}
+	target := sqlTarget(sqlCfg)
+	c, err := preparedSQLCollector(target, sqlConnectionString(sqlCfg, "password-1"), nil, false)
+	if err != nil {
+		t.Fatalf("preparedSQLCollector() returned error: %v", err)
+	}
+	if got, err := preparedSQLCollector(target, sqlConnectionString(sqlCfg, "password-1"), nil, false); err != nil || got != c {
+		t.Errorf("preparedSQLCollector() with the same connection string = %p, %v, want the collector %p of the previous collection", got, err, c)
+	}
+
+	// The rotated password replaces the collector of the target.
+	rotated, err := preparedSQLCollector(target, sqlConnectionString(sqlCfg, "password-2"), nil, false)
+	if err != nil {
+		t.Fatalf("preparedSQLCollector() returned error: %v", err)
+	}
+	if rotated == c {
+		t.Errorf("preparedSQLCollector() with the rotated password returned the collector %p of the previous password, want a new collector", c)
+	}
+	if len(preparedSQLCollectors) != 1 {
+		t.Errorf("preparedSQLCollector() kept %d collectors, want 1 collector per target", len(preparedSQLCollectors))
+	}
+
+	closePreparedSQLCollector(target)
+	if len(preparedSQLCollectors) != 0 {
+		t.Errorf("closePreparedSQLCollector() kept %d collectors, want 0", len(preparedSQLCollectors))
+	}
+}
@@ -18,19 +18,22 @@ package sqlservermetrics
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"time"
 
 	"github.com/GoogleCloudPlatform/sql-server-agent/internal/agentstatus"
+	"github.com/GoogleCloudPlatform/sql-server-agent/internal/configuration"
 	"github.com/GoogleCloudPlatform/sql-server-agent/internal/guestcollector"
+	"github.com/GoogleCloudPlatform/sql-server-agent/internal"
 	configpb "github.com/GoogleCloudPlatform/sql-server-agent/protos/sqlserveragentconfig"
 	"github.com/GoogleCloudPlatform/workloadagentplatform/integration/common/shared/log"
 )
 
-// LogPrefix is the log prefix for windows.
-func LogPrefix() string {
+// defaultLogPrefix is the default log prefix for windows.
+func defaultLogPrefix() string {
 	return filepath.Join(
 		os.Getenv("ProgramData"),
 		"Google",
@@ -65,7 +68,7 @@ func OSCollection(ctx context.Context, path, logPrefix string, cfg *configpb.Con
 	if cfg.GetCredentialConfiguration() == nil || len(cfg.GetCredentialConfiguration()) == 0 {
 		return fmt.Errorf("empty credentials")
 	}
-	wlm, err := initCollection(ctx)
+	wlm, err := initCollection(ctx, cfg)
 	if err != nil {
 		return err
 	}
@@ -76,57 +79,41 @@ func OSCollection(ctx context.Context, path, logPrefix string, cfg *configpb.Con
 	}
 
 	sourceInstanceProps := SIP
-	timeout := time.Duration(cfg.GetCollectionTimeoutSeconds()) * time.Second
 	interval := time.Duration(cfg.GetRetryIntervalInSeconds()) * time.Second
 
+	cp := loadCheckpoint(cfg, path, OS, onetime)
+	state := loadCollectionState(cfg, path, OS, onetime)
+
 	log.Logger.Info("Guest rules collection starts.")
-	for _, credentialCfg := range cfg.GetCredentialConfiguration() {
-		guestCfg := guestConfigFromCredential(credentialCfg)
-		if err := validateCredCfgGuest(cfg.GetRemoteCollection(), !guestCfg.LinuxRemote, guestCfg, credentialCfg.GetInstanceId(), credentialCfg.GetInstanceName()); err != nil {
-			log.Logger.Errorw("Invalid credential configuration", "error", err)
-			UsageMetricsLogger.Error(agentstatus.InvalidConfigurationsError)
+	targets := 0
+	var errs []error
+	for i, credentialCfg := range cfg.GetCredentialConfiguration() {
+		if skipCheckpointedTarget(cp, checkpointTarget(i, credentialCfg)) {
+			if !cfg.GetRemoteCollection() {
+				break
+			}
+			continue
+		}
+		targets++
+		details, err := collectTargetOSWithinCycle(ctx, cfg, credentialCfg, sourceInstanceProps)
+		if err != nil {
+			log.Logger.Errorw("Guest collection failed", "target", credentialCfg.GetInstanceName(), "error", err)
+			errs = append(errs, fmt.Errorf("target %s: %w", credentialCfg.GetInstanceName(), err))
 			if !cfg.GetRemoteCollection() {
 				break
 			}
 			continue
 		}
-
 		targetInstanceProps := sourceInstanceProps
-		var c guestcollector.GuestCollector
 		if cfg.GetRemoteCollection() {
 			// remote collection
 			targetInstanceProps = InstanceProperties{
 				InstanceID: credentialCfg.GetInstanceId(),
 				Instance:   credentialCfg.GetInstanceName(),
 			}
-			host := guestCfg.ServerName
-			username := guestCfg.GuestUserName
-			if !guestCfg.LinuxRemote {
-				log.Logger.Debug("Starting remote win guest collection for ip " + host)
-				pswd, err := secretValue(ctx, sourceInstanceProps.ProjectID, guestCfg.GuestSecretName)
-				if err != nil {
-					log.Logger.Errorw("Collection failed", "target", guestCfg.ServerName, "error", fmt.Errorf("failed to get secret value: %v", err))
-					UsageMetricsLogger.Error(agentstatus.SecretValueError)
-					if !cfg.GetRemoteCollection() {
-						break
-					}
-					continue
-				}
-				c = guestcollector.NewWindowsCollector(host, username, pswd, UsageMetricsLogger)
-			} else {
-				// on local windows vm collecting on remote linux vm's, we use ssh, otherwise we use wmi
-				log.Logger.Debug("Starting remote linux guest collection for ip " + host)
-				// disks only used for local linux collection
-				c = guestcollector.NewLinuxCollector(nil, host, username, guestCfg.LinuxSSHPrivateKeyPath, true, guestCfg.GuestPortNumber, UsageMetricsLogger)
-			}
-		} else {
-			// local win collection
-			log.Logger.Debug("Starting local win guest collection")
-			c = guestcollector.NewWindowsCollector(nil, nil, nil, UsageMetricsLogger)
 		}
-
-		details := runOSCollection(ctx, c, timeout)
-		updateCollectedData(wlm, sourceInstanceProps, targetInstanceProps, details)
+		sent, full := changedDetails(cfg, state, checkpointTarget(i, credentialCfg), details)
+		updateCollectedData(wlm, sourceInstanceProps, targetInstanceProps, sent)
 		log.Logger.Debug("Finished guest collection")
 
 		if onetime {
@@ -134,10 +121,15 @@ func OSCollection(ctx context.Context, path, logPrefix string, cfg *configpb.Con
 			if cfg.GetRemoteCollection() {
 				target = credentialCfg.GetInstanceName()
 			}
-			persistCollectedData(wlm, filepath.Join(filepath.Dir(logPrefix), fmt.Sprintf("%s-%s.json", target, "guest")))
+			if err := persistOnetimeData(wlm, details, filepath.Join(onetimeOutputDir(logPrefix), fmt.Sprintf("%s-%s.json", target, "guest"))); err != nil {
+				errs = append(errs, fmt.Errorf("target %s: failed to persist the collected data: %w", target, err))
+			}
 		} else {
 			log.Logger.Debugf("Source vm %s is sending os collected data on target machine, %s, to workload manager.", sourceInstanceProps.Instance, targetInstanceProps.Instance)
-			sendRequestToWLM(wlm, sourceInstanceProps.Name, cfg.GetMaxRetries(), interval)
+			if err := sendRequestToWLM(ctx, wlm, sourceInstanceProps.Name, secondaryWLMLocation(cfg), cfg.GetMaxRetries(), interval); err == nil {
+				markCheckpointedTarget(cp, checkpointTarget(i, credentialCfg))
+				recordSentDetails(state, checkpointTarget(i, credentialCfg), sent, full)
+			}
 		}
 		// Local collection.
 		// Exit the loop. Only take the first credential in the credentialconfiguration array.
@@ -147,7 +139,55 @@ func OSCollection(ctx context.Context, path, logPrefix string, cfg *configpb.Con
 	}
 	log.Logger.Info("Guest rules collection ends.")
 
-	return nil
+	return internal.NewTargetErrors(targets, errs)
+}
+
+// collectTargetOS collects the guest os details of the target of credentialCfg, the local machine
+// or a remote windows or linux machine.
+func collectTargetOS(ctx context.Context, cfg *configpb.Configuration, credentialCfg *configpb.CredentialConfiguration, ip InstanceProperties) ([]internal.Details, error) {
+	guestCfg := guestConfigFromCredential(credentialCfg)
+	if err := validateCredCfgGuest(cfg.GetRemoteCollection(), !guestCfg.LinuxRemote, guestCfg, credentialCfg.GetInstanceId(), credentialCfg.GetInstanceName()); err != nil {
+		UsageMetricsLogger.Error(agentstatus.InvalidConfigurationsError)
+		return nil, fmt.Errorf("invalid credential configuration: %w", err)
+	}
+
+	var c guestcollector.GuestCollector
+	if cfg.GetRemoteCollection() {
+		// remote collection
+		host := guestCfg.ServerName
+		username := guestCfg.GuestUserName
+		if !guestCfg.LinuxRemote {
+			log.Logger.Debug("Starting remote win guest collection for ip " + host)
+			pswd, err := secretValue(ctx, secretProjectID(guestCfg.GuestSecretProjectID, ip.ProjectID), guestCfg.GuestSecretName)
+			if err != nil {
+				UsageMetricsLogger.Error(agentstatus.SecretValueError)
+				return nil, fmt.Errorf("failed to get secret value: %v", err)
+			}
+			transport, err := guestcollector.ParseWindowsTransport(guestCfg.WindowsTransport)
+			if err != nil {
+				UsageMetricsLogger.Error(agentstatus.InvalidConfigurationsError)
+				return nil, fmt.Errorf("invalid credential configuration: %w", err)
+			}
+			wc := guestcollector.NewWindowsCollector(host, username, pswd, UsageMetricsLogger)
+			wc.SetTransport(transport)
+			c = wc
+		} else {
+			// on local windows vm collecting on remote linux vm's, we use ssh, otherwise we use wmi
+			log.Logger.Debug("Starting remote linux guest collection for ip " + host)
+			// disks only used for local linux collection
+			lc := guestcollector.NewLinuxCollector(nil, host, username, guestCfg.LinuxSSHPrivateKeyPath, guestCfg.HostKeyFingerprints, true, guestCfg.GuestPortNumber, UsageMetricsLogger)
+			lc.SetMaxConcurrentRules(int(cfg.GetGuestRuleConcurrency()))
+			c = lc
+		}
+	} else {
+		// local win collection
+		log.Logger.Debug("Starting local win guest collection")
+		c = guestcollector.NewWindowsCollector(nil, nil, nil, UsageMetricsLogger)
+	}
+
+	details := runOSCollection(ctx, c, osCollectionTimeout(cfg))
+	addCollectionSource(details, cfg.GetRemoteCollection(), guestCfg.LinuxRemote)
+	return details, nil
 }
 
 // SQLCollection is the windows implementation of SQLCollection.
@@ -159,7 +199,7 @@ func SQLCollection(ctx context.Context, path, logPrefix string, cfg *configpb.Co
 		return fmt.Errorf("empty credentials")
 	}
 
-	wlm, err := initCollection(ctx)
+	wlm, err := initCollection(ctx, cfg)
 	if err != nil {
 		return err
 	}
@@ -170,58 +210,21 @@ func SQLCollection(ctx context.Context, path, logPrefix string, cfg *configpb.Co
 	}
 
 	sourceInstanceProps := SIP
-	timeout := time.Duration(cfg.GetCollectionTimeoutSeconds()) * time.Second
 	interval := time.Duration(cfg.GetRetryIntervalInSeconds()) * time.Second
 
-	log.Logger.Info("SQL rules collection starts.")
-	for _, credentialCfg := range cfg.GetCredentialConfiguration() {
-		validationDetails := initDetails()
-		guestCfg := guestConfigFromCredential(credentialCfg)
-		for _, sqlCfg := range sqlConfigFromCredential(credentialCfg) {
-			if err := validateCredCfgSQL(cfg.GetRemoteCollection(), !guestCfg.LinuxRemote, sqlCfg, guestCfg, credentialCfg.GetInstanceId(), credentialCfg.GetInstanceName()); err != nil {
-				log.Logger.Errorw("Invalid credential configuration", "error", err)
-				UsageMetricsLogger.Error(agentstatus.InvalidConfigurationsError)
-				continue
-			}
-			pswd, err := secretValue(ctx, sourceInstanceProps.ProjectID, sqlCfg.SecretName)
-			if err != nil {
-				log.Logger.Errorw("Failed to get secret value", "error", err)
-				UsageMetricsLogger.Error(agentstatus.SecretValueError)
-				continue
-			}
-			conn := fmt.Sprintf("server=%s;user id=%s;password=%s;port=%d;", sqlCfg.Host, sqlCfg.Username, pswd, sqlCfg.PortNumber)
-			details, err := runSQLCollection(ctx, conn, timeout, !guestCfg.LinuxRemote)
-			if err != nil {
-				log.Logger.Errorw("Failed to run sql collection", "error", err)
-				UsageMetricsLogger.Error(agentstatus.SQLCollectionFailure)
-				continue
-			}
-
-			for _, detail := range details {
-				for _, field := range detail.Fields {
-					field["host_name"] = sqlCfg.Host
-					field["port_number"] = fmt.Sprintf("%d", sqlCfg.PortNumber)
-				}
-			}
+	cp := loadCheckpoint(cfg, path, SQL, onetime)
+	state := loadCollectionState(cfg, path, SQL, onetime)
 
-			// getting physical drive if on local windows collecting sql on linux remote
-			if cfg.GetRemoteCollection() && guestCfg.LinuxRemote {
-				addPhysicalDriveRemoteLinux(details, guestCfg)
-			} else {
-				addPhysicalDriveLocal(ctx, details, true)
-			}
-
-			for i, detail := range details {
-				for _, vd := range validationDetails {
-					if detail.Name == vd.Name {
-						detail.Fields = append(vd.Fields, detail.Fields...)
-						details[i] = detail
-						break
-					}
-				}
-			}
-			validationDetails = details
+	log.Logger.Info("SQL rules collection starts.")
+	targets := 0
+	var errs []error
+	for i, credentialCfg := range cfg.GetCredentialConfiguration() {
+		if skipCheckpointedTarget(cp, checkpointTarget(i, credentialCfg)) {
+			continue
 		}
+		targets++
+		validationDetails, collectErr := collectTargetSQL(ctx, cfg, credentialCfg, sourceInstanceProps)
+		hashDatabaseNames(cfg, validationDetails, sourceInstanceProps)
 
 		targetInstanceProps := sourceInstanceProps
 		// update targetInstanceProps value for remote collections.
@@ -232,18 +235,98 @@ func SQLCollection(ctx context.Context, path, logPrefix string, cfg *configpb.Co
 				Instance:   credentialCfg.GetInstanceName(),
 			}
 		}
-		updateCollectedData(wlm, sourceInstanceProps, targetInstanceProps, validationDetails)
+		sent, full := changedDetails(cfg, state, checkpointTarget(i, credentialCfg), validationDetails)
+		updateCollectedData(wlm, sourceInstanceProps, targetInstanceProps, sent)
+		target := "localhost"
+		if cfg.GetRemoteCollection() {
+			target = targetInstanceProps.Instance
+		}
+		var persistErr error
 		if onetime {
-			target := "localhost"
-			if cfg.GetRemoteCollection() {
-				target = targetInstanceProps.Instance
-			}
-			persistCollectedData(wlm, filepath.Join(filepath.Dir(logPrefix), fmt.Sprintf("%s-%s.json", target, "sql")))
+			persistErr = persistOnetimeData(wlm, validationDetails, filepath.Join(onetimeOutputDir(logPrefix), fmt.Sprintf("%s-%s.json", target, "sql")))
 		} else {
 			log.Logger.Debugf("Source vm %s is sending collected sql data on target machine, %s, to workload manager.", sourceInstanceProps.Instance, targetInstanceProps.Instance)
-			sendRequestToWLM(wlm, sourceInstanceProps.Name, cfg.GetMaxRetries(), interval)
+			if err := sendRequestToWLM(ctx, wlm, sourceInstanceProps.Name, secondaryWLMLocation(cfg), cfg.GetMaxRetries(), interval); err == nil {
+				markCheckpointedTarget(cp, checkpointTarget(i, credentialCfg))
+				recordSentDetails(state, checkpointTarget(i, credentialCfg), sent, full)
+			}
+		}
+		if err := errors.Join(collectErr, persistErr); err != nil {
+			errs = append(errs, fmt.Errorf("target %s: %w", target, err))
 		}
 	}
 	log.Logger.Info("SQL rules collection ends.")
-	return nil
+	return internal.NewTargetErrors(targets, errs)
+}
+
+// collectInstanceSQL collects the sql details of the instance of sqlCfg on the target of
+// credentialCfg.
+func collectInstanceSQL(ctx context.Context, cfg *configpb.Configuration, credentialCfg *configpb.CredentialConfiguration, sqlCfg *configuration.SQLConfig, ip InstanceProperties) ([]internal.Details, error) {
+	guestCfg := guestConfigFromCredential(credentialCfg)
+	if err := validateCredCfgSQL(cfg.GetRemoteCollection(), !guestCfg.LinuxRemote, sqlCfg, guestCfg, credentialCfg.GetInstanceId(), credentialCfg.GetInstanceName()); err != nil {
+		UsageMetricsLogger.Error(agentstatus.InvalidConfigurationsError)
+		return nil, fmt.Errorf("invalid credential configuration: %w", err)
+	}
+	pswd, err := sqlPassword(ctx, sqlCfg, ip.ProjectID)
+	if err != nil {
+		UsageMetricsLogger.Error(agentstatus.SecretValueError)
+		return nil, fmt.Errorf("failed to get secret value: %w", err)
+	}
+	conn := sqlConnectionString(sqlCfg, pswd)
+	details, err := runSQLCollection(ctx, cfg, sqlTarget(sqlCfg), conn, sqlAccessTokenProvider(sqlCfg), !guestCfg.LinuxRemote)
+	if err != nil {
+		UsageMetricsLogger.Error(agentstatus.SQLCollectionFailure)
+		return nil, fmt.Errorf("failed to run sql collection: %w", err)
+	}
+
+	details = addEditionLimits(details)
+	details = addPatchLevel(details)
+	addHostFields(details, sqlHostName(sqlCfg), sqlCfg.PortNumber)
+	addCollectionSource(details, cfg.GetRemoteCollection(), guestCfg.LinuxRemote)
+
+	// getting physical drive if on local windows collecting sql on linux remote
+	collectPhysicalDrive := collectPhysicalDriveMapping(cfg)
+	if collectPhysicalDrive && cfg.GetRemoteCollection() && guestCfg.LinuxRemote {
+		addPhysicalDriveRemoteLinux(details, guestCfg)
+	} else {
+		addPhysicalDriveLocal(ctx, details, true, collectPhysicalDrive)
+	}
+	if collectPhysicalDrive && !cfg.GetRemoteCollection() {
+		addDataVolumeAllocationUnits(details)
+		addDataVolumeCompression(details)
+		addTempdbOnLocalSSD(details)
+	}
+	return details, nil
+}
+
+// addDataVolumeAllocationUnits correlates the allocation units of the local volumes with the
+// physical drives of the database files in details.
+func addDataVolumeAllocationUnits(details []internal.Details) {
+	allocationUnits, err := guestcollector.NewWindowsCollector(nil, nil, nil, UsageMetricsLogger).DataDiskAllocationUnits()
+	if err != nil {
+		log.Logger.Errorw("Failed to get the data disk allocation units", "error", err)
+		UsageMetricsLogger.Error(agentstatus.WMIQueryExecutionError)
+	}
+	internal.AddDataVolumeAllocationUnits(details, allocationUnits)
+}
+
+// addDataVolumeCompression correlates the NTFS compression and data deduplication status of the
+// local volumes with the physical drives of the database files in details.
+func addDataVolumeCompression(details []internal.Details) {
+	volumes, err := guestcollector.NewWindowsCollector(nil, nil, nil, UsageMetricsLogger).DataVolumeCompression()
+	if err != nil {
+		log.Logger.Errorw("Failed to get the data volume compression", "error", err)
+		UsageMetricsLogger.Error(agentstatus.WMIQueryExecutionError)
+	}
+	internal.AddDataVolumeCompression(details, volumes)
+}
+
+// addTempdbOnLocalSSD verifies the tempdb data files reside on the local SSDs of the local machine.
+func addTempdbOnLocalSSD(details []internal.Details) {
+	diskTypes, err := guestcollector.NewWindowsCollector(nil, nil, nil, UsageMetricsLogger).LogicalDiskTypes()
+	if err != nil {
+		log.Logger.Errorw("Failed to get the disk types of the logical disks", "error", err)
+		UsageMetricsLogger.Error(agentstatus.WMIQueryExecutionError)
+	}
+	internal.AddTempdbOnLocalSSD(details, diskTypes)
 }
@@ -0,0 +1,105 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/workloadagentplatform/integration/common/shared/log"
+)
+
+// partitionPattern matches the partitions of the linux disks, e.g. sda1 or nvme0n1p1, and
+// captures their disk.
+var partitionPattern = regexp.MustCompile(`^(sd[a-z]+)[0-9]+$|^(nvme[0-9]+n[0-9]+)p[0-9]+$`)
+
+// AddTempdbOnLocalSSD verifies the tempdb data files in DB_LOG_DISK_SEPARATION reside on local
+// SSDs, and adds the result as the "tempdb_on_local_ssd" field of INSTANCE_METRICS. diskTypes is
+// the value of the local_ssd os field, which maps the disks of the machine to their disk type.
+// The result is false if any tempdb data file is on a disk other than a local SSD, including
+// machines without local SSDs, and unknown if the disk of a tempdb data file is not found.
+func AddTempdbOnLocalSSD(details []Details, diskTypes string) {
+	res := tempdbOnLocalSSD(details, parseDiskTypes(diskTypes))
+	for _, detail := range details {
+		if detail.Name != "INSTANCE_METRICS" {
+			continue
+		}
+		for _, field := range detail.Fields {
+			field["tempdb_on_local_ssd"] = res
+		}
+	}
+}
+
+// parseDiskTypes parses the value of the local_ssd os field into a map of disk to disk type.
+func parseDiskTypes(diskTypes string) map[string]string {
+	res := map[string]string{}
+	if diskTypes == "" || diskTypes == "unknown" {
+		return res
+	}
+	var types map[string]string
+	if err := json.Unmarshal([]byte(diskTypes), &types); err != nil {
+		log.Logger.Warnw("Failed to parse the disk types", "error", err)
+		return res
+	}
+	for disk, diskType := range types {
+		res[diskKey(disk)] = diskType
+	}
+	return res
+}
+
+// tempdbOnLocalSSD joins the physical drives of the tempdb data files with diskTypes.
+func tempdbOnLocalSSD(details []Details, diskTypes map[string]string) string {
+	found, unknown := false, false
+	for _, detail := range details {
+		if detail.Name != "DB_LOG_DISK_SEPARATION" {
+			continue
+		}
+		for _, field := range detail.Fields {
+			// filetype 0 is a data file.
+			if field["db_name"] != "tempdb" || field["filetype"] != "0" {
+				continue
+			}
+			found = true
+			for _, drive := range strings.Split(field["physical_drive"], ",") {
+				diskType, ok := diskTypes[diskKey(drive)]
+				if !ok {
+					unknown = true
+					continue
+				}
+				if diskType != "LOCAL-SSD" {
+					return "false"
+				}
+			}
+		}
+	}
+	if !found || unknown {
+		return "unknown"
+	}
+	return "true"
+}
+
+// diskKey returns the key of a disk or physical drive to join them with. Windows drives are
+// reported as "D" by physical_drive and as "D:" by local_ssd, and linux physical drives are
+// partitions of the disks reported by local_ssd.
+func diskKey(drive string) string {
+	drive = strings.TrimSuffix(strings.TrimSpace(drive), ":")
+	if m := partitionPattern.FindStringSubmatch(drive); m != nil {
+		drive = m[1] + m[2]
+	}
+	return strings.ToUpper(drive)
+}
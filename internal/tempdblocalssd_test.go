@@ -0,0 +1,131 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// tempdbDetails returns the details of an instance with tempdb data files on drives.
+func tempdbDetails(drives ...string) []Details {
+	files := []map[string]string{
+		{"db_name": "db1", "filetype": "0", "physical_drive": "E"},
+		{"db_name": "tempdb", "filetype": "1", "physical_drive": "L"},
+	}
+	for _, drive := range drives {
+		files = append(files, map[string]string{"db_name": "tempdb", "filetype": "0", "physical_drive": drive})
+	}
+	return []Details{
+		{Name: "DB_LOG_DISK_SEPARATION", Fields: files},
+		{Name: "INSTANCE_METRICS", Fields: []map[string]string{{"edition": "Enterprise"}}},
+	}
+}
+
+func TestAddTempdbOnLocalSSD(t *testing.T) {
+	testcases := []struct {
+		name      string
+		details   []Details
+		diskTypes string
+		want      string
+	}{
+		{
+			name:      "windows tempdb on local ssd",
+			details:   tempdbDetails("D", "d"),
+			diskTypes: `{"C:":"PERSISTENT-SSD","D:":"LOCAL-SSD","E:":"PERSISTENT-SSD","L:":"PERSISTENT-SSD"}`,
+			want:      "true",
+		},
+		{
+			name:      "windows tempdb file on persistent disk",
+			details:   tempdbDetails("D", "C"),
+			diskTypes: `{"C:":"PERSISTENT-SSD","D:":"LOCAL-SSD"}`,
+			want:      "false",
+		},
+		{
+			name:      "machine without local ssd",
+			details:   tempdbDetails("C"),
+			diskTypes: `{"C:":"PERSISTENT-SSD"}`,
+			want:      "false",
+		},
+		{
+			name:      "linux partitions of local ssds",
+			details:   tempdbDetails("nvme0n1p1", "sdb1, sdc"),
+			diskTypes: `{"nvme0n1":"LOCAL-SSD","sdb":"LOCAL-SSD","sdc":"LOCAL-SSD","sda":"PERSISTENT-SSD"}`,
+			want:      "true",
+		},
+		{
+			name:      "linux tempdb spanning a persistent disk",
+			details:   tempdbDetails("sdb1, sda1"),
+			diskTypes: `{"sdb":"LOCAL-SSD","sda":"PERSISTENT-SSD"}`,
+			want:      "false",
+		},
+		{
+			name:      "unknown physical drive",
+			details:   tempdbDetails("D", "unknown"),
+			diskTypes: `{"D:":"LOCAL-SSD"}`,
+			want:      "unknown",
+		},
+		{
+			name:      "unknown disk types",
+			details:   tempdbDetails("D"),
+			diskTypes: "unknown",
+			want:      "unknown",
+		},
+		{
+			name:      "invalid disk types",
+			details:   tempdbDetails("D"),
+			diskTypes: "{",
+			want:      "unknown",
+		},
+		{
+			name:      "no tempdb data file",
+			details:   tempdbDetails(),
+			diskTypes: `{"D:":"LOCAL-SSD"}`,
+			want:      "unknown",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			AddTempdbOnLocalSSD(tc.details, tc.diskTypes)
+			want := []map[string]string{{"edition": "Enterprise", "tempdb_on_local_ssd": tc.want}}
+			if diff := cmp.Diff(want, tc.details[1].Fields); diff != "" {
+				t.Errorf("AddTempdbOnLocalSSD() returned diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDiskKey(t *testing.T) {
+	testcases := []struct {
+		drive string
+		want  string
+	}{
+		{drive: "D:", want: "D"},
+		{drive: "d", want: "D"},
+		{drive: " sdb1", want: "SDB"},
+		{drive: "sdab12", want: "SDAB"},
+		{drive: "sdb", want: "SDB"},
+		{drive: "nvme0n1p2", want: "NVME0N1"},
+		{drive: "nvme0n1", want: "NVME0N1"},
+	}
+	for _, tc := range testcases {
+		if got := diskKey(tc.drive); got != tc.want {
+			t.Errorf("diskKey(%q) = %q, want %q", tc.drive, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestSetupDisabled(t *testing.T) {
+	shutdown, err := Setup(context.Background(), "")
+	if err != nil {
+		t.Fatalf("Setup(%q) returned an unexpected error: %v", "", err)
+	}
+	defer shutdown(context.Background())
+
+	_, span := Start(context.Background(), "phase")
+	defer span.End()
+	if span.IsRecording() {
+		t.Errorf("Start() returned a recording span, want no-op span when tracing is not set up")
+	}
+}
+
+func TestSetup(t *testing.T) {
+	defer func(tp trace.TracerProvider) { otel.SetTracerProvider(tp) }(otel.GetTracerProvider())
+	shutdown, err := Setup(context.Background(), "http://localhost:4318")
+	if err != nil {
+		t.Fatalf("Setup() returned an unexpected error: %v", err)
+	}
+	_, span := Start(context.Background(), "phase")
+	if !span.IsRecording() {
+		t.Errorf("Start() returned a no-op span, want recording span when tracing is set up")
+	}
+	span.End()
+	// Shutdown fails to flush the span without a collector listening, which is not tested here.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	shutdown(ctx)
+}
+
+func TestPhases(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	defer func(tp trace.TracerProvider) { otel.SetTracerProvider(tp) }(otel.GetTracerProvider())
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+
+	ctx, cycle := Start(context.Background(), "runSQLCollection")
+	_, secret := Start(ctx, "secretValue")
+	End(secret, errors.New("secret not found"))
+	_, send := Start(ctx, "sendRequestToWLM")
+	End(send, nil)
+	End(cycle, nil)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 3 {
+		t.Fatalf("got %d spans, want 3", len(spans))
+	}
+	got := map[string]tracetest.SpanStub{}
+	for _, s := range spans {
+		got[s.Name] = s
+	}
+	tests := []struct {
+		name       string
+		wantParent string
+		wantStatus codes.Code
+	}{
+		{name: "runSQLCollection", wantStatus: codes.Unset},
+		{name: "secretValue", wantParent: "runSQLCollection", wantStatus: codes.Error},
+		{name: "sendRequestToWLM", wantParent: "runSQLCollection", wantStatus: codes.Unset},
+	}
+	for _, tc := range tests {
+		s, ok := got[tc.name]
+		if !ok {
+			t.Errorf("span %q was not created", tc.name)
+			continue
+		}
+		if s.Status.Code != tc.wantStatus {
+			t.Errorf("span %q has status %v, want %v", tc.name, s.Status.Code, tc.wantStatus)
+		}
+		wantParent := trace.SpanID{}
+		if tc.wantParent != "" {
+			wantParent = got[tc.wantParent].SpanContext.SpanID()
+		}
+		if s.Parent.SpanID() != wantParent {
+			t.Errorf("span %q has parent %v, want %v", tc.name, s.Parent.SpanID(), wantParent)
+		}
+	}
+	if len(got["secretValue"].Events) != 1 {
+		t.Errorf("span %q has %d events, want the recorded error", "secretValue", len(got["secretValue"].Events))
+	}
+}
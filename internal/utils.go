@@ -19,14 +19,23 @@ package internal
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/jonboulle/clockwork"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 	"github.com/GoogleCloudPlatform/workloadagentplatform/integration/common/shared/commandlineexecutor"
 	"github.com/GoogleCloudPlatform/workloadagentplatform/integration/common/shared/log"
 )
@@ -140,6 +149,149 @@ func PrettyStruct(data any) (string, error) {
 	return string(val), nil
 }
 
+// SetMinTLSVersion sets the minimum TLS version of connections made by the given transport.
+func SetMinTLSVersion(t *http.Transport, version uint16) {
+	if t.TLSClientConfig == nil {
+		t.TLSClientConfig = &tls.Config{}
+	}
+	t.TLSClientConfig.MinVersion = version
+}
+
+// SetClientCertificate sets the certificate presented by the given transport to servers requesting a client certificate.
+func SetClientCertificate(t *http.Transport, cert tls.Certificate) {
+	if t.TLSClientConfig == nil {
+		t.TLSClientConfig = &tls.Config{}
+	}
+	t.TLSClientConfig.Certificates = []tls.Certificate{cert}
+}
+
+// WithLevel returns a logger writing the entries of logger enabled by level.
+// Changes of a zap.AtomicLevel apply to the returned logger while it is in use.
+func WithLevel(logger *zap.SugaredLogger, level zapcore.LevelEnabler) *zap.SugaredLogger {
+	return logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return &levelCore{Core: core, level: level}
+	}))
+}
+
+// levelCore is a zapcore.Core filtering the entries of the wrapped core by level.
+type levelCore struct {
+	zapcore.Core
+	level zapcore.LevelEnabler
+}
+
+// Enabled reports whether both the level and the wrapped core enable lvl.
+func (c *levelCore) Enabled(lvl zapcore.Level) bool {
+	return c.level.Enabled(lvl) && c.Core.Enabled(lvl)
+}
+
+// With adds fields to the wrapped core.
+func (c *levelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelCore{Core: c.Core.With(fields), level: c.level}
+}
+
+// Check passes the entry to the wrapped core if the level enables it.
+func (c *levelCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.level.Enabled(entry.Level) {
+		return ce
+	}
+	return c.Core.Check(entry, ce)
+}
+
+// WriteDetailsJSON writes the given details to w as a JSON array.
+func WriteDetailsJSON(w io.Writer, details []Details) error {
+	if details == nil {
+		details = []Details{}
+	}
+	val, err := PrettyStruct(details)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, val)
+	return err
+}
+
+// AddHostFields stamps the host name and port number into every field of the given details, and
+// keys their collection durations by the instance, so the durations of the instances of a target
+// are kept apart when their details are merged.
+func AddHostFields(details []Details, hostName string, portNumber int32) {
+	for i, detail := range details {
+		for _, field := range detail.Fields {
+			field["host_name"] = hostName
+			field["port_number"] = fmt.Sprintf("%d", portNumber)
+		}
+		if detail.CollectionDurationMs == nil {
+			continue
+		}
+		durations := map[string]int64{}
+		for rule, duration := range detail.CollectionDurationMs {
+			durations[fmt.Sprintf("%s:%d/%s", hostName, portNumber, rule)] = duration
+		}
+		details[i].CollectionDurationMs = durations
+	}
+}
+
+// MergeDetails prepends the fields of the details in previous to the fields of the details of the
+// same rule in details, e.g. to report the sql instances of a target together, and returns details.
+// The collection durations of the merged details are combined.
+func MergeDetails(previous, details []Details) []Details {
+	for i, detail := range details {
+		for _, p := range previous {
+			if detail.Name == p.Name {
+				detail.Fields = append(p.Fields, detail.Fields...)
+				detail.CollectionDurationMs = mergeDurations(p.CollectionDurationMs, detail.CollectionDurationMs)
+				details[i] = detail
+				break
+			}
+		}
+	}
+	return details
+}
+
+// mergeDurations returns the collection durations of previous and durations combined.
+func mergeDurations(previous, durations map[string]int64) map[string]int64 {
+	if previous == nil {
+		return durations
+	}
+	merged := map[string]int64{}
+	for rule, duration := range previous {
+		merged[rule] = duration
+	}
+	for rule, duration := range durations {
+		merged[rule] = duration
+	}
+	return merged
+}
+
+// Collection sources stamped into the collected details.
+const (
+	// CollectionSourceLocal means the agent collected the data of the machine it runs on.
+	CollectionSourceLocal = "local"
+	// CollectionSourceRemoteWindows means the agent collected the data of a remote windows machine.
+	CollectionSourceRemoteWindows = "remote_windows"
+	// CollectionSourceRemoteLinux means the agent collected the data of a remote linux machine.
+	CollectionSourceRemoteLinux = "remote_linux"
+)
+
+// CollectionSource returns the collection source of a target collected remotely or locally.
+func CollectionSource(remote, linuxRemote bool) string {
+	switch {
+	case !remote:
+		return CollectionSourceLocal
+	case linuxRemote:
+		return CollectionSourceRemoteLinux
+	}
+	return CollectionSourceRemoteWindows
+}
+
+// AddCollectionSource stamps the collection source into every field of the given details.
+func AddCollectionSource(details []Details, source string) {
+	for _, detail := range details {
+		for _, field := range detail.Fields {
+			field["collection_source"] = source
+		}
+	}
+}
+
 // CommandLineExecutorWrapper executes a windows or linux command with arguments given
 func CommandLineExecutorWrapper(ctx context.Context, executable string, argsToSplit string, exec commandlineexecutor.Execute) (string, error) {
 	result := exec(ctx, commandlineexecutor.Params{
@@ -152,6 +304,28 @@ func CommandLineExecutorWrapper(ctx context.Context, executable string, argsToSp
 	return strings.TrimSuffix(result.StdOut, "\n"), nil
 }
 
+// AddPhysicalDrive maps the physical_name of each DB_LOG_DISK_SEPARATION field to the physical drive
+// it is stored on. When collect is false the mapping is skipped and physical_drive is set to unknown.
+func AddPhysicalDrive(ctx context.Context, details []Details, windows, collect bool, exec commandlineexecutor.Execute) {
+	for _, detail := range details {
+		if detail.Name != "DB_LOG_DISK_SEPARATION" {
+			continue
+		}
+		for _, field := range detail.Fields {
+			if !collect {
+				field["physical_drive"] = "unknown"
+				continue
+			}
+			physicalPath, pathExists := field["physical_name"]
+			if !pathExists {
+				log.Logger.Warn("physical_name field for DB_LOG_DISK_SEPARATION does not exist")
+				continue
+			}
+			field["physical_drive"] = GetPhysicalDriveFromPath(ctx, physicalPath, windows, exec)
+		}
+	}
+}
+
 // GetPhysicalDriveFromPath gets the physical drive associated with a file path for linux and windows env
 func GetPhysicalDriveFromPath(ctx context.Context, path string, windows bool, exec commandlineexecutor.Execute) string {
 
@@ -166,18 +340,22 @@ func GetPhysicalDriveFromPath(ctx context.Context, path string, windows bool, ex
 		return mapping[0]
 	}
 
+	// The path is passed to find and df as arguments rather than through a shell, so paths with
+	// spaces or quotes are not split or interpreted.
 	dir, filename := filepath.Split(path)
-	filePath, filePathErr := CommandLineExecutorWrapper(ctx, "/bin/sh", fmt.Sprintf(" -c 'find %s -type f -iname \"%s\" -print'", dir, filename), exec)
+	filePath, filePathErr := commandLineExecutorArgs(ctx, "find", []string{dir, "-type", "f", "-iname", EscapeGlob(filename), "-print"}, exec)
 	if filePathErr != nil {
 		log.Logger.Warn(filePathErr)
 		return "unknown"
 	}
+	filePath = firstLine(filePath)
 
-	physicalPathMount, physicalPathErr := CommandLineExecutorWrapper(ctx, "/bin/sh", fmt.Sprintf(" -c 'df --output=target %s| tail -n 1'", filePath), exec)
+	physicalPathMount, physicalPathErr := commandLineExecutorArgs(ctx, "df", []string{"--output=target", filePath}, exec)
 	if physicalPathErr != nil {
 		log.Logger.Warn(physicalPathErr)
 		return "unknown"
 	}
+	physicalPathMount = lastLine(physicalPathMount)
 
 	resultMount, mountErr := CommandLineExecutorWrapper(ctx, "/bin/sh", fmt.Sprintf(" -c ' mount |grep sd'"), exec)
 	if mountErr != nil {
@@ -186,7 +364,7 @@ func GetPhysicalDriveFromPath(ctx context.Context, path string, windows bool, ex
 	}
 
 	allMounts := strings.TrimSuffix(resultMount, "\n")
-	physicalDriveHelper := regexp.MustCompile(` `+physicalPathMount+` `).Split(allMounts, -1)
+	physicalDriveHelper := regexp.MustCompile(` `+regexp.QuoteMeta(physicalPathMount)+` `).Split(allMounts, -1)
 
 	physicalDrives := []string{}
 	for i := 0; i < len(physicalDriveHelper)-1; i++ {
@@ -201,6 +379,35 @@ func GetPhysicalDriveFromPath(ctx context.Context, path string, windows bool, ex
 	return physicalDrive
 }
 
+// commandLineExecutorArgs executes a command with the given arguments without splitting them.
+func commandLineExecutorArgs(ctx context.Context, executable string, args []string, exec commandlineexecutor.Execute) (string, error) {
+	result := exec(ctx, commandlineexecutor.Params{
+		Executable: executable,
+		Args:       args,
+	})
+	if result.Error != nil {
+		return "", fmt.Errorf("Error when running CommandLineExecutor: %s", result.StdErr)
+	}
+	return strings.TrimSuffix(result.StdOut, "\n"), nil
+}
+
+// EscapeGlob escapes the characters of s which find -iname treats as a pattern.
+func EscapeGlob(s string) string {
+	return regexp.MustCompile(`([\\*?\[\]])`).ReplaceAllString(s, `\$1`)
+}
+
+// firstLine returns the first line of s.
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
+
+// lastLine returns the last line of s.
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	return lines[len(lines)-1]
+}
+
 // integerToString converts any valid integer type to a string representation.
 func integerToString(num any) (string, error) {
 	switch v := num.(type) {
@@ -230,3 +437,95 @@ func integerToString(num any) (string, error) {
 		return "", fmt.Errorf("unsupported number type: %T", num)
 	}
 }
+
+// RunCollectionService runs the cycles of a collection service, waiting for startupDelay before the
+// first cycle and for the duration returned by each cycle before starting the next one.
+// If once is set, it returns after the first cycle.
+func RunCollectionService(clock clockwork.Clock, startupDelay time.Duration, once bool, cycle func() time.Duration) {
+	if startupDelay > 0 {
+		clock.Sleep(startupDelay)
+	}
+	for {
+		wait := cycle()
+		if once {
+			return
+		}
+		clock.Sleep(wait)
+	}
+}
+
+// Jitter returns a random duration in [0, max] drawn from r, which offsets the start of the
+// collections so a fleet of agents does not send to workloadmanager at the same time.
+// Returns 0 if max is not positive.
+func Jitter(r *rand.Rand, max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	return time.Duration(r.Int63n(int64(max) + 1))
+}
+
+// Exit codes of onetime collections.
+const (
+	// ExitSuccess means all collections completed.
+	ExitSuccess = 0
+	// ExitPartialFailure means some of the collections failed.
+	ExitPartialFailure = 1
+	// ExitTotalFailure means all collections failed.
+	ExitTotalFailure = 2
+	// ExitConfigError means the configuration is invalid and no collection ran.
+	ExitConfigError = 3
+)
+
+// TargetErrors is the error of a collection whose targets failed. The collection of the other
+// targets completed.
+type TargetErrors struct {
+	// Targets is the number of targets the collection ran on.
+	Targets int
+	// Errs are the errors of the failed targets, one per target.
+	Errs []error
+}
+
+// NewTargetErrors returns the error of a collection of targets with the errors of its failed
+// targets. Returns nil if no target failed.
+func NewTargetErrors(targets int, errs []error) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return &TargetErrors{Targets: targets, Errs: errs}
+}
+
+func (e *TargetErrors) Error() string {
+	return fmt.Sprintf("%d of %d targets failed: %v", len(e.Errs), e.Targets, errors.Join(e.Errs...))
+}
+
+// Unwrap returns the errors of the failed targets.
+func (e *TargetErrors) Unwrap() []error {
+	return e.Errs
+}
+
+// OnetimeExitCode returns the exit code of a onetime collection from the errors returned by its
+// collections. The failed targets of a *TargetErrors are counted against all the targets of its
+// collection, any other error fails its whole collection.
+func OnetimeExitCode(errs ...error) int {
+	total, failed := 0, 0
+	for _, err := range errs {
+		var targetErrs *TargetErrors
+		switch {
+		case err == nil:
+			total++
+		case errors.As(err, &targetErrs):
+			total += targetErrs.Targets
+			failed += len(targetErrs.Errs)
+		default:
+			total++
+			failed++
+		}
+	}
+	switch {
+	case failed == 0:
+		return ExitSuccess
+	case failed < total:
+		return ExitPartialFailure
+	}
+	return ExitTotalFailure
+}
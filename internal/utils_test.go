@@ -17,12 +17,23 @@ limitations under the License.
 package internal
 
 import (
+	"bytes"
 	"context"
+	"crypto/tls"
+	"encoding/json"
 	"errors"
+	"math/rand"
+	"net/http"
 	"path"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/google/go-cmp/cmp"
+	"github.com/jonboulle/clockwork"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
 	"github.com/GoogleCloudPlatform/workloadagentplatform/integration/common/shared/commandlineexecutor"
 )
 
@@ -249,6 +260,264 @@ func TestPrettyStruct(t *testing.T) {
 	}
 }
 
+func TestSetMinTLSVersion(t *testing.T) {
+	tests := []struct {
+		name      string
+		transport *http.Transport
+		version   uint16
+	}{
+		{
+			name:      "transport without tls config",
+			transport: &http.Transport{},
+			version:   tls.VersionTLS12,
+		},
+		{
+			name:      "transport with tls config",
+			transport: &http.Transport{TLSClientConfig: &tls.Config{ServerName: "test"}},
+			version:   tls.VersionTLS13,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			SetMinTLSVersion(tc.transport, tc.version)
+			if got := tc.transport.TLSClientConfig.MinVersion; got != tc.version {
+				t.Errorf("SetMinTLSVersion(%v) set MinVersion = %v, want %v", tc.version, got, tc.version)
+			}
+			// Transports of the API clients are cloned from the configured transport.
+			if got := tc.transport.Clone().TLSClientConfig.MinVersion; got != tc.version {
+				t.Errorf("SetMinTLSVersion(%v) cloned transport MinVersion = %v, want %v", tc.version, got, tc.version)
+			}
+		})
+	}
+}
+
+func TestSetClientCertificate(t *testing.T) {
+	cert := tls.Certificate{Certificate: [][]byte{[]byte("test-cert")}}
+	tests := []struct {
+		name      string
+		transport *http.Transport
+	}{
+		{
+			name:      "transport without tls config",
+			transport: &http.Transport{},
+		},
+		{
+			name:      "transport with tls config",
+			transport: &http.Transport{TLSClientConfig: &tls.Config{MinVersion: tls.VersionTLS13}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			SetClientCertificate(tc.transport, cert)
+			want := []tls.Certificate{cert}
+			if diff := cmp.Diff(want, tc.transport.TLSClientConfig.Certificates); diff != "" {
+				t.Errorf("SetClientCertificate() returned diff (-want +got):\n%s", diff)
+			}
+			// Transports of the API clients are cloned from the configured transport.
+			if diff := cmp.Diff(want, tc.transport.Clone().TLSClientConfig.Certificates); diff != "" {
+				t.Errorf("SetClientCertificate() cloned transport returned diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestWithLevel(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	level := zap.NewAtomicLevelAt(zapcore.InfoLevel)
+	logger := WithLevel(zap.New(core).Sugar(), level).With("key", "value")
+
+	logger.Debug("debug before the change")
+	logger.Info("info before the change")
+	level.SetLevel(zapcore.DebugLevel)
+	logger.Debug("debug after the change")
+
+	var got []string
+	for _, entry := range logs.All() {
+		got = append(got, entry.Message)
+	}
+	want := []string{"info before the change", "debug after the change"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("WithLevel() logged diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestWriteDetailsJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		details []Details
+		want    []Details
+	}{
+		{
+			name: "details",
+			details: []Details{
+				{
+					Name:                 "OS",
+					Fields:               []map[string]string{{"power_profile_setting": "High performance"}},
+					CollectionDurationMs: map[string]int64{"power_profile_setting": 10},
+				},
+				{
+					Name:   "DB_LOG_DISK_SEPARATION",
+					Fields: []map[string]string{{"db_name": "test"}, {"db_name": "test2"}},
+				},
+			},
+			want: []Details{
+				{
+					Name:                 "OS",
+					Fields:               []map[string]string{{"power_profile_setting": "High performance"}},
+					CollectionDurationMs: map[string]int64{"power_profile_setting": 10},
+				},
+				{
+					Name:   "DB_LOG_DISK_SEPARATION",
+					Fields: []map[string]string{{"db_name": "test"}, {"db_name": "test2"}},
+				},
+			},
+		},
+		{
+			name: "no details",
+			want: []Details{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WriteDetailsJSON(&buf, tc.details); err != nil {
+				t.Fatalf("WriteDetailsJSON() returned an unexpected error: %v", err)
+			}
+			if !json.Valid(buf.Bytes()) {
+				t.Fatalf("WriteDetailsJSON() wrote invalid JSON: %s", buf.String())
+			}
+			var got []Details
+			if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+				t.Fatalf("json.Unmarshal(%s) returned an unexpected error: %v", buf.String(), err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("WriteDetailsJSON() returned an unexpected diff (-want +got): %v", diff)
+			}
+		})
+	}
+}
+
+func TestAddHostFields(t *testing.T) {
+	details := []Details{
+		{
+			Name:   "rule1",
+			Fields: []map[string]string{{"key": "value"}, {}},
+		},
+		{
+			Name:                 "rule2",
+			CollectionDurationMs: map[string]int64{"rule2": 42},
+		},
+	}
+	want := []Details{
+		{
+			Name: "rule1",
+			Fields: []map[string]string{
+				{"key": "value", "host_name": "test-fci-vnn", "port_number": "1433"},
+				{"host_name": "test-fci-vnn", "port_number": "1433"},
+			},
+		},
+		{
+			Name:                 "rule2",
+			CollectionDurationMs: map[string]int64{"test-fci-vnn:1433/rule2": 42},
+		},
+	}
+
+	AddHostFields(details, "test-fci-vnn", 1433)
+	if diff := cmp.Diff(want, details); diff != "" {
+		t.Errorf("AddHostFields() returned an unexpected diff (-want +got): %v", diff)
+	}
+}
+
+func TestMergeDetails(t *testing.T) {
+	previous := []Details{
+		{Name: "rule1", Fields: []map[string]string{{"host_name": "instance1"}}},
+		{Name: "rule3", Fields: []map[string]string{{"host_name": "instance1"}}},
+	}
+	details := []Details{
+		{Name: "rule1", Fields: []map[string]string{{"host_name": "instance2"}}},
+		{Name: "rule2", Fields: []map[string]string{{"host_name": "instance2"}}},
+	}
+	want := []Details{
+		{Name: "rule1", Fields: []map[string]string{{"host_name": "instance1"}, {"host_name": "instance2"}}},
+		{Name: "rule2", Fields: []map[string]string{{"host_name": "instance2"}}},
+	}
+
+	got := MergeDetails(previous, details)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("MergeDetails() returned an unexpected diff (-want +got): %v", diff)
+	}
+}
+
+func TestMergeDetailsCollectionDurations(t *testing.T) {
+	var merged []Details
+	for i, host := range []string{"instance1", "instance2"} {
+		details := []Details{{
+			Name:                 "rule1",
+			Fields:               []map[string]string{{}},
+			CollectionDurationMs: map[string]int64{"rule1": int64(10 * (i + 1))},
+		}}
+		AddHostFields(details, host, 1433)
+		merged = MergeDetails(merged, details)
+	}
+	want := map[string]int64{"instance1:1433/rule1": 10, "instance2:1433/rule1": 20}
+	if diff := cmp.Diff(want, merged[0].CollectionDurationMs); diff != "" {
+		t.Errorf("MergeDetails() returned an unexpected collection duration diff (-want +got): %v", diff)
+	}
+}
+
+func TestCollectionSource(t *testing.T) {
+	tests := []struct {
+		name        string
+		remote      bool
+		linuxRemote bool
+		want        string
+	}{
+		{name: "local", want: CollectionSourceLocal},
+		{name: "local ignores linuxRemote", linuxRemote: true, want: CollectionSourceLocal},
+		{name: "remote windows", remote: true, want: CollectionSourceRemoteWindows},
+		{name: "remote linux", remote: true, linuxRemote: true, want: CollectionSourceRemoteLinux},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := CollectionSource(tc.remote, tc.linuxRemote); got != tc.want {
+				t.Errorf("CollectionSource(%v, %v) = %q, want %q", tc.remote, tc.linuxRemote, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestAddCollectionSource(t *testing.T) {
+	details := []Details{
+		{
+			Name:   "rule1",
+			Fields: []map[string]string{{"key": "value"}, {}},
+		},
+		{
+			Name: "rule2",
+		},
+	}
+	want := []Details{
+		{
+			Name: "rule1",
+			Fields: []map[string]string{
+				{"key": "value", "collection_source": "remote_linux"},
+				{"collection_source": "remote_linux"},
+			},
+		},
+		{
+			Name: "rule2",
+		},
+	}
+
+	AddCollectionSource(details, CollectionSourceRemoteLinux)
+	if diff := cmp.Diff(want, details); diff != "" {
+		t.Errorf("AddCollectionSource() returned an unexpected diff (-want +got): %v", diff)
+	}
+}
+
 func TestCommandLineExecutorWrapper(t *testing.T) {
 	tests := []struct {
 		executable  string
@@ -332,7 +601,7 @@ func TestGetPhysicalDriveFromPath(t *testing.T) {
 			path:    "test happy path linux",
 			windows: false,
 			exec: func(ctx context.Context, params commandlineexecutor.Params) commandlineexecutor.Result {
-				if strings.Contains(params.ArgsToSplit, "df") {
+				if params.Executable == "df" {
 					return commandlineexecutor.Result{StdOut: "/"}
 				} else if strings.Contains(params.ArgsToSplit, "mount") {
 					return commandlineexecutor.Result{StdOut: "/dev/sda1 on / type"}
@@ -345,7 +614,7 @@ func TestGetPhysicalDriveFromPath(t *testing.T) {
 			path:    "find file path failed",
 			windows: false,
 			exec: func(ctx context.Context, params commandlineexecutor.Params) commandlineexecutor.Result {
-				if strings.Contains(params.ArgsToSplit, "df") {
+				if params.Executable == "df" {
 					return commandlineexecutor.Result{Error: errors.New("")}
 				} else if strings.Contains(params.ArgsToSplit, "mount") {
 					return commandlineexecutor.Result{StdOut: "/dev/sda1 on / type"}
@@ -358,7 +627,7 @@ func TestGetPhysicalDriveFromPath(t *testing.T) {
 			path:    "find file path failed",
 			windows: false,
 			exec: func(ctx context.Context, params commandlineexecutor.Params) commandlineexecutor.Result {
-				if strings.Contains(params.ArgsToSplit, "df") {
+				if params.Executable == "df" {
 					return commandlineexecutor.Result{StdOut: "/"}
 				} else if strings.Contains(params.ArgsToSplit, "mount") {
 					return commandlineexecutor.Result{Error: errors.New("")}
@@ -371,7 +640,7 @@ func TestGetPhysicalDriveFromPath(t *testing.T) {
 			path:    "find file path failed",
 			windows: false,
 			exec: func(ctx context.Context, params commandlineexecutor.Params) commandlineexecutor.Result {
-				if strings.Contains(params.ArgsToSplit, "df") {
+				if params.Executable == "df" {
 					return commandlineexecutor.Result{StdOut: "/"}
 				} else if strings.Contains(params.ArgsToSplit, "mount") {
 					return commandlineexecutor.Result{StdOut: "/dev/sda1 on / type"}
@@ -392,6 +661,136 @@ func TestGetPhysicalDriveFromPath(t *testing.T) {
 	}
 }
 
+func TestGetPhysicalDriveFromPathSpecialCharacters(t *testing.T) {
+	tests := []struct {
+		name      string
+		path      string
+		wantFind  []string
+		foundPath string
+		wantDf    []string
+	}{
+		{
+			name:      "path with spaces",
+			path:      "/var/opt/mssql/my data/test db.mdf",
+			wantFind:  []string{"/var/opt/mssql/my data/", "-type", "f", "-iname", "test db.mdf", "-print"},
+			foundPath: "/var/opt/mssql/my data/test db.mdf",
+			wantDf:    []string{"--output=target", "/var/opt/mssql/my data/test db.mdf"},
+		},
+		{
+			name:      "path with quotes",
+			path:      `/var/opt/mssql/data/it's "prod".mdf`,
+			wantFind:  []string{"/var/opt/mssql/data/", "-type", "f", "-iname", `it's "prod".mdf`, "-print"},
+			foundPath: `/var/opt/mssql/data/it's "prod".mdf`,
+			wantDf:    []string{"--output=target", `/var/opt/mssql/data/it's "prod".mdf`},
+		},
+		{
+			name:      "path with glob characters",
+			path:      "/var/opt/mssql/data/db[1]*?.mdf",
+			wantFind:  []string{"/var/opt/mssql/data/", "-type", "f", "-iname", `db\[1\]\*\?.mdf`, "-print"},
+			foundPath: "/var/opt/mssql/data/db[1]*?.mdf",
+			wantDf:    []string{"--output=target", "/var/opt/mssql/data/db[1]*?.mdf"},
+		},
+		{
+			name:      "multiple files found uses the first one",
+			path:      "/var/opt/mssql/data/test.mdf",
+			wantFind:  []string{"/var/opt/mssql/data/", "-type", "f", "-iname", "test.mdf", "-print"},
+			foundPath: "/var/opt/mssql/data/test.mdf\n/var/opt/mssql/data/TEST.mdf",
+			wantDf:    []string{"--output=target", "/var/opt/mssql/data/test.mdf"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var gotFind, gotDf []string
+			exec := func(ctx context.Context, params commandlineexecutor.Params) commandlineexecutor.Result {
+				switch params.Executable {
+				case "find":
+					gotFind = params.Args
+					return commandlineexecutor.Result{StdOut: tc.foundPath + "\n"}
+				case "df":
+					gotDf = params.Args
+					return commandlineexecutor.Result{StdOut: "Mounted on\n/var/opt/mssql\n"}
+				}
+				return commandlineexecutor.Result{StdOut: "/dev/sda1 on / type ext4\n/dev/sdb1 on /var/opt/mssql type ext4\n"}
+			}
+			got := GetPhysicalDriveFromPath(context.Background(), tc.path, false, exec)
+			if diff := cmp.Diff(tc.wantFind, gotFind); diff != "" {
+				t.Errorf("GetPhysicalDriveFromPath(%q) ran find with diff (-want +got):\n%s", tc.path, diff)
+			}
+			if diff := cmp.Diff(tc.wantDf, gotDf); diff != "" {
+				t.Errorf("GetPhysicalDriveFromPath(%q) ran df with diff (-want +got):\n%s", tc.path, diff)
+			}
+			if got != "sdb1" {
+				t.Errorf("GetPhysicalDriveFromPath(%q) = %q, want %q", tc.path, got, "sdb1")
+			}
+		})
+	}
+}
+
+func TestAddPhysicalDrive(t *testing.T) {
+	tests := []struct {
+		name      string
+		collect   bool
+		wantCalls bool
+		want      []Details
+	}{
+		{
+			name:      "collect physical drive mapping",
+			collect:   true,
+			wantCalls: true,
+			want: []Details{
+				{
+					Name: "DB_LOG_DISK_SEPARATION",
+					Fields: []map[string]string{
+						{"physical_name": "/var/opt/mssql/data/test.mdf", "physical_drive": "sdb1"},
+					},
+				},
+			},
+		},
+		{
+			name:    "physical drive mapping disabled",
+			collect: false,
+			want: []Details{
+				{
+					Name: "DB_LOG_DISK_SEPARATION",
+					Fields: []map[string]string{
+						{"physical_name": "/var/opt/mssql/data/test.mdf", "physical_drive": "unknown"},
+					},
+				},
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			called := false
+			exec := func(ctx context.Context, params commandlineexecutor.Params) commandlineexecutor.Result {
+				called = true
+				switch params.Executable {
+				case "find":
+					return commandlineexecutor.Result{StdOut: "/var/opt/mssql/data/test.mdf\n"}
+				case "df":
+					return commandlineexecutor.Result{StdOut: "Mounted on\n/var/opt/mssql\n"}
+				}
+				return commandlineexecutor.Result{StdOut: "/dev/sda1 on / type ext4\n/dev/sdb1 on /var/opt/mssql type ext4\n"}
+			}
+			details := []Details{
+				{
+					Name: "DB_LOG_DISK_SEPARATION",
+					Fields: []map[string]string{
+						{"physical_name": "/var/opt/mssql/data/test.mdf", "physical_drive": "unknown"},
+					},
+				},
+			}
+			AddPhysicalDrive(context.Background(), details, false, tc.collect, exec)
+			if called != tc.wantCalls {
+				t.Errorf("AddPhysicalDrive(collect=%v) ran commands: %v, want: %v", tc.collect, called, tc.wantCalls)
+			}
+			if diff := cmp.Diff(tc.want, details); diff != "" {
+				t.Errorf("AddPhysicalDrive(collect=%v) returned diff (-want +got):\n%s", tc.collect, diff)
+			}
+		})
+	}
+}
+
 func TestIntegerToString(t *testing.T) {
 	tests := []struct {
 		num     any
@@ -422,3 +821,106 @@ func TestIntegerToString(t *testing.T) {
 		}
 	}
 }
+
+func TestRunCollectionServiceOnce(t *testing.T) {
+	cycles := 0
+	RunCollectionService(clockwork.NewFakeClock(), 0, true, func() time.Duration {
+		cycles++
+		return time.Hour
+	})
+	if cycles != 1 {
+		t.Errorf("RunCollectionService(once=true) ran %d cycles, want 1", cycles)
+	}
+}
+
+func TestRunCollectionServiceStartupDelay(t *testing.T) {
+	clock := clockwork.NewFakeClock()
+	cycles := make(chan bool, 1)
+	done := make(chan bool)
+	go func() {
+		RunCollectionService(clock, time.Minute, true, func() time.Duration {
+			cycles <- true
+			return time.Hour
+		})
+		close(done)
+	}()
+
+	clock.BlockUntil(1)
+	clock.Advance(59 * time.Second)
+	select {
+	case <-cycles:
+		t.Fatalf("RunCollectionService() ran a cycle before the startup delay")
+	case <-time.After(50 * time.Millisecond):
+	}
+	clock.Advance(time.Second)
+	select {
+	case <-cycles:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("RunCollectionService() did not run a cycle after the startup delay")
+	}
+	<-done
+}
+
+func TestJitter(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, max := range []time.Duration{time.Second, time.Minute, time.Hour} {
+		for i := 0; i < 1000; i++ {
+			if got := Jitter(r, max); got < 0 || got > max {
+				t.Fatalf("Jitter(%v) = %v, want within [0, %v]", max, got, max)
+			}
+		}
+	}
+	if got := Jitter(r, 1); got < 0 || got > 1 {
+		t.Errorf("Jitter(1ns) = %v, want within [0, 1ns]", got)
+	}
+	for _, max := range []time.Duration{0, -time.Second} {
+		if got := Jitter(r, max); got != 0 {
+			t.Errorf("Jitter(%v) = %v, want 0", max, got)
+		}
+	}
+}
+
+func TestJitterSeeded(t *testing.T) {
+	a := Jitter(rand.New(rand.NewSource(42)), time.Hour)
+	b := Jitter(rand.New(rand.NewSource(42)), time.Hour)
+	if a != b {
+		t.Errorf("Jitter() with the same seed returned %v and %v, want equal offsets", a, b)
+	}
+}
+
+func TestOnetimeExitCode(t *testing.T) {
+	err := errors.New("collection failed")
+	tests := []struct {
+		name string
+		errs []error
+		want int
+	}{
+		{name: "all collections succeeded", errs: []error{nil, nil}, want: ExitSuccess},
+		{name: "os collection failed", errs: []error{err, nil}, want: ExitPartialFailure},
+		{name: "sql collection failed", errs: []error{nil, err}, want: ExitPartialFailure},
+		{name: "all collections failed", errs: []error{err, err}, want: ExitTotalFailure},
+		{name: "some sql targets failed", errs: []error{nil, NewTargetErrors(2, []error{err})}, want: ExitPartialFailure},
+		{name: "all sql targets failed", errs: []error{nil, NewTargetErrors(2, []error{err, err})}, want: ExitPartialFailure},
+		{name: "all targets failed", errs: []error{NewTargetErrors(1, []error{err}), NewTargetErrors(2, []error{err, err})}, want: ExitTotalFailure},
+		{name: "some targets of all collections failed", errs: []error{NewTargetErrors(2, []error{err}), NewTargetErrors(2, []error{err})}, want: ExitPartialFailure},
+		{name: "no collection", want: ExitSuccess},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := OnetimeExitCode(tc.errs...); got != tc.want {
+				t.Errorf("OnetimeExitCode(%v) = %d, want %d", tc.errs, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNewTargetErrors(t *testing.T) {
+	if err := NewTargetErrors(2, nil); err != nil {
+		t.Errorf("NewTargetErrors(2, nil) = %v, want nil", err)
+	}
+	targetErr := errors.New("target failed")
+	err := NewTargetErrors(2, []error{targetErr})
+	if !errors.Is(err, targetErr) {
+		t.Errorf("NewTargetErrors(2, [%v]) = %v, want it to wrap %v", targetErr, err, targetErr)
+	}
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/GoogleCloudPlatform/workloadagentplatform/integration/common/shared/log"
+)
+
+// VolumeCompression is a windows volume and whether NTFS compression and data deduplication are
+// enabled on it.
+type VolumeCompression struct {
+	Caption      string
+	Compressed   bool
+	DedupEnabled bool
+}
+
+// AddDataVolumeCompression correlates the NTFS compression and data deduplication status of the
+// windows volumes with the physical_drive of the database files in DB_LOG_DISK_SEPARATION.
+// volumes is a JSON list of VolumeCompression. Each database file gets
+// sql_volume_compression_enabled and sql_volume_dedup_enabled of its volume, both of which hurt
+// the performance of SQL Server. Both are unknown if the volume of the file is not found.
+func AddDataVolumeCompression(details []Details, volumes string) {
+	compression := map[string]VolumeCompression{}
+	var vs []VolumeCompression
+	if volumes != "" {
+		if err := json.Unmarshal([]byte(volumes), &vs); err != nil {
+			log.Logger.Warnw("Failed to parse the data volume compression", "error", err)
+		}
+	}
+	for _, v := range vs {
+		compression[volumeDrive(v.Caption)] = v
+	}
+	for _, detail := range details {
+		if detail.Name != "DB_LOG_DISK_SEPARATION" {
+			continue
+		}
+		for _, field := range detail.Fields {
+			field["sql_volume_compression_enabled"] = "unknown"
+			field["sql_volume_dedup_enabled"] = "unknown"
+			v, ok := compression[strings.ToUpper(field["physical_drive"])]
+			if !ok {
+				continue
+			}
+			field["sql_volume_compression_enabled"] = strconv.FormatBool(v.Compressed)
+			field["sql_volume_dedup_enabled"] = strconv.FormatBool(v.DedupEnabled)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestAddDataVolumeCompression(t *testing.T) {
+	testcases := []struct {
+		name    string
+		details []Details
+		volumes string
+		want    []Details
+	}{
+		{
+			name: "data volumes correlated with compression and dedup",
+			details: []Details{
+				{
+					Name: "DB_LOG_DISK_SEPARATION",
+					Fields: []map[string]string{
+						{"db_name": "db1", "physical_name": `D:\data\db1.mdf`, "physical_drive": "D"},
+						{"db_name": "db1", "physical_name": `e:\log\db1.ldf`, "physical_drive": "e"},
+						{"db_name": "db2", "physical_name": `F:\data\db2.mdf`, "physical_drive": "F"},
+					},
+				},
+				{
+					Name:   "INSTANCE_METRICS",
+					Fields: []map[string]string{{"os": "windows"}},
+				},
+			},
+			volumes: `[{"Caption":"C:\\","Compressed":false,"DedupEnabled":false},{"Caption":"D:\\","Compressed":true,"DedupEnabled":false},{"Caption":"E:\\","Compressed":false,"DedupEnabled":true}]`,
+			want: []Details{
+				{
+					Name: "DB_LOG_DISK_SEPARATION",
+					Fields: []map[string]string{
+						{"db_name": "db1", "physical_name": `D:\data\db1.mdf`, "physical_drive": "D", "sql_volume_compression_enabled": "true", "sql_volume_dedup_enabled": "false"},
+						{"db_name": "db1", "physical_name": `e:\log\db1.ldf`, "physical_drive": "e", "sql_volume_compression_enabled": "false", "sql_volume_dedup_enabled": "true"},
+						{"db_name": "db2", "physical_name": `F:\data\db2.mdf`, "physical_drive": "F", "sql_volume_compression_enabled": "unknown", "sql_volume_dedup_enabled": "unknown"},
+					},
+				},
+				{
+					Name:   "INSTANCE_METRICS",
+					Fields: []map[string]string{{"os": "windows"}},
+				},
+			},
+		},
+		{
+			name: "invalid volumes",
+			details: []Details{
+				{
+					Name:   "DB_LOG_DISK_SEPARATION",
+					Fields: []map[string]string{{"db_name": "db1", "physical_drive": "D"}},
+				},
+			},
+			volumes: "unknown",
+			want: []Details{
+				{
+					Name:   "DB_LOG_DISK_SEPARATION",
+					Fields: []map[string]string{{"db_name": "db1", "physical_drive": "D", "sql_volume_compression_enabled": "unknown", "sql_volume_dedup_enabled": "unknown"}},
+				},
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			AddDataVolumeCompression(tc.details, tc.volumes)
+			if diff := cmp.Diff(tc.details, tc.want); diff != "" {
+				t.Errorf("AddDataVolumeCompression() returned wrong result (-got +want):\n%s", diff)
+			}
+		})
+	}
+}
@@ -20,14 +20,28 @@ package wlm
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"sync"
+	"time"
 
 	"google.golang.org/api/option"
 	workloadmanager "google.golang.org/api/workloadmanager/v1"
 	"github.com/GoogleCloudPlatform/sql-server-agent/internal"
+	"github.com/GoogleCloudPlatform/workloadagentplatform/integration/common/shared/log"
 )
 
 const (
 	basePath = "https://workloadmanager-datawarehouse.googleapis.com/"
+
+	// SendStatusDetailName is the validation detail type of the send status reported to workloadmanager.
+	SendStatusDetailName = "AGENT_SEND_STATUS"
+
+	// HeartbeatDetailName is the validation detail type of the heartbeat sent to workloadmanager.
+	HeartbeatDetailName = "AGENT_HEARTBEAT"
+
+	// CollectionDurationDetailName is the validation detail type of the collection durations of the
+	// rules reported to workloadmanager.
+	CollectionDurationDetailName = "AGENT_COLLECTION_DURATION"
 )
 
 // WorkloadManagerService the interface of WLM.
@@ -38,8 +52,9 @@ type WorkloadManagerService interface {
 
 // WLM struct which contains workloadmanager service.
 type WLM struct {
-	wlmService *workloadmanager.Service
-	Request    *workloadmanager.WriteInsightRequest
+	wlmService     *workloadmanager.Service
+	Request        *workloadmanager.WriteInsightRequest
+	requestTimeout time.Duration
 }
 
 // NewWorkloadManager creates new WLM and it return non-nil error if any error was caught.
@@ -52,8 +67,21 @@ func NewWorkloadManager(ctx context.Context) (*WLM, error) {
 }
 
 // SendRequest sends request to workloadmanager.
+// The request is cancelled if it does not complete within the request timeout.
 func (wlm *WLM) SendRequest(location string) (*workloadmanager.WriteInsightResponse, error) {
-	return wlm.wlmService.Projects.Locations.Insights.WriteInsight(location, wlm.Request).Do()
+	call := wlm.wlmService.Projects.Locations.Insights.WriteInsight(location, wlm.Request)
+	if wlm.requestTimeout > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), wlm.requestTimeout)
+		defer cancel()
+		call = call.Context(ctx)
+	}
+	return call.Do()
+}
+
+// SetRequestTimeout sets the deadline of each request sent to workloadmanager.
+// Requests have no deadline if timeout is not positive.
+func (wlm *WLM) SetRequestTimeout(timeout time.Duration) {
+	wlm.requestTimeout = timeout
 }
 
 // UpdateRequest updates WLM request.
@@ -103,3 +131,130 @@ func UpdateValidationDetails(sqlservervalidation *workloadmanager.SqlserverValid
 	}
 	return sqlservervalidation
 }
+
+// CollectedDataRequest returns the WriteInsightRequest of the collected details. The collection
+// durations of the rules in details are reported in a detail of their own, since the fields of a
+// detail only hold the collected values.
+func CollectedDataRequest(projectID, instance, instanceID string, details []internal.Details) *workloadmanager.WriteInsightRequest {
+	reported := append([]internal.Details{}, details...)
+	if durations, ok := CollectionDurationDetails(details); ok {
+		reported = append(reported, durations)
+	}
+	sqlservervalidation := UpdateValidationDetails(InitializeSQLServerValidation(projectID, instance), reported)
+	request := InitializeWriteInsightRequest(sqlservervalidation, instanceID)
+	request.Insight.SentTime = time.Now().Format(time.RFC3339)
+	return request
+}
+
+// CollectionDurationDetails returns the detail of the collection durations in milliseconds of the
+// rules in details, keyed as they were recorded: by rule name, or by instance and rule name for the
+// sql rules. Returns false if no rule recorded its duration.
+func CollectionDurationDetails(details []internal.Details) (internal.Details, bool) {
+	fields := map[string]string{}
+	for _, detail := range details {
+		for rule, duration := range detail.CollectionDurationMs {
+			fields[rule] = strconv.FormatInt(duration, 10)
+		}
+	}
+	if len(fields) == 0 {
+		return internal.Details{}, false
+	}
+	return internal.Details{
+		Name:   CollectionDurationDetailName,
+		Fields: []map[string]string{fields},
+	}, true
+}
+
+// HeartbeatRequest returns the WriteInsightRequest of a heartbeat, which reports the instance is
+// alive and the version of its agent without any collected data. extraFields are added to the
+// fields of the heartbeat.
+func HeartbeatRequest(projectID, instance, instanceID string, extraFields map[string]string) *workloadmanager.WriteInsightRequest {
+	fields := map[string]string{
+		"alive":         "true",
+		"agent_version": internal.AgentVersion,
+	}
+	for k, v := range extraFields {
+		fields[k] = v
+	}
+	heartbeat := internal.Details{
+		Name:   HeartbeatDetailName,
+		Fields: []map[string]string{fields},
+	}
+	sqlservervalidation := UpdateValidationDetails(InitializeSQLServerValidation(projectID, instance), []internal.Details{heartbeat})
+	request := InitializeWriteInsightRequest(sqlservervalidation, instanceID)
+	request.Insight.SentTime = time.Now().Format(time.RFC3339)
+	return request
+}
+
+// SendWithFallback runs send for location and, if it fails and fallbackLocation is set, for
+// fallbackLocation. send sends the request to a single location, including its retries.
+func SendWithFallback(location, fallbackLocation string, send func(location string) error) error {
+	err := send(location)
+	if err == nil || fallbackLocation == "" {
+		return err
+	}
+	log.Logger.Warnw("Failed to send request to workload manager, sending it to the secondary location", "location", location, "secondary_location", fallbackLocation, "error", err)
+	return send(fallbackLocation)
+}
+
+// sendCycle is the outcome of sending one request to workloadmanager, including its retries.
+type sendCycle struct {
+	attempts int
+	lastErr  error
+	success  bool
+}
+
+// SendStatus aggregates the outcome of the requests sent to workloadmanager so the health of
+// sending can be reported with the next request. It is safe for concurrent use.
+type SendStatus struct {
+	mu      sync.Mutex
+	current sendCycle
+	last    *sendCycle
+}
+
+// RecordAttempt records one attempt of sending the current request and its error, if any.
+func (s *SendStatus) RecordAttempt(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.current.attempts++
+	if err != nil {
+		s.current.lastErr = err
+	}
+}
+
+// Finish completes the current request after its last attempt.
+// success reports whether the request was eventually sent.
+func (s *SendStatus) Finish(success bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := s.current
+	c.success = success
+	s.last = &c
+	s.current = sendCycle{}
+}
+
+// Details returns the outcome, the attempt count and the last error of the last finished request.
+// The outcome is "unknown" if no request has finished yet.
+func (s *SendStatus) Details() internal.Details {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fields := map[string]string{
+		"last_send_outcome":  "unknown",
+		"last_send_attempts": "0",
+		"last_send_error":    "",
+	}
+	if s.last != nil {
+		fields["last_send_outcome"] = "failure"
+		if s.last.success {
+			fields["last_send_outcome"] = "success"
+		}
+		fields["last_send_attempts"] = strconv.Itoa(s.last.attempts)
+		if s.last.lastErr != nil {
+			fields["last_send_error"] = s.last.lastErr.Error()
+		}
+	}
+	return internal.Details{
+		Name:   SendStatusDetailName,
+		Fields: []map[string]string{fields},
+	}
+}
@@ -28,6 +28,8 @@ type MockWlmService struct {
 	MockError    bool
 	MockHTTPCode int
 	Request      *workloadmanager.WriteInsightRequest
+	// FailedLocations are the locations requests fail for, regardless of MockError.
+	FailedLocations map[string]bool
 }
 
 // SendRequest mock function.
@@ -36,7 +38,7 @@ func (m *MockWlmService) SendRequest(location string) (*workloadmanager.WriteIns
 		return nil, fmt.Errorf("any error")
 	}
 	err := fmt.Errorf("any error")
-	if !m.MockError {
+	if !m.MockError && !m.FailedLocations[location] {
 		err = nil
 	}
 	return &workloadmanager.WriteInsightResponse{
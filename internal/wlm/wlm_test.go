@@ -17,9 +17,16 @@ limitations under the License.
 package wlm
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"google.golang.org/api/option"
 	workloadmanager "google.golang.org/api/workloadmanager/v1"
 	"google.golang.org/protobuf/testing/protocmp"
 	"github.com/GoogleCloudPlatform/sql-server-agent/internal"
@@ -97,6 +104,167 @@ func TestUpdateRequest(t *testing.T) {
 	}
 }
 
+func TestHeartbeatRequest(t *testing.T) {
+	want := &workloadmanager.WriteInsightRequest{
+		Insight: &workloadmanager.Insight{
+			InstanceId: "testInstanceID",
+			SqlserverValidation: &workloadmanager.SqlserverValidation{
+				AgentVersion: internal.AgentVersion,
+				ProjectId:    "testProjectID",
+				Instance:     "testInstance",
+				ValidationDetails: []*workloadmanager.SqlserverValidationValidationDetail{
+					{
+						Type: HeartbeatDetailName,
+						Details: []*workloadmanager.SqlserverValidationDetails{
+							{Fields: map[string]string{"alive": "true", "agent_version": internal.AgentVersion}},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	got := HeartbeatRequest("testProjectID", "testInstance", "testInstanceID", nil)
+	if _, err := time.Parse(time.RFC3339, got.Insight.SentTime); err != nil {
+		t.Errorf("HeartbeatRequest() returned invalid SentTime %q: %v", got.Insight.SentTime, err)
+	}
+	got.Insight.SentTime = ""
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("HeartbeatRequest() returned wrong result (-want +got):\n%s", diff)
+	}
+
+	m := &MockWlmService{}
+	m.UpdateRequest(got)
+	if _, err := m.SendRequest("testLocation"); err != nil {
+		t.Errorf("SendRequest() of the heartbeat returned unexpected error: %v", err)
+	}
+}
+
+func TestHeartbeatRequestExtraFields(t *testing.T) {
+	got := HeartbeatRequest("testProjectID", "testInstance", "testInstanceID", map[string]string{"agent_goroutines": "12"})
+	want := []*workloadmanager.SqlserverValidationDetails{
+		{Fields: map[string]string{"alive": "true", "agent_version": internal.AgentVersion, "agent_goroutines": "12"}},
+	}
+	if diff := cmp.Diff(want, got.Insight.SqlserverValidation.ValidationDetails[0].Details); diff != "" {
+		t.Errorf("HeartbeatRequest() returned wrong details (-want +got):\n%s", diff)
+	}
+}
+
+func TestCollectedDataRequest(t *testing.T) {
+	details := []internal.Details{
+		{
+			Name:                 "DB_LOG_DISK_SEPARATION",
+			Fields:               []map[string]string{{"physical_name": "/var/opt/mssql/data/master.mdf"}},
+			CollectionDurationMs: map[string]int64{"DB_LOG_DISK_SEPARATION": 42},
+		},
+		{
+			Name:                 "OS",
+			Fields:               []map[string]string{{"vm_swappiness": "60", "io_scheduler": "unknown"}},
+			CollectionDurationMs: map[string]int64{"vm_swappiness": 3, "io_scheduler": 0},
+		},
+	}
+	want := &workloadmanager.WriteInsightRequest{
+		Insight: &workloadmanager.Insight{
+			InstanceId: "testInstanceID",
+			SqlserverValidation: &workloadmanager.SqlserverValidation{
+				AgentVersion: internal.AgentVersion,
+				ProjectId:    "testProjectID",
+				Instance:     "testInstance",
+				ValidationDetails: []*workloadmanager.SqlserverValidationValidationDetail{
+					{
+						Type: "DB_LOG_DISK_SEPARATION",
+						Details: []*workloadmanager.SqlserverValidationDetails{
+							{Fields: map[string]string{"physical_name": "/var/opt/mssql/data/master.mdf"}},
+						},
+					},
+					{
+						Type: "OS",
+						Details: []*workloadmanager.SqlserverValidationDetails{
+							{Fields: map[string]string{"vm_swappiness": "60", "io_scheduler": "unknown"}},
+						},
+					},
+					{
+						Type: CollectionDurationDetailName,
+						Details: []*workloadmanager.SqlserverValidationDetails{
+							{Fields: map[string]string{"DB_LOG_DISK_SEPARATION": "42", "vm_swappiness": "3", "io_scheduler": "0"}},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	got := CollectedDataRequest("testProjectID", "testInstance", "testInstanceID", details)
+	if _, err := time.Parse(time.RFC3339, got.Insight.SentTime); err != nil {
+		t.Errorf("CollectedDataRequest() returned invalid SentTime %q: %v", got.Insight.SentTime, err)
+	}
+	got.Insight.SentTime = ""
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("CollectedDataRequest() returned wrong result (-want +got):\n%s", diff)
+	}
+}
+
+func TestCollectionDurationDetailsMergedInstances(t *testing.T) {
+	var details []internal.Details
+	for i, host := range []string{"instance1", "instance2"} {
+		instanceDetails := []internal.Details{{
+			Name:                 "INSTANCE_MAXDOP",
+			Fields:               []map[string]string{{"maxdop": "0"}},
+			CollectionDurationMs: map[string]int64{"INSTANCE_MAXDOP": int64(10 * (i + 1))},
+		}}
+		internal.AddHostFields(instanceDetails, host, 1433)
+		details = internal.MergeDetails(details, instanceDetails)
+	}
+	got, ok := CollectionDurationDetails(details)
+	if !ok {
+		t.Fatal("CollectionDurationDetails() returned no detail, want the durations of both instances")
+	}
+	want := []map[string]string{{"instance1:1433/INSTANCE_MAXDOP": "10", "instance2:1433/INSTANCE_MAXDOP": "20"}}
+	if diff := cmp.Diff(want, got.Fields); diff != "" {
+		t.Errorf("CollectionDurationDetails() returned wrong fields (-want +got):\n%s", diff)
+	}
+}
+
+func TestCollectedDataRequestNoDurations(t *testing.T) {
+	details := []internal.Details{
+		{Name: "OS", Fields: []map[string]string{{"vm_swappiness": "60"}}},
+	}
+	got := CollectedDataRequest("testProjectID", "testInstance", "testInstanceID", details)
+	for _, d := range got.Insight.SqlserverValidation.ValidationDetails {
+		if d.Type == CollectionDurationDetailName {
+			t.Errorf("CollectedDataRequest() reported %s without any collection duration", CollectionDurationDetailName)
+		}
+	}
+}
+
+func TestCollectedDataRequestPersisted(t *testing.T) {
+	details := []internal.Details{
+		{
+			Name:                 "DB_LOG_DISK_SEPARATION",
+			Fields:               []map[string]string{{"physical_name": "/var/opt/mssql/data/master.mdf"}},
+			CollectionDurationMs: map[string]int64{"DB_LOG_DISK_SEPARATION": 42},
+		},
+	}
+	// The collected data is saved locally as the pretty printed JSON of the request.
+	requestJSON, err := internal.PrettyStruct(CollectedDataRequest("testProjectID", "testInstance", "testInstanceID", details))
+	if err != nil {
+		t.Fatalf("PrettyStruct() returned unexpected error: %v", err)
+	}
+	var got workloadmanager.WriteInsightRequest
+	if err := json.Unmarshal([]byte(requestJSON), &got); err != nil {
+		t.Fatalf("json.Unmarshal() returned unexpected error: %v", err)
+	}
+	for _, d := range got.Insight.SqlserverValidation.ValidationDetails {
+		if d.Type == CollectionDurationDetailName {
+			if duration := d.Details[0].Fields["DB_LOG_DISK_SEPARATION"]; duration != "42" {
+				t.Errorf("persisted request reported collection duration %q for DB_LOG_DISK_SEPARATION, want %q", duration, "42")
+			}
+			return
+		}
+	}
+	t.Errorf("persisted request = %s, want a %s detail", requestJSON, CollectionDurationDetailName)
+}
+
 func TestMockWLMService(t *testing.T) {
 	w := MockWlmService{}
 	if _, err := w.SendRequest(""); err == nil {
@@ -107,3 +275,205 @@ func TestMockWLMService(t *testing.T) {
 		t.Errorf("Mocked SendRequest() returned unexpected error: %v", err)
 	}
 }
+
+func TestSendRequestTimeout(t *testing.T) {
+	testcases := []struct {
+		name    string
+		timeout time.Duration
+		delay   time.Duration
+		wantErr error
+	}{
+		{
+			name:    "request within timeout",
+			timeout: 5 * time.Second,
+		},
+		{
+			name:    "slow request is cancelled",
+			timeout: 100 * time.Millisecond,
+			delay:   5 * time.Second,
+			wantErr: context.DeadlineExceeded,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				select {
+				case <-time.After(tc.delay):
+				case <-r.Context().Done():
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte("{}"))
+			}))
+			defer server.Close()
+			service, err := workloadmanager.NewService(context.Background(), option.WithEndpoint(server.URL), option.WithoutAuthentication())
+			if err != nil {
+				t.Fatalf("workloadmanager.NewService() returned an unexpected error: %v", err)
+			}
+			wlm := &WLM{wlmService: service, Request: &workloadmanager.WriteInsightRequest{}}
+			wlm.SetRequestTimeout(tc.timeout)
+
+			start := time.Now()
+			_, err = wlm.SendRequest("projects/test-project/locations/us-central1")
+			if !errors.Is(err, tc.wantErr) {
+				t.Errorf("SendRequest() returned error %v, want %v", err, tc.wantErr)
+			}
+			if elapsed := time.Since(start); elapsed >= tc.timeout+time.Second {
+				t.Errorf("SendRequest() took %v, want less than %v", elapsed, tc.timeout+time.Second)
+			}
+		})
+	}
+}
+
+func TestSendWithFallback(t *testing.T) {
+	const (
+		primary   = "projects/test-project/locations/us-central1"
+		secondary = "projects/test-project/locations/us-east1"
+	)
+	testcases := []struct {
+		name             string
+		fallbackLocation string
+		failedLocations  map[string]bool
+		wantLocations    []string
+		wantErr          bool
+	}{
+		{
+			name:             "primary succeeds",
+			fallbackLocation: secondary,
+			wantLocations:    []string{primary},
+		},
+		{
+			name:             "primary fails and secondary succeeds",
+			fallbackLocation: secondary,
+			failedLocations:  map[string]bool{primary: true},
+			wantLocations:    []string{primary, secondary},
+		},
+		{
+			name:             "primary and secondary fail",
+			fallbackLocation: secondary,
+			failedLocations:  map[string]bool{primary: true, secondary: true},
+			wantLocations:    []string{primary, secondary},
+			wantErr:          true,
+		},
+		{
+			name:            "primary fails without secondary",
+			failedLocations: map[string]bool{primary: true},
+			wantLocations:   []string{primary},
+			wantErr:         true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := &MockWlmService{Request: &workloadmanager.WriteInsightRequest{}, FailedLocations: tc.failedLocations}
+			var gotLocations []string
+			send := func(location string) error {
+				gotLocations = append(gotLocations, location)
+				_, err := w.SendRequest(location)
+				return err
+			}
+			err := SendWithFallback(primary, tc.fallbackLocation, send)
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Errorf("SendWithFallback() returned error %v, want error: %v", err, tc.wantErr)
+			}
+			if diff := cmp.Diff(tc.wantLocations, gotLocations); diff != "" {
+				t.Errorf("SendWithFallback() sent to wrong locations (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSendStatusDetails(t *testing.T) {
+	tests := []struct {
+		name   string
+		record func(s *SendStatus)
+		want   map[string]string
+	}{
+		{
+			name:   "no request finished",
+			record: func(s *SendStatus) {},
+			want: map[string]string{
+				"last_send_outcome":  "unknown",
+				"last_send_attempts": "0",
+				"last_send_error":    "",
+			},
+		},
+		{
+			name: "in progress request is not reported",
+			record: func(s *SendStatus) {
+				s.RecordAttempt(errors.New("unavailable"))
+			},
+			want: map[string]string{
+				"last_send_outcome":  "unknown",
+				"last_send_attempts": "0",
+				"last_send_error":    "",
+			},
+		},
+		{
+			name: "success on first attempt",
+			record: func(s *SendStatus) {
+				s.RecordAttempt(nil)
+				s.Finish(true)
+			},
+			want: map[string]string{
+				"last_send_outcome":  "success",
+				"last_send_attempts": "1",
+				"last_send_error":    "",
+			},
+		},
+		{
+			name: "success after retries keeps the last error",
+			record: func(s *SendStatus) {
+				s.RecordAttempt(errors.New("unavailable"))
+				s.RecordAttempt(errors.New("deadline exceeded"))
+				s.RecordAttempt(nil)
+				s.Finish(true)
+			},
+			want: map[string]string{
+				"last_send_outcome":  "success",
+				"last_send_attempts": "3",
+				"last_send_error":    "deadline exceeded",
+			},
+		},
+		{
+			name: "retries exhausted",
+			record: func(s *SendStatus) {
+				s.RecordAttempt(errors.New("unavailable"))
+				s.RecordAttempt(errors.New("unavailable"))
+				s.Finish(false)
+			},
+			want: map[string]string{
+				"last_send_outcome":  "failure",
+				"last_send_attempts": "2",
+				"last_send_error":    "unavailable",
+			},
+		},
+		{
+			name: "only the last request is reported",
+			record: func(s *SendStatus) {
+				s.RecordAttempt(errors.New("unavailable"))
+				s.Finish(false)
+				s.RecordAttempt(nil)
+				s.Finish(true)
+			},
+			want: map[string]string{
+				"last_send_outcome":  "success",
+				"last_send_attempts": "1",
+				"last_send_error":    "",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s := &SendStatus{}
+			tc.record(s)
+			want := internal.Details{
+				Name:   SendStatusDetailName,
+				Fields: []map[string]string{tc.want},
+			}
+			if diff := cmp.Diff(want, s.Details()); diff != "" {
+				t.Errorf("Details() returned diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package collect runs the guest os and sql collections of the agent once and returns the collected
+// details, without sending them to workload manager or saving them, so the collections can be
+// embedded in other Go tools.
+package collect
+
+import (
+	"context"
+	"errors"
+
+	"github.com/GoogleCloudPlatform/sql-server-agent/internal"
+	"github.com/GoogleCloudPlatform/sql-server-agent/internal/sqlservermetrics"
+	configpb "github.com/GoogleCloudPlatform/sql-server-agent/protos/sqlserveragentconfig"
+	"github.com/GoogleCloudPlatform/workloadagentplatform/integration/common/shared/gce/metadataserver"
+)
+
+// Instance is the instance the collections run on.
+type Instance struct {
+	ProjectID     string
+	ProjectNumber string
+	Zone          string
+	InstanceID    string
+	InstanceName  string
+}
+
+// Options configures a collection.
+type Options struct {
+	// Config is the configuration of the collections, in the format of the configuration file of
+	// the agent.
+	Config *configpb.Configuration
+	// Instance is the instance the collections run on. If nil, it is read like the agent does, from
+	// the "instance_properties" of an on_prem Config or from the metadata server.
+	Instance *Instance
+}
+
+// Details are the details collected by a rule.
+type Details struct {
+	Name   string              `json:"name"`
+	Fields []map[string]string `json:"fields"`
+	// CollectionDurationMs records how long each rule took to collect in milliseconds, keyed by rule name.
+	// The durations of sql rules are keyed by "host:port/rule".
+	CollectionDurationMs map[string]int64 `json:"collection_duration_ms,omitempty"`
+}
+
+// CollectOnce runs the guest os and sql collections enabled in the configuration of opts once and
+// returns the collected details: of every credential configuration for remote collection, or of
+// the first one for local collection.
+// A target failing does not stop the collection of the others; the details collected are returned
+// with the errors of the failed targets.
+func CollectOnce(ctx context.Context, opts Options) (osDetails, sqlDetails []Details, err error) {
+	if opts.Config == nil {
+		return nil, nil, errors.New("empty configuration")
+	}
+	var cp *metadataserver.CloudProperties
+	if i := opts.Instance; i != nil {
+		cp = &metadataserver.CloudProperties{
+			ProjectID:        i.ProjectID,
+			NumericProjectID: i.ProjectNumber,
+			Zone:             i.Zone,
+			InstanceID:       i.InstanceID,
+			InstanceName:     i.InstanceName,
+			Image:            metadataserver.ImageUnknown,
+			MachineType:      metadataserver.MachineTypeUnknown,
+		}
+	}
+	osCollected, sqlCollected, err := sqlservermetrics.CollectOnce(ctx, opts.Config, cp)
+	return details(osCollected), details(sqlCollected), err
+}
+
+// details returns the exported details of the collected details.
+func details(collected []internal.Details) []Details {
+	if collected == nil {
+		return nil
+	}
+	ds := make([]Details, 0, len(collected))
+	for _, d := range collected {
+		ds = append(ds, Details{Name: d.Name, Fields: d.Fields, CollectionDurationMs: d.CollectionDurationMs})
+	}
+	return ds
+}
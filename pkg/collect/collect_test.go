@@ -0,0 +1,104 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package collect
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/GoogleCloudPlatform/sql-server-agent/internal"
+	configpb "github.com/GoogleCloudPlatform/sql-server-agent/protos/sqlserveragentconfig"
+)
+
+func TestCollectOnce(t *testing.T) {
+	instance := &Instance{ProjectID: "test-project", Zone: "us-central1-a", InstanceName: "test-instance"}
+	tests := []struct {
+		name    string
+		opts    Options
+		wantErr bool
+	}{
+		{
+			name:    "empty configuration",
+			opts:    Options{Instance: instance},
+			wantErr: true,
+		},
+		{
+			name: "empty credentials",
+			opts: Options{
+				Config:   &configpb.Configuration{CollectionConfiguration: &configpb.CollectionConfiguration{CollectSqlMetrics: true}},
+				Instance: instance,
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid secret provider",
+			opts: Options{
+				Config: &configpb.Configuration{
+					SecretProvider:          "vualt",
+					CredentialConfiguration: []*configpb.CredentialConfiguration{{InstanceName: "test-instance"}},
+				},
+				Instance: instance,
+			},
+			wantErr: true,
+		},
+		{
+			name: "no enabled collection",
+			opts: Options{
+				Config: &configpb.Configuration{
+					CollectionConfiguration: &configpb.CollectionConfiguration{},
+					CredentialConfiguration: []*configpb.CredentialConfiguration{{InstanceName: "test-instance"}},
+				},
+				Instance: instance,
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			osDetails, sqlDetails, err := CollectOnce(context.Background(), tc.opts)
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Fatalf("CollectOnce() returned error %v, want error presence = %v", err, tc.wantErr)
+			}
+			if len(osDetails) != 0 || len(sqlDetails) != 0 {
+				t.Errorf("CollectOnce() returned details %v and %v, want none", osDetails, sqlDetails)
+			}
+		})
+	}
+}
+
+func TestDetails(t *testing.T) {
+	collected := []internal.Details{
+		{
+			Name:                 "SQL",
+			Fields:               []map[string]string{{"host_name": "test-host"}},
+			CollectionDurationMs: map[string]int64{"test-host:1433/rule": 5},
+		},
+	}
+	want := []Details{
+		{
+			Name:                 "SQL",
+			Fields:               []map[string]string{{"host_name": "test-host"}},
+			CollectionDurationMs: map[string]int64{"test-host:1433/rule": 5},
+		},
+	}
+	if diff := cmp.Diff(want, details(collected)); diff != "" {
+		t.Errorf("details() returned unexpected diff (-want +got):\n%s", diff)
+	}
+	if got := details(nil); got != nil {
+		t.Errorf("details(nil) = %v, want nil", got)
+	}
+}
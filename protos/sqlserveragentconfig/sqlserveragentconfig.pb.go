@@ -38,6 +38,106 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type CredentialConfiguration_SqlCredentials_AuthMode int32
+
+const (
+	// user_name and the password stored in the secret_name secret
+	CredentialConfiguration_SqlCredentials_PASSWORD CredentialConfiguration_SqlCredentials_AuthMode = 0
+	// access token of the agent, user_name and secret_name are not used
+	CredentialConfiguration_SqlCredentials_ACCESS_TOKEN CredentialConfiguration_SqlCredentials_AuthMode = 1
+)
+
+// Enum value maps for CredentialConfiguration_SqlCredentials_AuthMode.
+var (
+	CredentialConfiguration_SqlCredentials_AuthMode_name = map[int32]string{
+		0: "PASSWORD",
+		1: "ACCESS_TOKEN",
+	}
+	CredentialConfiguration_SqlCredentials_AuthMode_value = map[string]int32{
+		"PASSWORD":     0,
+		"ACCESS_TOKEN": 1,
+	}
+)
+
+func (x CredentialConfiguration_SqlCredentials_AuthMode) Enum() *CredentialConfiguration_SqlCredentials_AuthMode {
+	p := new(CredentialConfiguration_SqlCredentials_AuthMode)
+	*p = x
+	return p
+}
+
+func (x CredentialConfiguration_SqlCredentials_AuthMode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (CredentialConfiguration_SqlCredentials_AuthMode) Descriptor() protoreflect.EnumDescriptor {
+	return file_sqlserveragentconfig_sqlserveragentconfig_proto_enumTypes[0].Descriptor()
+}
+
+func (CredentialConfiguration_SqlCredentials_AuthMode) Type() protoreflect.EnumType {
+	return &file_sqlserveragentconfig_sqlserveragentconfig_proto_enumTypes[0]
+}
+
+func (x CredentialConfiguration_SqlCredentials_AuthMode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use CredentialConfiguration_SqlCredentials_AuthMode.Descriptor instead.
+func (CredentialConfiguration_SqlCredentials_AuthMode) EnumDescriptor() ([]byte, []int) {
+	return file_sqlserveragentconfig_sqlserveragentconfig_proto_rawDescGZIP(), []int{5, 0, 0}
+}
+
+type CredentialConfiguration_GuestCredentialsRemoteWin_Transport int32
+
+const (
+	// WMI over DCOM
+	CredentialConfiguration_GuestCredentialsRemoteWin_WMI CredentialConfiguration_GuestCredentialsRemoteWin_Transport = 0
+	// WinRM over HTTP, port 5985
+	CredentialConfiguration_GuestCredentialsRemoteWin_WINRM_HTTP CredentialConfiguration_GuestCredentialsRemoteWin_Transport = 1
+	// WinRM over HTTPS, port 5986
+	CredentialConfiguration_GuestCredentialsRemoteWin_WINRM_HTTPS CredentialConfiguration_GuestCredentialsRemoteWin_Transport = 2
+)
+
+// Enum value maps for CredentialConfiguration_GuestCredentialsRemoteWin_Transport.
+var (
+	CredentialConfiguration_GuestCredentialsRemoteWin_Transport_name = map[int32]string{
+		0: "WMI",
+		1: "WINRM_HTTP",
+		2: "WINRM_HTTPS",
+	}
+	CredentialConfiguration_GuestCredentialsRemoteWin_Transport_value = map[string]int32{
+		"WMI":         0,
+		"WINRM_HTTP":  1,
+		"WINRM_HTTPS": 2,
+	}
+)
+
+func (x CredentialConfiguration_GuestCredentialsRemoteWin_Transport) Enum() *CredentialConfiguration_GuestCredentialsRemoteWin_Transport {
+	p := new(CredentialConfiguration_GuestCredentialsRemoteWin_Transport)
+	*p = x
+	return p
+}
+
+func (x CredentialConfiguration_GuestCredentialsRemoteWin_Transport) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (CredentialConfiguration_GuestCredentialsRemoteWin_Transport) Descriptor() protoreflect.EnumDescriptor {
+	return file_sqlserveragentconfig_sqlserveragentconfig_proto_enumTypes[1].Descriptor()
+}
+
+func (CredentialConfiguration_GuestCredentialsRemoteWin_Transport) Type() protoreflect.EnumType {
+	return &file_sqlserveragentconfig_sqlserveragentconfig_proto_enumTypes[1]
+}
+
+func (x CredentialConfiguration_GuestCredentialsRemoteWin_Transport) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use CredentialConfiguration_GuestCredentialsRemoteWin_Transport.Descriptor instead.
+func (CredentialConfiguration_GuestCredentialsRemoteWin_Transport) EnumDescriptor() ([]byte, []int) {
+	return file_sqlserveragentconfig_sqlserveragentconfig_proto_rawDescGZIP(), []int{5, 1, 0}
+}
+
 type Configuration struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -59,6 +159,123 @@ type Configuration struct {
 	LogToCloud bool `protobuf:"varint,8,opt,name=log_to_cloud,json=logToCloud,proto3" json:"log_to_cloud,omitempty"`
 	// default log_usage is false
 	DisableLogUsage bool `protobuf:"varint,9,opt,name=disable_log_usage,json=disableLogUsage,proto3" json:"disable_log_usage,omitempty"`
+	// default is 1, linux guest rules are collected sequentially
+	// max number of linux guest rules collected in parallel
+	GuestRuleConcurrency int32 `protobuf:"varint,10,opt,name=guest_rule_concurrency,json=guestRuleConcurrency,proto3" json:"guest_rule_concurrency,omitempty"`
+	// default is 1.2
+	// minimum TLS version of connections to Google Cloud APIs, "1.2" or "1.3"
+	MinTlsVersion string `protobuf:"bytes,11,opt,name=min_tls_version,json=minTlsVersion,proto3" json:"min_tls_version,omitempty"`
+	// default is false
+	// records the targets collected in a cycle, so a restarted agent skips them
+	// until the next collection interval
+	EnableCollectionCheckpoint bool `protobuf:"varint,12,opt,name=enable_collection_checkpoint,json=enableCollectionCheckpoint,proto3" json:"enable_collection_checkpoint,omitempty"`
+	// default is 1, sql rules are collected sequentially
+	// max number of sql rules collected in parallel, each on its own connection
+	SqlRuleConcurrency int32 `protobuf:"varint,13,opt,name=sql_rule_concurrency,json=sqlRuleConcurrency,proto3" json:"sql_rule_concurrency,omitempty"`
+	// default is 60
+	// timeout of each request sending collected data to workload manager
+	WlmRequestTimeoutSeconds int32 `protobuf:"varint,14,opt,name=wlm_request_timeout_seconds,json=wlmRequestTimeoutSeconds,proto3" json:"wlm_request_timeout_seconds,omitempty"`
+	// path of a JSON file holding the "credential_configuration" array, relative
+	// to the directory of configuration.json unless absolute. its credential
+	// configurations are appended to the ones in this file.
+	CredentialConfigurationFile string `protobuf:"bytes,15,opt,name=credential_configuration_file,json=credentialConfigurationFile,proto3" json:"credential_configuration_file,omitempty"`
+	// OTLP/HTTP endpoint of the OpenTelemetry collector receiving the traces of
+	// the collection cycles, e.g. "http://localhost:4318". Tracing is off unless
+	// it is set.
+	OtelEndpoint string `protobuf:"bytes,16,opt,name=otel_endpoint,json=otelEndpoint,proto3" json:"otel_endpoint,omitempty"`
+	// defaults to 0
+	// seconds the service waits before the first collection after it starts, to
+	// let the network and SQL Server become ready on boot
+	StartupDelaySeconds int32 `protobuf:"varint,17,opt,name=startup_delay_seconds,json=startupDelaySeconds,proto3" json:"startup_delay_seconds,omitempty"`
+	// defaults to none
+	// path of the PEM encoded client certificate presented to Google Cloud APIs
+	// in mutual TLS, set together with client_key_path
+	ClientCertPath string `protobuf:"bytes,18,opt,name=client_cert_path,json=clientCertPath,proto3" json:"client_cert_path,omitempty"`
+	// defaults to none
+	// path of the PEM encoded private key of client_cert_path
+	ClientKeyPath string `protobuf:"bytes,19,opt,name=client_key_path,json=clientKeyPath,proto3" json:"client_key_path,omitempty"`
+	// default is false
+	// replace the database names of the collected data with a hash salted with
+	// the project id of the agent, before the data is sent or saved
+	HashDatabaseNames bool `protobuf:"varint,20,opt,name=hash_database_names,json=hashDatabaseNames,proto3" json:"hash_database_names,omitempty"`
+	// defaults to none
+	// location, e.g. "us-east1", the collected data is sent to when sending to
+	// the location of the instance fails after all retries
+	SecondaryWlmLocation string `protobuf:"bytes,21,opt,name=secondary_wlm_location,json=secondaryWlmLocation,proto3" json:"secondary_wlm_location,omitempty"`
+	// defaults to "gce"
+	// environment the agent runs in, "gce" or "on_prem". on_prem agents do not
+	// query the metadata server and read the properties of the host from
+	// instance_properties instead
+	Environment string `protobuf:"bytes,22,opt,name=environment,proto3" json:"environment,omitempty"`
+	// defaults to none
+	// properties of the host, only used when environment is "on_prem"
+	InstanceProperties *InstanceProperties `protobuf:"bytes,23,opt,name=instance_properties,json=instanceProperties,proto3" json:"instance_properties,omitempty"`
+	// defaults to collection_timeout_seconds
+	// timeout in seconds of each rule of the sql collection
+	SqlCollectionTimeoutSeconds int32 `protobuf:"varint,24,opt,name=sql_collection_timeout_seconds,json=sqlCollectionTimeoutSeconds,proto3" json:"sql_collection_timeout_seconds,omitempty"`
+	// defaults to collection_timeout_seconds
+	// timeout in seconds of each rule of the guest os collection
+	OsCollectionTimeoutSeconds int32 `protobuf:"varint,25,opt,name=os_collection_timeout_seconds,json=osCollectionTimeoutSeconds,proto3" json:"os_collection_timeout_seconds,omitempty"`
+	// default is false
+	// send a heartbeat with the agent version to workload manager on the guest os
+	// collection interval when both guest os and sql collection are disabled, so
+	// the agent does not appear offline
+	SendHeartbeat bool `protobuf:"varint,26,opt,name=send_heartbeat,json=sendHeartbeat,proto3" json:"send_heartbeat,omitempty"`
+	// defaults to 0
+	// maximum seconds of the random delay added to the startup delay of the
+	// service before the first collection, so that agents started at the same
+	// time do not send to workload manager at the same time
+	CollectionJitterSeconds int32 `protobuf:"varint,27,opt,name=collection_jitter_seconds,json=collectionJitterSeconds,proto3" json:"collection_jitter_seconds,omitempty"`
+	// defaults to the secure algorithms of the agent
+	// algorithms allowed in the ssh connections to remote linux machines
+	SshAlgorithms *SSHAlgorithms `protobuf:"bytes,28,opt,name=ssh_algorithms,json=sshAlgorithms,proto3" json:"ssh_algorithms,omitempty"`
+	// default is "secret_manager"
+	// store of the secrets named by secret_name and guest_secret_name, one of
+	// "secret_manager", "file", "env" or "vault"
+	SecretProvider string `protobuf:"bytes,29,opt,name=secret_provider,json=secretProvider,proto3" json:"secret_provider,omitempty"`
+	// settings of the file and vault secret providers
+	SecretProviderConfiguration *SecretProviderConfiguration `protobuf:"bytes,30,opt,name=secret_provider_configuration,json=secretProviderConfiguration,proto3" json:"secret_provider_configuration,omitempty"`
+	// default is false
+	// sends only the rules changed since they were last sent to workload manager,
+	// recorded next to the agent, and all rules every
+	// full_refresh_interval_in_seconds
+	SendChangedRulesOnly bool `protobuf:"varint,31,opt,name=send_changed_rules_only,json=sendChangedRulesOnly,proto3" json:"send_changed_rules_only,omitempty"`
+	// default is 86400
+	// interval of sending all rules when send_changed_rules_only is set
+	FullRefreshIntervalInSeconds int32 `protobuf:"varint,32,opt,name=full_refresh_interval_in_seconds,json=fullRefreshIntervalInSeconds,proto3" json:"full_refresh_interval_in_seconds,omitempty"`
+	// default is 1
+	// number of identical sql rule failures logged in detail per collection,
+	// further identical failures are summarized at the end of the collection;
+	// -1 logs all failures in detail
+	RuleFailureLogLimit int32 `protobuf:"varint,33,opt,name=rule_failure_log_limit,json=ruleFailureLogLimit,proto3" json:"rule_failure_log_limit,omitempty"`
+	// default is false
+	// runs the sql rule queries as prepared statements kept open across the
+	// collection cycles; the driver still sends the text of each query, the
+	// benefit is the connections to the instances, which stay open between the
+	// cycles instead of logging in on every cycle
+	PrepareSqlStatements bool `protobuf:"varint,34,opt,name=prepare_sql_statements,json=prepareSqlStatements,proto3" json:"prepare_sql_statements,omitempty"`
+	// default is empty
+	// names of the opt-in sql rules to collect, e.g. "DB_HEAP_TABLES"; these rules
+	// are not collected by default because they are expensive on large instances
+	EnabledSqlRules []string `protobuf:"bytes,35,rep,name=enabled_sql_rules,json=enabledSqlRules,proto3" json:"enabled_sql_rules,omitempty"`
+	// default is false
+	// adds the peak memory, goroutines and CPU time of the agent process to the
+	// heartbeat sent to workload manager
+	ReportAgentResourceUsage bool `protobuf:"varint,36,opt,name=report_agent_resource_usage,json=reportAgentResourceUsage,proto3" json:"report_agent_resource_usage,omitempty"`
+	// default is 0, no maximum
+	// maximum duration of the collection of each target; when exceeded the
+	// remaining work is cancelled and the details collected so far are sent with
+	// the cycle_truncated marker
+	MaxCycleDurationSeconds int32 `protobuf:"varint,37,opt,name=max_cycle_duration_seconds,json=maxCycleDurationSeconds,proto3" json:"max_cycle_duration_seconds,omitempty"`
+	// defaults to /var/log on linux and
+	// %ProgramData%\Google\google-cloud-sql-server-agent\logs on windows
+	// directory of the log file, overridden by flag -log-dir and environment
+	// variable GOOGLE_CLOUD_SQL_SERVER_AGENT_LOG_DIR
+	LogDirectory string `protobuf:"bytes,38,opt,name=log_directory,json=logDirectory,proto3" json:"log_directory,omitempty"`
+	// defaults to the log directory
+	// directory of the results of the onetime collections, overridden by flag
+	// -output-dir and environment variable GOOGLE_CLOUD_SQL_SERVER_AGENT_OUTPUT_DIR
+	OnetimeOutputDirectory string `protobuf:"bytes,39,opt,name=onetime_output_directory,json=onetimeOutputDirectory,proto3" json:"onetime_output_directory,omitempty"`
 }
 
 func (x *Configuration) Reset() {
@@ -156,6 +373,460 @@ func (x *Configuration) GetDisableLogUsage() bool {
 	return false
 }
 
+func (x *Configuration) GetGuestRuleConcurrency() int32 {
+	if x != nil {
+		return x.GuestRuleConcurrency
+	}
+	return 0
+}
+
+func (x *Configuration) GetMinTlsVersion() string {
+	if x != nil {
+		return x.MinTlsVersion
+	}
+	return ""
+}
+
+func (x *Configuration) GetEnableCollectionCheckpoint() bool {
+	if x != nil {
+		return x.EnableCollectionCheckpoint
+	}
+	return false
+}
+
+func (x *Configuration) GetSqlRuleConcurrency() int32 {
+	if x != nil {
+		return x.SqlRuleConcurrency
+	}
+	return 0
+}
+
+func (x *Configuration) GetWlmRequestTimeoutSeconds() int32 {
+	if x != nil {
+		return x.WlmRequestTimeoutSeconds
+	}
+	return 0
+}
+
+func (x *Configuration) GetCredentialConfigurationFile() string {
+	if x != nil {
+		return x.CredentialConfigurationFile
+	}
+	return ""
+}
+
+func (x *Configuration) GetOtelEndpoint() string {
+	if x != nil {
+		return x.OtelEndpoint
+	}
+	return ""
+}
+
+func (x *Configuration) GetStartupDelaySeconds() int32 {
+	if x != nil {
+		return x.StartupDelaySeconds
+	}
+	return 0
+}
+
+func (x *Configuration) GetClientCertPath() string {
+	if x != nil {
+		return x.ClientCertPath
+	}
+	return ""
+}
+
+func (x *Configuration) GetClientKeyPath() string {
+	if x != nil {
+		return x.ClientKeyPath
+	}
+	return ""
+}
+
+func (x *Configuration) GetHashDatabaseNames() bool {
+	if x != nil {
+		return x.HashDatabaseNames
+	}
+	return false
+}
+
+func (x *Configuration) GetSecondaryWlmLocation() string {
+	if x != nil {
+		return x.SecondaryWlmLocation
+	}
+	return ""
+}
+
+func (x *Configuration) GetEnvironment() string {
+	if x != nil {
+		return x.Environment
+	}
+	return ""
+}
+
+func (x *Configuration) GetInstanceProperties() *InstanceProperties {
+	if x != nil {
+		return x.InstanceProperties
+	}
+	return nil
+}
+
+func (x *Configuration) GetSqlCollectionTimeoutSeconds() int32 {
+	if x != nil {
+		return x.SqlCollectionTimeoutSeconds
+	}
+	return 0
+}
+
+func (x *Configuration) GetOsCollectionTimeoutSeconds() int32 {
+	if x != nil {
+		return x.OsCollectionTimeoutSeconds
+	}
+	return 0
+}
+
+func (x *Configuration) GetSendHeartbeat() bool {
+	if x != nil {
+		return x.SendHeartbeat
+	}
+	return false
+}
+
+func (x *Configuration) GetCollectionJitterSeconds() int32 {
+	if x != nil {
+		return x.CollectionJitterSeconds
+	}
+	return 0
+}
+
+func (x *Configuration) GetSshAlgorithms() *SSHAlgorithms {
+	if x != nil {
+		return x.SshAlgorithms
+	}
+	return nil
+}
+
+func (x *Configuration) GetSecretProvider() string {
+	if x != nil {
+		return x.SecretProvider
+	}
+	return ""
+}
+
+func (x *Configuration) GetSecretProviderConfiguration() *SecretProviderConfiguration {
+	if x != nil {
+		return x.SecretProviderConfiguration
+	}
+	return nil
+}
+
+func (x *Configuration) GetSendChangedRulesOnly() bool {
+	if x != nil {
+		return x.SendChangedRulesOnly
+	}
+	return false
+}
+
+func (x *Configuration) GetFullRefreshIntervalInSeconds() int32 {
+	if x != nil {
+		return x.FullRefreshIntervalInSeconds
+	}
+	return 0
+}
+
+func (x *Configuration) GetRuleFailureLogLimit() int32 {
+	if x != nil {
+		return x.RuleFailureLogLimit
+	}
+	return 0
+}
+
+func (x *Configuration) GetPrepareSqlStatements() bool {
+	if x != nil {
+		return x.PrepareSqlStatements
+	}
+	return false
+}
+
+func (x *Configuration) GetEnabledSqlRules() []string {
+	if x != nil {
+		return x.EnabledSqlRules
+	}
+	return nil
+}
+
+func (x *Configuration) GetReportAgentResourceUsage() bool {
+	if x != nil {
+		return x.ReportAgentResourceUsage
+	}
+	return false
+}
+
+func (x *Configuration) GetMaxCycleDurationSeconds() int32 {
+	if x != nil {
+		return x.MaxCycleDurationSeconds
+	}
+	return 0
+}
+
+func (x *Configuration) GetLogDirectory() string {
+	if x != nil {
+		return x.LogDirectory
+	}
+	return ""
+}
+
+func (x *Configuration) GetOnetimeOutputDirectory() string {
+	if x != nil {
+		return x.OnetimeOutputDirectory
+	}
+	return ""
+}
+
+type SecretProviderConfiguration struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// defaults to the working directory of the agent
+	// directory the secret names of the file provider are relative to
+	FileDirectory string `protobuf:"bytes,1,opt,name=file_directory,json=fileDirectory,proto3" json:"file_directory,omitempty"`
+	// address of the vault server, e.g. "https://vault.example.com:8200"
+	VaultAddress string `protobuf:"bytes,2,opt,name=vault_address,json=vaultAddress,proto3" json:"vault_address,omitempty"`
+	// default is "secret"
+	// mount path of the vault kv version 2 secrets engine
+	VaultMount string `protobuf:"bytes,3,opt,name=vault_mount,json=vaultMount,proto3" json:"vault_mount,omitempty"`
+	// default is "password"
+	// key of the secret value in the vault secrets
+	VaultKey string `protobuf:"bytes,4,opt,name=vault_key,json=vaultKey,proto3" json:"vault_key,omitempty"`
+	// defaults to the VAULT_TOKEN environment variable
+	// file holding the vault token
+	VaultTokenFile string `protobuf:"bytes,5,opt,name=vault_token_file,json=vaultTokenFile,proto3" json:"vault_token_file,omitempty"`
+}
+
+func (x *SecretProviderConfiguration) Reset() {
+	*x = SecretProviderConfiguration{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sqlserveragentconfig_sqlserveragentconfig_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SecretProviderConfiguration) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SecretProviderConfiguration) ProtoMessage() {}
+
+func (x *SecretProviderConfiguration) ProtoReflect() protoreflect.Message {
+	mi := &file_sqlserveragentconfig_sqlserveragentconfig_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SecretProviderConfiguration.ProtoReflect.Descriptor instead.
+func (*SecretProviderConfiguration) Descriptor() ([]byte, []int) {
+	return file_sqlserveragentconfig_sqlserveragentconfig_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SecretProviderConfiguration) GetFileDirectory() string {
+	if x != nil {
+		return x.FileDirectory
+	}
+	return ""
+}
+
+func (x *SecretProviderConfiguration) GetVaultAddress() string {
+	if x != nil {
+		return x.VaultAddress
+	}
+	return ""
+}
+
+func (x *SecretProviderConfiguration) GetVaultMount() string {
+	if x != nil {
+		return x.VaultMount
+	}
+	return ""
+}
+
+func (x *SecretProviderConfiguration) GetVaultKey() string {
+	if x != nil {
+		return x.VaultKey
+	}
+	return ""
+}
+
+func (x *SecretProviderConfiguration) GetVaultTokenFile() string {
+	if x != nil {
+		return x.VaultTokenFile
+	}
+	return ""
+}
+
+type SSHAlgorithms struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// defaults to curve25519, ecdh and diffie-hellman group14 and group16 with
+	// sha2
+	// allowed key exchange algorithms in preference order
+	KeyExchanges []string `protobuf:"bytes,1,rep,name=key_exchanges,json=keyExchanges,proto3" json:"key_exchanges,omitempty"`
+	// defaults to aes gcm, chacha20-poly1305 and aes ctr
+	// allowed cipher algorithms in preference order
+	Ciphers []string `protobuf:"bytes,2,rep,name=ciphers,proto3" json:"ciphers,omitempty"`
+	// defaults to hmac-sha2-256 and hmac-sha2-512, with and without etm
+	// allowed MAC algorithms in preference order
+	Macs []string `protobuf:"bytes,3,rep,name=macs,proto3" json:"macs,omitempty"`
+}
+
+func (x *SSHAlgorithms) Reset() {
+	*x = SSHAlgorithms{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sqlserveragentconfig_sqlserveragentconfig_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SSHAlgorithms) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SSHAlgorithms) ProtoMessage() {}
+
+func (x *SSHAlgorithms) ProtoReflect() protoreflect.Message {
+	mi := &file_sqlserveragentconfig_sqlserveragentconfig_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SSHAlgorithms.ProtoReflect.Descriptor instead.
+func (*SSHAlgorithms) Descriptor() ([]byte, []int) {
+	return file_sqlserveragentconfig_sqlserveragentconfig_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *SSHAlgorithms) GetKeyExchanges() []string {
+	if x != nil {
+		return x.KeyExchanges
+	}
+	return nil
+}
+
+func (x *SSHAlgorithms) GetCiphers() []string {
+	if x != nil {
+		return x.Ciphers
+	}
+	return nil
+}
+
+func (x *SSHAlgorithms) GetMacs() []string {
+	if x != nil {
+		return x.Macs
+	}
+	return nil
+}
+
+type InstanceProperties struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// project the collected data is reported to
+	ProjectId string `protobuf:"bytes,1,opt,name=project_id,json=projectId,proto3" json:"project_id,omitempty"`
+	// number of project_id
+	ProjectNumber string `protobuf:"bytes,2,opt,name=project_number,json=projectNumber,proto3" json:"project_number,omitempty"`
+	// zone the host is reported in, e.g. "us-central1-a". the collected data is
+	// sent to its region
+	Zone string `protobuf:"bytes,3,opt,name=zone,proto3" json:"zone,omitempty"`
+	// name of the host
+	InstanceName string `protobuf:"bytes,4,opt,name=instance_name,json=instanceName,proto3" json:"instance_name,omitempty"`
+	// defaults to instance_name
+	// unique id of the host
+	InstanceId string `protobuf:"bytes,5,opt,name=instance_id,json=instanceId,proto3" json:"instance_id,omitempty"`
+}
+
+func (x *InstanceProperties) Reset() {
+	*x = InstanceProperties{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sqlserveragentconfig_sqlserveragentconfig_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *InstanceProperties) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InstanceProperties) ProtoMessage() {}
+
+func (x *InstanceProperties) ProtoReflect() protoreflect.Message {
+	mi := &file_sqlserveragentconfig_sqlserveragentconfig_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InstanceProperties.ProtoReflect.Descriptor instead.
+func (*InstanceProperties) Descriptor() ([]byte, []int) {
+	return file_sqlserveragentconfig_sqlserveragentconfig_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *InstanceProperties) GetProjectId() string {
+	if x != nil {
+		return x.ProjectId
+	}
+	return ""
+}
+
+func (x *InstanceProperties) GetProjectNumber() string {
+	if x != nil {
+		return x.ProjectNumber
+	}
+	return ""
+}
+
+func (x *InstanceProperties) GetZone() string {
+	if x != nil {
+		return x.Zone
+	}
+	return ""
+}
+
+func (x *InstanceProperties) GetInstanceName() string {
+	if x != nil {
+		return x.InstanceName
+	}
+	return ""
+}
+
+func (x *InstanceProperties) GetInstanceId() string {
+	if x != nil {
+		return x.InstanceId
+	}
+	return ""
+}
+
 type CollectionConfiguration struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -173,12 +844,16 @@ type CollectionConfiguration struct {
 	// defaults to 3600 (1 hour)
 	// SQL Server metrics collection interval
 	SqlMetricsCollectionIntervalInSeconds int32 `protobuf:"varint,4,opt,name=sql_metrics_collection_interval_in_seconds,json=sqlMetricsCollectionIntervalInSeconds,proto3" json:"sql_metrics_collection_interval_in_seconds,omitempty"`
+	// defaults to True
+	// maps the database files to the physical drives they are stored on, skip it
+	// to speed up SQL Server collection of instances with many database files
+	CollectPhysicalDriveMapping *bool `protobuf:"varint,5,opt,name=collect_physical_drive_mapping,json=collectPhysicalDriveMapping,proto3,oneof" json:"collect_physical_drive_mapping,omitempty"`
 }
 
 func (x *CollectionConfiguration) Reset() {
 	*x = CollectionConfiguration{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_sqlserveragentconfig_sqlserveragentconfig_proto_msgTypes[1]
+		mi := &file_sqlserveragentconfig_sqlserveragentconfig_proto_msgTypes[4]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -191,7 +866,7 @@ func (x *CollectionConfiguration) String() string {
 func (*CollectionConfiguration) ProtoMessage() {}
 
 func (x *CollectionConfiguration) ProtoReflect() protoreflect.Message {
-	mi := &file_sqlserveragentconfig_sqlserveragentconfig_proto_msgTypes[1]
+	mi := &file_sqlserveragentconfig_sqlserveragentconfig_proto_msgTypes[4]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -204,7 +879,7 @@ func (x *CollectionConfiguration) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CollectionConfiguration.ProtoReflect.Descriptor instead.
 func (*CollectionConfiguration) Descriptor() ([]byte, []int) {
-	return file_sqlserveragentconfig_sqlserveragentconfig_proto_rawDescGZIP(), []int{1}
+	return file_sqlserveragentconfig_sqlserveragentconfig_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *CollectionConfiguration) GetCollectGuestOsMetrics() bool {
@@ -235,6 +910,13 @@ func (x *CollectionConfiguration) GetSqlMetricsCollectionIntervalInSeconds() int
 	return 0
 }
 
+func (x *CollectionConfiguration) GetCollectPhysicalDriveMapping() bool {
+	if x != nil && x.CollectPhysicalDriveMapping != nil {
+		return *x.CollectPhysicalDriveMapping
+	}
+	return false
+}
+
 type CredentialConfiguration struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -297,7 +979,7 @@ type CredentialConfiguration struct {
 func (x *CredentialConfiguration) Reset() {
 	*x = CredentialConfiguration{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_sqlserveragentconfig_sqlserveragentconfig_proto_msgTypes[2]
+		mi := &file_sqlserveragentconfig_sqlserveragentconfig_proto_msgTypes[5]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -310,7 +992,7 @@ func (x *CredentialConfiguration) String() string {
 func (*CredentialConfiguration) ProtoMessage() {}
 
 func (x *CredentialConfiguration) ProtoReflect() protoreflect.Message {
-	mi := &file_sqlserveragentconfig_sqlserveragentconfig_proto_msgTypes[2]
+	mi := &file_sqlserveragentconfig_sqlserveragentconfig_proto_msgTypes[5]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -323,7 +1005,7 @@ func (x *CredentialConfiguration) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CredentialConfiguration.ProtoReflect.Descriptor instead.
 func (*CredentialConfiguration) Descriptor() ([]byte, []int) {
-	return file_sqlserveragentconfig_sqlserveragentconfig_proto_rawDescGZIP(), []int{2}
+	return file_sqlserveragentconfig_sqlserveragentconfig_proto_rawDescGZIP(), []int{5}
 }
 
 // Deprecated: Marked as deprecated in sqlserveragentconfig/sqlserveragentconfig.proto.
@@ -490,12 +1172,45 @@ type CredentialConfiguration_SqlCredentials struct {
 	SecretName string `protobuf:"bytes,3,opt,name=secret_name,json=secretName,proto3" json:"secret_name,omitempty"`
 	// defaults to 1433
 	PortNumber int32 `protobuf:"varint,4,opt,name=port_number,json=portNumber,proto3" json:"port_number,omitempty"`
+	// expected host name in the server certificate, used when it differs from
+	// host, e.g. when connecting through a load balancer
+	HostNameInCertificate string `protobuf:"bytes,5,opt,name=host_name_in_certificate,json=hostNameInCertificate,proto3" json:"host_name_in_certificate,omitempty"`
+	// virtual network name of a failover cluster instance; when set it is used
+	// instead of host for the connection and the reported host_name
+	ClusterNetworkName string `protobuf:"bytes,6,opt,name=cluster_network_name,json=clusterNetworkName,proto3" json:"cluster_network_name,omitempty"`
+	// additional go-mssqldb connection string parameters appended to the
+	// generated connection string, e.g. "packet size=8192;keepalive=30;".
+	// must not specify the password. the agent connects to the master database
+	// unless a database is specified here.
+	ExtraConnectionParams string `protobuf:"bytes,7,opt,name=extra_connection_params,json=extraConnectionParams,proto3" json:"extra_connection_params,omitempty"`
+	// defaults to the project of the agent
+	// project of the secret_name secret in secrets manager
+	SecretProjectId string `protobuf:"bytes,8,opt,name=secret_project_id,json=secretProjectId,proto3" json:"secret_project_id,omitempty"`
+	// defaults to PASSWORD
+	// how the agent authenticates to SQL Server
+	AuthMode CredentialConfiguration_SqlCredentials_AuthMode `protobuf:"varint,9,opt,name=auth_mode,json=authMode,proto3,enum=sqlserveragentconfig.CredentialConfiguration_SqlCredentials_AuthMode" json:"auth_mode,omitempty"`
+	// defaults to the access token of the service account of the instance,
+	// read from the metadata server
+	// path of a file holding the access token used in ACCESS_TOKEN mode. it is
+	// read before each collection so it can be refreshed by another process.
+	AccessTokenFile string `protobuf:"bytes,10,opt,name=access_token_file,json=accessTokenFile,proto3" json:"access_token_file,omitempty"`
+	// full path of the named pipe SQL Server listens on, e.g.
+	// "\\\\host\\pipe\\MSSQL$INSTANCE\\sql\\query" in configuration.json. when set
+	// the agent connects over the named pipe instead of tcp and port_number is
+	// not used. only supported by the windows agent.
+	NamedPipe string `protobuf:"bytes,11,opt,name=named_pipe,json=namedPipe,proto3" json:"named_pipe,omitempty"`
+	// server name indication of the instance when several instances share a
+	// listener which routes the connections by SNI. it is sent in the TLS
+	// handshake and used as the host name in the certificate and the server SPN.
+	// must not be set with host_name_in_certificate or named_pipe, or with
+	// encrypt=disable in extra_connection_params.
+	ServerSni string `protobuf:"bytes,12,opt,name=server_sni,json=serverSni,proto3" json:"server_sni,omitempty"`
 }
 
 func (x *CredentialConfiguration_SqlCredentials) Reset() {
 	*x = CredentialConfiguration_SqlCredentials{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_sqlserveragentconfig_sqlserveragentconfig_proto_msgTypes[3]
+		mi := &file_sqlserveragentconfig_sqlserveragentconfig_proto_msgTypes[6]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -508,7 +1223,7 @@ func (x *CredentialConfiguration_SqlCredentials) String() string {
 func (*CredentialConfiguration_SqlCredentials) ProtoMessage() {}
 
 func (x *CredentialConfiguration_SqlCredentials) ProtoReflect() protoreflect.Message {
-	mi := &file_sqlserveragentconfig_sqlserveragentconfig_proto_msgTypes[3]
+	mi := &file_sqlserveragentconfig_sqlserveragentconfig_proto_msgTypes[6]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -521,7 +1236,7 @@ func (x *CredentialConfiguration_SqlCredentials) ProtoReflect() protoreflect.Mes
 
 // Deprecated: Use CredentialConfiguration_SqlCredentials.ProtoReflect.Descriptor instead.
 func (*CredentialConfiguration_SqlCredentials) Descriptor() ([]byte, []int) {
-	return file_sqlserveragentconfig_sqlserveragentconfig_proto_rawDescGZIP(), []int{2, 0}
+	return file_sqlserveragentconfig_sqlserveragentconfig_proto_rawDescGZIP(), []int{5, 0}
 }
 
 func (x *CredentialConfiguration_SqlCredentials) GetHost() string {
@@ -552,6 +1267,62 @@ func (x *CredentialConfiguration_SqlCredentials) GetPortNumber() int32 {
 	return 0
 }
 
+func (x *CredentialConfiguration_SqlCredentials) GetHostNameInCertificate() string {
+	if x != nil {
+		return x.HostNameInCertificate
+	}
+	return ""
+}
+
+func (x *CredentialConfiguration_SqlCredentials) GetClusterNetworkName() string {
+	if x != nil {
+		return x.ClusterNetworkName
+	}
+	return ""
+}
+
+func (x *CredentialConfiguration_SqlCredentials) GetExtraConnectionParams() string {
+	if x != nil {
+		return x.ExtraConnectionParams
+	}
+	return ""
+}
+
+func (x *CredentialConfiguration_SqlCredentials) GetSecretProjectId() string {
+	if x != nil {
+		return x.SecretProjectId
+	}
+	return ""
+}
+
+func (x *CredentialConfiguration_SqlCredentials) GetAuthMode() CredentialConfiguration_SqlCredentials_AuthMode {
+	if x != nil {
+		return x.AuthMode
+	}
+	return CredentialConfiguration_SqlCredentials_PASSWORD
+}
+
+func (x *CredentialConfiguration_SqlCredentials) GetAccessTokenFile() string {
+	if x != nil {
+		return x.AccessTokenFile
+	}
+	return ""
+}
+
+func (x *CredentialConfiguration_SqlCredentials) GetNamedPipe() string {
+	if x != nil {
+		return x.NamedPipe
+	}
+	return ""
+}
+
+func (x *CredentialConfiguration_SqlCredentials) GetServerSni() string {
+	if x != nil {
+		return x.ServerSni
+	}
+	return ""
+}
+
 type CredentialConfiguration_GuestCredentialsRemoteWin struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -563,12 +1334,18 @@ type CredentialConfiguration_GuestCredentialsRemoteWin struct {
 	GuestUserName string `protobuf:"bytes,2,opt,name=guest_user_name,json=guestUserName,proto3" json:"guest_user_name,omitempty"`
 	// credential secret name stored in secrets manager
 	GuestSecretName string `protobuf:"bytes,3,opt,name=guest_secret_name,json=guestSecretName,proto3" json:"guest_secret_name,omitempty"`
+	// defaults to WMI
+	// transport used to run the guest collection queries on the server
+	Transport CredentialConfiguration_GuestCredentialsRemoteWin_Transport `protobuf:"varint,4,opt,name=transport,proto3,enum=sqlserveragentconfig.CredentialConfiguration_GuestCredentialsRemoteWin_Transport" json:"transport,omitempty"`
+	// defaults to the project of the agent
+	// project of the guest_secret_name secret in secrets manager
+	GuestSecretProjectId string `protobuf:"bytes,5,opt,name=guest_secret_project_id,json=guestSecretProjectId,proto3" json:"guest_secret_project_id,omitempty"`
 }
 
 func (x *CredentialConfiguration_GuestCredentialsRemoteWin) Reset() {
 	*x = CredentialConfiguration_GuestCredentialsRemoteWin{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_sqlserveragentconfig_sqlserveragentconfig_proto_msgTypes[4]
+		mi := &file_sqlserveragentconfig_sqlserveragentconfig_proto_msgTypes[7]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -581,7 +1358,7 @@ func (x *CredentialConfiguration_GuestCredentialsRemoteWin) String() string {
 func (*CredentialConfiguration_GuestCredentialsRemoteWin) ProtoMessage() {}
 
 func (x *CredentialConfiguration_GuestCredentialsRemoteWin) ProtoReflect() protoreflect.Message {
-	mi := &file_sqlserveragentconfig_sqlserveragentconfig_proto_msgTypes[4]
+	mi := &file_sqlserveragentconfig_sqlserveragentconfig_proto_msgTypes[7]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -594,7 +1371,7 @@ func (x *CredentialConfiguration_GuestCredentialsRemoteWin) ProtoReflect() proto
 
 // Deprecated: Use CredentialConfiguration_GuestCredentialsRemoteWin.ProtoReflect.Descriptor instead.
 func (*CredentialConfiguration_GuestCredentialsRemoteWin) Descriptor() ([]byte, []int) {
-	return file_sqlserveragentconfig_sqlserveragentconfig_proto_rawDescGZIP(), []int{2, 1}
+	return file_sqlserveragentconfig_sqlserveragentconfig_proto_rawDescGZIP(), []int{5, 1}
 }
 
 func (x *CredentialConfiguration_GuestCredentialsRemoteWin) GetServerName() string {
@@ -618,6 +1395,20 @@ func (x *CredentialConfiguration_GuestCredentialsRemoteWin) GetGuestSecretName()
 	return ""
 }
 
+func (x *CredentialConfiguration_GuestCredentialsRemoteWin) GetTransport() CredentialConfiguration_GuestCredentialsRemoteWin_Transport {
+	if x != nil {
+		return x.Transport
+	}
+	return CredentialConfiguration_GuestCredentialsRemoteWin_WMI
+}
+
+func (x *CredentialConfiguration_GuestCredentialsRemoteWin) GetGuestSecretProjectId() string {
+	if x != nil {
+		return x.GuestSecretProjectId
+	}
+	return ""
+}
+
 type CredentialConfiguration_GuestCredentialsRemoteLinux struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -631,12 +1422,16 @@ type CredentialConfiguration_GuestCredentialsRemoteLinux struct {
 	GuestPortNumber int32 `protobuf:"varint,3,opt,name=guest_port_number,json=guestPortNumber,proto3" json:"guest_port_number,omitempty"`
 	// private key for linux remote collection
 	LinuxSshPrivateKeyPath string `protobuf:"bytes,4,opt,name=linux_ssh_private_key_path,json=linuxSshPrivateKeyPath,proto3" json:"linux_ssh_private_key_path,omitempty"`
+	// defaults to the key of the server in the known_hosts file next to linux_ssh_private_key_path
+	// SHA256 fingerprints of the accepted host keys of the server, as printed by ssh-keygen -lf,
+	// e.g. "SHA256:nThbg6kXUpJWGl7E1IGOCspRomTxdCARLviKw6E5SY8"
+	HostKeyFingerprints []string `protobuf:"bytes,5,rep,name=host_key_fingerprints,json=hostKeyFingerprints,proto3" json:"host_key_fingerprints,omitempty"`
 }
 
 func (x *CredentialConfiguration_GuestCredentialsRemoteLinux) Reset() {
 	*x = CredentialConfiguration_GuestCredentialsRemoteLinux{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_sqlserveragentconfig_sqlserveragentconfig_proto_msgTypes[5]
+		mi := &file_sqlserveragentconfig_sqlserveragentconfig_proto_msgTypes[8]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -649,7 +1444,7 @@ func (x *CredentialConfiguration_GuestCredentialsRemoteLinux) String() string {
 func (*CredentialConfiguration_GuestCredentialsRemoteLinux) ProtoMessage() {}
 
 func (x *CredentialConfiguration_GuestCredentialsRemoteLinux) ProtoReflect() protoreflect.Message {
-	mi := &file_sqlserveragentconfig_sqlserveragentconfig_proto_msgTypes[5]
+	mi := &file_sqlserveragentconfig_sqlserveragentconfig_proto_msgTypes[8]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -662,7 +1457,7 @@ func (x *CredentialConfiguration_GuestCredentialsRemoteLinux) ProtoReflect() pro
 
 // Deprecated: Use CredentialConfiguration_GuestCredentialsRemoteLinux.ProtoReflect.Descriptor instead.
 func (*CredentialConfiguration_GuestCredentialsRemoteLinux) Descriptor() ([]byte, []int) {
-	return file_sqlserveragentconfig_sqlserveragentconfig_proto_rawDescGZIP(), []int{2, 2}
+	return file_sqlserveragentconfig_sqlserveragentconfig_proto_rawDescGZIP(), []int{5, 2}
 }
 
 func (x *CredentialConfiguration_GuestCredentialsRemoteLinux) GetServerName() string {
@@ -693,6 +1488,13 @@ func (x *CredentialConfiguration_GuestCredentialsRemoteLinux) GetLinuxSshPrivate
 	return ""
 }
 
+func (x *CredentialConfiguration_GuestCredentialsRemoteLinux) GetHostKeyFingerprints() []string {
+	if x != nil {
+		return x.HostKeyFingerprints
+	}
+	return nil
+}
+
 var File_sqlserveragentconfig_sqlserveragentconfig_proto protoreflect.FileDescriptor
 
 var file_sqlserveragentconfig_sqlserveragentconfig_proto_rawDesc = []byte{
@@ -700,7 +1502,7 @@ var file_sqlserveragentconfig_sqlserveragentconfig_proto_rawDesc = []byte{
 	0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2f, 0x73, 0x71, 0x6c, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72,
 	0x61, 0x67, 0x65, 0x6e, 0x74, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x70, 0x72, 0x6f, 0x74,
 	0x6f, 0x12, 0x14, 0x73, 0x71, 0x6c, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x61, 0x67, 0x65, 0x6e,
-	0x74, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x22, 0x95, 0x04, 0x0a, 0x0d, 0x43, 0x6f, 0x6e, 0x66,
+	0x74, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x22, 0xc0, 0x11, 0x0a, 0x0d, 0x43, 0x6f, 0x6e, 0x66,
 	0x69, 0x67, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x68, 0x0a, 0x18, 0x63, 0x6f, 0x6c,
 	0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x75, 0x72,
 	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x2d, 0x2e, 0x73, 0x71,
@@ -733,91 +1535,264 @@ var file_sqlserveragentconfig_sqlserveragentconfig_proto_rawDesc = []byte{
 	0x64, 0x18, 0x08, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0a, 0x6c, 0x6f, 0x67, 0x54, 0x6f, 0x43, 0x6c,
 	0x6f, 0x75, 0x64, 0x12, 0x2a, 0x0a, 0x11, 0x64, 0x69, 0x73, 0x61, 0x62, 0x6c, 0x65, 0x5f, 0x6c,
 	0x6f, 0x67, 0x5f, 0x75, 0x73, 0x61, 0x67, 0x65, 0x18, 0x09, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0f,
-	0x64, 0x69, 0x73, 0x61, 0x62, 0x6c, 0x65, 0x4c, 0x6f, 0x67, 0x55, 0x73, 0x61, 0x67, 0x65, 0x22,
-	0xc1, 0x02, 0x0a, 0x17, 0x43, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x43, 0x6f,
-	0x6e, 0x66, 0x69, 0x67, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x37, 0x0a, 0x18, 0x63,
-	0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x5f, 0x67, 0x75, 0x65, 0x73, 0x74, 0x5f, 0x6f, 0x73, 0x5f,
-	0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x15, 0x63,
-	0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x47, 0x75, 0x65, 0x73, 0x74, 0x4f, 0x73, 0x4d, 0x65, 0x74,
-	0x72, 0x69, 0x63, 0x73, 0x12, 0x62, 0x0a, 0x2f, 0x67, 0x75, 0x65, 0x73, 0x74, 0x5f, 0x6f, 0x73,
-	0x5f, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x5f, 0x63, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74,
-	0x69, 0x6f, 0x6e, 0x5f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x5f, 0x69, 0x6e, 0x5f,
-	0x73, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x29, 0x67,
-	0x75, 0x65, 0x73, 0x74, 0x4f, 0x73, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x43, 0x6f, 0x6c,
-	0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x6e, 0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x49,
-	0x6e, 0x53, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x12, 0x2e, 0x0a, 0x13, 0x63, 0x6f, 0x6c, 0x6c,
-	0x65, 0x63, 0x74, 0x5f, 0x73, 0x71, 0x6c, 0x5f, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x18,
-	0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x11, 0x63, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x53, 0x71,
-	0x6c, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x12, 0x59, 0x0a, 0x2a, 0x73, 0x71, 0x6c, 0x5f,
-	0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x5f, 0x63, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69,
-	0x6f, 0x6e, 0x5f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x5f, 0x69, 0x6e, 0x5f, 0x73,
-	0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x25, 0x73, 0x71,
-	0x6c, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x43, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69,
-	0x6f, 0x6e, 0x49, 0x6e, 0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x49, 0x6e, 0x53, 0x65, 0x63, 0x6f,
-	0x6e, 0x64, 0x73, 0x22, 0xf0, 0x0a, 0x0a, 0x17, 0x43, 0x72, 0x65, 0x64, 0x65, 0x6e, 0x74, 0x69,
-	0x61, 0x6c, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12,
-	0x16, 0x0a, 0x04, 0x68, 0x6f, 0x73, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x42, 0x02, 0x18,
-	0x01, 0x52, 0x04, 0x68, 0x6f, 0x73, 0x74, 0x12, 0x1f, 0x0a, 0x09, 0x75, 0x73, 0x65, 0x72, 0x5f,
-	0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x42, 0x02, 0x18, 0x01, 0x52, 0x08,
-	0x75, 0x73, 0x65, 0x72, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x23, 0x0a, 0x0b, 0x73, 0x65, 0x63, 0x72,
-	0x65, 0x74, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x42, 0x02, 0x18,
-	0x01, 0x52, 0x0a, 0x73, 0x65, 0x63, 0x72, 0x65, 0x74, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x23, 0x0a,
-	0x0b, 0x70, 0x6f, 0x72, 0x74, 0x5f, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x18, 0x04, 0x20, 0x01,
-	0x28, 0x05, 0x42, 0x02, 0x18, 0x01, 0x52, 0x0a, 0x70, 0x6f, 0x72, 0x74, 0x4e, 0x75, 0x6d, 0x62,
-	0x65, 0x72, 0x12, 0x23, 0x0a, 0x0b, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x5f, 0x6e, 0x61, 0x6d,
-	0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x42, 0x02, 0x18, 0x01, 0x52, 0x0a, 0x73, 0x65, 0x72,
-	0x76, 0x65, 0x72, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x2a, 0x0a, 0x0f, 0x67, 0x75, 0x65, 0x73, 0x74,
-	0x5f, 0x75, 0x73, 0x65, 0x72, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09,
-	0x42, 0x02, 0x18, 0x01, 0x52, 0x0d, 0x67, 0x75, 0x65, 0x73, 0x74, 0x55, 0x73, 0x65, 0x72, 0x4e,
-	0x61, 0x6d, 0x65, 0x12, 0x2e, 0x0a, 0x11, 0x67, 0x75, 0x65, 0x73, 0x74, 0x5f, 0x73, 0x65, 0x63,
-	0x72, 0x65, 0x74, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x42, 0x02,
-	0x18, 0x01, 0x52, 0x0f, 0x67, 0x75, 0x65, 0x73, 0x74, 0x53, 0x65, 0x63, 0x72, 0x65, 0x74, 0x4e,
-	0x61, 0x6d, 0x65, 0x12, 0x23, 0x0a, 0x0d, 0x69, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x5f,
-	0x6e, 0x61, 0x6d, 0x65, 0x18, 0x08, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x69, 0x6e, 0x73, 0x74,
+	0x64, 0x69, 0x73, 0x61, 0x62, 0x6c, 0x65, 0x4c, 0x6f, 0x67, 0x55, 0x73, 0x61, 0x67, 0x65, 0x12,
+	0x34, 0x0a, 0x16, 0x67, 0x75, 0x65, 0x73, 0x74, 0x5f, 0x72, 0x75, 0x6c, 0x65, 0x5f, 0x63, 0x6f,
+	0x6e, 0x63, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x63, 0x79, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x05, 0x52,
+	0x14, 0x67, 0x75, 0x65, 0x73, 0x74, 0x52, 0x75, 0x6c, 0x65, 0x43, 0x6f, 0x6e, 0x63, 0x75, 0x72,
+	0x72, 0x65, 0x6e, 0x63, 0x79, 0x12, 0x26, 0x0a, 0x0f, 0x6d, 0x69, 0x6e, 0x5f, 0x74, 0x6c, 0x73,
+	0x5f, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d,
+	0x6d, 0x69, 0x6e, 0x54, 0x6c, 0x73, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x40, 0x0a,
+	0x1c, 0x65, 0x6e, 0x61, 0x62, 0x6c, 0x65, 0x5f, 0x63, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69,
+	0x6f, 0x6e, 0x5f, 0x63, 0x68, 0x65, 0x63, 0x6b, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x18, 0x0c, 0x20,
+	0x01, 0x28, 0x08, 0x52, 0x1a, 0x65, 0x6e, 0x61, 0x62, 0x6c, 0x65, 0x43, 0x6f, 0x6c, 0x6c, 0x65,
+	0x63, 0x74, 0x69, 0x6f, 0x6e, 0x43, 0x68, 0x65, 0x63, 0x6b, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x12,
+	0x30, 0x0a, 0x14, 0x73, 0x71, 0x6c, 0x5f, 0x72, 0x75, 0x6c, 0x65, 0x5f, 0x63, 0x6f, 0x6e, 0x63,
+	0x75, 0x72, 0x72, 0x65, 0x6e, 0x63, 0x79, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x05, 0x52, 0x12, 0x73,
+	0x71, 0x6c, 0x52, 0x75, 0x6c, 0x65, 0x43, 0x6f, 0x6e, 0x63, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x63,
+	0x79, 0x12, 0x3d, 0x0a, 0x1b, 0x77, 0x6c, 0x6d, 0x5f, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x5f, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x5f, 0x73, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73,
+	0x18, 0x0e, 0x20, 0x01, 0x28, 0x05, 0x52, 0x18, 0x77, 0x6c, 0x6d, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x54, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x53, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73,
+	0x12, 0x42, 0x0a, 0x1d, 0x63, 0x72, 0x65, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x61, 0x6c, 0x5f, 0x63,
+	0x6f, 0x6e, 0x66, 0x69, 0x67, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x66, 0x69, 0x6c,
+	0x65, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x09, 0x52, 0x1b, 0x63, 0x72, 0x65, 0x64, 0x65, 0x6e, 0x74,
+	0x69, 0x61, 0x6c, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x46, 0x69, 0x6c, 0x65, 0x12, 0x23, 0x0a, 0x0d, 0x6f, 0x74, 0x65, 0x6c, 0x5f, 0x65, 0x6e, 0x64,
+	0x70, 0x6f, 0x69, 0x6e, 0x74, 0x18, 0x10, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x6f, 0x74, 0x65,
+	0x6c, 0x45, 0x6e, 0x64, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x12, 0x32, 0x0a, 0x15, 0x73, 0x74, 0x61,
+	0x72, 0x74, 0x75, 0x70, 0x5f, 0x64, 0x65, 0x6c, 0x61, 0x79, 0x5f, 0x73, 0x65, 0x63, 0x6f, 0x6e,
+	0x64, 0x73, 0x18, 0x11, 0x20, 0x01, 0x28, 0x05, 0x52, 0x13, 0x73, 0x74, 0x61, 0x72, 0x74, 0x75,
+	0x70, 0x44, 0x65, 0x6c, 0x61, 0x79, 0x53, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x12, 0x28, 0x0a,
+	0x10, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x5f, 0x63, 0x65, 0x72, 0x74, 0x5f, 0x70, 0x61, 0x74,
+	0x68, 0x18, 0x12, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x43,
+	0x65, 0x72, 0x74, 0x50, 0x61, 0x74, 0x68, 0x12, 0x26, 0x0a, 0x0f, 0x63, 0x6c, 0x69, 0x65, 0x6e,
+	0x74, 0x5f, 0x6b, 0x65, 0x79, 0x5f, 0x70, 0x61, 0x74, 0x68, 0x18, 0x13, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x0d, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x4b, 0x65, 0x79, 0x50, 0x61, 0x74, 0x68, 0x12,
+	0x2e, 0x0a, 0x13, 0x68, 0x61, 0x73, 0x68, 0x5f, 0x64, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65,
+	0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x73, 0x18, 0x14, 0x20, 0x01, 0x28, 0x08, 0x52, 0x11, 0x68, 0x61,
+	0x73, 0x68, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x12,
+	0x34, 0x0a, 0x16, 0x73, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x61, 0x72, 0x79, 0x5f, 0x77, 0x6c, 0x6d,
+	0x5f, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x15, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x14, 0x73, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x61, 0x72, 0x79, 0x57, 0x6c, 0x6d, 0x4c, 0x6f, 0x63,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x20, 0x0a, 0x0b, 0x65, 0x6e, 0x76, 0x69, 0x72, 0x6f, 0x6e,
+	0x6d, 0x65, 0x6e, 0x74, 0x18, 0x16, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x65, 0x6e, 0x76, 0x69,
+	0x72, 0x6f, 0x6e, 0x6d, 0x65, 0x6e, 0x74, 0x12, 0x59, 0x0a, 0x13, 0x69, 0x6e, 0x73, 0x74, 0x61,
+	0x6e, 0x63, 0x65, 0x5f, 0x70, 0x72, 0x6f, 0x70, 0x65, 0x72, 0x74, 0x69, 0x65, 0x73, 0x18, 0x17,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x28, 0x2e, 0x73, 0x71, 0x6c, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72,
+	0x61, 0x67, 0x65, 0x6e, 0x74, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x49, 0x6e, 0x73, 0x74,
+	0x61, 0x6e, 0x63, 0x65, 0x50, 0x72, 0x6f, 0x70, 0x65, 0x72, 0x74, 0x69, 0x65, 0x73, 0x52, 0x12,
+	0x69, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x50, 0x72, 0x6f, 0x70, 0x65, 0x72, 0x74, 0x69,
+	0x65, 0x73, 0x12, 0x43, 0x0a, 0x1e, 0x73, 0x71, 0x6c, 0x5f, 0x63, 0x6f, 0x6c, 0x6c, 0x65, 0x63,
+	0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x5f, 0x73, 0x65, 0x63,
+	0x6f, 0x6e, 0x64, 0x73, 0x18, 0x18, 0x20, 0x01, 0x28, 0x05, 0x52, 0x1b, 0x73, 0x71, 0x6c, 0x43,
+	0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x54, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74,
+	0x53, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x12, 0x41, 0x0a, 0x1d, 0x6f, 0x73, 0x5f, 0x63, 0x6f,
+	0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74,
+	0x5f, 0x73, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x18, 0x19, 0x20, 0x01, 0x28, 0x05, 0x52, 0x1a,
+	0x6f, 0x73, 0x43, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x54, 0x69, 0x6d, 0x65,
+	0x6f, 0x75, 0x74, 0x53, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x12, 0x25, 0x0a, 0x0e, 0x73, 0x65,
+	0x6e, 0x64, 0x5f, 0x68, 0x65, 0x61, 0x72, 0x74, 0x62, 0x65, 0x61, 0x74, 0x18, 0x1a, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x0d, 0x73, 0x65, 0x6e, 0x64, 0x48, 0x65, 0x61, 0x72, 0x74, 0x62, 0x65, 0x61,
+	0x74, 0x12, 0x3a, 0x0a, 0x19, 0x63, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x5f,
+	0x6a, 0x69, 0x74, 0x74, 0x65, 0x72, 0x5f, 0x73, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x18, 0x1b,
+	0x20, 0x01, 0x28, 0x05, 0x52, 0x17, 0x63, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e,
+	0x4a, 0x69, 0x74, 0x74, 0x65, 0x72, 0x53, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x12, 0x4a, 0x0a,
+	0x0e, 0x73, 0x73, 0x68, 0x5f, 0x61, 0x6c, 0x67, 0x6f, 0x72, 0x69, 0x74, 0x68, 0x6d, 0x73, 0x18,
+	0x1c, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x23, 0x2e, 0x73, 0x71, 0x6c, 0x73, 0x65, 0x72, 0x76, 0x65,
+	0x72, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x53, 0x53, 0x48,
+	0x41, 0x6c, 0x67, 0x6f, 0x72, 0x69, 0x74, 0x68, 0x6d, 0x73, 0x52, 0x0d, 0x73, 0x73, 0x68, 0x41,
+	0x6c, 0x67, 0x6f, 0x72, 0x69, 0x74, 0x68, 0x6d, 0x73, 0x12, 0x27, 0x0a, 0x0f, 0x73, 0x65, 0x63,
+	0x72, 0x65, 0x74, 0x5f, 0x70, 0x72, 0x6f, 0x76, 0x69, 0x64, 0x65, 0x72, 0x18, 0x1d, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x0e, 0x73, 0x65, 0x63, 0x72, 0x65, 0x74, 0x50, 0x72, 0x6f, 0x76, 0x69, 0x64,
+	0x65, 0x72, 0x12, 0x75, 0x0a, 0x1d, 0x73, 0x65, 0x63, 0x72, 0x65, 0x74, 0x5f, 0x70, 0x72, 0x6f,
+	0x76, 0x69, 0x64, 0x65, 0x72, 0x5f, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x75, 0x72, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x18, 0x1e, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x31, 0x2e, 0x73, 0x71, 0x6c, 0x73,
+	0x65, 0x72, 0x76, 0x65, 0x72, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67,
+	0x2e, 0x53, 0x65, 0x63, 0x72, 0x65, 0x74, 0x50, 0x72, 0x6f, 0x76, 0x69, 0x64, 0x65, 0x72, 0x43,
+	0x6f, 0x6e, 0x66, 0x69, 0x67, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x1b, 0x73, 0x65,
+	0x63, 0x72, 0x65, 0x74, 0x50, 0x72, 0x6f, 0x76, 0x69, 0x64, 0x65, 0x72, 0x43, 0x6f, 0x6e, 0x66,
+	0x69, 0x67, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x35, 0x0a, 0x17, 0x73, 0x65, 0x6e,
+	0x64, 0x5f, 0x63, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x64, 0x5f, 0x72, 0x75, 0x6c, 0x65, 0x73, 0x5f,
+	0x6f, 0x6e, 0x6c, 0x79, 0x18, 0x1f, 0x20, 0x01, 0x28, 0x08, 0x52, 0x14, 0x73, 0x65, 0x6e, 0x64,
+	0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x64, 0x52, 0x75, 0x6c, 0x65, 0x73, 0x4f, 0x6e, 0x6c, 0x79,
+	0x12, 0x46, 0x0a, 0x20, 0x66, 0x75, 0x6c, 0x6c, 0x5f, 0x72, 0x65, 0x66, 0x72, 0x65, 0x73, 0x68,
+	0x5f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x5f, 0x69, 0x6e, 0x5f, 0x73, 0x65, 0x63,
+	0x6f, 0x6e, 0x64, 0x73, 0x18, 0x20, 0x20, 0x01, 0x28, 0x05, 0x52, 0x1c, 0x66, 0x75, 0x6c, 0x6c,
+	0x52, 0x65, 0x66, 0x72, 0x65, 0x73, 0x68, 0x49, 0x6e, 0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x49,
+	0x6e, 0x53, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x12, 0x33, 0x0a, 0x16, 0x72, 0x75, 0x6c, 0x65,
+	0x5f, 0x66, 0x61, 0x69, 0x6c, 0x75, 0x72, 0x65, 0x5f, 0x6c, 0x6f, 0x67, 0x5f, 0x6c, 0x69, 0x6d,
+	0x69, 0x74, 0x18, 0x21, 0x20, 0x01, 0x28, 0x05, 0x52, 0x13, 0x72, 0x75, 0x6c, 0x65, 0x46, 0x61,
+	0x69, 0x6c, 0x75, 0x72, 0x65, 0x4c, 0x6f, 0x67, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x12, 0x34, 0x0a,
+	0x16, 0x70, 0x72, 0x65, 0x70, 0x61, 0x72, 0x65, 0x5f, 0x73, 0x71, 0x6c, 0x5f, 0x73, 0x74, 0x61,
+	0x74, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x73, 0x18, 0x22, 0x20, 0x01, 0x28, 0x08, 0x52, 0x14, 0x70,
+	0x72, 0x65, 0x70, 0x61, 0x72, 0x65, 0x53, 0x71, 0x6c, 0x53, 0x74, 0x61, 0x74, 0x65, 0x6d, 0x65,
+	0x6e, 0x74, 0x73, 0x12, 0x2a, 0x0a, 0x11, 0x65, 0x6e, 0x61, 0x62, 0x6c, 0x65, 0x64, 0x5f, 0x73,
+	0x71, 0x6c, 0x5f, 0x72, 0x75, 0x6c, 0x65, 0x73, 0x18, 0x23, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0f,
+	0x65, 0x6e, 0x61, 0x62, 0x6c, 0x65, 0x64, 0x53, 0x71, 0x6c, 0x52, 0x75, 0x6c, 0x65, 0x73, 0x12,
+	0x3d, 0x0a, 0x1b, 0x72, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x5f, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x5f,
+	0x72, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x5f, 0x75, 0x73, 0x61, 0x67, 0x65, 0x18, 0x24,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x18, 0x72, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x41, 0x67, 0x65, 0x6e,
+	0x74, 0x52, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x55, 0x73, 0x61, 0x67, 0x65, 0x12, 0x3b,
+	0x0a, 0x1a, 0x6d, 0x61, 0x78, 0x5f, 0x63, 0x79, 0x63, 0x6c, 0x65, 0x5f, 0x64, 0x75, 0x72, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x73, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x18, 0x25, 0x20, 0x01,
+	0x28, 0x05, 0x52, 0x17, 0x6d, 0x61, 0x78, 0x43, 0x79, 0x63, 0x6c, 0x65, 0x44, 0x75, 0x72, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x53, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x12, 0x23, 0x0a, 0x0d, 0x6c,
+	0x6f, 0x67, 0x5f, 0x64, 0x69, 0x72, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x79, 0x18, 0x26, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x0c, 0x6c, 0x6f, 0x67, 0x44, 0x69, 0x72, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x79,
+	0x12, 0x38, 0x0a, 0x18, 0x6f, 0x6e, 0x65, 0x74, 0x69, 0x6d, 0x65, 0x5f, 0x6f, 0x75, 0x74, 0x70,
+	0x75, 0x74, 0x5f, 0x64, 0x69, 0x72, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x79, 0x18, 0x27, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x16, 0x6f, 0x6e, 0x65, 0x74, 0x69, 0x6d, 0x65, 0x4f, 0x75, 0x74, 0x70, 0x75,
+	0x74, 0x44, 0x69, 0x72, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x79, 0x22, 0xd1, 0x01, 0x0a, 0x1b, 0x53,
+	0x65, 0x63, 0x72, 0x65, 0x74, 0x50, 0x72, 0x6f, 0x76, 0x69, 0x64, 0x65, 0x72, 0x43, 0x6f, 0x6e,
+	0x66, 0x69, 0x67, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x25, 0x0a, 0x0e, 0x66, 0x69,
+	0x6c, 0x65, 0x5f, 0x64, 0x69, 0x72, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x79, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x0d, 0x66, 0x69, 0x6c, 0x65, 0x44, 0x69, 0x72, 0x65, 0x63, 0x74, 0x6f, 0x72,
+	0x79, 0x12, 0x23, 0x0a, 0x0d, 0x76, 0x61, 0x75, 0x6c, 0x74, 0x5f, 0x61, 0x64, 0x64, 0x72, 0x65,
+	0x73, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x76, 0x61, 0x75, 0x6c, 0x74, 0x41,
+	0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x12, 0x1f, 0x0a, 0x0b, 0x76, 0x61, 0x75, 0x6c, 0x74, 0x5f,
+	0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x76, 0x61, 0x75,
+	0x6c, 0x74, 0x4d, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x1b, 0x0a, 0x09, 0x76, 0x61, 0x75, 0x6c, 0x74,
+	0x5f, 0x6b, 0x65, 0x79, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x76, 0x61, 0x75, 0x6c,
+	0x74, 0x4b, 0x65, 0x79, 0x12, 0x28, 0x0a, 0x10, 0x76, 0x61, 0x75, 0x6c, 0x74, 0x5f, 0x74, 0x6f,
+	0x6b, 0x65, 0x6e, 0x5f, 0x66, 0x69, 0x6c, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e,
+	0x76, 0x61, 0x75, 0x6c, 0x74, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x46, 0x69, 0x6c, 0x65, 0x22, 0x62,
+	0x0a, 0x0d, 0x53, 0x53, 0x48, 0x41, 0x6c, 0x67, 0x6f, 0x72, 0x69, 0x74, 0x68, 0x6d, 0x73, 0x12,
+	0x23, 0x0a, 0x0d, 0x6b, 0x65, 0x79, 0x5f, 0x65, 0x78, 0x63, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x73,
+	0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0c, 0x6b, 0x65, 0x79, 0x45, 0x78, 0x63, 0x68, 0x61,
+	0x6e, 0x67, 0x65, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x69, 0x70, 0x68, 0x65, 0x72, 0x73, 0x18,
+	0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x07, 0x63, 0x69, 0x70, 0x68, 0x65, 0x72, 0x73, 0x12, 0x12,
+	0x0a, 0x04, 0x6d, 0x61, 0x63, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x09, 0x52, 0x04, 0x6d, 0x61,
+	0x63, 0x73, 0x22, 0xb4, 0x01, 0x0a, 0x12, 0x49, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x50,
+	0x72, 0x6f, 0x70, 0x65, 0x72, 0x74, 0x69, 0x65, 0x73, 0x12, 0x1d, 0x0a, 0x0a, 0x70, 0x72, 0x6f,
+	0x6a, 0x65, 0x63, 0x74, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x70,
+	0x72, 0x6f, 0x6a, 0x65, 0x63, 0x74, 0x49, 0x64, 0x12, 0x25, 0x0a, 0x0e, 0x70, 0x72, 0x6f, 0x6a,
+	0x65, 0x63, 0x74, 0x5f, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x0d, 0x70, 0x72, 0x6f, 0x6a, 0x65, 0x63, 0x74, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x12,
+	0x12, 0x0a, 0x04, 0x7a, 0x6f, 0x6e, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x7a,
+	0x6f, 0x6e, 0x65, 0x12, 0x23, 0x0a, 0x0d, 0x69, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x5f,
+	0x6e, 0x61, 0x6d, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x69, 0x6e, 0x73, 0x74,
 	0x61, 0x6e, 0x63, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x69, 0x6e, 0x73, 0x74,
-	0x61, 0x6e, 0x63, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x09, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x69,
-	0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x49, 0x64, 0x12, 0x2e, 0x0a, 0x11, 0x67, 0x75, 0x65,
-	0x73, 0x74, 0x5f, 0x70, 0x6f, 0x72, 0x74, 0x5f, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x18, 0x0a,
-	0x20, 0x01, 0x28, 0x05, 0x42, 0x02, 0x18, 0x01, 0x52, 0x0f, 0x67, 0x75, 0x65, 0x73, 0x74, 0x50,
-	0x6f, 0x72, 0x74, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x12, 0x25, 0x0a, 0x0c, 0x6c, 0x69, 0x6e,
-	0x75, 0x78, 0x5f, 0x72, 0x65, 0x6d, 0x6f, 0x74, 0x65, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x08, 0x42,
-	0x02, 0x18, 0x01, 0x52, 0x0b, 0x6c, 0x69, 0x6e, 0x75, 0x78, 0x52, 0x65, 0x6d, 0x6f, 0x74, 0x65,
-	0x12, 0x3e, 0x0a, 0x1a, 0x6c, 0x69, 0x6e, 0x75, 0x78, 0x5f, 0x73, 0x73, 0x68, 0x5f, 0x70, 0x72,
-	0x69, 0x76, 0x61, 0x74, 0x65, 0x5f, 0x6b, 0x65, 0x79, 0x5f, 0x70, 0x61, 0x74, 0x68, 0x18, 0x0c,
-	0x20, 0x01, 0x28, 0x09, 0x42, 0x02, 0x18, 0x01, 0x52, 0x16, 0x6c, 0x69, 0x6e, 0x75, 0x78, 0x53,
-	0x73, 0x68, 0x50, 0x72, 0x69, 0x76, 0x61, 0x74, 0x65, 0x4b, 0x65, 0x79, 0x50, 0x61, 0x74, 0x68,
-	0x12, 0x6b, 0x0a, 0x12, 0x73, 0x71, 0x6c, 0x5f, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x75, 0x72,
-	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x0d, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x3c, 0x2e, 0x73,
-	0x71, 0x6c, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x63, 0x6f, 0x6e,
-	0x66, 0x69, 0x67, 0x2e, 0x43, 0x72, 0x65, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x61, 0x6c, 0x43, 0x6f,
-	0x6e, 0x66, 0x69, 0x67, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x53, 0x71, 0x6c, 0x43,
-	0x72, 0x65, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x61, 0x6c, 0x73, 0x52, 0x11, 0x73, 0x71, 0x6c, 0x43,
-	0x6f, 0x6e, 0x66, 0x69, 0x67, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x2b, 0x0a,
-	0x10, 0x6c, 0x6f, 0x63, 0x61, 0x6c, 0x5f, 0x63, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f,
-	0x6e, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x08, 0x48, 0x00, 0x52, 0x0f, 0x6c, 0x6f, 0x63, 0x61, 0x6c,
-	0x43, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x68, 0x0a, 0x0a, 0x72, 0x65,
-	0x6d, 0x6f, 0x74, 0x65, 0x5f, 0x77, 0x69, 0x6e, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x47,
-	0x2e, 0x73, 0x71, 0x6c, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x63,
-	0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x43, 0x72, 0x65, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x61, 0x6c,
-	0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x47, 0x75,
-	0x65, 0x73, 0x74, 0x43, 0x72, 0x65, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x61, 0x6c, 0x73, 0x52, 0x65,
-	0x6d, 0x6f, 0x74, 0x65, 0x57, 0x69, 0x6e, 0x48, 0x00, 0x52, 0x09, 0x72, 0x65, 0x6d, 0x6f, 0x74,
-	0x65, 0x57, 0x69, 0x6e, 0x12, 0x6e, 0x0a, 0x0c, 0x72, 0x65, 0x6d, 0x6f, 0x74, 0x65, 0x5f, 0x6c,
-	0x69, 0x6e, 0x75, 0x78, 0x18, 0x10, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x49, 0x2e, 0x73, 0x71, 0x6c,
-	0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x63, 0x6f, 0x6e, 0x66, 0x69,
-	0x67, 0x2e, 0x43, 0x72, 0x65, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x61, 0x6c, 0x43, 0x6f, 0x6e, 0x66,
-	0x69, 0x67, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x47, 0x75, 0x65, 0x73, 0x74, 0x43,
-	0x72, 0x65, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x61, 0x6c, 0x73, 0x52, 0x65, 0x6d, 0x6f, 0x74, 0x65,
-	0x4c, 0x69, 0x6e, 0x75, 0x78, 0x48, 0x00, 0x52, 0x0b, 0x72, 0x65, 0x6d, 0x6f, 0x74, 0x65, 0x4c,
-	0x69, 0x6e, 0x75, 0x78, 0x1a, 0x83, 0x01, 0x0a, 0x0e, 0x53, 0x71, 0x6c, 0x43, 0x72, 0x65, 0x64,
-	0x65, 0x6e, 0x74, 0x69, 0x61, 0x6c, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x68, 0x6f, 0x73, 0x74, 0x18,
-	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x68, 0x6f, 0x73, 0x74, 0x12, 0x1b, 0x0a, 0x09, 0x75,
-	0x73, 0x65, 0x72, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08,
-	0x75, 0x73, 0x65, 0x72, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x73, 0x65, 0x63, 0x72,
-	0x65, 0x74, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x73,
-	0x65, 0x63, 0x72, 0x65, 0x74, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x70, 0x6f, 0x72,
-	0x74, 0x5f, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0a,
-	0x70, 0x6f, 0x72, 0x74, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x1a, 0x90, 0x01, 0x0a, 0x19, 0x47,
+	0x61, 0x6e, 0x63, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x69,
+	0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x49, 0x64, 0x22, 0xae, 0x03, 0x0a, 0x17, 0x43, 0x6f,
+	0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x75, 0x72,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x37, 0x0a, 0x18, 0x63, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74,
+	0x5f, 0x67, 0x75, 0x65, 0x73, 0x74, 0x5f, 0x6f, 0x73, 0x5f, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63,
+	0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x15, 0x63, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74,
+	0x47, 0x75, 0x65, 0x73, 0x74, 0x4f, 0x73, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x12, 0x62,
+	0x0a, 0x2f, 0x67, 0x75, 0x65, 0x73, 0x74, 0x5f, 0x6f, 0x73, 0x5f, 0x6d, 0x65, 0x74, 0x72, 0x69,
+	0x63, 0x73, 0x5f, 0x63, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x69, 0x6e,
+	0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x5f, 0x69, 0x6e, 0x5f, 0x73, 0x65, 0x63, 0x6f, 0x6e, 0x64,
+	0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x29, 0x67, 0x75, 0x65, 0x73, 0x74, 0x4f, 0x73,
+	0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x43, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f,
+	0x6e, 0x49, 0x6e, 0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x49, 0x6e, 0x53, 0x65, 0x63, 0x6f, 0x6e,
+	0x64, 0x73, 0x12, 0x2e, 0x0a, 0x13, 0x63, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x5f, 0x73, 0x71,
+	0x6c, 0x5f, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52,
+	0x11, 0x63, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x53, 0x71, 0x6c, 0x4d, 0x65, 0x74, 0x72, 0x69,
+	0x63, 0x73, 0x12, 0x59, 0x0a, 0x2a, 0x73, 0x71, 0x6c, 0x5f, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63,
+	0x73, 0x5f, 0x63, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x69, 0x6e, 0x74,
+	0x65, 0x72, 0x76, 0x61, 0x6c, 0x5f, 0x69, 0x6e, 0x5f, 0x73, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x25, 0x73, 0x71, 0x6c, 0x4d, 0x65, 0x74, 0x72, 0x69,
+	0x63, 0x73, 0x43, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x6e, 0x74, 0x65,
+	0x72, 0x76, 0x61, 0x6c, 0x49, 0x6e, 0x53, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x12, 0x48, 0x0a,
+	0x1e, 0x63, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x5f, 0x70, 0x68, 0x79, 0x73, 0x69, 0x63, 0x61,
+	0x6c, 0x5f, 0x64, 0x72, 0x69, 0x76, 0x65, 0x5f, 0x6d, 0x61, 0x70, 0x70, 0x69, 0x6e, 0x67, 0x18,
+	0x05, 0x20, 0x01, 0x28, 0x08, 0x48, 0x00, 0x52, 0x1b, 0x63, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74,
+	0x50, 0x68, 0x79, 0x73, 0x69, 0x63, 0x61, 0x6c, 0x44, 0x72, 0x69, 0x76, 0x65, 0x4d, 0x61, 0x70,
+	0x70, 0x69, 0x6e, 0x67, 0x88, 0x01, 0x01, 0x42, 0x21, 0x0a, 0x1f, 0x5f, 0x63, 0x6f, 0x6c, 0x6c,
+	0x65, 0x63, 0x74, 0x5f, 0x70, 0x68, 0x79, 0x73, 0x69, 0x63, 0x61, 0x6c, 0x5f, 0x64, 0x72, 0x69,
+	0x76, 0x65, 0x5f, 0x6d, 0x61, 0x70, 0x70, 0x69, 0x6e, 0x67, 0x22, 0xcc, 0x10, 0x0a, 0x17, 0x43,
+	0x72, 0x65, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x61, 0x6c, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x75,
+	0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x16, 0x0a, 0x04, 0x68, 0x6f, 0x73, 0x74, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x42, 0x02, 0x18, 0x01, 0x52, 0x04, 0x68, 0x6f, 0x73, 0x74, 0x12, 0x1f,
+	0x0a, 0x09, 0x75, 0x73, 0x65, 0x72, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x42, 0x02, 0x18, 0x01, 0x52, 0x08, 0x75, 0x73, 0x65, 0x72, 0x4e, 0x61, 0x6d, 0x65, 0x12,
+	0x23, 0x0a, 0x0b, 0x73, 0x65, 0x63, 0x72, 0x65, 0x74, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x09, 0x42, 0x02, 0x18, 0x01, 0x52, 0x0a, 0x73, 0x65, 0x63, 0x72, 0x65, 0x74,
+	0x4e, 0x61, 0x6d, 0x65, 0x12, 0x23, 0x0a, 0x0b, 0x70, 0x6f, 0x72, 0x74, 0x5f, 0x6e, 0x75, 0x6d,
+	0x62, 0x65, 0x72, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x42, 0x02, 0x18, 0x01, 0x52, 0x0a, 0x70,
+	0x6f, 0x72, 0x74, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x12, 0x23, 0x0a, 0x0b, 0x73, 0x65, 0x72,
+	0x76, 0x65, 0x72, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x42, 0x02,
+	0x18, 0x01, 0x52, 0x0a, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x2a,
+	0x0a, 0x0f, 0x67, 0x75, 0x65, 0x73, 0x74, 0x5f, 0x75, 0x73, 0x65, 0x72, 0x5f, 0x6e, 0x61, 0x6d,
+	0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x42, 0x02, 0x18, 0x01, 0x52, 0x0d, 0x67, 0x75, 0x65,
+	0x73, 0x74, 0x55, 0x73, 0x65, 0x72, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x2e, 0x0a, 0x11, 0x67, 0x75,
+	0x65, 0x73, 0x74, 0x5f, 0x73, 0x65, 0x63, 0x72, 0x65, 0x74, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18,
+	0x07, 0x20, 0x01, 0x28, 0x09, 0x42, 0x02, 0x18, 0x01, 0x52, 0x0f, 0x67, 0x75, 0x65, 0x73, 0x74,
+	0x53, 0x65, 0x63, 0x72, 0x65, 0x74, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x23, 0x0a, 0x0d, 0x69, 0x6e,
+	0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x08, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x0c, 0x69, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x12,
+	0x1f, 0x0a, 0x0b, 0x69, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x09,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x69, 0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x49, 0x64,
+	0x12, 0x2e, 0x0a, 0x11, 0x67, 0x75, 0x65, 0x73, 0x74, 0x5f, 0x70, 0x6f, 0x72, 0x74, 0x5f, 0x6e,
+	0x75, 0x6d, 0x62, 0x65, 0x72, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x05, 0x42, 0x02, 0x18, 0x01, 0x52,
+	0x0f, 0x67, 0x75, 0x65, 0x73, 0x74, 0x50, 0x6f, 0x72, 0x74, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72,
+	0x12, 0x25, 0x0a, 0x0c, 0x6c, 0x69, 0x6e, 0x75, 0x78, 0x5f, 0x72, 0x65, 0x6d, 0x6f, 0x74, 0x65,
+	0x18, 0x0b, 0x20, 0x01, 0x28, 0x08, 0x42, 0x02, 0x18, 0x01, 0x52, 0x0b, 0x6c, 0x69, 0x6e, 0x75,
+	0x78, 0x52, 0x65, 0x6d, 0x6f, 0x74, 0x65, 0x12, 0x3e, 0x0a, 0x1a, 0x6c, 0x69, 0x6e, 0x75, 0x78,
+	0x5f, 0x73, 0x73, 0x68, 0x5f, 0x70, 0x72, 0x69, 0x76, 0x61, 0x74, 0x65, 0x5f, 0x6b, 0x65, 0x79,
+	0x5f, 0x70, 0x61, 0x74, 0x68, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x09, 0x42, 0x02, 0x18, 0x01, 0x52,
+	0x16, 0x6c, 0x69, 0x6e, 0x75, 0x78, 0x53, 0x73, 0x68, 0x50, 0x72, 0x69, 0x76, 0x61, 0x74, 0x65,
+	0x4b, 0x65, 0x79, 0x50, 0x61, 0x74, 0x68, 0x12, 0x6b, 0x0a, 0x12, 0x73, 0x71, 0x6c, 0x5f, 0x63,
+	0x6f, 0x6e, 0x66, 0x69, 0x67, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x0d, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x3c, 0x2e, 0x73, 0x71, 0x6c, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x61,
+	0x67, 0x65, 0x6e, 0x74, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x43, 0x72, 0x65, 0x64, 0x65,
+	0x6e, 0x74, 0x69, 0x61, 0x6c, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x75, 0x72, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x2e, 0x53, 0x71, 0x6c, 0x43, 0x72, 0x65, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x61, 0x6c,
+	0x73, 0x52, 0x11, 0x73, 0x71, 0x6c, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x75, 0x72, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x73, 0x12, 0x2b, 0x0a, 0x10, 0x6c, 0x6f, 0x63, 0x61, 0x6c, 0x5f, 0x63, 0x6f,
+	0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x08, 0x48, 0x00,
+	0x52, 0x0f, 0x6c, 0x6f, 0x63, 0x61, 0x6c, 0x43, 0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f,
+	0x6e, 0x12, 0x68, 0x0a, 0x0a, 0x72, 0x65, 0x6d, 0x6f, 0x74, 0x65, 0x5f, 0x77, 0x69, 0x6e, 0x18,
+	0x0f, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x47, 0x2e, 0x73, 0x71, 0x6c, 0x73, 0x65, 0x72, 0x76, 0x65,
+	0x72, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x43, 0x72, 0x65,
+	0x64, 0x65, 0x6e, 0x74, 0x69, 0x61, 0x6c, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x75, 0x72, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x47, 0x75, 0x65, 0x73, 0x74, 0x43, 0x72, 0x65, 0x64, 0x65, 0x6e,
+	0x74, 0x69, 0x61, 0x6c, 0x73, 0x52, 0x65, 0x6d, 0x6f, 0x74, 0x65, 0x57, 0x69, 0x6e, 0x48, 0x00,
+	0x52, 0x09, 0x72, 0x65, 0x6d, 0x6f, 0x74, 0x65, 0x57, 0x69, 0x6e, 0x12, 0x6e, 0x0a, 0x0c, 0x72,
+	0x65, 0x6d, 0x6f, 0x74, 0x65, 0x5f, 0x6c, 0x69, 0x6e, 0x75, 0x78, 0x18, 0x10, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x49, 0x2e, 0x73, 0x71, 0x6c, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x61, 0x67, 0x65,
+	0x6e, 0x74, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x43, 0x72, 0x65, 0x64, 0x65, 0x6e, 0x74,
+	0x69, 0x61, 0x6c, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x2e, 0x47, 0x75, 0x65, 0x73, 0x74, 0x43, 0x72, 0x65, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x61, 0x6c,
+	0x73, 0x52, 0x65, 0x6d, 0x6f, 0x74, 0x65, 0x4c, 0x69, 0x6e, 0x75, 0x78, 0x48, 0x00, 0x52, 0x0b,
+	0x72, 0x65, 0x6d, 0x6f, 0x74, 0x65, 0x4c, 0x69, 0x6e, 0x75, 0x78, 0x1a, 0xcc, 0x04, 0x0a, 0x0e,
+	0x53, 0x71, 0x6c, 0x43, 0x72, 0x65, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x61, 0x6c, 0x73, 0x12, 0x12,
+	0x0a, 0x04, 0x68, 0x6f, 0x73, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x68, 0x6f,
+	0x73, 0x74, 0x12, 0x1b, 0x0a, 0x09, 0x75, 0x73, 0x65, 0x72, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x75, 0x73, 0x65, 0x72, 0x4e, 0x61, 0x6d, 0x65, 0x12,
+	0x1f, 0x0a, 0x0b, 0x73, 0x65, 0x63, 0x72, 0x65, 0x74, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x73, 0x65, 0x63, 0x72, 0x65, 0x74, 0x4e, 0x61, 0x6d, 0x65,
+	0x12, 0x1f, 0x0a, 0x0b, 0x70, 0x6f, 0x72, 0x74, 0x5f, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0a, 0x70, 0x6f, 0x72, 0x74, 0x4e, 0x75, 0x6d, 0x62, 0x65,
+	0x72, 0x12, 0x37, 0x0a, 0x18, 0x68, 0x6f, 0x73, 0x74, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x5f, 0x69,
+	0x6e, 0x5f, 0x63, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x18, 0x05, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x15, 0x68, 0x6f, 0x73, 0x74, 0x4e, 0x61, 0x6d, 0x65, 0x49, 0x6e, 0x43,
+	0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x12, 0x30, 0x0a, 0x14, 0x63, 0x6c,
+	0x75, 0x73, 0x74, 0x65, 0x72, 0x5f, 0x6e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x5f, 0x6e, 0x61,
+	0x6d, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x12, 0x63, 0x6c, 0x75, 0x73, 0x74, 0x65,
+	0x72, 0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x36, 0x0a, 0x17,
+	0x65, 0x78, 0x74, 0x72, 0x61, 0x5f, 0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e,
+	0x5f, 0x70, 0x61, 0x72, 0x61, 0x6d, 0x73, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x15, 0x65,
+	0x78, 0x74, 0x72, 0x61, 0x43, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x50, 0x61,
+	0x72, 0x61, 0x6d, 0x73, 0x12, 0x2a, 0x0a, 0x11, 0x73, 0x65, 0x63, 0x72, 0x65, 0x74, 0x5f, 0x70,
+	0x72, 0x6f, 0x6a, 0x65, 0x63, 0x74, 0x5f, 0x69, 0x64, 0x18, 0x08, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x0f, 0x73, 0x65, 0x63, 0x72, 0x65, 0x74, 0x50, 0x72, 0x6f, 0x6a, 0x65, 0x63, 0x74, 0x49, 0x64,
+	0x12, 0x62, 0x0a, 0x09, 0x61, 0x75, 0x74, 0x68, 0x5f, 0x6d, 0x6f, 0x64, 0x65, 0x18, 0x09, 0x20,
+	0x01, 0x28, 0x0e, 0x32, 0x45, 0x2e, 0x73, 0x71, 0x6c, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x61,
+	0x67, 0x65, 0x6e, 0x74, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x43, 0x72, 0x65, 0x64, 0x65,
+	0x6e, 0x74, 0x69, 0x61, 0x6c, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x75, 0x72, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x2e, 0x53, 0x71, 0x6c, 0x43, 0x72, 0x65, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x61, 0x6c,
+	0x73, 0x2e, 0x41, 0x75, 0x74, 0x68, 0x4d, 0x6f, 0x64, 0x65, 0x52, 0x08, 0x61, 0x75, 0x74, 0x68,
+	0x4d, 0x6f, 0x64, 0x65, 0x12, 0x2a, 0x0a, 0x11, 0x61, 0x63, 0x63, 0x65, 0x73, 0x73, 0x5f, 0x74,
+	0x6f, 0x6b, 0x65, 0x6e, 0x5f, 0x66, 0x69, 0x6c, 0x65, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x0f, 0x61, 0x63, 0x63, 0x65, 0x73, 0x73, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x46, 0x69, 0x6c, 0x65,
+	0x12, 0x1d, 0x0a, 0x0a, 0x6e, 0x61, 0x6d, 0x65, 0x64, 0x5f, 0x70, 0x69, 0x70, 0x65, 0x18, 0x0b,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x6e, 0x61, 0x6d, 0x65, 0x64, 0x50, 0x69, 0x70, 0x65, 0x12,
+	0x1d, 0x0a, 0x0a, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x5f, 0x73, 0x6e, 0x69, 0x18, 0x0c, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x09, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x53, 0x6e, 0x69, 0x22, 0x2a,
+	0x0a, 0x08, 0x41, 0x75, 0x74, 0x68, 0x4d, 0x6f, 0x64, 0x65, 0x12, 0x0c, 0x0a, 0x08, 0x50, 0x41,
+	0x53, 0x53, 0x57, 0x4f, 0x52, 0x44, 0x10, 0x00, 0x12, 0x10, 0x0a, 0x0c, 0x41, 0x43, 0x43, 0x45,
+	0x53, 0x53, 0x5f, 0x54, 0x4f, 0x4b, 0x45, 0x4e, 0x10, 0x01, 0x1a, 0xef, 0x02, 0x0a, 0x19, 0x47,
 	0x75, 0x65, 0x73, 0x74, 0x43, 0x72, 0x65, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x61, 0x6c, 0x73, 0x52,
 	0x65, 0x6d, 0x6f, 0x74, 0x65, 0x57, 0x69, 0x6e, 0x12, 0x1f, 0x0a, 0x0b, 0x73, 0x65, 0x72, 0x76,
 	0x65, 0x72, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x73,
@@ -826,22 +1801,40 @@ var file_sqlserveragentconfig_sqlserveragentconfig_proto_rawDesc = []byte{
 	0x28, 0x09, 0x52, 0x0d, 0x67, 0x75, 0x65, 0x73, 0x74, 0x55, 0x73, 0x65, 0x72, 0x4e, 0x61, 0x6d,
 	0x65, 0x12, 0x2a, 0x0a, 0x11, 0x67, 0x75, 0x65, 0x73, 0x74, 0x5f, 0x73, 0x65, 0x63, 0x72, 0x65,
 	0x74, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0f, 0x67, 0x75,
-	0x65, 0x73, 0x74, 0x53, 0x65, 0x63, 0x72, 0x65, 0x74, 0x4e, 0x61, 0x6d, 0x65, 0x1a, 0xce, 0x01,
-	0x0a, 0x1b, 0x47, 0x75, 0x65, 0x73, 0x74, 0x43, 0x72, 0x65, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x61,
-	0x6c, 0x73, 0x52, 0x65, 0x6d, 0x6f, 0x74, 0x65, 0x4c, 0x69, 0x6e, 0x75, 0x78, 0x12, 0x1f, 0x0a,
-	0x0b, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x09, 0x52, 0x0a, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x26,
-	0x0a, 0x0f, 0x67, 0x75, 0x65, 0x73, 0x74, 0x5f, 0x75, 0x73, 0x65, 0x72, 0x5f, 0x6e, 0x61, 0x6d,
-	0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x67, 0x75, 0x65, 0x73, 0x74, 0x55, 0x73,
-	0x65, 0x72, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x2a, 0x0a, 0x11, 0x67, 0x75, 0x65, 0x73, 0x74, 0x5f,
-	0x70, 0x6f, 0x72, 0x74, 0x5f, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28,
-	0x05, 0x52, 0x0f, 0x67, 0x75, 0x65, 0x73, 0x74, 0x50, 0x6f, 0x72, 0x74, 0x4e, 0x75, 0x6d, 0x62,
-	0x65, 0x72, 0x12, 0x3a, 0x0a, 0x1a, 0x6c, 0x69, 0x6e, 0x75, 0x78, 0x5f, 0x73, 0x73, 0x68, 0x5f,
-	0x70, 0x72, 0x69, 0x76, 0x61, 0x74, 0x65, 0x5f, 0x6b, 0x65, 0x79, 0x5f, 0x70, 0x61, 0x74, 0x68,
-	0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x16, 0x6c, 0x69, 0x6e, 0x75, 0x78, 0x53, 0x73, 0x68,
-	0x50, 0x72, 0x69, 0x76, 0x61, 0x74, 0x65, 0x4b, 0x65, 0x79, 0x50, 0x61, 0x74, 0x68, 0x42, 0x16,
-	0x0a, 0x14, 0x67, 0x75, 0x65, 0x73, 0x74, 0x5f, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x75, 0x72,
-	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x65, 0x73, 0x74, 0x53, 0x65, 0x63, 0x72, 0x65, 0x74, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x6f, 0x0a,
+	0x09, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x70, 0x6f, 0x72, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0e,
+	0x32, 0x51, 0x2e, 0x73, 0x71, 0x6c, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x61, 0x67, 0x65, 0x6e,
+	0x74, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x43, 0x72, 0x65, 0x64, 0x65, 0x6e, 0x74, 0x69,
+	0x61, 0x6c, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e,
+	0x47, 0x75, 0x65, 0x73, 0x74, 0x43, 0x72, 0x65, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x61, 0x6c, 0x73,
+	0x52, 0x65, 0x6d, 0x6f, 0x74, 0x65, 0x57, 0x69, 0x6e, 0x2e, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x70,
+	0x6f, 0x72, 0x74, 0x52, 0x09, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x70, 0x6f, 0x72, 0x74, 0x12, 0x35,
+	0x0a, 0x17, 0x67, 0x75, 0x65, 0x73, 0x74, 0x5f, 0x73, 0x65, 0x63, 0x72, 0x65, 0x74, 0x5f, 0x70,
+	0x72, 0x6f, 0x6a, 0x65, 0x63, 0x74, 0x5f, 0x69, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x14, 0x67, 0x75, 0x65, 0x73, 0x74, 0x53, 0x65, 0x63, 0x72, 0x65, 0x74, 0x50, 0x72, 0x6f, 0x6a,
+	0x65, 0x63, 0x74, 0x49, 0x64, 0x22, 0x35, 0x0a, 0x09, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x70, 0x6f,
+	0x72, 0x74, 0x12, 0x07, 0x0a, 0x03, 0x57, 0x4d, 0x49, 0x10, 0x00, 0x12, 0x0e, 0x0a, 0x0a, 0x57,
+	0x49, 0x4e, 0x52, 0x4d, 0x5f, 0x48, 0x54, 0x54, 0x50, 0x10, 0x01, 0x12, 0x0f, 0x0a, 0x0b, 0x57,
+	0x49, 0x4e, 0x52, 0x4d, 0x5f, 0x48, 0x54, 0x54, 0x50, 0x53, 0x10, 0x02, 0x1a, 0x82, 0x02, 0x0a,
+	0x1b, 0x47, 0x75, 0x65, 0x73, 0x74, 0x43, 0x72, 0x65, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x61, 0x6c,
+	0x73, 0x52, 0x65, 0x6d, 0x6f, 0x74, 0x65, 0x4c, 0x69, 0x6e, 0x75, 0x78, 0x12, 0x1f, 0x0a, 0x0b,
+	0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x0a, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x26, 0x0a,
+	0x0f, 0x67, 0x75, 0x65, 0x73, 0x74, 0x5f, 0x75, 0x73, 0x65, 0x72, 0x5f, 0x6e, 0x61, 0x6d, 0x65,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x67, 0x75, 0x65, 0x73, 0x74, 0x55, 0x73, 0x65,
+	0x72, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x2a, 0x0a, 0x11, 0x67, 0x75, 0x65, 0x73, 0x74, 0x5f, 0x70,
+	0x6f, 0x72, 0x74, 0x5f, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05,
+	0x52, 0x0f, 0x67, 0x75, 0x65, 0x73, 0x74, 0x50, 0x6f, 0x72, 0x74, 0x4e, 0x75, 0x6d, 0x62, 0x65,
+	0x72, 0x12, 0x3a, 0x0a, 0x1a, 0x6c, 0x69, 0x6e, 0x75, 0x78, 0x5f, 0x73, 0x73, 0x68, 0x5f, 0x70,
+	0x72, 0x69, 0x76, 0x61, 0x74, 0x65, 0x5f, 0x6b, 0x65, 0x79, 0x5f, 0x70, 0x61, 0x74, 0x68, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x16, 0x6c, 0x69, 0x6e, 0x75, 0x78, 0x53, 0x73, 0x68, 0x50,
+	0x72, 0x69, 0x76, 0x61, 0x74, 0x65, 0x4b, 0x65, 0x79, 0x50, 0x61, 0x74, 0x68, 0x12, 0x32, 0x0a,
+	0x15, 0x68, 0x6f, 0x73, 0x74, 0x5f, 0x6b, 0x65, 0x79, 0x5f, 0x66, 0x69, 0x6e, 0x67, 0x65, 0x72,
+	0x70, 0x72, 0x69, 0x6e, 0x74, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x09, 0x52, 0x13, 0x68, 0x6f,
+	0x73, 0x74, 0x4b, 0x65, 0x79, 0x46, 0x69, 0x6e, 0x67, 0x65, 0x72, 0x70, 0x72, 0x69, 0x6e, 0x74,
+	0x73, 0x42, 0x16, 0x0a, 0x14, 0x67, 0x75, 0x65, 0x73, 0x74, 0x5f, 0x63, 0x6f, 0x6e, 0x66, 0x69,
+	0x67, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x33,
 }
 
 var (
@@ -856,26 +1849,37 @@ func file_sqlserveragentconfig_sqlserveragentconfig_proto_rawDescGZIP() []byte {
 	return file_sqlserveragentconfig_sqlserveragentconfig_proto_rawDescData
 }
 
-var file_sqlserveragentconfig_sqlserveragentconfig_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_sqlserveragentconfig_sqlserveragentconfig_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_sqlserveragentconfig_sqlserveragentconfig_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
 var file_sqlserveragentconfig_sqlserveragentconfig_proto_goTypes = []interface{}{
-	(*Configuration)(nil),                                       // 0: sqlserveragentconfig.Configuration
-	(*CollectionConfiguration)(nil),                             // 1: sqlserveragentconfig.CollectionConfiguration
-	(*CredentialConfiguration)(nil),                             // 2: sqlserveragentconfig.CredentialConfiguration
-	(*CredentialConfiguration_SqlCredentials)(nil),              // 3: sqlserveragentconfig.CredentialConfiguration.SqlCredentials
-	(*CredentialConfiguration_GuestCredentialsRemoteWin)(nil),   // 4: sqlserveragentconfig.CredentialConfiguration.GuestCredentialsRemoteWin
-	(*CredentialConfiguration_GuestCredentialsRemoteLinux)(nil), // 5: sqlserveragentconfig.CredentialConfiguration.GuestCredentialsRemoteLinux
+	(CredentialConfiguration_SqlCredentials_AuthMode)(0),             // 0: sqlserveragentconfig.CredentialConfiguration.SqlCredentials.AuthMode
+	(CredentialConfiguration_GuestCredentialsRemoteWin_Transport)(0), // 1: sqlserveragentconfig.CredentialConfiguration.GuestCredentialsRemoteWin.Transport
+	(*Configuration)(nil),                                       // 2: sqlserveragentconfig.Configuration
+	(*SecretProviderConfiguration)(nil),                         // 3: sqlserveragentconfig.SecretProviderConfiguration
+	(*SSHAlgorithms)(nil),                                       // 4: sqlserveragentconfig.SSHAlgorithms
+	(*InstanceProperties)(nil),                                  // 5: sqlserveragentconfig.InstanceProperties
+	(*CollectionConfiguration)(nil),                             // 6: sqlserveragentconfig.CollectionConfiguration
+	(*CredentialConfiguration)(nil),                             // 7: sqlserveragentconfig.CredentialConfiguration
+	(*CredentialConfiguration_SqlCredentials)(nil),              // 8: sqlserveragentconfig.CredentialConfiguration.SqlCredentials
+	(*CredentialConfiguration_GuestCredentialsRemoteWin)(nil),   // 9: sqlserveragentconfig.CredentialConfiguration.GuestCredentialsRemoteWin
+	(*CredentialConfiguration_GuestCredentialsRemoteLinux)(nil), // 10: sqlserveragentconfig.CredentialConfiguration.GuestCredentialsRemoteLinux
 }
 var file_sqlserveragentconfig_sqlserveragentconfig_proto_depIdxs = []int32{
-	1, // 0: sqlserveragentconfig.Configuration.collection_configuration:type_name -> sqlserveragentconfig.CollectionConfiguration
-	2, // 1: sqlserveragentconfig.Configuration.credential_configuration:type_name -> sqlserveragentconfig.CredentialConfiguration
-	3, // 2: sqlserveragentconfig.CredentialConfiguration.sql_configurations:type_name -> sqlserveragentconfig.CredentialConfiguration.SqlCredentials
-	4, // 3: sqlserveragentconfig.CredentialConfiguration.remote_win:type_name -> sqlserveragentconfig.CredentialConfiguration.GuestCredentialsRemoteWin
-	5, // 4: sqlserveragentconfig.CredentialConfiguration.remote_linux:type_name -> sqlserveragentconfig.CredentialConfiguration.GuestCredentialsRemoteLinux
-	5, // [5:5] is the sub-list for method output_type
-	5, // [5:5] is the sub-list for method input_type
-	5, // [5:5] is the sub-list for extension type_name
-	5, // [5:5] is the sub-list for extension extendee
-	0, // [0:5] is the sub-list for field type_name
+	6,  // 0: sqlserveragentconfig.Configuration.collection_configuration:type_name -> sqlserveragentconfig.CollectionConfiguration
+	7,  // 1: sqlserveragentconfig.Configuration.credential_configuration:type_name -> sqlserveragentconfig.CredentialConfiguration
+	5,  // 2: sqlserveragentconfig.Configuration.instance_properties:type_name -> sqlserveragentconfig.InstanceProperties
+	4,  // 3: sqlserveragentconfig.Configuration.ssh_algorithms:type_name -> sqlserveragentconfig.SSHAlgorithms
+	3,  // 4: sqlserveragentconfig.Configuration.secret_provider_configuration:type_name -> sqlserveragentconfig.SecretProviderConfiguration
+	8,  // 5: sqlserveragentconfig.CredentialConfiguration.sql_configurations:type_name -> sqlserveragentconfig.CredentialConfiguration.SqlCredentials
+	9,  // 6: sqlserveragentconfig.CredentialConfiguration.remote_win:type_name -> sqlserveragentconfig.CredentialConfiguration.GuestCredentialsRemoteWin
+	10, // 7: sqlserveragentconfig.CredentialConfiguration.remote_linux:type_name -> sqlserveragentconfig.CredentialConfiguration.GuestCredentialsRemoteLinux
+	0,  // 8: sqlserveragentconfig.CredentialConfiguration.SqlCredentials.auth_mode:type_name -> sqlserveragentconfig.CredentialConfiguration.SqlCredentials.AuthMode
+	1,  // 9: sqlserveragentconfig.CredentialConfiguration.GuestCredentialsRemoteWin.transport:type_name -> sqlserveragentconfig.CredentialConfiguration.GuestCredentialsRemoteWin.Transport
+	10, // [10:10] is the sub-list for method output_type
+	10, // [10:10] is the sub-list for method input_type
+	10, // [10:10] is the sub-list for extension type_name
+	10, // [10:10] is the sub-list for extension extendee
+	0,  // [0:10] is the sub-list for field type_name
 }
 
 func init() { file_sqlserveragentconfig_sqlserveragentconfig_proto_init() }
@@ -897,7 +1901,7 @@ func file_sqlserveragentconfig_sqlserveragentconfig_proto_init() {
 			}
 		}
 		file_sqlserveragentconfig_sqlserveragentconfig_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*CollectionConfiguration); i {
+			switch v := v.(*SecretProviderConfiguration); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -909,7 +1913,7 @@ func file_sqlserveragentconfig_sqlserveragentconfig_proto_init() {
 			}
 		}
 		file_sqlserveragentconfig_sqlserveragentconfig_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*CredentialConfiguration); i {
+			switch v := v.(*SSHAlgorithms); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -921,7 +1925,7 @@ func file_sqlserveragentconfig_sqlserveragentconfig_proto_init() {
 			}
 		}
 		file_sqlserveragentconfig_sqlserveragentconfig_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*CredentialConfiguration_SqlCredentials); i {
+			switch v := v.(*InstanceProperties); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -933,7 +1937,7 @@ func file_sqlserveragentconfig_sqlserveragentconfig_proto_init() {
 			}
 		}
 		file_sqlserveragentconfig_sqlserveragentconfig_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*CredentialConfiguration_GuestCredentialsRemoteWin); i {
+			switch v := v.(*CollectionConfiguration); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -945,6 +1949,42 @@ func file_sqlserveragentconfig_sqlserveragentconfig_proto_init() {
 			}
 		}
 		file_sqlserveragentconfig_sqlserveragentconfig_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CredentialConfiguration); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sqlserveragentconfig_sqlserveragentconfig_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CredentialConfiguration_SqlCredentials); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sqlserveragentconfig_sqlserveragentconfig_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CredentialConfiguration_GuestCredentialsRemoteWin); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sqlserveragentconfig_sqlserveragentconfig_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*CredentialConfiguration_GuestCredentialsRemoteLinux); i {
 			case 0:
 				return &v.state
@@ -957,7 +1997,8 @@ func file_sqlserveragentconfig_sqlserveragentconfig_proto_init() {
 			}
 		}
 	}
-	file_sqlserveragentconfig_sqlserveragentconfig_proto_msgTypes[2].OneofWrappers = []interface{}{
+	file_sqlserveragentconfig_sqlserveragentconfig_proto_msgTypes[4].OneofWrappers = []interface{}{}
+	file_sqlserveragentconfig_sqlserveragentconfig_proto_msgTypes[5].OneofWrappers = []interface{}{
 		(*CredentialConfiguration_LocalCollection)(nil),
 		(*CredentialConfiguration_RemoteWin)(nil),
 		(*CredentialConfiguration_RemoteLinux)(nil),
@@ -967,13 +2008,14 @@ func file_sqlserveragentconfig_sqlserveragentconfig_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_sqlserveragentconfig_sqlserveragentconfig_proto_rawDesc,
-			NumEnums:      0,
-			NumMessages:   6,
+			NumEnums:      2,
+			NumMessages:   9,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
 		GoTypes:           file_sqlserveragentconfig_sqlserveragentconfig_proto_goTypes,
 		DependencyIndexes: file_sqlserveragentconfig_sqlserveragentconfig_proto_depIdxs,
+		EnumInfos:         file_sqlserveragentconfig_sqlserveragentconfig_proto_enumTypes,
 		MessageInfos:      file_sqlserveragentconfig_sqlserveragentconfig_proto_msgTypes,
 	}.Build()
 	File_sqlserveragentconfig_sqlserveragentconfig_proto = out.File